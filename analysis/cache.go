@@ -0,0 +1,80 @@
+package analysis
+
+import (
+	"crypto/sha256"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/iyut/graphql-go/lru"
+)
+
+// Query is a query read and validated once for all the requests sending it.
+type Query struct {
+	Text string
+
+	// Prepared is the query ready for the schema to execute, nil when it
+	// is not valid; Errors then says why.
+	Prepared *graphql.Prepared
+
+	// Errors are the errors of validation, whatever the variables.
+	Errors []*errors.QueryError
+}
+
+// Check reads and validates a query against the schema, preparing it for
+// execution.
+func Check(schema *graphql.Schema, text string) *Query {
+
+	q := &Query{Text: text}
+
+	q.Prepared, q.Errors = schema.Prepare(text)
+
+	return q
+}
+
+// Cache keeps checked queries by the hash of their text. A query's size is
+// reckoned by its text, which its document is in proportion to.
+type Cache struct {
+	mu     sync.Mutex
+	schema *graphql.Schema
+	lru    *lru.Cache
+}
+
+func NewCache(maxEntries int, maxBytes int) *Cache {
+
+	return &Cache{lru: lru.New(maxEntries, maxBytes)}
+}
+
+// Get returns the query checked against the schema, checking it when it is
+// not cached. The cache is emptied when the schema changes, as on a reload.
+func (c *Cache) Get(schema *graphql.Schema, text string) *Query {
+
+	c.mu.Lock()
+	if c.schema != schema {
+		c.lru.Purge()
+		c.schema = schema
+	}
+	c.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(text))
+	key := string(sum[:])
+
+	if q, ok := c.lru.Get(key); ok && q.(*Query).Text == text {
+		return q.(*Query)
+	}
+
+	q := Check(schema, text)
+
+	c.mu.Lock()
+	if c.schema == schema {
+		c.lru.Add(key, q, len(text))
+	}
+	c.mu.Unlock()
+
+	return q
+}
+
+func (c *Cache) Stats() lru.Stats {
+
+	return c.lru.Stats()
+}
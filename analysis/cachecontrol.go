@@ -0,0 +1,369 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// Scopes of @cacheControl. Private responses are for one viewer only.
+const (
+	ScopePublic  = "PUBLIC"
+	ScopePrivate = "PRIVATE"
+)
+
+// cacheControlDirective is the directive giving cache hints in the schema:
+//
+//	directive @cacheControl(maxAge: Int, scope: CacheControlScope, inheritMaxAge: Boolean) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+const cacheControlDirective = "cacheControl"
+
+// CacheHint is a @cacheControl of the schema. A nil MaxAge leaves the age to
+// the type of the field, or to the default.
+type CacheHint struct {
+	MaxAge        *int
+	Scope         string
+	InheritMaxAge bool
+}
+
+// CacheHints are the hints of types, and of fields by "Type.field".
+type CacheHints struct {
+	Types  map[string]*CacheHint
+	Fields map[string]*CacheHint
+}
+
+// CachePolicy is how long and by whom a response may be cached.
+type CachePolicy struct {
+	MaxAge int
+	Scope  string
+}
+
+// ReadCacheHints reads the @cacheControl hints of a schema. graphql-go does
+// not accept directives on types, so it also returns the schema with those
+// blanked out, for graphql.ParseSchema.
+func ReadCacheHints(sdl string) (*CacheHints, string, error) {
+
+	hints := &CacheHints{
+		Types:  make(map[string]*CacheHint),
+		Fields: make(map[string]*CacheHint),
+	}
+
+	blanked := []byte(sdl)
+
+	p := &parser{lex: newLexer(sdl)}
+	if err := p.read(); err != nil {
+		return nil, "", err
+	}
+
+	for p.tok.kind != tokenEOF {
+
+		var err error
+
+		switch {
+		case p.peek("{"):
+			err = p.skipBalanced("{", "}")
+		case p.peek("("):
+			err = p.skipBalanced("(", ")")
+		case p.tok.kind != tokenName:
+			err = p.read()
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if p.tok.kind != tokenName {
+			continue
+		}
+
+		keyword := p.tok.value
+		if err := p.read(); err != nil {
+			return nil, "", err
+		}
+
+		switch keyword {
+		case "type", "interface", "union":
+		default:
+			continue
+		}
+
+		typeName, err := p.name()
+		if err != nil {
+			return nil, "", err
+		}
+
+		for p.tok.kind == tokenName || p.peek("&") {
+			if err := p.read(); err != nil {
+				return nil, "", err
+			}
+		}
+
+		for p.peek("@") {
+
+			start := p.tok.pos
+
+			directives, err := p.directives()
+			if err != nil {
+				return nil, "", err
+			}
+
+			hint, err := cacheHint(directives)
+			if err != nil {
+				return nil, "", fmt.Errorf("%s: %s", typeName, err)
+			}
+			if hint != nil {
+				hints.Types[typeName] = hint
+				blank(blanked, start, p.tok.pos)
+			}
+		}
+
+		if keyword == "union" || !p.peek("{") {
+			continue
+		}
+
+		if err := p.fieldHints(typeName, hints); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return hints, string(blanked), nil
+}
+
+// fieldHints reads the fields of a type, keeping their hints.
+func (p *parser) fieldHints(typeName string, hints *CacheHints) error {
+
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	for !p.peek("}") {
+
+		if p.tok.kind == tokenString {
+			if err := p.read(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fieldName, err := p.name()
+		if err != nil {
+			return err
+		}
+
+		if p.peek("(") {
+			if err := p.skipBalanced("(", ")"); err != nil {
+				return err
+			}
+		}
+
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+
+		if err := p.typeRef(); err != nil {
+			return err
+		}
+
+		directives, err := p.directives()
+		if err != nil {
+			return err
+		}
+
+		hint, err := cacheHint(directives)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %s", typeName, fieldName, err)
+		}
+		if hint != nil {
+			hints.Fields[typeName+"."+fieldName] = hint
+		}
+	}
+
+	return p.read()
+}
+
+// skipBalanced reads past the brackets at hand and all they enclose.
+func (p *parser) skipBalanced(open string, close string) error {
+
+	depth := 0
+
+	for {
+		switch {
+		case p.tok.kind == tokenEOF:
+			return p.unexpected()
+		case p.peek(open):
+			depth++
+		case p.peek(close):
+			depth--
+		}
+
+		if err := p.read(); err != nil {
+			return err
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func cacheHint(directives []*Directive) (*CacheHint, error) {
+
+	for _, directive := range directives {
+
+		if directive.Name != cacheControlDirective {
+			continue
+		}
+
+		hint := &CacheHint{}
+
+		if maxAge, ok := directive.Arguments["maxAge"]; ok {
+			n, ok := maxAge.(int64)
+			if !ok || n < 0 {
+				return nil, fmt.Errorf("invalid maxAge %v", maxAge)
+			}
+			age := int(n)
+			hint.MaxAge = &age
+		}
+
+		if scope, ok := directive.Arguments["scope"]; ok {
+			switch scope {
+			case Enum(ScopePublic), Enum(ScopePrivate):
+				hint.Scope = string(scope.(Enum))
+			default:
+				return nil, fmt.Errorf("invalid scope %v", scope)
+			}
+		}
+
+		if inherit, ok := directive.Arguments["inheritMaxAge"]; ok {
+			hint.InheritMaxAge = inherit == true
+		}
+
+		return hint, nil
+	}
+
+	return nil, nil
+}
+
+// blank replaces the text with spaces, keeping line breaks so that errors
+// report the same positions.
+func blank(b []byte, start int, end int) {
+
+	for i := start; i < end; i++ {
+		if b[i] != '\n' && b[i] != '\r' {
+			b[i] = ' '
+		}
+	}
+}
+
+// CachePolicy returns the cache policy of the operation's response, as
+// Apollo Server computes it: the least maxAge of its fields, private if any
+// field is. Fields returning objects, and root fields, take the hint of the
+// field, else of the type returned, else DefaultMaxAge; other fields only
+// count when they have a hint. The policy is computed from the operation, so
+// fields that turn out null count all the same. Only queries are cached.
+func (a *Analyzer) CachePolicy(op *graphql.Operation) CachePolicy {
+
+	if op.Type != "query" {
+		return CachePolicy{Scope: ScopePublic}
+	}
+
+	w := &cacheWalk{a: a, maxAge: -1, scope: ScopePublic, seen: make(map[cacheKey]bool)}
+	w.selections(op.Selections, a.roots[op.Type], true)
+
+	if w.maxAge < 0 {
+		w.maxAge = 0
+	}
+
+	return CachePolicy{MaxAge: w.maxAge, Scope: w.scope}
+}
+
+// cacheKey is a selection set on a type, which only needs to be walked once
+// however often its fragment is spread.
+type cacheKey struct {
+	set      *graphql.SelectionSet
+	typeName string
+	root     bool
+}
+
+type cacheWalk struct {
+	a      *Analyzer
+	maxAge int
+	scope  string
+	seen   map[cacheKey]bool
+}
+
+func (w *cacheWalk) selections(set *graphql.SelectionSet, typeName string, root bool) {
+
+	key := cacheKey{set, typeName, root}
+	if w.seen[key] {
+		return
+	}
+	w.seen[key] = true
+
+	for _, field := range set.Fields {
+		w.field(field, typeName, root)
+	}
+
+	for _, fragment := range set.Fragments {
+		fragmentType := typeName
+		if len(fragment.TypeCondition) > 0 {
+			fragmentType = fragment.TypeCondition
+		}
+		w.selections(fragment.Selections, fragmentType, root)
+	}
+}
+
+func (w *cacheWalk) field(field *graphql.SelectedField, typeName string, root bool) {
+
+	t, ok := w.a.types[typeName]
+	if !ok {
+		return
+	}
+
+	info, ok := t.fields[field.Name]
+	if !ok {
+		return
+	}
+
+	var maxAge *int
+	var scope string
+	inherit := false
+
+	if !info.leaf {
+		if hint := w.a.CacheHints.Types[info.typeName]; hint != nil {
+			maxAge, scope, inherit = hint.MaxAge, hint.Scope, hint.InheritMaxAge
+		}
+	}
+
+	if hint := w.a.CacheHints.Fields[typeName+"."+field.Name]; hint != nil {
+		if hint.MaxAge != nil || hint.InheritMaxAge {
+			maxAge, inherit = hint.MaxAge, hint.InheritMaxAge
+		}
+		if len(hint.Scope) > 0 {
+			scope = hint.Scope
+		}
+	}
+
+	if maxAge == nil && !inherit && (!info.leaf || root) {
+		maxAge = &w.a.DefaultMaxAge
+	}
+
+	if maxAge != nil && (w.maxAge < 0 || *maxAge < w.maxAge) {
+		w.maxAge = *maxAge
+	}
+	if scope == ScopePrivate {
+		w.scope = ScopePrivate
+	}
+
+	if field.Selections != nil {
+		w.selections(field.Selections, info.typeName, false)
+	}
+}
+
+// CacheControl is the value of the Cache-Control header of the policy.
+func (c CachePolicy) CacheControl() string {
+
+	if c.MaxAge <= 0 {
+		return "no-store"
+	}
+
+	return fmt.Sprintf("max-age=%d, %s", c.MaxAge, strings.ToLower(c.Scope))
+}
@@ -0,0 +1,251 @@
+// Package analysis reads GraphQL requests ahead of execution, to judge what
+// they would cost, and whether they are valid, before any resolver runs.
+package analysis
+
+import (
+	"math"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// DefaultListSize is the length assumed for lists that have no first or
+// last argument.
+const DefaultListSize = 10
+
+// maxCost caps costs so that deeply nested pagination cannot overflow.
+const maxCost = math.MaxInt32
+
+type typeInfo struct {
+	fields map[string]*fieldInfo
+}
+
+// fieldInfo is the named type a field returns and whether in a list.
+type fieldInfo struct {
+	typeName string
+	list     bool
+	leaf     bool
+}
+
+// Analyzer estimates what operations cost before they run. A field returning
+// objects costs 1, for the query behind it, and a scalar field nothing,
+// unless FieldCosts says otherwise. The selections below a field count once
+// per item it returns: as many as its first or last argument asks for, or
+// DefaultListSize for other lists. A first or last not above 0 counts as not
+// given. Lists right below a paginated field, like the edges of a
+// connection, count once.
+type Analyzer struct {
+	DefaultListSize int
+
+	// FieldCosts are the costs of fields by "Type.field".
+	FieldCosts map[string]int
+
+	// CacheHints are the @cacheControl hints of the schema, and
+	// DefaultMaxAge the maxAge of fields returning objects without one.
+	CacheHints    *CacheHints
+	DefaultMaxAge int
+
+	types map[string]*typeInfo
+	roots map[string]string
+}
+
+// NewAnalyzer reads the types of the schema.
+func NewAnalyzer(schema *graphql.Schema) *Analyzer {
+
+	a := &Analyzer{
+		DefaultListSize: DefaultListSize,
+		FieldCosts:      make(map[string]int),
+		CacheHints:      &CacheHints{},
+		types:           make(map[string]*typeInfo),
+		roots:           make(map[string]string),
+	}
+
+	inspected := schema.Inspect()
+
+	for op, t := range map[string]*introspection.Type{
+		"query":        inspected.QueryType(),
+		"mutation":     inspected.MutationType(),
+		"subscription": inspected.SubscriptionType(),
+	} {
+		if t != nil && t.Name() != nil {
+			a.roots[op] = *t.Name()
+		}
+	}
+
+	includeDeprecated := &struct{ IncludeDeprecated bool }{true}
+
+	for _, t := range inspected.Types() {
+
+		fields := t.Fields(includeDeprecated)
+		if t.Name() == nil || fields == nil {
+			continue
+		}
+
+		info := &typeInfo{fields: make(map[string]*fieldInfo)}
+
+		for _, field := range *fields {
+			info.fields[field.Name()] = newFieldInfo(field.Type())
+		}
+
+		a.types[*t.Name()] = info
+	}
+
+	return a
+}
+
+func newFieldInfo(t *introspection.Type) *fieldInfo {
+
+	info := &fieldInfo{}
+
+	for t.Kind() == "NON_NULL" || t.Kind() == "LIST" {
+		if t.Kind() == "LIST" {
+			info.list = true
+		}
+		t = t.OfType()
+	}
+
+	info.typeName = *t.Name()
+	info.leaf = t.Kind() == "SCALAR" || t.Kind() == "ENUM"
+
+	return info
+}
+
+// Cost returns the cost of the operation. Fields unknown to the schema cost
+// nothing; validation reports them.
+func (a *Analyzer) Cost(op *graphql.Operation) int {
+
+	w := &costWalk{a: a, costs: make(map[costKey]int)}
+
+	return w.selections(op.Selections, a.roots[op.Type], false)
+}
+
+// costKey is a selection set on a type. The spreads of a fragment share its
+// selection set, which is costed once.
+type costKey struct {
+	set       *graphql.SelectionSet
+	typeName  string
+	paginated bool
+}
+
+type costWalk struct {
+	a     *Analyzer
+	costs map[costKey]int
+}
+
+func (w *costWalk) selections(set *graphql.SelectionSet, typeName string, paginated bool) int {
+
+	key := costKey{set, typeName, paginated}
+	if cost, ok := w.costs[key]; ok {
+		return cost
+	}
+
+	total := 0
+
+	for _, field := range set.Fields {
+		total = addCost(total, w.field(field, typeName, paginated))
+	}
+
+	for _, fragment := range set.Fragments {
+		fragmentType := typeName
+		if len(fragment.TypeCondition) > 0 {
+			fragmentType = fragment.TypeCondition
+		}
+		total = addCost(total, w.selections(fragment.Selections, fragmentType, paginated))
+	}
+
+	w.costs[key] = total
+
+	return total
+}
+
+func (w *costWalk) field(field *graphql.SelectedField, typeName string, paginated bool) int {
+
+	t, ok := w.a.types[typeName]
+	if !ok {
+		return 0
+	}
+
+	info, ok := t.fields[field.Name]
+	if !ok {
+		return 0
+	}
+
+	cost, ok := w.a.FieldCosts[typeName+"."+field.Name]
+	if !ok && !info.leaf {
+		cost = 1
+	}
+
+	if field.Selections == nil {
+		return cost
+	}
+
+	size, hasSize := pageSize(field)
+
+	multiplier := 1
+	switch {
+	case hasSize:
+		multiplier = size
+	case info.list && !paginated:
+		multiplier = w.a.DefaultListSize
+	}
+
+	return addCost(cost, mulCost(multiplier, w.selections(field.Selections, info.typeName, hasSize)))
+}
+
+// pageSize is the first or last argument of the field, when above 0. The
+// resolvers answer a first or last of 0 or less with their default page, an
+// empty one or an error, so it costs as if it were not given.
+func pageSize(field *graphql.SelectedField) (int, bool) {
+
+	for _, name := range []string{"first", "last"} {
+
+		var size int
+		switch v := field.Arguments[name].(type) {
+		case int32:
+			size = int(v)
+		case int64:
+			if v > maxCost {
+				v = maxCost
+			}
+			size = int(v)
+		case int:
+			size = v
+		case float64:
+			if v > maxCost {
+				v = maxCost
+			}
+			size = int(v)
+		default:
+			continue
+		}
+
+		if size <= 0 {
+			continue
+		}
+		if size > maxCost {
+			size = maxCost
+		}
+
+		return size, true
+	}
+
+	return 0, false
+}
+
+func addCost(a int, b int) int {
+
+	if a > maxCost-b {
+		return maxCost
+	}
+
+	return a + b
+}
+
+func mulCost(a int, b int) int {
+
+	if a != 0 && b > maxCost/a {
+		return maxCost
+	}
+
+	return a * b
+}
@@ -0,0 +1,189 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+const testSchema = `
+schema {
+	query: Query
+}
+
+type Query {
+	users(first: Int, last: Int): UserConnection!
+	posts: [Post!]!
+	post(id: ID!): Post
+}
+
+type UserConnection {
+	edges: [UserEdge!]!
+	total: Int!
+}
+
+type UserEdge {
+	node: User!
+}
+
+type User {
+	name: String!
+	posts: [Post!]!
+}
+
+type Post {
+	title: String!
+	author: User!
+}
+`
+
+func testOperation(t *testing.T, query string, operationName string, variables map[string]interface{}) *graphql.Operation {
+
+	schema := graphql.MustParseSchema(testSchema, nil)
+
+	prepared, errs := schema.Prepare(query)
+	if len(errs) > 0 {
+		t.Fatalf("%s: %v", query, errs)
+	}
+
+	op, err := prepared.Operation(operationName, variables)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+
+	return op
+}
+
+func TestCost(t *testing.T) {
+
+	tests := []struct {
+		query     string
+		variables map[string]interface{}
+		want      int
+	}{
+		{`{ users(first: 5) { edges { node { name } } } }`, nil, 11},
+		{`{ users(last: 5) { edges { node { name } } } }`, nil, 11},
+		{`{ users { edges { node { name } } } }`, nil, 12},
+		{`{ users(first: 0) { edges { node { name } } } }`, nil, 12},
+		{`{ users(first: -1) { edges { node { name } } } }`, nil, 12},
+		{`{ users(first: -2147483648) { edges { node { name } } } }`, nil, 12},
+		{`query($n: Int) { users(first: $n) { edges { node { name } } } }`, map[string]interface{}{"n": float64(20)}, 41},
+		{`query($n: Int) { users(first: $n) { edges { node { name } } } }`, map[string]interface{}{"n": float64(-20)}, 12},
+		{`query($n: Int) { users(first: $n) { edges { node { name } } } }`, map[string]interface{}{"n": "20"}, 12},
+		{`query($n: Int) { users(first: $n) { edges { node { name } } } }`, map[string]interface{}{"n": 1e300}, maxCost},
+		{`query($n: Int = 3) { users(first: $n) { edges { node { name } } } }`, nil, 7},
+		{`query($n: Int = 3) { users(first: $n) { edges { node { name } } } }`, map[string]interface{}{"n": float64(4)}, 9},
+		{`{ users(first: 2147483647) { edges { node { posts { author { posts { title } } } } } } }`, nil, maxCost},
+		{`{ users(first: 5) { total } }`, nil, 1},
+		{`{ posts { title author { name } } }`, nil, 11},
+		{`{ users(first: 5) { edges @skip(if: true) { node { name } } } }`, nil, 1},
+		{`{ users(first: 5) { edges @include(if: false) { node { name } } } }`, nil, 1},
+		{`query($skip: Boolean!) { users(first: 5) { edges @skip(if: $skip) { node { name } } } }`, map[string]interface{}{"skip": false}, 11},
+		{`query($skip: Boolean!) { users(first: 5) { edges @skip(if: $skip) { node { name } } } }`, map[string]interface{}{"skip": true}, 1},
+		{`{ users(first: 5) { ...Edges } } fragment Edges on UserConnection { edges { node { name } } }`, nil, 11},
+		{`{ users(first: 5) { ... on UserConnection { edges { node { name } } } } }`, nil, 11},
+		{`{ users(first: 5) { ...Edges @skip(if: true) } } fragment Edges on UserConnection { edges { node { name } } }`, nil, 1},
+		{`{ a: posts { title } b: posts { title } }`, nil, 2},
+	}
+
+	for _, tt := range tests {
+
+		a := NewAnalyzer(graphql.MustParseSchema(testSchema, nil))
+
+		if got := a.Cost(testOperation(t, tt.query, "", tt.variables)); got != tt.want {
+			t.Errorf("Cost(%q, %v) = %d, want %d", tt.query, tt.variables, got, tt.want)
+		}
+	}
+}
+
+// TestCostSpreads checks that fragments spread many times over are costed
+// once, not once per path to them.
+func TestCostSpreads(t *testing.T) {
+
+	const levels = 30
+
+	var query strings.Builder
+	query.WriteString("{ posts { ...F0 } }\n")
+
+	for i := 0; i < levels-1; i++ {
+		fmt.Fprintf(&query, "fragment F%d on Post { author { posts { ...F%d ...F%d } } }\n", i, i+1, i+1)
+	}
+	fmt.Fprintf(&query, "fragment F%d on Post { title }\n", levels-1)
+
+	a := NewAnalyzer(graphql.MustParseSchema(testSchema, nil))
+
+	if got := a.Cost(testOperation(t, query.String(), "", nil)); got != maxCost {
+		t.Errorf("Cost of %d nested spreads = %d, want %d", levels, got, maxCost)
+	}
+}
+
+func TestOperation(t *testing.T) {
+
+	schema := graphql.MustParseSchema(testSchema, nil)
+
+	prepared, errs := schema.Prepare(`query A { posts { title } } query B { users { total } }`)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	tests := []struct {
+		operationName string
+		wantErr       bool
+	}{
+		{"A", false},
+		{"B", false},
+		{"", true},
+		{"C", true},
+	}
+
+	for _, tt := range tests {
+		op, err := prepared.Operation(tt.operationName, nil)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Operation(%q) error %v, want error %t", tt.operationName, err, tt.wantErr)
+		}
+		if err == nil && op.Name != tt.operationName {
+			t.Errorf("Operation(%q) = %q", tt.operationName, op.Name)
+		}
+	}
+}
+
+func TestCachePolicy(t *testing.T) {
+
+	hints, sdl, err := ReadCacheHints(`
+directive @cacheControl(maxAge: Int, scope: CacheControlScope, inheritMaxAge: Boolean) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+
+enum CacheControlScope {
+	PUBLIC
+	PRIVATE
+}
+` + strings.Replace(testSchema, "posts: [Post!]!\n\tpost", "posts: [Post!]! @cacheControl(maxAge: 30)\n\tpost", 1) + `
+type Viewer @cacheControl(maxAge: 5, scope: PRIVATE) {
+	name: String!
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  CachePolicy
+	}{
+		{`{ posts { title } }`, CachePolicy{MaxAge: 30, Scope: ScopePublic}},
+		{`{ posts { title } users { total } }`, CachePolicy{MaxAge: 0, Scope: ScopePublic}},
+		{`{ ...P } fragment P on Query { posts { title } }`, CachePolicy{MaxAge: 30, Scope: ScopePublic}},
+		{`{ posts { title } users @skip(if: true) { total } }`, CachePolicy{MaxAge: 30, Scope: ScopePublic}},
+	}
+
+	for _, tt := range tests {
+
+		a := NewAnalyzer(graphql.MustParseSchema(sdl, nil))
+		a.CacheHints = hints
+
+		if got := a.CachePolicy(testOperation(t, tt.query, "", nil)); got != tt.want {
+			t.Errorf("CachePolicy(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package analysis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind   tokenKind
+	value  string
+	pos    int
+	line   int
+	column int
+}
+
+// lexer splits a GraphQL document into tokens, dropping whitespace, commas
+// and comments.
+type lexer struct {
+	src    string
+	pos    int
+	line   int
+	column int
+}
+
+func newLexer(src string) *lexer {
+
+	return &lexer{src: src, line: 1, column: 1}
+}
+
+func (l *lexer) errorf(format string, a ...interface{}) error {
+
+	return fmt.Errorf("syntax error: %s (line %d, column %d)", fmt.Sprintf(format, a...), l.line, l.column)
+}
+
+func (l *lexer) advance(n int) {
+
+	for i := 0; i < n; i++ {
+		if l.src[l.pos] == '\n' {
+			l.line++
+			l.column = 1
+		} else {
+			l.column++
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) skipIgnored() {
+
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.advance(1)
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.advance(1)
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\ufeff"):
+			l.pos += len("\ufeff")
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+
+	l.skipIgnored()
+
+	tok := token{pos: l.pos, line: l.line, column: l.column}
+
+	if l.pos >= len(l.src) {
+		tok.kind = tokenEOF
+		return tok, nil
+	}
+
+	c := l.src[l.pos]
+
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		tok.kind = tokenPunct
+		tok.value = "..."
+		l.advance(3)
+
+	case strings.IndexByte("!$()&:=@[]{}|", c) >= 0:
+		tok.kind = tokenPunct
+		tok.value = string(c)
+		l.advance(1)
+
+	case c == '_' || isLetter(c):
+		start := l.pos
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.advance(1)
+		}
+		tok.kind = tokenName
+		tok.value = l.src[start:l.pos]
+
+	case c == '-' || isDigit(c):
+		return l.number(tok)
+
+	case strings.HasPrefix(l.src[l.pos:], `"""`):
+		return l.blockString(tok)
+
+	case c == '"':
+		return l.string(tok)
+
+	default:
+		r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+		return tok, l.errorf("unexpected character %q", r)
+	}
+
+	return tok, nil
+}
+
+func (l *lexer) number(tok token) (token, error) {
+
+	start := l.pos
+	tok.kind = tokenInt
+
+	if l.src[l.pos] == '-' {
+		l.advance(1)
+	}
+
+	if !l.digits() {
+		return tok, l.errorf("invalid number")
+	}
+
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		tok.kind = tokenFloat
+		l.advance(1)
+		if !l.digits() {
+			return tok, l.errorf("invalid number")
+		}
+	}
+
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		tok.kind = tokenFloat
+		l.advance(1)
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.advance(1)
+		}
+		if !l.digits() {
+			return tok, l.errorf("invalid number")
+		}
+	}
+
+	tok.value = l.src[start:l.pos]
+
+	return tok, nil
+}
+
+func (l *lexer) digits() bool {
+
+	start := l.pos
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.advance(1)
+	}
+
+	return l.pos > start
+}
+
+func (l *lexer) string(tok token) (token, error) {
+
+	tok.kind = tokenString
+	l.advance(1)
+
+	var b strings.Builder
+
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' {
+			return tok, l.errorf("unterminated string")
+		}
+
+		c := l.src[l.pos]
+
+		if c == '"' {
+			l.advance(1)
+			tok.value = b.String()
+			return tok, nil
+		}
+
+		if c != '\\' {
+			_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			b.WriteString(l.src[l.pos : l.pos+size])
+			l.advance(size)
+			continue
+		}
+
+		if l.pos+1 >= len(l.src) {
+			return tok, l.errorf("unterminated string")
+		}
+
+		escape := l.src[l.pos+1]
+		l.advance(2)
+
+		switch escape {
+		case '"', '\\', '/':
+			b.WriteByte(escape)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if l.pos+4 > len(l.src) {
+				return tok, l.errorf("invalid unicode escape")
+			}
+			code, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+			if err != nil {
+				return tok, l.errorf("invalid unicode escape")
+			}
+			b.WriteRune(rune(code))
+			l.advance(4)
+		default:
+			return tok, l.errorf("invalid escape \\%c", escape)
+		}
+	}
+}
+
+// blockString reads a """ string. Its indentation is kept, which makes no
+// difference to the analysis.
+func (l *lexer) blockString(tok token) (token, error) {
+
+	tok.kind = tokenString
+	l.advance(3)
+
+	var b strings.Builder
+
+	for {
+		if l.pos >= len(l.src) {
+			return tok, l.errorf("unterminated string")
+		}
+
+		if strings.HasPrefix(l.src[l.pos:], `\"""`) {
+			b.WriteString(`"""`)
+			l.advance(4)
+			continue
+		}
+
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			l.advance(3)
+			tok.value = b.String()
+			return tok, nil
+		}
+
+		b.WriteByte(l.src[l.pos])
+		l.advance(1)
+	}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
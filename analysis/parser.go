@@ -0,0 +1,233 @@
+package analysis
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Directive is a directive of a schema definition.
+type Directive struct {
+	Name      string
+	Arguments map[string]interface{}
+}
+
+// Enum is an enum value. Argument values are int64, float64, string, bool,
+// nil, Enum, []interface{} or map[string]interface{}.
+type Enum string
+
+// parser reads the directives of schema definitions, for the cache hints.
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+func (p *parser) read() error {
+
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+
+	p.tok = tok
+
+	return nil
+}
+
+func (p *parser) unexpected() error {
+
+	if p.tok.kind == tokenEOF {
+		return fmt.Errorf("syntax error: unexpected end of document")
+	}
+
+	return fmt.Errorf("syntax error: unexpected %q (line %d, column %d)", p.tok.value, p.tok.line, p.tok.column)
+}
+
+func (p *parser) peek(punct string) bool {
+
+	return p.tok.kind == tokenPunct && p.tok.value == punct
+}
+
+// skip reads past the punctuator when it is next, telling whether it was.
+func (p *parser) skip(punct string) (bool, error) {
+
+	if !p.peek(punct) {
+		return false, nil
+	}
+
+	return true, p.read()
+}
+
+func (p *parser) expect(punct string) error {
+
+	if !p.peek(punct) {
+		return p.unexpected()
+	}
+
+	return p.read()
+}
+
+func (p *parser) name() (string, error) {
+
+	if p.tok.kind != tokenName {
+		return "", p.unexpected()
+	}
+
+	name := p.tok.value
+
+	return name, p.read()
+}
+
+func (p *parser) typeRef() error {
+
+	if ok, err := p.skip("["); err != nil {
+		return err
+	} else if ok {
+		if err := p.typeRef(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+
+	_, err := p.skip("!")
+
+	return err
+}
+
+func (p *parser) arguments() (map[string]interface{}, error) {
+
+	args := make(map[string]interface{})
+
+	ok, err := p.skip("(")
+	if err != nil || !ok {
+		return args, err
+	}
+
+	for !p.peek(")") {
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		args[name] = value
+	}
+
+	return args, p.read()
+}
+
+func (p *parser) directives() ([]*Directive, error) {
+
+	var directives []*Directive
+
+	for p.peek("@") {
+
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+
+		directives = append(directives, &Directive{Name: name, Arguments: args})
+	}
+
+	return directives, nil
+}
+
+// value reads an argument value, which in a schema is constant.
+func (p *parser) value() (interface{}, error) {
+
+	tok := p.tok
+
+	switch tok.kind {
+	case tokenInt:
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error: invalid integer %s (line %d, column %d)", tok.value, tok.line, tok.column)
+		}
+		return n, p.read()
+
+	case tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error: invalid float %s (line %d, column %d)", tok.value, tok.line, tok.column)
+		}
+		return f, p.read()
+
+	case tokenString:
+		return tok.value, p.read()
+
+	case tokenName:
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		switch tok.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return Enum(tok.value), nil
+	}
+
+	switch {
+	case p.peek("["):
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		list := []interface{}{}
+		for !p.peek("]") {
+			item, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.read()
+
+	case p.peek("{"):
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		object := make(map[string]interface{})
+		for !p.peek("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			item, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			object[name] = item
+		}
+		return object, p.read()
+	}
+
+	return nil, p.unexpected()
+}
@@ -0,0 +1,102 @@
+package analysis
+
+import "strings"
+
+// RedactedValue replaces the values Redact hides.
+const RedactedValue = `"[REDACTED]"`
+
+// Redact returns the query with the literal values of sensitive arguments,
+// input fields and variable defaults replaced by RedactedValue, so that it
+// can be logged, and the variables given to them, whose values are as
+// sensitive. A name is sensitive when the function says so; every literal
+// in a list or input object given to a sensitive name is hidden. Text past
+// a syntax error is dropped, as it cannot be told apart.
+func Redact(query string, sensitive func(name string) bool) (string, []string) {
+
+	const (
+		normal = iota
+		valueStart
+		variableName
+		inValue
+		afterValue
+	)
+
+	lex := newLexer(query)
+
+	var b strings.Builder
+	last := 0
+	state := normal
+	depth := 0
+	var prev token
+	var variables []string
+
+	for {
+		read := lex.pos
+		tok, err := lex.next()
+		if err != nil {
+			b.WriteString(query[last:read])
+			return b.String(), variables
+		}
+		if tok.kind == tokenEOF {
+			break
+		}
+
+		literal := tok.kind == tokenString || tok.kind == tokenInt || tok.kind == tokenFloat
+		if literal && (state == valueStart || state == inValue) {
+			b.WriteString(query[last:tok.pos])
+			b.WriteString(RedactedValue)
+			last = lex.pos
+		}
+
+		switch state {
+		case valueStart:
+			switch {
+			case tok.kind == tokenPunct && (tok.value == "[" || tok.value == "{"):
+				state = inValue
+				depth = 1
+			case tok.kind == tokenPunct && tok.value == "$":
+				state = variableName
+			default:
+				state = afterValue
+			}
+
+		case variableName:
+			variables = append(variables, tok.value)
+			state = afterValue
+
+		case inValue:
+			if tok.kind == tokenName && prev.kind == tokenPunct && prev.value == "$" {
+				variables = append(variables, tok.value)
+			}
+			if tok.kind == tokenPunct && (tok.value == "[" || tok.value == "{") {
+				depth++
+			}
+			if tok.kind == tokenPunct && (tok.value == "]" || tok.value == "}") {
+				depth--
+				if depth == 0 {
+					state = afterValue
+				}
+			}
+
+		case afterValue:
+			// The type of a variable is followed by its default value.
+			switch {
+			case tok.kind == tokenPunct && tok.value == "!":
+			case tok.kind == tokenPunct && tok.value == "=":
+				state = valueStart
+			default:
+				state = normal
+			}
+		}
+
+		if state == normal && tok.kind == tokenPunct && tok.value == ":" && prev.kind == tokenName && sensitive(prev.value) {
+			state = valueStart
+		}
+
+		prev = tok
+	}
+
+	b.WriteString(query[last:])
+
+	return b.String(), variables
+}
@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/phpass"
+	"github.com/iyut/graphql-go/phpserialize"
+	"github.com/iyut/graphql-go/service"
+)
+
+var (
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrWeakSecret         = errors.New("the token secret is empty or the old placeholder \"change-me\"")
+)
+
+// PlaceholderSecret is the secret settings.json used to ship with, refused
+// like an empty one.
+const PlaceholderSecret = "change-me"
+
+// PostPassCookie is the prefix of WordPress' post password cookie, which is
+// followed by the MD5 of the site URL.
+const PostPassCookie = "wp-postpass_"
+
+// DefaultTokenTTL is how long tokens issued by login stay valid.
+const DefaultTokenTTL = 14 * 24 * time.Hour
+
+// Authenticator issues and checks the bearer tokens of logged in users.
+// Tokens are "userID.expiry.signature" signed with HMAC-SHA256. Like the
+// auth cookies of WordPress, the signature also covers a fragment of the
+// password hash, so changing the password revokes the tokens issued before.
+type Authenticator struct {
+	Secret   []byte
+	TokenTTL time.Duration
+
+	db     *sql.DB
+	prefix string
+}
+
+func NewAuthenticator(db *sql.DB, prefix string, secret string) (*Authenticator, error) {
+
+	if len(secret) == 0 || secret == PlaceholderSecret {
+		return nil, ErrWeakSecret
+	}
+
+	return &Authenticator{Secret: []byte(secret), TokenTTL: DefaultTokenTTL, db: db, prefix: prefix}, nil
+}
+
+// Login checks the password of the user, by login or email like
+// wp_authenticate(), and returns a new token.
+func (a *Authenticator) Login(username string, password string) (string, error) {
+
+	userService := service.NewUserService(a.db, a.prefix)
+
+	args := service.ArgsUser{Username: username}
+	if strings.Contains(username, "@") {
+		args = service.ArgsUser{Email: username}
+	}
+
+	users, err := userService.GetUsers(args)
+	if err != nil {
+		return "", err
+	}
+
+	if len(users) != 1 || !phpass.Check(password, users[0].UserPassword) {
+		return "", ErrInvalidCredentials
+	}
+
+	return a.newToken(helper.GraphqlIDToInt(users[0].UserID), users[0].UserPassword), nil
+}
+
+// NewToken returns a token for the user.
+func (a *Authenticator) NewToken(userID int64) (string, error) {
+
+	hash, err := a.passwordHash(userID)
+	if err != nil {
+		return "", err
+	}
+
+	return a.newToken(userID, hash), nil
+}
+
+func (a *Authenticator) newToken(userID int64, hash string) string {
+
+	payload := strconv.FormatInt(userID, 10) + "." + strconv.FormatInt(time.Now().Add(a.TokenTTL).Unix(), 10)
+
+	return payload + "." + a.sign(payload+"|"+passFrag(hash))
+}
+
+func (a *Authenticator) sign(payload string) string {
+
+	mac := hmac.New(sha256.New, a.Secret)
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// passFrag is the part of the password hash wp_generate_auth_cookie() signs.
+func passFrag(hash string) string {
+
+	if len(hash) < 12 {
+		return hash
+	}
+
+	return hash[8:12]
+}
+
+// passwordHash returns the user_pass of the user, ErrInvalidToken when the
+// user does not exist.
+func (a *Authenticator) passwordHash(userID int64) (string, error) {
+
+	users, err := service.NewUserService(a.db, a.prefix).GetUsers(service.ArgsUser{UserID: userID})
+	if err != nil {
+		return "", err
+	}
+
+	if len(users) != 1 {
+		return "", ErrInvalidToken
+	}
+
+	return users[0].UserPassword, nil
+}
+
+// ParseToken returns the user ID of a valid token.
+func (a *Authenticator) ParseToken(token string) (int64, error) {
+
+	userID, _, err := a.parseToken(token)
+
+	return userID, err
+}
+
+// parseToken returns the user ID and the expiry of a valid token.
+func (a *Authenticator) parseToken(token string) (int64, time.Time, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || len(a.Secret) == 0 {
+		return 0, time.Time{}, ErrInvalidToken
+	}
+
+	userID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, ErrInvalidToken
+	}
+
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expiry {
+		return 0, time.Time{}, ErrInvalidToken
+	}
+
+	hash, err := a.passwordHash(userID)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	if !hmac.Equal([]byte(parts[2]), []byte(a.sign(parts[0]+"."+parts[1]+"|"+passFrag(hash)))) {
+		return 0, time.Time{}, ErrInvalidToken
+	}
+
+	return userID, time.Unix(expiry, 0), nil
+}
+
+// Authenticate returns the viewer of the request. Requests without an
+// Authorization header are anonymous, a bad token is an error.
+func (a *Authenticator) Authenticate(r *http.Request) (*Viewer, error) {
+
+	viewer, err := a.Viewer(r.Header.Get("Authorization"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cookie := range r.Cookies() {
+		if !strings.HasPrefix(cookie.Name, PostPassCookie) {
+			continue
+		}
+
+		// PHP's setcookie() URL encodes the value, the phpass hash included.
+		if viewer.PostPass, err = url.QueryUnescape(cookie.Value); err != nil {
+			viewer.PostPass = cookie.Value
+		}
+	}
+
+	return viewer, nil
+}
+
+// Viewer returns the viewer of an Authorization header value, anonymous when
+// it is empty.
+func (a *Authenticator) Viewer(authorization string) (*Viewer, error) {
+
+	viewer := &Viewer{}
+
+	if len(authorization) == 0 {
+		return viewer, nil
+	}
+
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return nil, ErrInvalidToken
+	}
+
+	userID, expires, err := a.parseToken(strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer ")))
+	if err != nil {
+		return nil, err
+	}
+
+	viewer.UserID = userID
+	viewer.Expires = expires
+
+	if viewer.Roles, err = a.Roles(userID); err != nil {
+		return nil, err
+	}
+
+	return viewer, nil
+}
+
+// Roles reads the roles of the user from the capabilities user meta.
+func (a *Authenticator) Roles(userID int64) ([]string, error) {
+
+	var roles []string
+
+	userService := service.NewUserService(a.db, a.prefix)
+
+	metas, err := userService.GetMeta(helper.IntToGraphqlID(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, meta := range metas {
+		if meta.MetaKey == a.prefix+"capabilities" {
+			return ParseRoles(meta.MetaValue)
+		}
+	}
+
+	return roles, nil
+}
+
+// ParseRoles returns the roles granted in a serialized capabilities user meta
+// value, in sorted order.
+func ParseRoles(capabilities string) ([]string, error) {
+
+	var roles []string
+
+	value, err := phpserialize.Unserialize(capabilities)
+	if err != nil {
+		return nil, err
+	}
+
+	caps, _ := value.(map[string]interface{})
+	for role, granted := range caps {
+		if ok, _ := granted.(bool); ok {
+			roles = append(roles, role)
+		}
+	}
+
+	sort.Strings(roles)
+
+	return roles, nil
+}
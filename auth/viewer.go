@@ -0,0 +1,120 @@
+// Package auth identifies who is asking and what they may see.
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/model"
+)
+
+// Viewer is the caller of a request. The zero value is an anonymous visitor.
+type Viewer struct {
+	UserID int64
+	Roles  []string
+
+	// PostPass is the hashed post password from the wp-postpass cookie.
+	PostPass string
+
+	// Expires is when the token of the viewer expires, zero for anonymous
+	// visitors.
+	Expires time.Time
+}
+
+// roleCaps are the capabilities of WordPress' default roles that the API
+// checks.
+var roleCaps = map[string][]string{
+	"administrator": {"read", "read_private_posts", "read_private_pages", "edit_posts", "edit_others_posts", "edit_published_posts", "edit_private_posts", "publish_posts", "edit_pages", "edit_others_pages", "edit_published_pages", "edit_private_pages", "publish_pages", "list_users", "create_users", "edit_users", "delete_users", "promote_users", "manage_options"},
+	"editor":        {"read", "read_private_posts", "read_private_pages", "edit_posts", "edit_others_posts", "edit_published_posts", "edit_private_posts", "publish_posts", "edit_pages", "edit_others_pages", "edit_published_pages", "edit_private_pages", "publish_pages"},
+	"author":        {"read", "edit_posts", "edit_published_posts", "publish_posts"},
+	"contributor":   {"read", "edit_posts"},
+	"subscriber":    {"read"},
+}
+
+// roleLevels are the legacy user_level values WordPress stores per role.
+var roleLevels = map[string]int{
+	"administrator": 10,
+	"editor":        7,
+	"author":        2,
+	"contributor":   1,
+	"subscriber":    0,
+}
+
+// IsRole reports whether the role is one of the default roles.
+func IsRole(role string) bool {
+	_, ok := roleCaps[role]
+	return ok
+}
+
+// UserLevel returns the user_level of the role.
+func UserLevel(role string) int {
+	return roleLevels[role]
+}
+
+// IsAnonymous reports whether the viewer is not logged in.
+func (v *Viewer) IsAnonymous() bool {
+	return v == nil || v.UserID == 0
+}
+
+// Can reports whether one of the viewer's roles has the capability.
+func (v *Viewer) Can(capability string) bool {
+
+	if v.IsAnonymous() {
+		return false
+	}
+
+	for _, role := range v.Roles {
+		for _, c := range roleCaps[role] {
+			if c == capability {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// CanEditPost is the edit_post meta capability of map_meta_cap(): authors
+// edit their own posts, editors everybody's.
+func (v *Viewer) CanEditPost(post *model.Post) bool {
+
+	if v.IsAnonymous() {
+		return false
+	}
+
+	plural := "posts"
+	if post.PostType == "page" {
+		plural = "pages"
+	}
+
+	if helper.GraphqlIDToInt(post.PostAuthor) != v.UserID {
+		return v.Can("edit_others_" + plural)
+	}
+
+	switch post.PostStatus {
+	case "publish", "future":
+		return v.Can("edit_published_" + plural)
+	case "private":
+		return v.Can("edit_private_" + plural)
+	}
+
+	return v.Can("edit_" + plural)
+}
+
+type viewerKey struct{}
+
+// WithViewer returns a context carrying the viewer.
+func WithViewer(ctx context.Context, viewer *Viewer) context.Context {
+	return context.WithValue(ctx, viewerKey{}, viewer)
+}
+
+// ViewerFrom returns the viewer of the request, anonymous when there is none.
+func ViewerFrom(ctx context.Context) *Viewer {
+
+	if viewer, ok := ctx.Value(viewerKey{}).(*Viewer); ok && viewer != nil {
+		return viewer
+	}
+
+	return &Viewer{}
+}
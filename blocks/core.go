@@ -0,0 +1,248 @@
+package blocks
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/****
+*********************
+CORE BLOCK ATTRIBUTES
+*********************
+****/
+
+// Most core block attributes are sourced from the saved markup rather than
+// from the comment delimiter, so the functions below read both, the same way
+// the block editor does when it loads a post.
+
+type Paragraph struct {
+	Content string
+	Align   string
+	DropCap bool
+}
+
+type Heading struct {
+	Content string
+	Level   int
+	Align   string
+}
+
+type Image struct {
+	ID              int64
+	URL             string
+	Alt             string
+	Caption         string
+	Href            string
+	SizeSlug        string
+	LinkDestination string
+	Width           int
+	Height          int
+}
+
+type List struct {
+	Ordered bool
+	Values  string
+	Items   []string
+}
+
+type Quote struct {
+	Value    string
+	Citation string
+}
+
+type Gallery struct {
+	Images  []Image
+	Columns int
+}
+
+type Embed struct {
+	URL              string
+	Type             string
+	ProviderNameSlug string
+	Caption          string
+}
+
+var headingTagRegexp = regexp.MustCompile(`(?i)^\s*<h([1-6])`)
+
+func ParagraphOf(b *Block) Paragraph {
+	return Paragraph{
+		Content: innerOf(b.InnerHTML, "p"),
+		Align:   b.StringAttr("align"),
+		DropCap: b.BoolAttr("dropCap"),
+	}
+}
+
+func HeadingOf(b *Block) Heading {
+
+	heading := Heading{Level: b.IntAttr("level"), Align: b.StringAttr("textAlign")}
+
+	if heading.Level == 0 {
+		heading.Level = 2
+		if m := headingTagRegexp.FindStringSubmatch(b.InnerHTML); m != nil {
+			heading.Level, _ = strconv.Atoi(m[1])
+		}
+	}
+
+	heading.Content = innerOf(b.InnerHTML, fmt.Sprintf("h%d", heading.Level))
+
+	return heading
+}
+
+func ImageOf(b *Block) Image {
+
+	image := Image{
+		ID:              int64(b.IntAttr("id")),
+		SizeSlug:        b.StringAttr("sizeSlug"),
+		LinkDestination: b.StringAttr("linkDestination"),
+		Width:           b.IntAttr("width"),
+		Height:          b.IntAttr("height"),
+	}
+
+	if img := findElement(b.InnerHTML, "img"); img != nil {
+		image.URL = img.Attr("src")
+		image.Alt = img.Attr("alt")
+	}
+
+	if a := findElement(b.InnerHTML, "a"); a != nil {
+		image.Href = a.Attr("href")
+	}
+
+	image.Caption = innerOf(b.InnerHTML, "figcaption")
+
+	if len(image.URL) == 0 {
+		image.URL = b.StringAttr("url")
+	}
+
+	return image
+}
+
+func ListOf(b *Block) List {
+
+	list := List{Ordered: b.BoolAttr("ordered")}
+
+	tag := "ul"
+	if list.Ordered {
+		tag = "ol"
+	}
+
+	if e := findElement(b.InnerHTML, tag); e != nil {
+		list.Values = strings.TrimSpace(e.Inner)
+	}
+
+	for _, li := range findElements(list.Values, "li") {
+		list.Items = append(list.Items, strings.TrimSpace(li.Inner))
+	}
+
+	// Since WordPress 6.1 list items are core/list-item inner blocks.
+	for _, inner := range b.InnerBlocks {
+		if inner.Name == "core/list-item" {
+			list.Items = append(list.Items, innerOf(inner.InnerHTML, "li"))
+		}
+	}
+
+	return list
+}
+
+var citeRegexp = regexp.MustCompile(`(?is)<cite[^>]*>.*?</cite>`)
+
+func QuoteOf(b *Block) Quote {
+
+	quote := Quote{Citation: innerOf(b.InnerHTML, "cite")}
+
+	if e := findElement(b.InnerHTML, "blockquote"); e != nil {
+		quote.Value = strings.TrimSpace(citeRegexp.ReplaceAllString(e.Inner, ""))
+	}
+
+	return quote
+}
+
+func GalleryOf(b *Block) Gallery {
+
+	gallery := Gallery{Columns: b.IntAttr("columns")}
+
+	// Since WordPress 5.9 the images are core/image inner blocks.
+	for _, inner := range b.InnerBlocks {
+		if inner.Name == "core/image" {
+			gallery.Images = append(gallery.Images, ImageOf(inner))
+		}
+	}
+
+	if len(gallery.Images) > 0 {
+		return gallery
+	}
+
+	for _, figure := range findElements(b.InnerHTML, "figure") {
+
+		img := findElement(figure.Inner, "img")
+		if img == nil {
+			continue
+		}
+
+		image := Image{
+			URL:     img.Attr("src"),
+			Alt:     img.Attr("alt"),
+			Caption: innerOf(figure.Inner, "figcaption"),
+		}
+
+		image.ID, _ = strconv.ParseInt(img.Attr("data-id"), 10, 64)
+
+		gallery.Images = append(gallery.Images, image)
+	}
+
+	return gallery
+}
+
+func EmbedOf(b *Block) Embed {
+
+	embed := Embed{
+		URL:              b.StringAttr("url"),
+		Type:             b.StringAttr("type"),
+		ProviderNameSlug: b.StringAttr("providerNameSlug"),
+		Caption:          innerOf(b.InnerHTML, "figcaption"),
+	}
+
+	// Before WordPress 5.6 every provider had its own core-embed/* block.
+	if len(embed.ProviderNameSlug) == 0 && strings.HasPrefix(b.Name, "core-embed/") {
+		embed.ProviderNameSlug = strings.TrimPrefix(b.Name, "core-embed/")
+	}
+
+	return embed
+}
+
+// IsEmbed reports whether the block is an embed, including the legacy ones.
+func (b *Block) IsEmbed() bool {
+	return b.Name == "core/embed" || strings.HasPrefix(b.Name, "core-embed/")
+}
+
+func (b *Block) StringAttr(name string) string {
+
+	switch v := b.Attrs[name].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return ""
+}
+
+func (b *Block) IntAttr(name string) int {
+
+	switch v := b.Attrs[name].(type) {
+	case float64:
+		return int(v)
+	case string:
+		i, _ := strconv.Atoi(v)
+		return i
+	}
+
+	return 0
+}
+
+func (b *Block) BoolAttr(name string) bool {
+
+	v, _ := b.Attrs[name].(bool)
+	return v
+}
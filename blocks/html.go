@@ -0,0 +1,133 @@
+package blocks
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// element is an HTML element found in a block's markup.
+type element struct {
+	Tag   string
+	Attrs string
+	Inner string
+}
+
+var attrRegexps = struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}{m: make(map[string]*regexp.Regexp)}
+
+// Attr returns the unescaped value of the attribute, or "" when it is absent.
+func (e *element) Attr(name string) string {
+
+	attrRegexps.Lock()
+	re, ok := attrRegexps.m[name]
+	if !ok {
+		re = regexp.MustCompile(`(?is)(?:^|\s)` + regexp.QuoteMeta(name) + `\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+		attrRegexps.m[name] = re
+	}
+	attrRegexps.Unlock()
+
+	m := re.FindStringSubmatch(e.Attrs)
+	if m == nil {
+		return ""
+	}
+
+	return html.UnescapeString(m[1] + m[2] + m[3])
+}
+
+// HasClass reports whether the element's class attribute contains class.
+func (e *element) HasClass(class string) bool {
+
+	for _, c := range strings.Fields(e.Attr("class")) {
+		if c == class {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findElements returns the top level elements with the tag in markup, taking
+// nested elements of the same tag into account.
+func findElements(markup string, tag string) []*element {
+
+	var elements []*element
+
+	open := regexp.MustCompile(`(?i)<` + tag + `(\s[^>]*)?>|</` + tag + `\s*>`)
+
+	depth := 0
+	var current *element
+	innerStart := 0
+
+	for _, m := range open.FindAllStringSubmatchIndex(markup, -1) {
+
+		token := markup[m[0]:m[1]]
+
+		if !strings.HasPrefix(token, "</") {
+
+			if depth == 0 {
+				current = &element{Tag: strings.ToLower(tag)}
+				if m[2] >= 0 {
+					current.Attrs = markup[m[2]:m[3]]
+				}
+				innerStart = m[1]
+			}
+
+			// Void elements such as img never have a closing tag.
+			if isVoidElement(tag) || strings.HasSuffix(token, "/>") {
+				if depth == 0 {
+					elements = append(elements, current)
+				}
+				continue
+			}
+
+			depth++
+			continue
+		}
+
+		if depth == 0 {
+			continue
+		}
+
+		depth--
+		if depth == 0 {
+			current.Inner = markup[innerStart:m[0]]
+			elements = append(elements, current)
+		}
+	}
+
+	return elements
+}
+
+func findElement(markup string, tag string) *element {
+
+	elements := findElements(markup, tag)
+	if len(elements) == 0 {
+		return nil
+	}
+
+	return elements[0]
+}
+
+func isVoidElement(tag string) bool {
+
+	switch strings.ToLower(tag) {
+	case "img", "br", "hr", "input", "source", "embed":
+		return true
+	}
+
+	return false
+}
+
+// innerOf returns the inner HTML of the first element with the tag.
+func innerOf(markup string, tag string) string {
+
+	if e := findElement(markup, tag); e != nil {
+		return strings.TrimSpace(e.Inner)
+	}
+
+	return ""
+}
@@ -0,0 +1,380 @@
+package blocks
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+/****
+*********************
+BLOCK PARSER
+*********************
+****/
+
+// Block is a parsed block, as returned by WordPress' parse_blocks(). Freeform
+// HTML between blocks becomes a block with an empty Name. InnerContent holds
+// the HTML chunks in order, with nil where an inner block goes.
+type Block struct {
+	Name         string
+	Attrs        map[string]interface{}
+	InnerBlocks  []*Block
+	InnerHTML    string
+	InnerContent []*string
+}
+
+// Parse parses post_content into a list of blocks. It is a port of
+// WP_Block_Parser, so malformed documents are handled the same way: unclosed
+// blocks are closed at the end of the document and stray closers turn the
+// rest of it into freeform HTML.
+func Parse(document string) []*Block {
+
+	p := &parser{document: document}
+
+	for p.proceed() {
+	}
+
+	return p.output
+}
+
+type frame struct {
+	block             *Block
+	tokenStart        int
+	tokenLength       int
+	prevOffset        int
+	leadingHTMLStart  int
+	hasLeadingHTMLPos bool
+}
+
+type parser struct {
+	document string
+	offset   int
+	output   []*Block
+	stack    []*frame
+}
+
+const (
+	tokenNone = iota
+	tokenVoid
+	tokenOpener
+	tokenCloser
+)
+
+func (p *parser) proceed() bool {
+
+	tokenType, name, attrs, startOffset, tokenLength := p.nextToken()
+	stackDepth := len(p.stack)
+
+	leadingHTMLStart := p.offset
+	hasLeadingHTML := startOffset > p.offset
+
+	switch tokenType {
+
+	case tokenNone:
+		if stackDepth == 0 {
+			p.addFreeform(-1)
+			return false
+		}
+
+		for len(p.stack) > 0 {
+			p.addBlockFromStack(-1)
+		}
+
+		return false
+
+	case tokenVoid:
+		block := &Block{Name: name, Attrs: attrs}
+
+		if stackDepth == 0 {
+			if hasLeadingHTML {
+				p.output = append(p.output, freeform(p.document[leadingHTMLStart:startOffset]))
+			}
+			p.output = append(p.output, block)
+			p.offset = startOffset + tokenLength
+			return true
+		}
+
+		p.addInnerBlock(block, startOffset, tokenLength, -1)
+		p.offset = startOffset + tokenLength
+		return true
+
+	case tokenOpener:
+		p.stack = append(p.stack, &frame{
+			block:             &Block{Name: name, Attrs: attrs},
+			tokenStart:        startOffset,
+			tokenLength:       tokenLength,
+			prevOffset:        startOffset + tokenLength,
+			leadingHTMLStart:  leadingHTMLStart,
+			hasLeadingHTMLPos: hasLeadingHTML,
+		})
+		p.offset = startOffset + tokenLength
+		return true
+
+	case tokenCloser:
+		if stackDepth == 0 {
+			p.addFreeform(-1)
+			return false
+		}
+
+		if stackDepth == 1 {
+			p.addBlockFromStack(startOffset)
+			p.offset = startOffset + tokenLength
+			return true
+		}
+
+		top := p.pop()
+		html := p.document[top.prevOffset:startOffset]
+		top.block.appendHTML(html)
+		top.prevOffset = startOffset + tokenLength
+
+		p.addInnerBlock(top.block, top.tokenStart, top.tokenLength, startOffset+tokenLength)
+		p.offset = startOffset + tokenLength
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) pop() *frame {
+	top := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	return top
+}
+
+// addFreeform adds the HTML from the current offset as a freeform block;
+// a negative length means up to the end of the document.
+func (p *parser) addFreeform(length int) {
+
+	if length < 0 {
+		length = len(p.document) - p.offset
+	}
+
+	if length == 0 {
+		return
+	}
+
+	p.output = append(p.output, freeform(p.document[p.offset:p.offset+length]))
+}
+
+func (p *parser) addInnerBlock(block *Block, tokenStart int, tokenLength int, lastOffset int) {
+
+	parent := p.stack[len(p.stack)-1]
+	parent.block.InnerBlocks = append(parent.block.InnerBlocks, block)
+
+	html := p.document[parent.prevOffset:tokenStart]
+	if len(html) > 0 {
+		parent.block.appendHTML(html)
+	}
+
+	parent.block.InnerContent = append(parent.block.InnerContent, nil)
+
+	if lastOffset >= 0 {
+		parent.prevOffset = lastOffset
+	} else {
+		parent.prevOffset = tokenStart + tokenLength
+	}
+}
+
+// addBlockFromStack closes the top block at endOffset, or at the end of the
+// document when endOffset is negative, and moves it to the output.
+func (p *parser) addBlockFromStack(endOffset int) {
+
+	top := p.pop()
+
+	var html string
+	if endOffset >= 0 {
+		html = p.document[top.prevOffset:endOffset]
+	} else {
+		html = p.document[top.prevOffset:]
+	}
+
+	if len(html) > 0 {
+		top.block.appendHTML(html)
+	}
+
+	if top.hasLeadingHTMLPos {
+		p.output = append(p.output, freeform(p.document[top.leadingHTMLStart:top.tokenStart]))
+	}
+
+	p.output = append(p.output, top.block)
+}
+
+func (b *Block) appendHTML(html string) {
+	b.InnerHTML += html
+	b.InnerContent = append(b.InnerContent, &html)
+}
+
+func freeform(html string) *Block {
+	return &Block{InnerHTML: html, InnerContent: []*string{&html}}
+}
+
+// nextToken finds the next block comment delimiter from the current offset.
+func (p *parser) nextToken() (int, string, map[string]interface{}, int, int) {
+
+	search := p.offset
+
+	for {
+
+		i := strings.Index(p.document[search:], "<!--")
+		if i < 0 {
+			return tokenNone, "", nil, -1, 0
+		}
+
+		start := search + i
+
+		if tokenType, name, attrs, length, ok := matchDelimiter(p.document, start); ok {
+			return tokenType, name, attrs, start, length
+		}
+
+		search = start + 4
+	}
+}
+
+// matchDelimiter matches a block comment delimiter at start, the equivalent
+// of the regular expression used by WP_Block_Parser::next_token():
+//
+//	<!--\s+(/)?wp:([a-z][a-z0-9_-]*/)?([a-z][a-z0-9_-]*)\s+({...}\s+)?(/)?-->
+func matchDelimiter(doc string, start int) (int, string, map[string]interface{}, int, bool) {
+
+	pos := start + len("<!--")
+
+	n := skipSpace(doc, pos)
+	if n == pos {
+		return tokenNone, "", nil, 0, false
+	}
+	pos = n
+
+	isCloser := false
+	if pos < len(doc) && doc[pos] == '/' {
+		isCloser = true
+		pos++
+	}
+
+	if !strings.HasPrefix(doc[pos:], "wp:") {
+		return tokenNone, "", nil, 0, false
+	}
+	pos += len("wp:")
+
+	name, n := scanName(doc, pos)
+	if n == pos {
+		return tokenNone, "", nil, 0, false
+	}
+	pos = n
+
+	if pos < len(doc) && doc[pos] == '/' {
+		second, n := scanName(doc, pos+1)
+		if n == pos+1 {
+			return tokenNone, "", nil, 0, false
+		}
+		name = name + "/" + second
+		pos = n
+	} else {
+		name = "core/" + name
+	}
+
+	n = skipSpace(doc, pos)
+	if n == pos {
+		return tokenNone, "", nil, 0, false
+	}
+	pos = n
+
+	var attrs map[string]interface{}
+	hasAttrs := false
+
+	if pos < len(doc) && doc[pos] == '{' {
+		end := attrsEnd(doc, pos)
+		if end < 0 {
+			return tokenNone, "", nil, 0, false
+		}
+
+		// Invalid JSON leaves the attributes nil, as json_decode() does.
+		if err := json.Unmarshal([]byte(doc[pos:end+1]), &attrs); err != nil {
+			attrs = nil
+		}
+
+		hasAttrs = true
+		pos = skipSpace(doc, end+1)
+	}
+
+	isVoid := false
+	if pos < len(doc) && doc[pos] == '/' {
+		isVoid = true
+		pos++
+	}
+
+	if !strings.HasPrefix(doc[pos:], "-->") {
+		return tokenNone, "", nil, 0, false
+	}
+	pos += len("-->")
+
+	if attrs == nil && !hasAttrs {
+		attrs = map[string]interface{}{}
+	}
+
+	// Closers with attributes or a void flag are malformed, but harmless.
+	switch {
+	case isVoid:
+		return tokenVoid, name, attrs, pos - start, true
+	case isCloser:
+		return tokenCloser, name, nil, pos - start, true
+	}
+
+	return tokenOpener, name, attrs, pos - start, true
+}
+
+// attrsEnd returns the offset of the "}" closing the attributes object, the
+// first one followed by whitespace and the end of the delimiter.
+func attrsEnd(doc string, start int) int {
+
+	for i := start + 1; i < len(doc); i++ {
+
+		if doc[i] != '}' {
+			continue
+		}
+
+		n := skipSpace(doc, i+1)
+		if n == i+1 {
+			continue
+		}
+
+		if strings.HasPrefix(doc[n:], "-->") || strings.HasPrefix(doc[n:], "/-->") {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func skipSpace(doc string, pos int) int {
+
+	for pos < len(doc) {
+		switch doc[pos] {
+		case ' ', '\t', '\n', '\r', '\f', '\v':
+			pos++
+		default:
+			return pos
+		}
+	}
+
+	return pos
+}
+
+// scanName reads a [a-z][a-z0-9_-]* name.
+func scanName(doc string, pos int) (string, int) {
+
+	start := pos
+
+	if pos >= len(doc) || doc[pos] < 'a' || doc[pos] > 'z' {
+		return "", pos
+	}
+
+	for pos < len(doc) {
+		c := doc[pos]
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' || c == '-' {
+			pos++
+			continue
+		}
+		break
+	}
+
+	return doc[start:pos], pos
+}
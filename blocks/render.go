@@ -0,0 +1,41 @@
+package blocks
+
+import "strings"
+
+// Render serializes parsed blocks back to HTML without their comment
+// delimiters, as render_block() does for static blocks. Reusable blocks are
+// replaced by their expanded inner blocks.
+func Render(blocks []*Block) string {
+
+	var b strings.Builder
+
+	for _, block := range blocks {
+		render(&b, block)
+	}
+
+	return b.String()
+}
+
+func render(b *strings.Builder, block *Block) {
+
+	if block.Name == "core/block" {
+		for _, inner := range block.InnerBlocks {
+			render(b, inner)
+		}
+		return
+	}
+
+	next := 0
+	for _, chunk := range block.InnerContent {
+
+		if chunk != nil {
+			b.WriteString(*chunk)
+			continue
+		}
+
+		if next < len(block.InnerBlocks) {
+			render(b, block.InnerBlocks[next])
+			next++
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package blocks
+
+// maxReusableExpansions caps the reusable blocks expanded in one document,
+// which reusable blocks referencing others many times over would multiply.
+const maxReusableExpansions = 100
+
+// ExpandReusable replaces the inner blocks of every core/block with the
+// parsed content of the wp_block post its "ref" attribute points to. load
+// returns that content and false when the reference cannot be resolved; it
+// is called once for each reference. A reusable block within itself is left
+// empty, as render_block_core_block() does, and so are the reusable blocks
+// past maxReusableExpansions.
+func ExpandReusable(blocks []*Block, load func(ref int64) (string, bool, error)) error {
+
+	e := &reusableExpansion{
+		load:     load,
+		contents: make(map[int64]*string),
+		seen:     make(map[int64]bool),
+	}
+
+	return e.expand(blocks)
+}
+
+// reusableExpansion is the state of ExpandReusable: the contents loaded, nil
+// for references that cannot be resolved, the references being expanded,
+// like the $seen_refs of render_block_core_block(), and the count of
+// expansions.
+type reusableExpansion struct {
+	load     func(ref int64) (string, bool, error)
+	contents map[int64]*string
+	seen     map[int64]bool
+	expanded int
+}
+
+func (e *reusableExpansion) expand(blocks []*Block) error {
+
+	for _, b := range blocks {
+
+		if b.Name != "core/block" {
+			if err := e.expand(b.InnerBlocks); err != nil {
+				return err
+			}
+			continue
+		}
+
+		ref := int64(b.IntAttr("ref"))
+		if ref <= 0 {
+			continue
+		}
+
+		if e.seen[ref] || e.expanded >= maxReusableExpansions {
+			b.InnerBlocks = nil
+			continue
+		}
+
+		content, err := e.content(ref)
+		if err != nil {
+			return err
+		}
+		if content == nil {
+			continue
+		}
+
+		e.expanded++
+		b.InnerBlocks = Parse(*content)
+
+		e.seen[ref] = true
+		err = e.expand(b.InnerBlocks)
+		delete(e.seen, ref)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *reusableExpansion) content(ref int64) (*string, error) {
+
+	if content, ok := e.contents[ref]; ok {
+		return content, nil
+	}
+
+	content, ok, err := e.load(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		e.contents[ref] = &content
+	} else {
+		e.contents[ref] = nil
+	}
+
+	return e.contents[ref], nil
+}
@@ -0,0 +1,142 @@
+package blocks
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func paragraph(text string) string {
+
+	return "<!-- wp:paragraph --><p>" + text + "</p><!-- /wp:paragraph -->"
+}
+
+func reference(ref string) string {
+
+	return `<!-- wp:block {"ref":` + ref + `} /-->`
+}
+
+func TestExpandReusable(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		document  string
+		posts     map[int64]string
+		want      string
+		wantLoads int
+	}{
+		{
+			name:      "reference",
+			document:  paragraph("a") + reference("1"),
+			posts:     map[int64]string{1: paragraph("b")},
+			want:      "<p>a</p><p>b</p>",
+			wantLoads: 1,
+		},
+		{
+			name:      "missing",
+			document:  reference("1") + reference("0") + reference("-1"),
+			want:      "",
+			wantLoads: 1,
+		},
+		{
+			name:      "same reference twice",
+			document:  reference("1") + reference("1"),
+			posts:     map[int64]string{1: paragraph("b")},
+			want:      "<p>b</p><p>b</p>",
+			wantLoads: 1,
+		},
+		{
+			name:      "self reference",
+			document:  reference("1"),
+			posts:     map[int64]string{1: paragraph("a") + reference("1")},
+			want:      "<p>a</p>",
+			wantLoads: 1,
+		},
+		{
+			name:     "mutual reference",
+			document: reference("1"),
+			posts: map[int64]string{
+				1: paragraph("a") + reference("2"),
+				2: paragraph("b") + reference("1"),
+			},
+			want:      "<p>a</p><p>b</p>",
+			wantLoads: 2,
+		},
+		{
+			name:     "nested in another block",
+			document: "<!-- wp:group --><div>" + reference("1") + "</div><!-- /wp:group -->",
+			posts: map[int64]string{
+				1: "<!-- wp:group --><div>" + reference("1") + paragraph("a") + "</div><!-- /wp:group -->",
+			},
+			want:      "<div><div><p>a</p></div></div>",
+			wantLoads: 1,
+		},
+	}
+
+	for _, tt := range tests {
+
+		loads := 0
+		load := func(ref int64) (string, bool, error) {
+			loads++
+			content, ok := tt.posts[ref]
+			return content, ok, nil
+		}
+
+		parsed := Parse(tt.document)
+		if err := ExpandReusable(parsed, load); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		if got := Render(parsed); got != tt.want {
+			t.Errorf("%s: rendered %q, want %q", tt.name, got, tt.want)
+		}
+		if loads != tt.wantLoads {
+			t.Errorf("%s: %d loads, want %d", tt.name, loads, tt.wantLoads)
+		}
+	}
+}
+
+// TestExpandReusableFanOut checks that reusable blocks referencing others
+// many times over are expanded no more than maxReusableExpansions times.
+func TestExpandReusableFanOut(t *testing.T) {
+
+	posts := map[int64]string{10: paragraph("x")}
+	for ref := 1; ref < 10; ref++ {
+		posts[int64(ref)] = strings.Repeat(reference(strconv.Itoa(ref+1)), 10)
+	}
+
+	loads := 0
+	load := func(ref int64) (string, bool, error) {
+		loads++
+		content, ok := posts[ref]
+		return content, ok, nil
+	}
+
+	parsed := Parse(reference("1"))
+	if err := ExpandReusable(parsed, load); err != nil {
+		t.Fatal(err)
+	}
+
+	if loads != 10 {
+		t.Errorf("%d loads, want 10", loads)
+	}
+
+	if got := strings.Count(Render(parsed), "<p>x</p>"); got > maxReusableExpansions {
+		t.Errorf("%d paragraphs rendered, want at most %d", got, maxReusableExpansions)
+	}
+}
+
+func TestExpandReusableError(t *testing.T) {
+
+	errLoad := errors.New("load failed")
+
+	err := ExpandReusable(Parse(reference("1")), func(ref int64) (string, bool, error) {
+		return "", false, errLoad
+	})
+
+	if err != errLoad {
+		t.Errorf("ExpandReusable() = %v, want %v", err, errLoad)
+	}
+}
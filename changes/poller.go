@@ -0,0 +1,509 @@
+// Package changes finds edits made to the database behind the server's back,
+// in wp-admin or by plugins, and publishes them on the event bus.
+package changes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iyut/graphql-go/events"
+)
+
+// DefaultInterval is how often a site is polled when it sets no interval.
+const DefaultInterval = 10 * time.Second
+
+// DefaultSweepInterval is how often the whole tables of a site are checked
+// when it sets no sweep interval.
+const DefaultSweepInterval = 5 * time.Minute
+
+// overlapSeconds re-reads rows stamped shortly before the watermark, in case
+// their transaction committed late. The snapshot drops the repeats.
+const overlapSeconds = 60
+
+// Site is one WordPress site of the database, by table prefix. Rows past the
+// high-water marks are read every Interval, the whole tables are checked
+// every Sweep.
+type Site struct {
+	Prefix   string
+	Interval time.Duration
+	Sweep    time.Duration
+}
+
+// Poller diffs the posts, comments and terms of its sites against a snapshot
+// kept in memory. New rows are found on every poll by their ID and date
+// columns. At the slower sweep interval a checksum over the whole table
+// reveals deletions and changes that touch no date, like WP-Cron publishing a
+// scheduled post, and only then is the whole table read. Terms have no date,
+// so they are only checked by the sweep.
+//
+// Only the high-water marks are persisted. After a restart the rows past
+// them are announced and the first sweep takes a new snapshot, so deletions
+// and dateless changes made while the server was down go unnoticed.
+type Poller struct {
+	db    *sql.DB
+	bus   *events.Bus
+	file  string
+	sites []Site
+
+	mu    sync.Mutex
+	state *watermark
+
+	// recent holds when events published by others were seen, so that a
+	// poll does not announce the same change again.
+	recentMu sync.Mutex
+	recent   map[string]time.Time
+	window   time.Duration
+}
+
+// NewPoller returns a poller keeping its watermark in file.
+func NewPoller(db *sql.DB, bus *events.Bus, file string, sites []Site) (*Poller, error) {
+
+	state, err := loadWatermark(file)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Poller{
+		db:     db,
+		bus:    bus,
+		file:   file,
+		state:  state,
+		recent: make(map[string]time.Time),
+	}
+
+	for _, site := range sites {
+		if site.Interval <= 0 {
+			site.Interval = DefaultInterval
+		}
+		if site.Sweep <= 0 {
+			site.Sweep = DefaultSweepInterval
+		}
+		if window := 2*site.Interval + overlapSeconds*time.Second; window > p.window {
+			p.window = window
+		}
+		p.sites = append(p.sites, site)
+	}
+
+	return p, nil
+}
+
+// Run polls every site at its interval until the context ends.
+func (p *Poller) Run(ctx context.Context) {
+
+	sub, unsubscribe := p.bus.Subscribe()
+	defer unsubscribe()
+
+	var wg sync.WaitGroup
+
+	for _, site := range p.sites {
+		wg.Add(1)
+		go func(site Site) {
+			defer wg.Done()
+			p.runSite(ctx, site)
+		}(site)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+
+		case event := <-sub:
+			if event.Source != events.SourcePoll {
+				p.remember(event)
+			}
+		}
+	}
+}
+
+func (p *Poller) runSite(ctx context.Context, site Site) {
+
+	ticker := time.NewTicker(site.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.poll(site); err != nil {
+			log.Printf("changes: %s: %s", site.Prefix, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll publishes the changes of a site since the previous poll, sweeping the
+// whole tables when the sweep interval has passed, and saves the watermark
+// when a high-water mark moved. The first poll of a site only takes its
+// snapshot.
+func (p *Poller) poll(site Site) error {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prefix := site.Prefix
+
+	state, known := p.state.Sites[prefix]
+	if !known {
+		state = newSiteState()
+	}
+
+	marks := state.highWater
+	sweep := !state.loaded || time.Since(state.swept) >= site.Sweep
+
+	found, err := p.pollPosts(prefix, state, sweep)
+	if err == nil {
+		var commentEvents []events.Event
+		commentEvents, err = p.pollComments(prefix, state, sweep)
+		found = append(found, commentEvents...)
+	}
+	if err == nil && sweep {
+		var termEvents []events.Event
+		termEvents, err = p.pollTerms(prefix, state)
+		found = append(found, termEvents...)
+	}
+	if err == nil && sweep {
+		state.loaded = true
+		state.swept = time.Now()
+	}
+
+	if !known {
+		if err != nil {
+			return err
+		}
+		p.state.Sites[prefix] = state
+		return p.state.save(p.file)
+	}
+
+	for _, event := range found {
+		event.Site = prefix
+		event.Source = events.SourcePoll
+		if !p.announced(event) {
+			p.bus.Publish(event)
+		}
+	}
+
+	if state.highWater != marks {
+		if saveErr := p.state.save(p.file); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}
+
+	return err
+}
+
+func (p *Poller) pollPosts(prefix string, state *siteState, sweep bool) ([]events.Event, error) {
+
+	table := prefix + "posts"
+	filter := "post_type != 'revision' AND post_status != 'auto-draft'"
+	hash := "CRC32(CONCAT(ID, ':', post_status, ':', post_modified_gmt))"
+	columns := "ID, post_status, post_modified_gmt, " + hash
+
+	query := "SELECT " + columns + " FROM " + table + " WHERE " + filter + " AND (ID > ? OR post_modified_gmt >= DATE_SUB(?, INTERVAL " + strconv.Itoa(overlapSeconds) + " SECOND))"
+
+	_, found, err := p.scanPosts(state, query, state.PostMaxID, state.PostModified)
+	if err != nil || !sweep {
+		return found, err
+	}
+
+	if state.loaded {
+		count, sum, err := p.checksum("SELECT COUNT(*), COALESCE(SUM(" + hash + "), 0) FROM " + table + " WHERE " + filter)
+		if err != nil {
+			return found, err
+		}
+
+		if snapCount, snapSum := state.postChecksum(); count == snapCount && sum == snapSum {
+			return found, nil
+		}
+	}
+
+	seen, more, err := p.scanPosts(state, "SELECT "+columns+" FROM "+table+" WHERE "+filter)
+	found = append(found, more...)
+	if err != nil {
+		return found, err
+	}
+
+	for postID := range state.Posts {
+		if !seen[postID] {
+			delete(state.Posts, postID)
+			found = append(found, events.Event{Type: events.PostDeleted, ObjectID: postID})
+		}
+	}
+
+	return found, nil
+}
+
+// scanPosts brings the snapshot up to date with the rows of the query,
+// returning the posts it read and the events of their changes. Until the
+// first sweep the snapshot is incomplete, and a post it lacks is only a
+// change when it lies past the high-water marks.
+func (p *Poller) scanPosts(state *siteState, query string, args ...interface{}) (map[int64]bool, []events.Event, error) {
+
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	maxID, modified := state.PostMaxID, state.PostModified
+
+	seen := make(map[int64]bool)
+
+	var found []events.Event
+
+	for rows.Next() {
+
+		var postID int64
+		var post postState
+
+		if err := rows.Scan(&postID, &post.Status, &post.Modified, &post.Hash); err != nil {
+			return nil, found, err
+		}
+
+		seen[postID] = true
+
+		old, ok := state.Posts[postID]
+		state.Posts[postID] = post
+
+		if postID > state.PostMaxID {
+			state.PostMaxID = postID
+		}
+		if post.Modified > state.PostModified {
+			state.PostModified = post.Modified
+		}
+
+		switch {
+		case ok && old == post:
+			continue
+		case ok:
+			found = append(found, events.Event{Type: events.PostUpdated, ObjectID: postID})
+		case state.loaded || postID > maxID:
+			found = append(found, events.Event{Type: events.PostCreated, ObjectID: postID})
+		case post.Modified > modified:
+			found = append(found, events.Event{Type: events.PostUpdated, ObjectID: postID})
+			continue
+		default:
+			continue
+		}
+
+		if post.Status == "publish" && old.Status != "publish" {
+			found = append(found, events.Event{Type: events.PostPublished, ObjectID: postID})
+		}
+	}
+
+	return seen, found, rows.Err()
+}
+
+func (p *Poller) pollComments(prefix string, state *siteState, sweep bool) ([]events.Event, error) {
+
+	table := prefix + "comments"
+	hash := "CRC32(CONCAT(comment_ID, ':', comment_post_ID, ':', comment_approved, ':', comment_content))"
+	columns := "comment_ID, comment_post_ID, comment_approved, comment_date_gmt, " + hash
+
+	query := "SELECT " + columns + " FROM " + table + " WHERE comment_ID > ? OR comment_date_gmt >= DATE_SUB(?, INTERVAL " + strconv.Itoa(overlapSeconds) + " SECOND)"
+
+	_, found, err := p.scanComments(state, query, state.CommentMaxID, state.CommentDate)
+	if err != nil || !sweep {
+		return found, err
+	}
+
+	if state.loaded {
+		count, sum, err := p.checksum("SELECT COUNT(*), COALESCE(SUM(" + hash + "), 0) FROM " + table)
+		if err != nil {
+			return found, err
+		}
+
+		if snapCount, snapSum := state.commentChecksum(); count == snapCount && sum == snapSum {
+			return found, nil
+		}
+	}
+
+	seen, more, err := p.scanComments(state, "SELECT "+columns+" FROM "+table)
+	found = append(found, more...)
+	if err != nil {
+		return found, err
+	}
+
+	for commentID, comment := range state.Comments {
+		if !seen[commentID] {
+			delete(state.Comments, commentID)
+			found = append(found, events.Event{Type: events.CommentDeleted, ObjectID: commentID, PostID: comment.PostID})
+		}
+	}
+
+	return found, nil
+}
+
+// scanComments brings the snapshot up to date with the rows of the query,
+// returning the comments it read and the events of their changes. Until the
+// first sweep only comments past the high-water mark are new.
+func (p *Poller) scanComments(state *siteState, query string, args ...interface{}) (map[int64]bool, []events.Event, error) {
+
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	maxID := state.CommentMaxID
+
+	seen := make(map[int64]bool)
+
+	var found []events.Event
+
+	for rows.Next() {
+
+		var commentID int64
+		var date string
+		var comment commentState
+
+		if err := rows.Scan(&commentID, &comment.PostID, &comment.Approved, &date, &comment.Hash); err != nil {
+			return nil, found, err
+		}
+
+		seen[commentID] = true
+
+		old, ok := state.Comments[commentID]
+		state.Comments[commentID] = comment
+
+		if commentID > state.CommentMaxID {
+			state.CommentMaxID = commentID
+		}
+		if date > state.CommentDate {
+			state.CommentDate = date
+		}
+
+		switch {
+		case !ok && (state.loaded || commentID > maxID):
+			found = append(found, events.Event{Type: events.CommentCreated, ObjectID: commentID, PostID: comment.PostID})
+		case !ok:
+			continue
+		case comment.Approved == "1" && old.Approved != "1":
+			found = append(found, events.Event{Type: events.CommentApproved, ObjectID: commentID, PostID: comment.PostID})
+		case old != comment:
+			found = append(found, events.Event{Type: events.CommentUpdated, ObjectID: commentID, PostID: comment.PostID})
+		}
+	}
+
+	return seen, found, rows.Err()
+}
+
+// pollTerms compares terms by name, slug, taxonomy, description and parent.
+// The post count is left out, it changes with every post assigned. The first
+// sweep only takes the snapshot.
+func (p *Poller) pollTerms(prefix string, state *siteState) ([]events.Event, error) {
+
+	from := prefix + "term_taxonomy tt INNER JOIN " + prefix + "terms t ON t.term_id = tt.term_id"
+	hash := "CRC32(CONCAT(tt.term_taxonomy_id, ':', t.term_id, ':', t.name, ':', t.slug, ':', tt.taxonomy, ':', tt.description, ':', tt.parent))"
+
+	if state.loaded {
+		count, sum, err := p.checksum("SELECT COUNT(*), COALESCE(SUM(" + hash + "), 0) FROM " + from)
+		if err != nil {
+			return nil, err
+		}
+
+		if snapCount, snapSum := state.termChecksum(); count == snapCount && sum == snapSum {
+			return nil, nil
+		}
+	}
+
+	rows, err := p.db.Query("SELECT tt.term_taxonomy_id, t.term_id, " + hash + " FROM " + from)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	seen := make(map[int64]bool)
+
+	var found []events.Event
+
+	for rows.Next() {
+
+		var termTaxonomyID int64
+		var term termState
+
+		if err := rows.Scan(&termTaxonomyID, &term.TermID, &term.Hash); err != nil {
+			return found, err
+		}
+
+		seen[termTaxonomyID] = true
+
+		old, ok := state.Terms[termTaxonomyID]
+		state.Terms[termTaxonomyID] = term
+
+		switch {
+		case !ok && state.loaded:
+			found = append(found, events.Event{Type: events.TermCreated, ObjectID: term.TermID})
+		case !ok:
+			continue
+		case old != term:
+			found = append(found, events.Event{Type: events.TermUpdated, ObjectID: term.TermID})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return found, err
+	}
+
+	for termTaxonomyID, term := range state.Terms {
+		if !seen[termTaxonomyID] {
+			delete(state.Terms, termTaxonomyID)
+			found = append(found, events.Event{Type: events.TermDeleted, ObjectID: term.TermID})
+		}
+	}
+
+	return found, nil
+}
+
+func (p *Poller) checksum(query string) (int, uint64, error) {
+
+	var count int
+	var sum uint64
+
+	err := p.db.QueryRow(query).Scan(&count, &sum)
+
+	return count, sum, err
+}
+
+func recentKey(event events.Event) string {
+
+	return fmt.Sprintf("%s %s %d", event.Site, event.Type, event.ObjectID)
+}
+
+func (p *Poller) remember(event events.Event) {
+
+	p.recentMu.Lock()
+	defer p.recentMu.Unlock()
+
+	now := time.Now()
+
+	for key, seen := range p.recent {
+		if now.Sub(seen) > p.window {
+			delete(p.recent, key)
+		}
+	}
+
+	p.recent[recentKey(event)] = now
+}
+
+// announced tells whether someone else published the event lately.
+func (p *Poller) announced(event events.Event) bool {
+
+	p.recentMu.Lock()
+	defer p.recentMu.Unlock()
+
+	seen, ok := p.recent[recentKey(event)]
+
+	return ok && time.Since(seen) <= p.window
+}
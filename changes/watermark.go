@@ -0,0 +1,150 @@
+package changes
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// watermark holds the high-water marks of every site, persisted between runs
+// so that rows created or modified while the server was down are found on
+// start.
+type watermark struct {
+	Sites map[string]*siteState `json:"sites"`
+}
+
+// highWater is the newest row the poller has seen in each table.
+type highWater struct {
+	PostMaxID    int64  `json:"post_max_id"`
+	PostModified string `json:"post_modified_gmt"`
+	CommentMaxID int64  `json:"comment_max_id"`
+	CommentDate  string `json:"comment_date_gmt"`
+}
+
+// siteState holds the high-water marks of a site and, in memory only, a
+// snapshot of its rows taken by the last sweep.
+type siteState struct {
+	highWater
+
+	loaded bool
+	swept  time.Time
+
+	Posts    map[int64]postState    `json:"-"`
+	Comments map[int64]commentState `json:"-"`
+	Terms    map[int64]termState    `json:"-"`
+}
+
+type postState struct {
+	Status   string
+	Modified string
+	Hash     uint32
+}
+
+type commentState struct {
+	PostID   int64
+	Approved string
+	Hash     uint32
+}
+
+// termState is a term in one taxonomy, by term_taxonomy_id.
+type termState struct {
+	TermID int64
+	Hash   uint32
+}
+
+func newSiteState() *siteState {
+
+	return &siteState{
+		Posts:    make(map[int64]postState),
+		Comments: make(map[int64]commentState),
+		Terms:    make(map[int64]termState),
+	}
+}
+
+func (s *siteState) postChecksum() (int, uint64) {
+
+	var sum uint64
+	for _, post := range s.Posts {
+		sum += uint64(post.Hash)
+	}
+
+	return len(s.Posts), sum
+}
+
+func (s *siteState) commentChecksum() (int, uint64) {
+
+	var sum uint64
+	for _, comment := range s.Comments {
+		sum += uint64(comment.Hash)
+	}
+
+	return len(s.Comments), sum
+}
+
+func (s *siteState) termChecksum() (int, uint64) {
+
+	var sum uint64
+	for _, term := range s.Terms {
+		sum += uint64(term.Hash)
+	}
+
+	return len(s.Terms), sum
+}
+
+// loadWatermark reads the watermark file, which need not exist yet.
+func loadWatermark(file string) (*watermark, error) {
+
+	w := &watermark{Sites: make(map[string]*siteState)}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return w, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, err
+	}
+
+	if w.Sites == nil {
+		w.Sites = make(map[string]*siteState)
+	}
+
+	for prefix, state := range w.Sites {
+		if state == nil {
+			delete(w.Sites, prefix)
+			continue
+		}
+		state.Posts = make(map[int64]postState)
+		state.Comments = make(map[int64]commentState)
+		state.Terms = make(map[int64]termState)
+	}
+
+	return w, nil
+}
+
+// save writes the watermark file, replacing the previous one atomically.
+func (w *watermark) save(file string) error {
+
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(tmp).Encode(w); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/metafield"
+	"github.com/iyut/graphql-go/model"
+	"github.com/iyut/graphql-go/resolver"
+	"github.com/iyut/graphql-go/sdl"
+)
+
+/****
+*********************
+COMMANDS
+*********************
+****/
+
+const usage = `usage: graphql-go [command]
+
+Serves the GraphQL API when no command is given.
+
+commands:
+  check [-strict]        compare the schema with its resolvers, exiting with
+                         status 1 on errors, or warnings too when strict
+  generate [-schema FILE] [-dir DIR] [-models DIR]
+                         write the resolver interfaces, input types and enums
+                         of the schema, and stubs of the resolvers missing
+  schema print [-json]   print the schema served, as SDL or as introspection JSON
+  schema diff OLD NEW    list the changes between two schema files, exiting
+                         with status 1 when any is breaking
+`
+
+// runCommand runs the command of the arguments, and returns the exit status.
+func runCommand(args []string) int {
+
+	switch {
+	case len(args) >= 1 && args[0] == "check":
+		return check(openJSONFile(), args[1:])
+	case len(args) >= 1 && args[0] == "generate":
+		return generate(args[1:])
+	case len(args) >= 2 && args[0] == "schema" && args[1] == "print":
+		return schemaPrint(openJSONFile(), args[2:])
+	case len(args) >= 2 && args[0] == "schema" && args[1] == "diff":
+		return schemaDiff(args[2:])
+	}
+
+	fmt.Fprint(os.Stderr, usage)
+
+	return 2
+}
+
+// effectiveSchema is the schema served: main-schema.graphql and the types
+// of the meta fields of the settings.
+func effectiveSchema(settings Settings) ([]sdl.Source, *metafield.Registry, error) {
+
+	sources, err := sdl.ReadSources(getMainPath() + "main-schema.graphql")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metaFields, err := metafield.NewRegistry(settings.MetaFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sources = append(sources, sdl.Source{Name: "meta_fields", Text: metaFields.SDL()})
+
+	return sources, metaFields, nil
+}
+
+func schemaPrint(settings Settings, args []string) int {
+
+	flags := flag.NewFlagSet("schema print", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the result of the introspection query")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	sources, _, err := effectiveSchema(settings)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	schema, hints, err := sdl.Load(sdl.Join(sources))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if !*asJSON {
+		fmt.Print(sdl.Print(schema, hints))
+		return 0
+	}
+
+	b, err := schema.ToJSON()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Println(string(b))
+
+	return 0
+}
+
+func schemaDiff(args []string) int {
+
+	if len(args) != 2 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	oldSchema, err := loadSchemaFile(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	newSchema, err := loadSchemaFile(args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	counts := make(map[string]int)
+	for _, change := range sdl.Diff(oldSchema, newSchema) {
+		counts[change.Severity]++
+		fmt.Println(change)
+	}
+
+	fmt.Printf("%d breaking, %d dangerous, %d safe changes\n", counts[sdl.Breaking], counts[sdl.Dangerous], counts[sdl.Safe])
+
+	if counts[sdl.Breaking] > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func check(settings Settings, args []string) int {
+
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	strict := flags.Bool("strict", false, "fail on warnings too")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	sources, metaFields, err := effectiveSchema(settings)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	rootResolver := &resolver.RootResolver{MetaFields: metaFields}
+
+	metaFieldFuncs, err := rootResolver.MetaFieldFuncs()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	problems, err := sdl.CheckResolvers(sources, rootResolver, metaFieldFuncs, modelPackage)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	counts := make(map[string]int)
+	for _, problem := range problems {
+		counts[problem.Severity]++
+		fmt.Println(problem)
+	}
+
+	fmt.Printf("%d errors, %d warnings\n", counts[sdl.Error], counts[sdl.Warning])
+
+	if counts[sdl.Error] > 0 || (*strict && counts[sdl.Warning] > 0) {
+		return 1
+	}
+
+	return 0
+}
+
+func generate(args []string) int {
+
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	schemaFile := flags.String("schema", "main-schema.graphql", "the schema to generate code for")
+	dir := flags.String("dir", "resolver", "the directory of the resolvers")
+	modelsDir := flags.String("models", "model", "the directory of the models")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	schema, err := loadSchemaFile(*schemaFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	stubs, err := sdl.Generate(schema, *dir, *modelsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Println("wrote", filepath.Join(*dir, sdl.GeneratedFile))
+	if stubs > 0 {
+		fmt.Printf("added %d stubs to %s\n", stubs, filepath.Join(*dir, sdl.StubsFile))
+	}
+
+	return 0
+}
+
+// modelPackage is the import path of the models that resolvers wrap.
+var modelPackage = reflect.TypeOf(model.User{}).PkgPath()
+
+// loadSchemaFile reads a schema file, with an error naming the file.
+func loadSchemaFile(file string) (*graphql.Schema, error) {
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, _, err := sdl.Load(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	return schema, nil
+}
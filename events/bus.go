@@ -0,0 +1,228 @@
+// Package events carries content changes from the places they are made or
+// detected to the parts of the server reacting to them, like subscriptions.
+package events
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCursorExpired is returned for an event ID to resume after when the
+// events that followed it are not known anymore: the ID is older than the
+// history, from an earlier run of the server, or was never issued. The
+// client has to refetch what it shows and subscribe again.
+var ErrCursorExpired = errors.New("the events after this event ID are no longer available, refetch and subscribe again")
+
+// Event types.
+const (
+	PostCreated     = "post.created"
+	PostUpdated     = "post.updated"
+	PostPublished   = "post.published"
+	PostDeleted     = "post.deleted"
+	CommentCreated  = "comment.created"
+	CommentUpdated  = "comment.updated"
+	CommentApproved = "comment.approved"
+	CommentDeleted  = "comment.deleted"
+	TermCreated     = "term.created"
+	TermUpdated     = "term.updated"
+	TermDeleted     = "term.deleted"
+	UserCreated     = "user.created"
+	UserUpdated     = "user.updated"
+	UserDeleted     = "user.deleted"
+)
+
+// Sources of events.
+const (
+	// SourceAPI marks changes made through the GraphQL API.
+	SourceAPI = "api"
+	// SourcePoll marks changes found by polling the database.
+	SourcePoll = "poll"
+)
+
+// Event is a change of one object. PostID is set for comments too, to the
+// post they belong to. Site is the table prefix of the site the object
+// belongs to. Seq numbers the events of a bus in publishing order, and ID is
+// Seq prefixed with the epoch of the bus, which is unique across restarts.
+type Event struct {
+	Seq      uint64    `json:"seq"`
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	ObjectID int64     `json:"objectID"`
+	PostID   int64     `json:"postID,omitempty"`
+	Site     string    `json:"site,omitempty"`
+	Source   string    `json:"source,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// SubscriberBuffer is how many events a slow subscriber may lag behind
+// before it misses some.
+const SubscriberBuffer = 64
+
+// HistorySize is how many of the latest events a bus keeps for subscribers
+// resuming after a reconnect.
+const HistorySize = 256
+
+// Bus is an in-process publish/subscribe hub. Publishing never blocks on a
+// subscriber, only on listeners.
+type Bus struct {
+	epoch string
+
+	mu          sync.Mutex
+	seq         uint64
+	history     []Event
+	subscribers map[chan Event]struct{}
+	listeners   []func(Event)
+}
+
+func NewBus() *Bus {
+
+	return &Bus{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish numbers the event, sends it to every subscriber and then calls
+// every listener with it, stamping it with the current time when it has
+// none.
+func (b *Bus) Publish(event Event) {
+
+	if b == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	event, listeners := b.publish(event)
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// publish numbers the event and sends it to the subscribers, returning it
+// with the listeners to call.
+func (b *Bus) publish(event Event) (Event, []func(Event)) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event.Seq = b.seq
+	event.ID = b.epoch + "-" + strconv.FormatUint(b.seq, 10)
+
+	if len(b.history) == HistorySize {
+		copy(b.history, b.history[1:])
+		b.history = b.history[:HistorySize-1]
+	}
+	b.history = append(b.history, event)
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("events: dropped %s %d for a slow subscriber", event.Type, event.ObjectID)
+		}
+	}
+
+	return event, b.listeners
+}
+
+// Listen calls fn with every event published from now on, from the
+// publishing goroutine and after the subscribers got it. Unlike a
+// subscriber, a listener misses nothing, so it has to be quick and must not
+// publish.
+func (b *Bus) Listen(fn func(Event)) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.listeners = append(b.listeners, fn)
+}
+
+// Subscribe returns a channel of all events published from now on, and the
+// function to call once done with it, which closes the channel.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.subscribe(nil)
+}
+
+// SubscribeSince is Subscribe, first replaying the events after the event
+// ID. It fails with ErrCursorExpired when the bus cannot tell which events
+// followed it.
+func (b *Bus) SubscribeSince(id string) (<-chan Event, func(), error) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	seq, ok := b.parseID(id)
+	if !ok {
+		return nil, nil, ErrCursorExpired
+	}
+
+	// The event right after seq has to be in the history, unless there is
+	// none yet.
+	if seq < b.seq && (len(b.history) == 0 || b.history[0].Seq > seq+1) {
+		return nil, nil, ErrCursorExpired
+	}
+
+	var replay []Event
+	for _, event := range b.history {
+		if event.Seq > seq {
+			replay = append(replay, event)
+		}
+	}
+
+	ch, unsubscribe := b.subscribe(replay)
+
+	return ch, unsubscribe, nil
+}
+
+// parseID returns the seq of an event ID the bus issued.
+func (b *Bus) parseID(id string) (uint64, bool) {
+
+	i := strings.LastIndexByte(id, '-')
+	if i < 0 || id[:i] != b.epoch {
+		return 0, false
+	}
+
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil || seq > b.seq {
+		return 0, false
+	}
+
+	return seq, true
+}
+
+// subscribe registers a subscriber with the replayed events already queued.
+// The caller holds b.mu.
+func (b *Bus) subscribe(replay []Event) (<-chan Event, func()) {
+
+	ch := make(chan Event, SubscriberBuffer+len(replay))
+	for _, event := range replay {
+		ch <- event
+	}
+
+	b.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			close(ch)
+			b.mu.Unlock()
+		})
+	}
+
+	return ch, unsubscribe
+}
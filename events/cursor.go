@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Cursor connects a subscription to the transport carrying it. The transport
+// says which event to resume after, and learns which event each result it
+// sends came from, so that the client can resume from there after a
+// reconnect.
+type Cursor struct {
+	after string
+
+	mu      sync.Mutex
+	pending []string
+}
+
+// NewCursor returns a cursor resuming after the event ID, or starting with
+// new events when it is empty.
+func NewCursor(after string) *Cursor {
+
+	return &Cursor{after: after}
+}
+
+// After is the ID of the event to resume after, empty for none.
+func (c *Cursor) After() string {
+
+	return c.after
+}
+
+// Deliver records the event behind the result a subscription is about to
+// send.
+func (c *Cursor) Deliver(id string) {
+
+	c.mu.Lock()
+	c.pending = append(c.pending, id)
+	c.mu.Unlock()
+}
+
+// Next returns the ID of the event behind the next result sent, in the
+// order they were delivered, and false for results that came from no event.
+func (c *Cursor) Next() (string, bool) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.pending) == 0 {
+		return "", false
+	}
+
+	id := c.pending[0]
+	c.pending = c.pending[1:]
+
+	return id, true
+}
+
+type cursorKey struct{}
+
+func WithCursor(ctx context.Context, cursor *Cursor) context.Context {
+
+	return context.WithValue(ctx, cursorKey{}, cursor)
+}
+
+// CursorFrom returns the cursor of the context, nil when it has none.
+func CursorFrom(ctx context.Context) *Cursor {
+
+	cursor, _ := ctx.Value(cursorKey{}).(*Cursor)
+
+	return cursor
+}
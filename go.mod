@@ -9,3 +9,7 @@ require (
 	github.com/opentracing/opentracing-go v1.1.0
 	google.golang.org/appengine v1.6.5 // indirect
 )
+
+// graphql-go is built from a fork carrying local patches, see
+// third_party/graphql-go/FORK.md. vendor/ is generated from it.
+replace github.com/graph-gophers/graphql-go => ./third_party/graphql-go
@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iyut/graphql-go/analysis"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/logging"
+)
+
+/****
+*********************
+ACCESS LOG
+*********************
+****/
+
+// Headers naming the client application, as Apollo Client sends them.
+const (
+	ClientNameHeader    = "Apollographql-Client-Name"
+	ClientVersionHeader = "Apollographql-Client-Version"
+)
+
+// DefaultSensitiveNames are the SensitiveNames when none are set.
+var DefaultSensitiveNames = []string{"password", "token", "secret"}
+
+// accessRecord is what the access log keeps of a request, or of an
+// operation of a WebSocket connection, filled in as it is served. Operations
+// of batches run concurrently, hence the lock.
+type accessRecord struct {
+	mu         sync.Mutex
+	start      time.Time
+	viewerID   int64
+	operations []*accessOperation
+	errors     int
+}
+
+type accessOperation struct {
+	name          string
+	operationType string
+	query         string
+	variables     map[string]interface{}
+}
+
+type accessRecordKey struct{}
+
+// accessFrom returns the access record of the request, nil when there is
+// none, which its methods accept.
+func accessFrom(ctx context.Context) *accessRecord {
+
+	access, _ := ctx.Value(accessRecordKey{}).(*accessRecord)
+
+	return access
+}
+
+func (a *accessRecord) viewer(viewer *auth.Viewer) {
+
+	if a == nil || viewer == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.viewerID = viewer.UserID
+}
+
+func (a *accessRecord) operation(req *graphqlRequest, info *operationInfo) {
+
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.operations = append(a.operations, &accessOperation{
+		name:          info.name,
+		operationType: info.operation,
+		query:         req.Query,
+		variables:     req.Variables,
+	})
+}
+
+func (a *accessRecord) addErrors(n int) {
+
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.errors += n
+}
+
+// accessWriter counts the status and size of the response. It flushes and
+// hijacks as the writer it wraps does, for event streams and WebSockets.
+type accessWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *accessWriter) WriteHeader(statusCode int) {
+
+	if w.status == 0 {
+		w.status = statusCode
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *accessWriter) Write(b []byte) (int, error) {
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+
+	return n, err
+}
+
+func (w *accessWriter) Flush() {
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *accessWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("hijacking unsupported")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.status = http.StatusSwitchingProtocols
+	}
+
+	return conn, rw, err
+}
+
+// startRequest gives the request its ID, taken from X-Request-ID or new,
+// which the response echoes, and starts its access record.
+func (h *GraphqlHandler) startRequest(w http.ResponseWriter, r *http.Request) (*accessWriter, *http.Request, *accessRecord) {
+
+	requestID := logging.IncomingRequestID(r)
+	w.Header().Set(logging.RequestIDHeader, requestID)
+
+	access := &accessRecord{start: time.Now()}
+
+	ctx := logging.WithRequestID(r.Context(), requestID)
+	ctx = context.WithValue(ctx, accessRecordKey{}, access)
+
+	return &accessWriter{ResponseWriter: w}, r.WithContext(ctx), access
+}
+
+// startOperation starts the access record of an operation of a WebSocket
+// connection. Each operation is logged when it completes, the connection
+// itself when it closes.
+func startOperation(ctx context.Context) (context.Context, *accessRecord) {
+
+	access := &accessRecord{start: time.Now()}
+
+	if connection := accessFrom(ctx); connection != nil {
+		connection.mu.Lock()
+		access.viewerID = connection.viewerID
+		connection.mu.Unlock()
+	}
+
+	return context.WithValue(ctx, accessRecordKey{}, access), access
+}
+
+// logAccess writes the access log record of a served request. The query
+// and variables of its operations are only written at the debug level,
+// with sensitive values redacted.
+func (h *GraphqlHandler) logAccess(w *accessWriter, r *http.Request, access *accessRecord) {
+
+	if !h.AccessLog {
+		return
+	}
+
+	access.mu.Lock()
+	defer access.mu.Unlock()
+
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	fields := h.accessFields(r, access)
+	fields["status"] = status
+	fields["response_bytes"] = w.size
+
+	h.logger().Info(r.Context(), "access", fields)
+}
+
+// logOperationAccess writes the access log record of a completed operation
+// of a WebSocket connection, with the ID the client gave it.
+func (h *GraphqlHandler) logOperationAccess(ctx context.Context, r *http.Request, id string, access *accessRecord) {
+
+	if !h.AccessLog {
+		return
+	}
+
+	access.mu.Lock()
+	defer access.mu.Unlock()
+
+	fields := h.accessFields(r, access)
+	fields["operation_id"] = id
+
+	h.logger().Info(ctx, "access", fields)
+}
+
+// accessFields returns the fields of the access log record common to
+// requests and WebSocket operations. The caller holds access.mu.
+func (h *GraphqlHandler) accessFields(r *http.Request, access *accessRecord) logging.Fields {
+
+	fields := logging.Fields{
+		"method":      r.Method,
+		"path":        r.URL.Path,
+		"remote_addr": r.RemoteAddr,
+		"duration_ms": float64(time.Since(access.start).Microseconds()) / 1000,
+		"errors":      access.errors,
+	}
+
+	if access.viewerID != 0 {
+		fields["viewer_id"] = access.viewerID
+	}
+	if clientName := r.Header.Get(ClientNameHeader); len(clientName) > 0 {
+		fields["client_name"] = clientName
+	}
+	if clientVersion := r.Header.Get(ClientVersionHeader); len(clientVersion) > 0 {
+		fields["client_version"] = clientVersion
+	}
+
+	debug := h.logger().Enabled(logging.LevelDebug)
+
+	var operations []map[string]interface{}
+	for _, op := range access.operations {
+		operation := map[string]interface{}{"operation_type": op.operationType}
+		if len(op.name) > 0 {
+			operation["operation_name"] = op.name
+		}
+		if debug {
+			query, sensitiveVariables := analysis.Redact(op.query, h.sensitive)
+			operation["query"] = query
+			if op.variables != nil {
+				operation["variables"] = h.redactVariables(op.variables, sensitiveVariables)
+			}
+		}
+		operations = append(operations, operation)
+	}
+
+	// A single operation is written flat, the operations of batches as a
+	// list.
+	if len(operations) == 1 {
+		for name, value := range operations[0] {
+			fields[name] = value
+		}
+	} else if len(operations) > 1 {
+		fields["operations"] = operations
+	}
+
+	return fields
+}
+
+func (h *GraphqlHandler) logger() *logging.Logger {
+
+	if h.Log == nil {
+		return logging.Default
+	}
+
+	return h.Log
+}
+
+// sensitive tells whether values of the argument, input field or variable
+// are hidden from the log: whether its name holds one of SensitiveNames,
+// ignoring case.
+func (h *GraphqlHandler) sensitive(name string) bool {
+
+	names := h.SensitiveNames
+	if names == nil {
+		names = DefaultSensitiveNames
+	}
+
+	name = strings.ToLower(name)
+	for _, sensitiveName := range names {
+		if strings.Contains(name, strings.ToLower(sensitiveName)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactVariables returns a copy of the variables with the values of
+// sensitive names, at any depth of input objects, and of the variables the
+// query gives to sensitive arguments, replaced.
+func (h *GraphqlHandler) redactVariables(variables map[string]interface{}, sensitiveVariables []string) map[string]interface{} {
+
+	redacted := make(map[string]interface{}, len(variables))
+	for name, value := range variables {
+		if h.sensitive(name) {
+			redacted[name] = "[REDACTED]"
+			continue
+		}
+		redacted[name] = h.redactValue(value)
+	}
+
+	for _, name := range sensitiveVariables {
+		if _, ok := redacted[name]; ok {
+			redacted[name] = "[REDACTED]"
+		}
+	}
+
+	return redacted
+}
+
+func (h *GraphqlHandler) redactValue(value interface{}) interface{} {
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return h.redactVariables(value, nil)
+	case []interface{}:
+		redacted := make([]interface{}, len(value))
+		for i, item := range value {
+			redacted[i] = h.redactValue(item)
+		}
+		return redacted
+	}
+
+	return value
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/iyut/graphql-go/logging"
+)
+
+/****
+*********************
+QUERY BATCHING
+*********************
+****/
+
+// DefaultMaxBatchSize is how many operations a batch may hold when
+// MaxBatchSize is zero.
+const DefaultMaxBatchSize = 10
+
+// isBatch tells whether the body of the request is a JSON array of
+// operations, as Apollo's BatchHttpLink sends them. Only the leading
+// whitespace and the first character are read, and the body stays readable.
+func isBatch(r *http.Request) bool {
+
+	if r.Method != http.MethodPost || r.Body == nil {
+		return false
+	}
+
+	body := bufio.NewReader(r.Body)
+	r.Body = bufferedBody{body, r.Body}
+
+	for n := 1; ; n++ {
+		peeked, err := body.Peek(n)
+		if err != nil {
+			return false
+		}
+		switch peeked[n-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return peeked[n-1] == '['
+	}
+}
+
+// bufferedBody is a request body read through the buffer that peeked at it.
+type bufferedBody struct {
+	*bufio.Reader
+	io.Closer
+}
+
+// serveBatch runs the operations of a batch concurrently, with the viewer
+// and context of the request, and writes their results in the same order.
+// An operation refused or failing only has errors in its own result.
+//
+// The context holds the data loaders of the request, so the operations
+// share them: what several of them ask for is loaded once.
+func (h *GraphqlHandler) serveBatch(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+
+	var reqs []*graphqlRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		RespondBadRequest(w)
+		h.logger().Info(ctx, "bad request", logging.Fields{"error": err})
+		return
+	}
+
+	maxBatchSize := h.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
+
+	if len(reqs) == 0 || len(reqs) > maxBatchSize {
+		refusal := &graphql.Response{
+			Errors: []*errors.QueryError{{
+				Message:    fmt.Sprintf("a batch must hold from 1 to %d operations, not %d", maxBatchSize, len(reqs)),
+				Extensions: map[string]interface{}{"code": "BATCH_TOO_LARGE"},
+			}},
+		}
+		accessFrom(ctx).addErrors(len(refusal.Errors))
+		h.respondGraphqlError(ctx, w, StatusCodeBadRequest, refusal)
+		return
+	}
+
+	results := make([]json.RawMessage, len(reqs))
+
+	var wg sync.WaitGroup
+
+	for i, req := range reqs {
+
+		wg.Add(1)
+
+		go func(i int, req *graphqlRequest) {
+			defer wg.Done()
+
+			if req == nil || (len(req.Query) == 0 && len(req.ID) == 0 && req.Extensions.PersistedQuery == nil) {
+				accessFrom(ctx).addErrors(1)
+				results[i], _ = json.Marshal(&graphql.Response{Errors: []*errors.QueryError{errors.Errorf("no query data")}})
+				return
+			}
+
+			result := h.run(ctx, nil, r, req)
+			if result.refusal != nil {
+				results[i], _ = json.Marshal(result.refusal)
+				return
+			}
+
+			results[i] = result.resp.body
+		}(i, req)
+	}
+
+	wg.Wait()
+
+	resultsJSON, err := json.MarshalIndent(results, "", "\t")
+	if err != nil {
+		RespondServerError(w)
+		h.logger().Error(ctx, "encoding the response", logging.Fields{"error": err})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(resultsJSON)
+}
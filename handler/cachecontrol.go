@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iyut/graphql-go/analysis"
+)
+
+/****
+*********************
+CACHE CONTROL
+*********************
+****/
+
+// etag is the entity tag of a response body.
+func etag(body []byte) string {
+
+	sum := sha256.Sum256(body)
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// writeResponse writes a response body. Operations sent in the URL of a GET
+// request, which caches can tell apart, get the Cache-Control of their
+// policy and an ETag, and no body when the client has it already.
+func writeResponse(w http.ResponseWriter, r *http.Request, req *graphqlRequest, resp *cachedResponse) {
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if req.fromURL {
+
+		w.Header().Set("Cache-Control", resp.policy.CacheControl())
+		w.Header().Set("ETag", resp.etag)
+		w.Header().Add("Vary", "Authorization, Cookie")
+
+		if age := time.Since(resp.stored); age >= time.Second {
+			w.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+		}
+
+		if etagMatches(r.Header.Get("If-None-Match"), resp.etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Write(resp.body)
+}
+
+// etagMatches tells whether an If-None-Match header names the entity tag,
+// comparing weakly as RFC 7232 asks.
+func etagMatches(ifNoneMatch string, tag string) bool {
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newCachedResponse is the response body along with its entity tag and
+// cache policy.
+func newCachedResponse(body []byte, policy analysis.CachePolicy) *cachedResponse {
+
+	return &cachedResponse{
+		body:   body,
+		etag:   etag(body),
+		policy: policy,
+		stored: time.Now(),
+	}
+}
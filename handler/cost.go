@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/iyut/graphql-go/analysis"
+	"github.com/iyut/graphql-go/logging"
+)
+
+/****
+*********************
+QUERY COST
+*********************
+****/
+
+// CostExtension is the response extension reporting the cost of a query.
+const CostExtension = "cost"
+
+// operationInfo is what is known of an operation before it runs: its type
+// and name, its static cost and the limit it was held to when costed, the
+// cache policy of its response, and its document as prepared for the schema.
+type operationInfo struct {
+	operation string
+	name      string
+	costed    bool
+	cost      int
+	maxCost   int
+	policy    analysis.CachePolicy
+	prepared  *graphql.Prepared
+}
+
+// analyze reads and validates the document, then computes the cost of the
+// operation from it. It returns a response rejecting the operation when the
+// document is invalid, when the operation cannot be told, or when it costs
+// more than MaxCost: what cannot be costed does not run. With a document
+// cache, each document is read and validated only once.
+func (h *GraphqlHandler) analyze(req *graphqlRequest) (*operationInfo, *graphql.Response) {
+
+	info := &operationInfo{operation: "query", name: req.OperationName, maxCost: h.MaxCost}
+
+	var errs []*errors.QueryError
+
+	if h.Documents != nil {
+		query := h.Documents.Get(h.Schema, req.Query)
+		info.prepared, errs = query.Prepared, query.Errors
+	} else {
+		info.prepared, errs = h.Schema.Prepare(req.Query)
+	}
+
+	if len(errs) > 0 {
+		return info, &graphql.Response{Errors: errs}
+	}
+
+	op, err := info.prepared.Operation(req.OperationName, req.Variables)
+	if err != nil {
+		return info, &graphql.Response{Errors: []*errors.QueryError{errors.Errorf("%s", err)}}
+	}
+
+	info.operation = op.Type
+	info.name = op.Name
+
+	if h.Analyzer == nil {
+		return info, nil
+	}
+
+	info.policy = h.Analyzer.CachePolicy(op)
+
+	info.costed = true
+	info.cost = h.Analyzer.Cost(op)
+
+	if h.MaxCost <= 0 || info.cost <= h.MaxCost {
+		return info, nil
+	}
+
+	resp := &graphql.Response{
+		Errors: []*errors.QueryError{{
+			Message: fmt.Sprintf("query cost %d exceeds the maximum cost of %d", info.cost, h.MaxCost),
+			Extensions: map[string]interface{}{
+				"code":    "QUERY_TOO_COSTLY",
+				"cost":    info.cost,
+				"maxCost": h.MaxCost,
+			},
+		}},
+	}
+	info.extend(resp)
+
+	return info, resp
+}
+
+// exec executes the operation as prepared by analyze.
+func (h *GraphqlHandler) exec(ctx context.Context, req *graphqlRequest, info *operationInfo) *graphql.Response {
+
+	return h.Schema.ExecPrepared(ctx, info.prepared, req.OperationName, req.Variables)
+}
+
+// subscribe subscribes to the operation as prepared by analyze.
+func (h *GraphqlHandler) subscribe(ctx context.Context, req *graphqlRequest, info *operationInfo) (<-chan interface{}, error) {
+
+	return h.Schema.SubscribePrepared(ctx, info.prepared, req.OperationName, req.Variables)
+}
+
+// extend reports the cost in the extensions of the response.
+func (c *operationInfo) extend(resp *graphql.Response) {
+
+	if !c.costed {
+		return
+	}
+
+	if resp.Extensions == nil {
+		resp.Extensions = make(map[string]interface{})
+	}
+
+	cost := map[string]interface{}{"requestedQueryCost": c.cost}
+	if c.maxCost > 0 {
+		cost["maxQueryCost"] = c.maxCost
+	}
+
+	resp.Extensions[CostExtension] = cost
+}
+
+// respondGraphqlError writes a response carrying only errors.
+func (h *GraphqlHandler) respondGraphqlError(ctx context.Context, w http.ResponseWriter, statusCode int, resp *graphql.Response) {
+
+	respJSON, err := json.Marshal(resp)
+	if err != nil {
+		RespondServerError(w)
+		h.logger().Error(ctx, "encoding the response", logging.Fields{"error": err})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(statusCode)
+	w.Write(respJSON)
+}
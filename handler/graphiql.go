@@ -0,0 +1,606 @@
+package handler
+
+import (
+	"html/template"
+	"net"
+	"net/http"
+
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/logging"
+)
+
+/****
+*********************
+GRAPHIQL
+*********************
+****/
+
+// graphiQLPolicy keeps the page from loading or calling anything but the
+// GraphQL URL itself.
+const graphiQLPolicy = "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'; img-src data:; base-uri 'none'; form-action 'none'"
+
+// wantsGraphiQL tells whether the request is a browser opening the GraphQL
+// URL, rather than an operation sent over GET.
+func wantsGraphiQL(r *http.Request) bool {
+
+	if r.Method != http.MethodGet || !accepts(r, "text/html") {
+		return false
+	}
+
+	query := r.URL.Query()
+
+	return len(query.Get("query")) == 0 && len(query.Get("extensions")) == 0 && len(query.Get("id")) == 0
+}
+
+// serveGraphiQL writes the GraphiQL page, its Authorization header
+// prefilled with a token of DevUserID when the request may have one.
+func (h *GraphqlHandler) serveGraphiQL(w http.ResponseWriter, r *http.Request) {
+
+	headers := map[string]string{}
+	if token, ok := h.devToken(r); ok {
+		headers["Authorization"] = "Bearer " + token
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", graphiQLPolicy)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "DENY")
+
+	err := graphiQLTemplate.Execute(w, map[string]interface{}{
+		"Endpoint": r.URL.Path,
+		"Headers":  headers,
+	})
+	if err != nil {
+		h.logger().Error(r.Context(), "writing the GraphiQL page", logging.Fields{"error": err})
+	}
+}
+
+// devToken returns a token of DevUserID for the GraphiQL page. Only
+// requests made on the server's own machine, not relayed by a proxy, get
+// one, and never for a user who may manage the site.
+func (h *GraphqlHandler) devToken(r *http.Request) (string, bool) {
+
+	if h.DevUserID <= 0 || h.Auth == nil || !fromLoopback(r) {
+		return "", false
+	}
+
+	roles, err := h.Auth.Roles(h.DevUserID)
+	if err != nil {
+		h.logger().Error(r.Context(), "reading the roles of the GraphiQL user", logging.Fields{"error": err})
+		return "", false
+	}
+
+	if (&auth.Viewer{UserID: h.DevUserID, Roles: roles}).Can("manage_options") {
+		h.logger().Warn(r.Context(), "refusing a GraphiQL token to an administrator", logging.Fields{"user_id": h.DevUserID})
+		return "", false
+	}
+
+	token, err := h.Auth.NewToken(h.DevUserID)
+	if err != nil {
+		h.logger().Error(r.Context(), "issuing the GraphiQL token", logging.Fields{"error": err})
+		return "", false
+	}
+
+	return token, true
+}
+
+// fromLoopback tells whether the request comes from the loopback interface
+// without forwarding headers, which a proxy on the same machine would add.
+func fromLoopback(r *http.Request) bool {
+
+	if len(r.Header.Get("X-Forwarded-For")) > 0 || len(r.Header.Get("Forwarded")) > 0 {
+		return false
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// graphiQLTemplate is the GraphiQL page. It is not the GraphiQL build from
+// npm but a small editor after it, written into the page so that it needs
+// no assets and no network: it sends queries and mutations over POST,
+// subscriptions over Server-Sent Events, and browses the schema by
+// introspection.
+var graphiQLTemplate = template.Must(template.New("graphiql").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="referrer" content="no-referrer">
+<title>GraphiQL</title>
+<style>
+* { box-sizing: border-box; }
+html, body { height: 100%; margin: 0; }
+body { display: flex; flex-direction: column; font: 14px/1.4 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1b2240; background: #f6f7f9; }
+header { display: flex; align-items: center; gap: 8px; padding: 8px 12px; background: #fff; border-bottom: 1px solid #dde1e8; }
+header h1 { font-size: 16px; margin: 0 12px 0 0; color: #e10098; }
+header .spacer { flex: 1; }
+button, select { font: inherit; padding: 4px 12px; border: 1px solid #c7ccd6; border-radius: 4px; background: #fff; cursor: pointer; }
+button.run { background: #e10098; border-color: #e10098; color: #fff; font-weight: 600; }
+button:disabled { opacity: .5; cursor: default; }
+main { flex: 1; display: flex; min-height: 0; }
+section { display: flex; flex-direction: column; min-width: 0; border-right: 1px solid #dde1e8; }
+#editors { flex: 1; }
+#result-pane { flex: 1; background: #fff; }
+#docs { width: 320px; display: none; overflow: auto; background: #fff; padding: 8px 12px; }
+#docs.open { display: block; }
+label { display: block; padding: 4px 12px; font-size: 12px; font-weight: 600; text-transform: uppercase; letter-spacing: .04em; color: #6b7385; background: #eef0f4; border-top: 1px solid #dde1e8; cursor: pointer; user-select: none; }
+textarea, pre { margin: 0; padding: 8px 12px; border: 0; font: 13px/1.5 Menlo, Consolas, "Liberation Mono", monospace; tab-size: 2; }
+textarea { resize: none; outline: none; background: #fff; color: inherit; white-space: pre; }
+#query { flex: 3; }
+#variables, #headers { flex: 1; }
+#variables.closed, #headers.closed { display: none; }
+pre { flex: 1; overflow: auto; white-space: pre-wrap; word-break: break-word; }
+#status { padding: 4px 12px; font-size: 12px; color: #6b7385; border-top: 1px solid #dde1e8; }
+#docs h2 { font-size: 14px; margin: 8px 0; }
+#docs a { color: #1f61a0; cursor: pointer; text-decoration: none; }
+#docs a:hover { text-decoration: underline; }
+#docs .field { margin: 6px 0; font-family: Menlo, Consolas, monospace; font-size: 12px; }
+#docs .description { color: #6b7385; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2px 0 0 0; }
+#docs input { width: 100%; font: inherit; padding: 4px 8px; margin-bottom: 8px; border: 1px solid #c7ccd6; border-radius: 4px; }
+</style>
+</head>
+<body>
+<header>
+	<h1>GraphiQL</h1>
+	<button class="run" id="run" title="Run (Ctrl-Enter)">&#9654; Run</button>
+	<select id="operation" title="Operation to run" hidden></select>
+	<button id="prettify" title="Prettify (Shift-Ctrl-P)">Prettify</button>
+	<button id="stop" disabled>Stop</button>
+	<span class="spacer"></span>
+	<button id="toggle-docs">Docs</button>
+</header>
+<main>
+	<section id="editors">
+		<textarea id="query" spellcheck="false" autocomplete="off" aria-label="Query"></textarea>
+		<label for="variables" data-toggle="variables">Variables</label>
+		<textarea id="variables" spellcheck="false" autocomplete="off" placeholder="{}"></textarea>
+		<label for="headers" data-toggle="headers">Headers</label>
+		<textarea id="headers" class="closed" spellcheck="false" autocomplete="off" placeholder="{}"></textarea>
+	</section>
+	<section id="result-pane">
+		<pre id="result" aria-live="polite"></pre>
+		<div id="status"></div>
+	</section>
+	<section id="docs"></section>
+</main>
+<script>
+(function () {
+	"use strict";
+
+	var endpoint = {{.Endpoint}};
+	var prefilledHeaders = {{.Headers}};
+
+	var $ = function (id) { return document.getElementById(id); };
+	var storage = window.localStorage;
+	var introspection = null;
+	var running = null;
+
+	var defaultQuery = "# Welcome to GraphiQL.\n#\n# Type a query below and press Ctrl-Enter to run it.\n\nquery {\n  __typename\n}\n";
+
+	$("query").value = storage.getItem("graphiql:query") || defaultQuery;
+	$("variables").value = storage.getItem("graphiql:variables") || "";
+	$("headers").value = Object.keys(prefilledHeaders).length > 0 ? JSON.stringify(prefilledHeaders, null, 2) : (storage.getItem("graphiql:headers") || "");
+
+	["query", "variables", "headers"].forEach(function (id) {
+		$(id).addEventListener("input", function () {
+			storage.setItem("graphiql:" + id, $(id).value);
+			if (id === "query") {
+				listOperations();
+			}
+		});
+		$(id).addEventListener("keydown", function (e) {
+			if (e.key === "Tab") {
+				e.preventDefault();
+				document.execCommand("insertText", false, "  ");
+			}
+		});
+	});
+
+	Array.prototype.forEach.call(document.querySelectorAll("[data-toggle]"), function (label) {
+		label.addEventListener("click", function (e) {
+			e.preventDefault();
+			$(label.getAttribute("data-toggle")).classList.toggle("closed");
+		});
+	});
+
+	function parseJSON(text, what) {
+		if (text.trim() === "") {
+			return {};
+		}
+		try {
+			return JSON.parse(text);
+		} catch (err) {
+			throw new Error(what + " are not valid JSON: " + err.message);
+		}
+	}
+
+	// operations lists the named operations of the query, with their type.
+	function operations(query) {
+		var stripped = query.replace(/#[^\n]*/g, "").replace(/"""[\s\S]*?"""|"(?:\\.|[^"\\])*"/g, "\"\"");
+		var found = [];
+		var depth = 0;
+		var re = /[{}]|\b(query|mutation|subscription)\b\s*([_A-Za-z][_0-9A-Za-z]*)?/g;
+		var m;
+		while ((m = re.exec(stripped)) !== null) {
+			if (m[0] === "{") {
+				if (depth === 0 && found.length === 0) {
+					found.push({type: "query", name: ""});
+				}
+				depth++;
+			} else if (m[0] === "}") {
+				depth--;
+			} else if (depth === 0) {
+				found.push({type: m[1], name: m[2] || ""});
+			}
+		}
+		return found;
+	}
+
+	function listOperations() {
+		var ops = operations($("query").value).filter(function (op) { return op.name; });
+		var select = $("operation");
+		var selected = select.value;
+		select.innerHTML = "";
+		ops.forEach(function (op) {
+			var option = document.createElement("option");
+			option.value = op.name;
+			option.textContent = op.name;
+			select.appendChild(option);
+		});
+		if (ops.some(function (op) { return op.name === selected; })) {
+			select.value = selected;
+		}
+		select.hidden = ops.length < 2;
+	}
+
+	function setStatus(text) {
+		$("status").textContent = text;
+	}
+
+	function show(value) {
+		$("result").textContent = typeof value === "string" ? value : JSON.stringify(value, null, 2);
+	}
+
+	function request(headers, accept) {
+		var h = {"Content-Type": "application/json", "Accept": accept};
+		Object.keys(headers).forEach(function (k) { h[k] = String(headers[k]); });
+		return h;
+	}
+
+	function run() {
+		var query = $("query").value;
+		var operationName = $("operation").hidden ? "" : $("operation").value;
+		var variables, headers;
+
+		try {
+			variables = parseJSON($("variables").value, "Variables");
+			headers = parseJSON($("headers").value, "Headers");
+		} catch (err) {
+			show(err.message);
+			return;
+		}
+
+		stop();
+
+		var ops = operations(query);
+		var op = ops.filter(function (o) { return !operationName || o.name === operationName; })[0];
+		var body = JSON.stringify({query: query, operationName: operationName || undefined, variables: variables});
+		var started = Date.now();
+
+		if (op && op.type === "subscription") {
+			subscribe(body, headers);
+			return;
+		}
+
+		var controller = new AbortController();
+		running = controller;
+		$("stop").disabled = false;
+		setStatus("Running\u2026");
+
+		fetch(endpoint, {method: "POST", headers: request(headers, "application/json"), body: body, credentials: "same-origin", signal: controller.signal})
+			.then(function (resp) {
+				return resp.text().then(function (text) {
+					setStatus(resp.status + " " + resp.statusText + " \u00b7 " + (Date.now() - started) + " ms");
+					try {
+						show(JSON.parse(text));
+					} catch (err) {
+						show(text);
+					}
+				});
+			})
+			.catch(function (err) {
+				if (err.name !== "AbortError") {
+					setStatus("");
+					show(err.message);
+				}
+			})
+			.then(function () {
+				if (running === controller) {
+					running = null;
+					$("stop").disabled = true;
+				}
+			});
+	}
+
+	// subscribe streams the events of a subscription, newest first.
+	function subscribe(body, headers) {
+		var controller = new AbortController();
+		var events = [];
+		running = controller;
+		$("stop").disabled = false;
+		show("");
+		setStatus("Subscribing\u2026");
+
+		fetch(endpoint, {method: "POST", headers: request(headers, "text/event-stream"), body: body, credentials: "same-origin", signal: controller.signal})
+			.then(function (resp) {
+				if (!resp.ok || !resp.body) {
+					return resp.text().then(function (text) {
+						setStatus(resp.status + " " + resp.statusText);
+						show(text);
+					});
+				}
+
+				setStatus("Subscribed \u00b7 waiting for events");
+
+				var reader = resp.body.getReader();
+				var decoder = new TextDecoder();
+				var buffer = "";
+
+				function read() {
+					return reader.read().then(function (chunk) {
+						if (chunk.done) {
+							setStatus("Subscription ended \u00b7 " + events.length + " events");
+							return;
+						}
+						buffer += decoder.decode(chunk.value, {stream: true}).replace(/\r\n?/g, "\n");
+						var i;
+						while ((i = buffer.indexOf("\n\n")) >= 0) {
+							var data = buffer.slice(0, i).split("\n").filter(function (line) {
+								return line.indexOf("data:") === 0;
+							}).map(function (line) {
+								return line.slice(5).replace(/^ /, "");
+							}).join("\n");
+							buffer = buffer.slice(i + 2);
+							if (data) {
+								try {
+									events.unshift(JSON.parse(data));
+								} catch (err) {
+									events.unshift(data);
+								}
+								setStatus("Subscribed \u00b7 " + events.length + " events");
+								show(events);
+							}
+						}
+						return read();
+					});
+				}
+
+				return read();
+			})
+			.catch(function (err) {
+				if (err.name !== "AbortError") {
+					setStatus("");
+					show(err.message);
+				}
+			})
+			.then(function () {
+				if (running === controller) {
+					running = null;
+					$("stop").disabled = true;
+				}
+			});
+	}
+
+	function stop() {
+		if (running) {
+			running.abort();
+			running = null;
+			$("stop").disabled = true;
+			setStatus("Stopped");
+		}
+	}
+
+	// prettify reindents the query by its brackets.
+	function prettify() {
+		var out = "";
+		var depth = 0;
+		var lines = $("query").value.split("\n");
+		lines.forEach(function (line) {
+			var trimmed = line.trim();
+			if (trimmed === "") {
+				out += "\n";
+				return;
+			}
+			var code = trimmed.replace(/"(?:\\.|[^"\\])*"|#.*$/g, "");
+			var opens = (code.match(/[{(]/g) || []).length;
+			var closes = (code.match(/[})]/g) || []).length;
+			var leading = (code.match(/^[})]+/) || [""])[0].length;
+			depth = Math.max(depth - leading, 0);
+			out += new Array(depth + 1).join("  ") + trimmed + "\n";
+			depth = Math.max(depth + leading + opens - closes, 0);
+		});
+		$("query").value = out.replace(/\n{3,}/g, "\n\n").replace(/\n+$/, "\n");
+		storage.setItem("graphiql:query", $("query").value);
+	}
+
+	var introspectionQuery = "query IntrospectionQuery { __schema { queryType { name } mutationType { name } subscriptionType { name } types { kind name description fields(includeDeprecated: true) { name description args { name description type { ...TypeRef } defaultValue } type { ...TypeRef } isDeprecated deprecationReason } inputFields { name description type { ...TypeRef } defaultValue } enumValues(includeDeprecated: true) { name description isDeprecated deprecationReason } possibleTypes { name } } } } fragment TypeRef on __Type { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } }";
+
+	function loadSchema() {
+		var headers;
+		try {
+			headers = parseJSON($("headers").value, "Headers");
+		} catch (err) {
+			$("docs").textContent = err.message;
+			return;
+		}
+		$("docs").textContent = "Loading schema\u2026";
+		fetch(endpoint, {method: "POST", headers: request(headers, "application/json"), body: JSON.stringify({query: introspectionQuery}), credentials: "same-origin"})
+			.then(function (resp) { return resp.json(); })
+			.then(function (resp) {
+				if (!resp.data) {
+					throw new Error((resp.errors || []).map(function (e) { return e.message; }).join("\n") || "no schema");
+				}
+				introspection = resp.data.__schema;
+				showRoots();
+			})
+			.catch(function (err) {
+				$("docs").textContent = "Could not load the schema: " + err.message;
+			});
+	}
+
+	function typeName(t) {
+		if (t.kind === "NON_NULL") {
+			return typeName(t.ofType) + "!";
+		}
+		if (t.kind === "LIST") {
+			return "[" + typeName(t.ofType) + "]";
+		}
+		return t.name;
+	}
+
+	function namedType(t) {
+		return t.ofType ? namedType(t.ofType) : t.name;
+	}
+
+	function el(tag, className, text) {
+		var e = document.createElement(tag);
+		if (className) {
+			e.className = className;
+		}
+		if (text) {
+			e.textContent = text;
+		}
+		return e;
+	}
+
+	function typeLink(t) {
+		var a = el("a", "", typeName(t));
+		a.addEventListener("click", function () { showType(namedType(t)); });
+		return a;
+	}
+
+	function showRoots() {
+		var docs = $("docs");
+		docs.innerHTML = "";
+
+		var search = el("input");
+		search.placeholder = "Search types\u2026";
+		docs.appendChild(search);
+
+		var list = el("div");
+		docs.appendChild(list);
+
+		function render() {
+			var term = search.value.toLowerCase();
+			list.innerHTML = "";
+			list.appendChild(el("h2", "", "Root types"));
+			[["query", introspection.queryType], ["mutation", introspection.mutationType], ["subscription", introspection.subscriptionType]].forEach(function (root) {
+				if (root[1]) {
+					var div = el("div", "field", root[0] + ": ");
+					div.appendChild(typeLink({name: root[1].name}));
+					list.appendChild(div);
+				}
+			});
+			list.appendChild(el("h2", "", "All types"));
+			introspection.types.filter(function (t) {
+				return t.name.indexOf("__") !== 0 && t.name.toLowerCase().indexOf(term) >= 0;
+			}).forEach(function (t) {
+				var div = el("div", "field");
+				div.appendChild(typeLink({name: t.name}));
+				list.appendChild(div);
+			});
+		}
+
+		search.addEventListener("input", render);
+		render();
+	}
+
+	function showType(name) {
+		var t = introspection.types.filter(function (t) { return t.name === name; })[0];
+		var docs = $("docs");
+		if (!t) {
+			return;
+		}
+
+		docs.innerHTML = "";
+		var back = el("a", "", "\u2039 Schema");
+		back.addEventListener("click", showRoots);
+		docs.appendChild(back);
+		docs.appendChild(el("h2", "", t.name + " (" + t.kind.toLowerCase().replace("_", " ") + ")"));
+		if (t.description) {
+			docs.appendChild(el("p", "description", t.description));
+		}
+
+		(t.fields || t.inputFields || []).forEach(function (f) {
+			var div = el("div", "field", f.name);
+			if (f.args && f.args.length > 0) {
+				div.appendChild(document.createTextNode("("));
+				f.args.forEach(function (arg, i) {
+					div.appendChild(document.createTextNode((i > 0 ? ", " : "") + arg.name + ": "));
+					div.appendChild(typeLink(arg.type));
+					if (arg.defaultValue !== null && arg.defaultValue !== undefined) {
+						div.appendChild(document.createTextNode(" = " + arg.defaultValue));
+					}
+				});
+				div.appendChild(document.createTextNode(")"));
+			}
+			div.appendChild(document.createTextNode(": "));
+			div.appendChild(typeLink(f.type));
+			if (f.isDeprecated) {
+				div.appendChild(el("div", "description", "Deprecated: " + (f.deprecationReason || "")));
+			}
+			if (f.description) {
+				div.appendChild(el("div", "description", f.description));
+			}
+			docs.appendChild(div);
+		});
+
+		(t.enumValues || []).forEach(function (v) {
+			var div = el("div", "field", v.name);
+			if (v.description) {
+				div.appendChild(el("div", "description", v.description));
+			}
+			docs.appendChild(div);
+		});
+
+		(t.possibleTypes || []).forEach(function (p) {
+			var div = el("div", "field");
+			div.appendChild(typeLink({name: p.name}));
+			docs.appendChild(div);
+		});
+	}
+
+	$("run").addEventListener("click", run);
+	$("stop").addEventListener("click", stop);
+	$("prettify").addEventListener("click", prettify);
+	$("toggle-docs").addEventListener("click", function () {
+		$("docs").classList.toggle("open");
+		if ($("docs").classList.contains("open") && !introspection) {
+			loadSchema();
+		}
+	});
+
+	document.addEventListener("keydown", function (e) {
+		if ((e.ctrlKey || e.metaKey) && e.key === "Enter") {
+			e.preventDefault();
+			run();
+		} else if ((e.ctrlKey || e.metaKey) && e.shiftKey && (e.key === "P" || e.key === "p")) {
+			e.preventDefault();
+			prettify();
+		}
+	});
+
+	listOperations();
+})();
+</script>
+</body>
+</html>
+`))
@@ -2,12 +2,24 @@ package handler
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
-	"fmt"
-	"log"
+	goerrors "errors"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/iyut/graphql-go/analysis"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/logging"
+	"github.com/iyut/graphql-go/persisted"
+	"github.com/iyut/graphql-go/ratelimit"
+	"github.com/iyut/graphql-go/websocket"
 )
 
 /****
@@ -16,36 +28,42 @@ RESPONSE STATUS URL
 *********************
 ****/
 const (
-	StatusCodeOK              = 200
-	StatusCodeBadRequest      = 400
-	StatusCodeUnauthorized    = 401
-	StatusCodeRequestFailed   = 402
-	StatusCodeNotFound        = 404
-	StatusCodeConflict        = 409
-	StatusCodeTooManyRequests = 429
-	StatusCodeServerError     = 500
+	StatusCodeOK               = 200
+	StatusCodeBadRequest       = 400
+	StatusCodeUnauthorized     = 401
+	StatusCodeForbidden        = 403
+	StatusCodeRequestFailed    = 402
+	StatusCodeNotFound         = 404
+	StatusCodeMethodNotAllowed = 405
+	StatusCodeConflict         = 409
+	StatusCodeTooManyRequests  = 429
+	StatusCodeServerError      = 500
 )
 
 var Statuses = map[int]string{
-	StatusCodeOK:              "OK",
-	StatusCodeBadRequest:      "Bad Request",
-	StatusCodeUnauthorized:    "Unauthorized",
-	StatusCodeRequestFailed:   "Request Failed",
-	StatusCodeNotFound:        "Not Found",
-	StatusCodeConflict:        "Conflict",
-	StatusCodeTooManyRequests: "Too Many Requests",
-	StatusCodeServerError:     "Server Error",
+	StatusCodeOK:               "OK",
+	StatusCodeBadRequest:       "Bad Request",
+	StatusCodeUnauthorized:     "Unauthorized",
+	StatusCodeForbidden:        "Forbidden",
+	StatusCodeRequestFailed:    "Request Failed",
+	StatusCodeNotFound:         "Not Found",
+	StatusCodeMethodNotAllowed: "Method Not Allowed",
+	StatusCodeConflict:         "Conflict",
+	StatusCodeTooManyRequests:  "Too Many Requests",
+	StatusCodeServerError:      "Server Error",
 }
 
 var (
-	RespondOK              = NewResponder(StatusCodeOK)
-	RespondBadRequest      = NewResponder(StatusCodeBadRequest)
-	RespondUnauthorized    = NewResponder(StatusCodeUnauthorized)
-	RespondRequestFailed   = NewResponder(StatusCodeRequestFailed)
-	RespondNotFound        = NewResponder(StatusCodeNotFound)
-	RespondConflict        = NewResponder(StatusCodeConflict)
-	RespondTooManyRequests = NewResponder(StatusCodeTooManyRequests)
-	RespondServerError     = NewResponder(StatusCodeServerError)
+	RespondOK               = NewResponder(StatusCodeOK)
+	RespondBadRequest       = NewResponder(StatusCodeBadRequest)
+	RespondUnauthorized     = NewResponder(StatusCodeUnauthorized)
+	RespondForbidden        = NewResponder(StatusCodeForbidden)
+	RespondRequestFailed    = NewResponder(StatusCodeRequestFailed)
+	RespondNotFound         = NewResponder(StatusCodeNotFound)
+	RespondMethodNotAllowed = NewResponder(StatusCodeMethodNotAllowed)
+	RespondConflict         = NewResponder(StatusCodeConflict)
+	RespondTooManyRequests  = NewResponder(StatusCodeTooManyRequests)
+	RespondServerError      = NewResponder(StatusCodeServerError)
 )
 
 func NewResponder(statusCode int) func(http.ResponseWriter) {
@@ -62,61 +80,302 @@ func NewResponder(statusCode int) func(http.ResponseWriter) {
 
 type GraphqlHandler struct {
 	Schema *graphql.Schema
+	Auth   *auth.Authenticator
+
+	// ConnectionInitTimeout limits the wait for connection_init on
+	// WebSocket connections, DefaultConnectionInitTimeout when zero.
+	ConnectionInitTimeout time.Duration
+
+	// AllowedOrigins are the origins, besides the server's own, whose
+	// pages may open WebSocket connections, any with "*". Clients sending
+	// no Origin header are not browsers and are let in.
+	AllowedOrigins []string
+
+	// MaxConnectionOperations limits the operations running at once on a
+	// WebSocket connection, DefaultMaxConnectionOperations when zero.
+	MaxConnectionOperations int
+
+	// WriteTimeout limits each write to a WebSocket connection,
+	// websocket.DefaultWriteTimeout when zero.
+	WriteTimeout time.Duration
+
+	// SSEHeartbeat is the interval of keep-alive comments on event
+	// streams, DefaultSSEHeartbeat when zero.
+	SSEHeartbeat time.Duration
+
+	// Analyzer computes the cost of operations, which are rejected above
+	// MaxCost. No limit applies when MaxCost is zero.
+	Analyzer *analysis.Analyzer
+	MaxCost  int
+
+	// Limiter holds clients to their budgets, no limits apply when nil.
+	Limiter *ratelimit.Limiter
+
+	// Persisted resolves queries sent by hash or ID, which are refused
+	// when nil.
+	Persisted *persisted.Registry
+
+	// Documents caches queries read and validated, when not nil.
+	Documents *analysis.Cache
+
+	// Responses caches the responses to queries their cache policy allows
+	// to, when not nil.
+	Responses *ResponseCache
+
+	// Loaders returns the context with a new set of the data loaders of
+	// a request, which all the operations of a batch share. Requests have
+	// none when nil.
+	Loaders func(ctx context.Context) context.Context
+
+	// MaxBatchSize limits the operations of a batch, DefaultMaxBatchSize
+	// when zero.
+	MaxBatchSize int
+
+	// MaxBodyBytes limits the size of request bodies, DefaultMaxBodyBytes
+	// when zero.
+	MaxBodyBytes int64
+
+	// GraphiQL serves the GraphiQL page to browsers opening the GraphQL
+	// URL. Its Authorization header is prefilled with a token of
+	// DevUserID, when set, for browsers on the server's own machine and
+	// unless that user is an administrator.
+	GraphiQL  bool
+	DevUserID int64
+
+	// Log receives the errors of requests, and their access log when
+	// AccessLog is set. Values of arguments and variables whose names hold
+	// one of SensitiveNames, DefaultSensitiveNames when nil, are redacted
+	// from the queries logged.
+	Log            *logging.Logger
+	AccessLog      bool
+	SensitiveNames []string
 }
 
-func (h *GraphqlHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+func (h *GraphqlHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 
-	if r.Method != http.MethodGet {
-		RespondNotFound(w)
+	w, r, access := h.startRequest(rw, r)
+	defer h.logAccess(w, r, access)
+
+	if websocket.IsUpgrade(r) {
+		h.serveWebSocket(w, r)
+		return
+	}
+
+	h.limitBody(w, r)
+
+	if accepts(r, "text/event-stream") {
+		h.serveSSE(w, r)
 		return
 	}
 
-	if r.Body == nil {
-		RespondServerError(w)
-		log.Printf("Request Body: %s", "No query data")
+	if h.GraphiQL && wantsGraphiQL(r) {
+		h.serveGraphiQL(w, r)
 		return
 	}
 
-	type JSON = map[string]interface{}
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		RespondNotFound(w)
+		return
+	}
 
-	type ClientQuery struct {
-		OperationName string `json:"operationName"`
-		Query         string `json:"query"`
-		Variables     JSON   `json:"variables"`
+	ctx, err := h.authenticate(r)
+	if err != nil {
+		RespondUnauthorized(w)
+		h.logger().Warn(r.Context(), "authentication failed", logging.Fields{"error": err})
+		return
 	}
 
-	var rBody ClientQuery
+	if h.Loaders != nil {
+		ctx = h.Loaders(ctx)
+	}
 
-	err := json.NewDecoder(r.Body).Decode(&rBody)
+	if isBatch(r) {
+		h.serveBatch(ctx, w, r)
+		return
+	}
 
+	req, err := parseRequest(r)
 	if err != nil {
-		RespondServerError(w)
-		log.Printf("Error parsing JSON request body")
+		RespondBadRequest(w)
+		h.logger().Info(ctx, "bad request", logging.Fields{"error": err})
 		return
 	}
 
-	q1 := ClientQuery{
-		OperationName: rBody.OperationName,
-		Query:         rBody.Query,
-		Variables:     rBody.Variables,
+	result := h.run(ctx, w.Header(), r, req)
+
+	switch {
+	case result.refusal != nil:
+		h.respondGraphqlError(ctx, w, result.statusCode, result.refusal)
+	case result.failed:
+		// Errors that left no data are answered with the status of the
+		// worst of them, see failureStatus. The errors of a partial result
+		// are part of a normal response.
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(result.statusCode)
+		w.Write(result.resp.body)
+	default:
+		writeResponse(w, r, req, result.resp)
+	}
+}
+
+// operationResult is the outcome of one operation: the response of an
+// operation refused before it ran, with its status, or the response it
+// ran to, which failed when it has errors and no data, with the status
+// failureStatus gives it.
+type operationResult struct {
+	refusal    *graphql.Response
+	statusCode int
+	resp       *cachedResponse
+	failed     bool
+	errors     int
+}
+
+// run takes an operation through persisted queries, analysis, rate
+// limiting and the response cache, and executes it. Rate limit headers are
+// written when there is a header to write to. The operation and its errors
+// are counted in the access log.
+func (h *GraphqlHandler) run(ctx context.Context, header http.Header, r *http.Request, req *graphqlRequest) (result operationResult) {
+
+	defer func() {
+		if result.refusal != nil {
+			result.errors = len(result.refusal.Errors)
+		}
+		accessFrom(ctx).addErrors(result.errors)
+	}()
+
+	if refusal, statusCode := h.resolvePersisted(req); refusal != nil {
+		if header != nil {
+			header.Set("Cache-Control", "private, no-cache, must-revalidate")
+		}
+		return operationResult{refusal: refusal, statusCode: statusCode}
+	}
+
+	info, rejection := h.analyze(req)
+	accessFrom(ctx).operation(req, info)
+	if rejection != nil {
+		h.logger().Info(ctx, "operation rejected", logging.Fields{"operation_name": info.name, "error": rejection.Errors[0].Message})
+		return operationResult{refusal: rejection, statusCode: StatusCodeBadRequest}
+	}
+
+	// GET requests may be cached or prefetched, so they must not change
+	// anything.
+	if req.fromURL && info.operation == "mutation" {
+		if header != nil {
+			header.Set("Allow", http.MethodPost)
+		}
+		refusal := &graphql.Response{Errors: []*errors.QueryError{errors.Errorf("mutations are only allowed over POST")}}
+		return operationResult{refusal: refusal, statusCode: StatusCodeMethodNotAllowed}
+	}
+
+	if allowed, res := h.rateLimit(ctx, header, r, info); !allowed {
+		refusal := &graphql.Response{Errors: []*errors.QueryError{rateLimitError(res)}}
+		return operationResult{refusal: refusal, statusCode: StatusCodeTooManyRequests}
 	}
 
-	resp1 := h.Schema.Exec(context.Background(), q1.Query, q1.OperationName, q1.Variables)
+	scope, cacheable := responseScope(ctx, &info.policy)
+	cacheable = cacheable && h.Responses != nil && info.operation == "query" && info.policy.MaxAge > 0
+
+	var cacheKey string
+	var generation uint64
+
+	if cacheable {
+		cacheKey = responseCacheKey(req, scope)
+		if cached, ok := h.Responses.get(cacheKey); ok {
+			return operationResult{resp: cached}
+		}
+		generation = h.Responses.current()
+	}
+
+	resp1 := h.exec(ctx, req, info)
 	if len(resp1.Errors) > 0 {
-		RespondServerError(w)
-		log.Printf("Schema.Exec: %+v", resp1.Errors)
-		return
+		h.logger().Warn(ctx, "operation failed", logging.Fields{"operation_name": info.name, "errors": resp1.Errors})
 	}
 
+	info.extend(resp1)
+
 	json1, err := json.MarshalIndent(resp1, "", "\t")
 	if err != nil {
-		RespondServerError(w)
-		log.Printf("json.MarshalIndent: %s", err)
-		panic(err)
+		h.logger().Error(ctx, "encoding the response", logging.Fields{"error": err})
+		refusal := &graphql.Response{Errors: []*errors.QueryError{errors.Errorf("%s", Statuses[StatusCodeServerError])}}
+		return operationResult{refusal: refusal, statusCode: StatusCodeServerError}
+	}
+
+	resp := newCachedResponse(json1, info.policy)
+	if cacheable && len(resp1.Errors) == 0 {
+		h.Responses.add(cacheKey, resp, generation)
+	}
+
+	noData := len(resp1.Data) == 0 || string(resp1.Data) == "null"
+	if len(resp1.Errors) == 0 || !noData {
+		return operationResult{resp: resp, errors: len(resp1.Errors)}
+	}
+
+	return operationResult{resp: resp, failed: true, statusCode: failureStatus(resp1.Errors), errors: len(resp1.Errors)}
+}
+
+// failureStatus is the status of a response that has errors and no data:
+// 500 when the server failed, on a panic or when the database cannot be
+// reached, 400 when the variables are invalid, and else 200, as the errors
+// resolvers return, like a post not found or an action forbidden, are the
+// answer to the request.
+func failureStatus(errs []*errors.QueryError) int {
+
+	statusCode := StatusCodeOK
+
+	for _, err := range errs {
+		switch {
+		case serverFault(err):
+			return StatusCodeServerError
+		case len(err.Rule) > 0:
+			statusCode = StatusCodeBadRequest
+		}
+	}
+
+	return statusCode
+}
+
+// serverFault tells whether the error is the server's: a panic, or a
+// resolver error of the database or the network.
+func serverFault(err *errors.QueryError) bool {
+
+	if err.ResolverError == nil {
+		return strings.Contains(err.Message, "panic occurred")
+	}
+
+	var mysqlErr *mysql.MySQLError
+	var netErr net.Error
+
+	switch {
+	case goerrors.As(err.ResolverError, &mysqlErr), goerrors.As(err.ResolverError, &netErr):
+		return true
+	}
+
+	for _, fault := range []error{driver.ErrBadConn, mysql.ErrInvalidConn, sql.ErrConnDone, sql.ErrTxDone, context.DeadlineExceeded} {
+		if goerrors.Is(err.ResolverError, fault) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authenticate returns the request's context carrying its viewer, whom the
+// access log records.
+func (h *GraphqlHandler) authenticate(r *http.Request) (context.Context, error) {
+
+	ctx := r.Context()
+
+	if h.Auth == nil {
+		return ctx, nil
+	}
+
+	viewer, err := h.Auth.Authenticate(r)
+	if err != nil {
+		return nil, err
 	}
 
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.Header().Set("X-Content-Type-Options", "nosniff")
+	accessFrom(ctx).viewer(viewer)
 
-	fmt.Fprintf(w, string(json1))
+	return auth.WithViewer(ctx, viewer), nil
 }
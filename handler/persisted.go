@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"fmt"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/iyut/graphql-go/persisted"
+)
+
+/****
+*********************
+PERSISTED QUERIES
+*********************
+****/
+
+// persistedQueryVersion is the version of Automatic Persisted Queries
+// understood.
+const persistedQueryVersion = 1
+
+// resolvePersisted fills in the query of a request that sends a hash or
+// manifest ID instead, registering the query when a client sends both. It
+// returns a response refusing the request, with its status, when the query
+// is unknown or not allowed. The errors of Automatic Persisted Queries that
+// clients negotiate on come with status OK, as Apollo clients expect.
+func (h *GraphqlHandler) resolvePersisted(req *graphqlRequest) (*graphql.Response, int) {
+
+	ext := req.Extensions.PersistedQuery
+
+	if h.Persisted == nil {
+		if ext != nil || len(req.ID) > 0 {
+			return persistedQueryError(persisted.ErrNotSupported, "PERSISTED_QUERY_NOT_SUPPORTED"), StatusCodeOK
+		}
+		return nil, 0
+	}
+
+	var query string
+	var ok bool
+
+	switch {
+	case len(req.ID) > 0:
+		if query, ok = h.Persisted.Lookup(req.ID); !ok {
+			return persistedQueryError(persisted.ErrNotFound, "PERSISTED_QUERY_NOT_FOUND"), StatusCodeOK
+		}
+
+	case ext != nil && ext.Version != persistedQueryVersion:
+		return persistedQueryError(fmt.Errorf("unsupported persisted query version %d", ext.Version), "PERSISTED_QUERY_VERSION"), StatusCodeBadRequest
+
+	case ext != nil && len(req.Query) == 0:
+		if query, ok = h.Persisted.Lookup(ext.Sha256Hash); !ok {
+			return persistedQueryError(persisted.ErrNotFound, "PERSISTED_QUERY_NOT_FOUND"), StatusCodeOK
+		}
+
+	case ext != nil:
+		switch err := h.Persisted.Register(ext.Sha256Hash, req.Query); err {
+		case nil:
+			return nil, 0
+		case persisted.ErrNotSupported:
+			return persistedQueryError(err, "PERSISTED_QUERY_NOT_SUPPORTED"), StatusCodeOK
+		case persisted.ErrNotAllowed:
+			return persistedQueryError(err, "PERSISTED_QUERY_NOT_ALLOWED"), StatusCodeBadRequest
+		default:
+			return persistedQueryError(err, "PERSISTED_QUERY_HASH_MISMATCH"), StatusCodeBadRequest
+		}
+
+	case h.Persisted.Strict:
+		if !h.Persisted.Allowed(req.Query) {
+			return persistedQueryError(persisted.ErrNotAllowed, "PERSISTED_QUERY_NOT_ALLOWED"), StatusCodeBadRequest
+		}
+		return nil, 0
+
+	default:
+		return nil, 0
+	}
+
+	req.Query = query
+
+	return nil, 0
+}
+
+func persistedQueryError(err error, code string) *graphql.Response {
+
+	return &graphql.Response{
+		Errors: []*errors.QueryError{{
+			Message:    err.Error(),
+			Extensions: map[string]interface{}{"code": code},
+		}},
+	}
+}
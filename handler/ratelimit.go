@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/logging"
+	"github.com/iyut/graphql-go/ratelimit"
+)
+
+/****
+*********************
+RATE LIMITING
+*********************
+****/
+
+// rateLimit charges the operation to its client, writing the RateLimit
+// headers when there is a header to write to. A failing store lets the
+// operation through.
+func (h *GraphqlHandler) rateLimit(ctx context.Context, header http.Header, r *http.Request, info *operationInfo) (bool, ratelimit.Result) {
+
+	if h.Limiter == nil {
+		return true, ratelimit.Result{Allowed: true}
+	}
+
+	var userID int64
+	if viewer := auth.ViewerFrom(ctx); viewer != nil {
+		userID = viewer.UserID
+	}
+
+	limit, res, err := h.Limiter.Allow(r, userID, info.operation, info.cost)
+	if err != nil {
+		h.logger().Error(ctx, "rate limit store failed", logging.Fields{"error": err})
+		return true, ratelimit.Result{Allowed: true}
+	}
+
+	if header != nil && limit.Requests > 0 {
+		ratelimit.SetHeaders(header, limit, res)
+	}
+
+	return res.Allowed, res
+}
+
+// rateLimitError is the GraphQL error of a refused operation, for transports
+// without a status code.
+func rateLimitError(res ratelimit.Result) *errors.QueryError {
+
+	retryAfter := int(math.Ceil(res.RetryAfter.Seconds()))
+
+	return &errors.QueryError{
+		Message: fmt.Sprintf("rate limit exceeded, retry in %d seconds", retryAfter),
+		Extensions: map[string]interface{}{
+			"code":       "RATE_LIMITED",
+			"retryAfter": retryAfter,
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// graphqlRequest is an operation as clients send it, over any transport.
+type graphqlRequest struct {
+	OperationName string                 `json:"operationName"`
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    requestExtensions      `json:"extensions"`
+
+	// ID names an operation of the persisted query manifest.
+	ID string `json:"id"`
+
+	// fromURL tells that the operation came in the URL of a GET request,
+	// where mutations are not allowed.
+	fromURL bool
+}
+
+type requestExtensions struct {
+	PersistedQuery *persistedQueryExtension `json:"persistedQuery"`
+}
+
+type persistedQueryExtension struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+// DefaultMaxBodyBytes is how large the body of a request may be when
+// MaxBodyBytes is zero.
+const DefaultMaxBodyBytes = 1 << 20
+
+// limitBody caps the body of the request at MaxBodyBytes; reading past it
+// fails.
+func (h *GraphqlHandler) limitBody(w http.ResponseWriter, r *http.Request) {
+
+	if r.Body == nil {
+		return
+	}
+
+	maxBodyBytes := h.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = DefaultMaxBodyBytes
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+}
+
+// parseRequest reads the operation from the URL parameters of a GET
+// request, or from the JSON body.
+func parseRequest(r *http.Request) (*graphqlRequest, error) {
+
+	var req graphqlRequest
+
+	params := r.URL.Query()
+
+	if r.Method == http.MethodGet && (len(params.Get("query")) > 0 || len(params.Get("extensions")) > 0 || len(params.Get("id")) > 0) {
+
+		req.Query = params.Get("query")
+		req.OperationName = params.Get("operationName")
+		req.ID = params.Get("id")
+		req.fromURL = true
+
+		if variables := params.Get("variables"); len(variables) > 0 {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return nil, fmt.Errorf("variables: %s", err)
+			}
+		}
+
+		if extensions := params.Get("extensions"); len(extensions) > 0 {
+			if err := json.Unmarshal([]byte(extensions), &req.Extensions); err != nil {
+				return nil, fmt.Errorf("extensions: %s", err)
+			}
+		}
+
+		return &req, nil
+	}
+
+	if r.Body == nil {
+		return nil, fmt.Errorf("no query data")
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+
+	if len(req.Query) == 0 && len(req.ID) == 0 && req.Extensions.PersistedQuery == nil {
+		return nil, fmt.Errorf("no query data")
+	}
+
+	return &req, nil
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iyut/graphql-go/analysis"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/events"
+	"github.com/iyut/graphql-go/lru"
+)
+
+/****
+*********************
+RESPONSE CACHE
+*********************
+****/
+
+// ResponseCache keeps whole responses to queries for as long as their cache
+// policy allows, or until content changes. Public responses are shared by
+// all viewers, private ones kept for each viewer.
+type ResponseCache struct {
+	mu         sync.Mutex
+	generation uint64
+	lru        *lru.Cache
+}
+
+type cachedResponse struct {
+	body   []byte
+	etag   string
+	policy analysis.CachePolicy
+	stored time.Time
+}
+
+func NewResponseCache(maxEntries int, maxBytes int) *ResponseCache {
+
+	return &ResponseCache{lru: lru.New(maxEntries, maxBytes)}
+}
+
+// Run empties the cache on every content change until the context ends.
+func (c *ResponseCache) Run(ctx context.Context, bus *events.Bus) {
+
+	sub, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-sub:
+			c.Purge()
+		}
+	}
+}
+
+// Purge forgets every response, and the responses being computed.
+func (c *ResponseCache) Purge() {
+
+	c.mu.Lock()
+	c.generation++
+	c.lru.Purge()
+	c.mu.Unlock()
+}
+
+func (c *ResponseCache) Stats() lru.Stats {
+
+	return c.lru.Stats()
+}
+
+// current returns the generation of the cache, to store a response computed
+// from now on with.
+func (c *ResponseCache) current() uint64 {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+func (c *ResponseCache) get(key string) (*cachedResponse, bool) {
+
+	cached, ok := c.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	resp := cached.(*cachedResponse)
+	if time.Since(resp.stored) >= time.Duration(resp.policy.MaxAge)*time.Second {
+		return nil, false
+	}
+
+	return resp, true
+}
+
+// add keeps a response, unless content changed since the generation it
+// was computed in.
+func (c *ResponseCache) add(key string, resp *cachedResponse, generation uint64) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation == generation {
+		c.lru.Add(key, resp, len(resp.body))
+	}
+}
+
+// responseCacheKey is the key of the response to the request in the
+// viewer's share of the cache.
+func responseCacheKey(req *graphqlRequest, scope string) string {
+
+	variables, _ := json.Marshal(req.Variables)
+
+	h := sha256.New()
+	for _, part := range []string{req.Query, req.OperationName, string(variables), scope} {
+		h.Write([]byte(strconv.Itoa(len(part))))
+		h.Write([]byte{':'})
+		h.Write([]byte(part))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseScope makes the policy private for viewers who are logged in, or
+// have a post password, and returns the viewer's share of the response
+// cache. Private responses to anonymous viewers are not cached.
+func responseScope(ctx context.Context, policy *analysis.CachePolicy) (string, bool) {
+
+	viewer := auth.ViewerFrom(ctx)
+
+	if viewer != nil && (viewer.UserID > 0 || len(viewer.PostPass) > 0) {
+		policy.Scope = analysis.ScopePrivate
+	}
+
+	if policy.Scope != analysis.ScopePrivate {
+		return "public", true
+	}
+
+	if viewer == nil || (viewer.UserID == 0 && len(viewer.PostPass) == 0) {
+		return "", false
+	}
+
+	return "user:" + strconv.FormatInt(viewer.UserID, 10) + ":" + viewer.PostPass, true
+}
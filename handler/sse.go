@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/events"
+	"github.com/iyut/graphql-go/logging"
+)
+
+/****
+*********************
+GRAPHQL OVER SERVER-SENT EVENTS
+*********************
+****/
+
+// DefaultSSEHeartbeat is how often an idle event stream gets a comment, to
+// keep proxies from timing it out.
+const DefaultSSEHeartbeat = 15 * time.Second
+
+// Event names of the GraphQL over SSE "distinct connections" mode,
+// https://github.com/enisdenjo/graphql-sse/blob/master/PROTOCOL.md
+const (
+	sseNext     = "next"
+	sseComplete = "complete"
+)
+
+// accepts tells whether the Accept header of the request names the media
+// type.
+func accepts(r *http.Request, mediaType string) bool {
+
+	for _, accept := range r.Header["Accept"] {
+		for _, accepted := range strings.Split(accept, ",") {
+			if i := strings.Index(accepted, ";"); i >= 0 {
+				accepted = accepted[:i]
+			}
+			if strings.EqualFold(strings.TrimSpace(accepted), mediaType) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// serveSSE streams the results of one operation until it completes or the
+// client goes away. Every result of a subscription carries the id of the
+// event it came from, so an EventSource reconnecting with Last-Event-ID
+// resumes after it, or gets an error asking to refetch when the events
+// since are gone.
+func (h *GraphqlHandler) serveSSE(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		RespondNotFound(w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		RespondServerError(w)
+		h.logger().Error(r.Context(), "streaming unsupported", nil)
+		return
+	}
+
+	req, err := parseRequest(r)
+	if err != nil {
+		RespondBadRequest(w)
+		h.logger().Info(r.Context(), "bad request", logging.Fields{"error": err})
+		return
+	}
+
+	ctx, err := h.authenticate(r)
+	if err != nil {
+		RespondUnauthorized(w)
+		h.logger().Warn(r.Context(), "authentication failed", logging.Fields{"error": err})
+		return
+	}
+
+	if refusal, statusCode := h.resolvePersisted(req); refusal != nil {
+		accessFrom(ctx).addErrors(len(refusal.Errors))
+		h.respondGraphqlError(ctx, w, statusCode, refusal)
+		return
+	}
+
+	info, rejection := h.analyze(req)
+	accessFrom(ctx).operation(req, info)
+	if rejection != nil {
+		accessFrom(ctx).addErrors(len(rejection.Errors))
+		h.respondGraphqlError(ctx, w, StatusCodeBadRequest, rejection)
+		h.logger().Info(ctx, "operation rejected", logging.Fields{"operation_name": info.name, "error": rejection.Errors[0].Message})
+		return
+	}
+
+	if allowed, _ := h.rateLimit(ctx, w.Header(), r, info); !allowed {
+		accessFrom(ctx).addErrors(1)
+		RespondTooManyRequests(w)
+		return
+	}
+
+	cursor := events.NewCursor(r.Header.Get("Last-Event-ID"))
+
+	ctx, cancel := context.WithCancel(events.WithCursor(ctx, cursor))
+	defer cancel()
+
+	responses, err := h.subscribe(ctx, req, info)
+	if err != nil {
+		accessFrom(ctx).addErrors(1)
+		RespondServerError(w)
+		h.logger().Error(ctx, "subscribing", logging.Fields{"operation_name": info.name, "error": err})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(StatusCodeOK)
+	flusher.Flush()
+
+	heartbeat := h.SSEHeartbeat
+	if heartbeat <= 0 {
+		heartbeat = DefaultSSEHeartbeat
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ":\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case response, ok := <-responses:
+			if !ok {
+				writeSSEEvent(w, "", sseComplete, nil)
+				flusher.Flush()
+				return
+			}
+
+			resp, ok := response.(*graphql.Response)
+			if !ok {
+				continue
+			}
+
+			accessFrom(ctx).addErrors(len(resp.Errors))
+			info.extend(resp)
+
+			respJSON, err := json.Marshal(resp)
+			if err != nil {
+				h.logger().Error(ctx, "encoding the response", logging.Fields{"error": err})
+				continue
+			}
+
+			id, _ := cursor.Next()
+
+			if err := writeSSEEvent(w, id, sseNext, respJSON); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSSEEvent(w http.ResponseWriter, id string, event string, data []byte) error {
+
+	var buf bytes.Buffer
+
+	if len(id) > 0 {
+		fmt.Fprintf(&buf, "id: %s\n", id)
+	}
+	fmt.Fprintf(&buf, "event: %s\n", event)
+	fmt.Fprintf(&buf, "data: %s\n\n", data)
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
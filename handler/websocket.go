@@ -0,0 +1,437 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/logging"
+	"github.com/iyut/graphql-go/websocket"
+)
+
+/****
+*********************
+GRAPHQL OVER WEBSOCKET
+*********************
+****/
+
+// SubprotocolTransportWS is the graphql-ws library's protocol,
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const SubprotocolTransportWS = "graphql-transport-ws"
+
+// DefaultConnectionInitTimeout is how long a client has to send
+// connection_init after connecting.
+const DefaultConnectionInitTimeout = 3 * time.Second
+
+// DefaultMaxConnectionOperations is how many operations may run at once on
+// a connection.
+const DefaultMaxConnectionOperations = 100
+
+// Message types of graphql-transport-ws.
+const (
+	wsConnectionInit = "connection_init"
+	wsConnectionAck  = "connection_ack"
+	wsPing           = "ping"
+	wsPong           = "pong"
+	wsSubscribe      = "subscribe"
+	wsNext           = "next"
+	wsError          = "error"
+	wsComplete       = "complete"
+)
+
+// Close codes of graphql-transport-ws.
+const (
+	wsCloseBadRequest       = 4400
+	wsCloseUnauthorized     = 4401
+	wsCloseForbidden        = 4403
+	wsCloseInitTimeout      = 4408
+	wsCloseSubscriberExists = 4409
+	wsCloseTooManyInits     = 4429
+	wsCloseTooManyRequests  = 4429
+)
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsInitPayload carries the credentials of connection_init.
+type wsInitPayload struct {
+	Authorization string `json:"Authorization"`
+	AuthToken     string `json:"authToken"`
+}
+
+// wsSession is one WebSocket connection and its running operations.
+type wsSession struct {
+	h    *GraphqlHandler
+	conn *websocket.Conn
+	r    *http.Request
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// expiry closes the connection when the token of the viewer expires.
+	expiry *time.Timer
+
+	mu         sync.Mutex
+	initDone   bool
+	operations map[string]*wsOperation
+}
+
+type wsOperation struct {
+	cancel context.CancelFunc
+}
+
+// serveWebSocket runs operations over graphql-transport-ws until the
+// connection closes.
+func (h *GraphqlHandler) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+
+	// Browsers let any page open a WebSocket, cookies and all.
+	if !h.allowedOrigin(r) {
+		RespondForbidden(w)
+		h.logger().Info(r.Context(), "WebSocket origin refused", logging.Fields{"origin": r.Header.Get("Origin")})
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r, []string{SubprotocolTransportWS})
+	if err != nil {
+		h.logger().Info(r.Context(), "WebSocket upgrade failed", logging.Fields{"error": err})
+		return
+	}
+
+	conn.WriteTimeout = h.WriteTimeout
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	s := &wsSession{h: h, conn: conn, r: r, ctx: ctx, cancel: cancel, operations: make(map[string]*wsOperation)}
+	s.run()
+
+	if s.expiry != nil {
+		s.expiry.Stop()
+	}
+}
+
+// allowedOrigin reports whether the page the request comes from, if any,
+// may open a connection: one of the server itself or of AllowedOrigins.
+func (h *GraphqlHandler) allowedOrigin(r *http.Request) bool {
+
+	origin := r.Header.Get("Origin")
+	if len(origin) == 0 {
+		return true
+	}
+
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	for _, allowed := range h.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *wsSession) run() {
+
+	initTimeout := s.h.ConnectionInitTimeout
+	if initTimeout <= 0 {
+		initTimeout = DefaultConnectionInitTimeout
+	}
+
+	s.conn.SetReadDeadline(time.Now().Add(initTimeout))
+
+	for {
+
+		opcode, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() && !s.acknowledged() {
+				s.conn.WriteClose(wsCloseInitTimeout, "Connection initialisation timeout")
+			}
+			return
+		}
+
+		if opcode != websocket.TextMessage {
+			s.conn.WriteClose(wsCloseBadRequest, "Invalid message received")
+			return
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil || len(msg.Type) == 0 {
+			s.conn.WriteClose(wsCloseBadRequest, "Invalid message received")
+			return
+		}
+
+		if !s.handle(&msg) {
+			return
+		}
+	}
+}
+
+// handle processes one client message, returning false once the connection
+// has to be closed.
+func (s *wsSession) handle(msg *wsMessage) bool {
+
+	switch msg.Type {
+	case wsConnectionInit:
+		return s.init(msg)
+
+	case wsPing:
+		return s.send(&wsMessage{Type: wsPong})
+
+	case wsPong:
+		return true
+
+	case wsSubscribe:
+		return s.subscribe(msg)
+
+	case wsComplete:
+		s.mu.Lock()
+		if op, ok := s.operations[msg.ID]; ok {
+			op.cancel()
+			delete(s.operations, msg.ID)
+		}
+		s.mu.Unlock()
+		return true
+	}
+
+	s.conn.WriteClose(wsCloseBadRequest, "Invalid message received")
+
+	return false
+}
+
+// init authenticates the connection with the token of connection_init,
+// falling back to the headers of the upgrade request.
+func (s *wsSession) init(msg *wsMessage) bool {
+
+	s.mu.Lock()
+	initDone := s.initDone
+	s.initDone = true
+	s.mu.Unlock()
+
+	if initDone {
+		s.conn.WriteClose(wsCloseTooManyInits, "Too many initialisation requests")
+		return false
+	}
+
+	if s.h.Auth != nil {
+
+		var payload wsInitPayload
+		if len(msg.Payload) > 0 && string(msg.Payload) != "null" {
+			if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+				s.conn.WriteClose(wsCloseBadRequest, "Invalid message received")
+				return false
+			}
+		}
+
+		authorization := payload.Authorization
+		if len(authorization) == 0 && len(payload.AuthToken) > 0 {
+			authorization = "Bearer " + payload.AuthToken
+		}
+
+		var viewer *auth.Viewer
+		var err error
+
+		if len(authorization) > 0 {
+			viewer, err = s.h.Auth.Viewer(authorization)
+		} else {
+			viewer, err = s.h.Auth.Authenticate(s.r)
+		}
+
+		if err != nil {
+			s.h.logger().Warn(s.ctx, "authentication failed", logging.Fields{"error": err})
+			s.conn.WriteClose(wsCloseForbidden, "Forbidden")
+			return false
+		}
+
+		accessFrom(s.ctx).viewer(viewer)
+		s.ctx = auth.WithViewer(s.ctx, viewer)
+
+		// The token is checked once, so the connection ends with it.
+		if !viewer.Expires.IsZero() {
+			s.expiry = time.AfterFunc(time.Until(viewer.Expires), s.expire)
+		}
+	}
+
+	s.conn.SetReadDeadline(time.Time{})
+
+	return s.send(&wsMessage{Type: wsConnectionAck})
+}
+
+// expire closes the connection once the token it was opened with expired.
+func (s *wsSession) expire() {
+
+	s.conn.WriteClose(wsCloseForbidden, "Token expired")
+	s.cancel()
+	s.conn.Close()
+}
+
+func (s *wsSession) acknowledged() bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.initDone
+}
+
+func (s *wsSession) subscribe(msg *wsMessage) bool {
+
+	if !s.acknowledged() {
+		s.conn.WriteClose(wsCloseUnauthorized, "Unauthorized")
+		return false
+	}
+
+	var payload graphqlRequest
+	if len(msg.ID) == 0 || json.Unmarshal(msg.Payload, &payload) != nil {
+		s.conn.WriteClose(wsCloseBadRequest, "Invalid message received")
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	ctx, access := startOperation(ctx)
+	op := &wsOperation{cancel: cancel}
+
+	maxOperations := s.h.MaxConnectionOperations
+	if maxOperations <= 0 {
+		maxOperations = DefaultMaxConnectionOperations
+	}
+
+	s.mu.Lock()
+	_, exists := s.operations[msg.ID]
+	tooMany := !exists && len(s.operations) >= maxOperations
+	if !exists && !tooMany {
+		s.operations[msg.ID] = op
+	}
+	s.mu.Unlock()
+
+	if exists {
+		cancel()
+		s.conn.WriteClose(wsCloseSubscriberExists, fmt.Sprintf("Subscriber for %s already exists", msg.ID))
+		return false
+	}
+
+	if tooMany {
+		cancel()
+		s.conn.WriteClose(wsCloseTooManyRequests, "Too many operations")
+		return false
+	}
+
+	go func() {
+		s.execute(ctx, op, msg.ID, &payload)
+		s.h.logOperationAccess(ctx, s.r, msg.ID, access)
+	}()
+
+	return true
+}
+
+// execute streams the results of an operation. Queries and mutations send a
+// single result. Errors before execution starts are sent as an error
+// message, which ends the operation.
+func (s *wsSession) execute(ctx context.Context, op *wsOperation, id string, payload *graphqlRequest) {
+
+	defer func() {
+		op.cancel()
+
+		s.mu.Lock()
+		if s.operations[id] == op {
+			delete(s.operations, id)
+		}
+		s.mu.Unlock()
+	}()
+
+	if refusal, _ := s.h.resolvePersisted(payload); refusal != nil {
+		accessFrom(ctx).addErrors(len(refusal.Errors))
+		errorsJSON, _ := json.Marshal(refusal.Errors)
+		s.send(&wsMessage{ID: id, Type: wsError, Payload: errorsJSON})
+		return
+	}
+
+	info, rejection := s.h.analyze(payload)
+	accessFrom(ctx).operation(payload, info)
+	if rejection != nil {
+		accessFrom(ctx).addErrors(len(rejection.Errors))
+		errorsJSON, _ := json.Marshal(rejection.Errors)
+		s.send(&wsMessage{ID: id, Type: wsError, Payload: errorsJSON})
+		return
+	}
+
+	if allowed, res := s.h.rateLimit(ctx, nil, s.r, info); !allowed {
+		accessFrom(ctx).addErrors(1)
+		errorsJSON, _ := json.Marshal([]*errors.QueryError{rateLimitError(res)})
+		s.send(&wsMessage{ID: id, Type: wsError, Payload: errorsJSON})
+		return
+	}
+
+	responses, err := s.h.subscribe(ctx, payload, info)
+	if err != nil {
+		accessFrom(ctx).addErrors(1)
+		errorsJSON, _ := json.Marshal([]map[string]string{{"message": err.Error()}})
+		s.send(&wsMessage{ID: id, Type: wsError, Payload: errorsJSON})
+		return
+	}
+
+	first := true
+
+	for response := range responses {
+
+		resp, ok := response.(*graphql.Response)
+		if !ok {
+			continue
+		}
+
+		accessFrom(ctx).addErrors(len(resp.Errors))
+
+		if first && resp.Data == nil && len(resp.Errors) > 0 {
+			errorsJSON, _ := json.Marshal(resp.Errors)
+			s.send(&wsMessage{ID: id, Type: wsError, Payload: errorsJSON})
+			return
+		}
+		first = false
+
+		info.extend(resp)
+
+		respJSON, err := json.Marshal(resp)
+		if err != nil {
+			s.h.logger().Error(ctx, "encoding the response", logging.Fields{"error": err})
+			continue
+		}
+
+		if !s.send(&wsMessage{ID: id, Type: wsNext, Payload: respJSON}) {
+			return
+		}
+	}
+
+	// A client that completed the operation itself expects nothing more.
+	if ctx.Err() == nil {
+		s.send(&wsMessage{ID: id, Type: wsComplete})
+	}
+}
+
+func (s *wsSession) send(msg *wsMessage) bool {
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		s.h.logger().Error(s.r.Context(), "encoding the message", logging.Fields{"error": err})
+		return false
+	}
+
+	if err := s.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		s.cancel()
+		return false
+	}
+
+	return true
+}
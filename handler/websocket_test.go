@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowedOrigin(t *testing.T) {
+
+	tests := []struct {
+		origin  string
+		allowed []string
+		want    bool
+	}{
+		{"", nil, true},
+		{"https://api.example.com", nil, true},
+		{"https://API.example.com", nil, true},
+		{"https://evil.example", nil, false},
+		{"https://api.example.com.evil.example", nil, false},
+		{"null", nil, false},
+		{"https://www.example.com", []string{"https://www.example.com"}, true},
+		{"http://www.example.com", []string{"https://www.example.com"}, false},
+		{"https://evil.example", []string{"https://www.example.com"}, false},
+		{"https://evil.example", []string{"*"}, true},
+	}
+
+	for _, tt := range tests {
+
+		r := httptest.NewRequest("GET", "https://api.example.com/graphql", nil)
+		if len(tt.origin) > 0 {
+			r.Header.Set("Origin", tt.origin)
+		}
+
+		h := &GraphqlHandler{AllowedOrigins: tt.allowed}
+		if got := h.allowedOrigin(r); got != tt.want {
+			t.Errorf("origin %q, allowed %v: got %t, want %t", tt.origin, tt.allowed, got, tt.want)
+		}
+	}
+}
@@ -1,8 +1,15 @@
 package helper
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"math/big"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/graph-gophers/graphql-go"
 )
@@ -29,3 +36,98 @@ func IntToGraphqlID(id int64) graphql.ID {
 	return graphql.ID(idStr)
 
 }
+
+func GraphqlIDToInt(id graphql.ID) int64 {
+
+	idInt, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return idInt
+}
+
+// StringToTime parses a MySQL DATETIME value. WordPress stores unset dates
+// (e.g. post_date_gmt of a draft) as all zeroes, which becomes the zero time.
+func StringToTime(value string) (time.Time, error) {
+
+	if len(value) == 0 || value == "0000-00-00 00:00:00" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse("2006-01-02 15:04:05", value)
+}
+
+const cursorPrefix = "cursor:"
+
+// EncodeCursor returns the opaque connection cursor of the item at offset.
+func EncodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+// DecodeCursor returns the offset encoded by EncodeCursor.
+func DecodeCursor(cursor string) (int, error) {
+
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(decoded), cursorPrefix) {
+		return 0, errors.New("invalid cursor")
+	}
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(decoded), cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+
+	return offset, nil
+}
+
+const passwordChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// RandomString returns n random letters and digits, like
+// wp_generate_password(n, false).
+func RandomString(n int) (string, error) {
+
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(passwordChars)))
+
+	for i := range b {
+		c, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = passwordChars[c.Int64()]
+	}
+
+	return string(b), nil
+}
+
+var (
+	userUnsafeRegexp  = regexp.MustCompile(`[^a-zA-Z0-9 _.\-@]`)
+	whitespaceRegexp  = regexp.MustCompile(`\s+`)
+	titleUnsafeRegexp = regexp.MustCompile(`[^a-z0-9_\-]+`)
+	dashesRegexp      = regexp.MustCompile(`-+`)
+	tagRegexp         = regexp.MustCompile(`<[^>]*>`)
+)
+
+// SanitizeUser is sanitize_user() in strict mode: only letters, digits,
+// spaces and _ . - @ are kept.
+func SanitizeUser(username string) string {
+
+	username = tagRegexp.ReplaceAllString(username, "")
+	username = userUnsafeRegexp.ReplaceAllString(username, "")
+	username = whitespaceRegexp.ReplaceAllString(strings.TrimSpace(username), " ")
+
+	return username
+}
+
+// SanitizeTitle makes a URL slug, like sanitize_title() does for ASCII text.
+func SanitizeTitle(title string) string {
+
+	title = strings.ToLower(tagRegexp.ReplaceAllString(title, ""))
+	title = strings.Replace(title, ".", "-", -1)
+	title = strings.Replace(title, "@", "", -1)
+	title = titleUnsafeRegexp.ReplaceAllString(title, "-")
+	title = dashesRegexp.ReplaceAllString(title, "-")
+
+	return strings.Trim(title, "-")
+}
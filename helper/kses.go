@@ -0,0 +1,234 @@
+package helper
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ksesAllowedTags are the tags and attributes wp_filter_kses() keeps outside
+// of post content, WordPress' $allowedtags.
+var ksesAllowedTags = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"acronym":    {"title"},
+	"b":          nil,
+	"blockquote": {"cite"},
+	"cite":       nil,
+	"code":       nil,
+	"del":        {"datetime"},
+	"em":         nil,
+	"i":          nil,
+	"q":          {"cite"},
+	"s":          nil,
+	"strike":     nil,
+	"strong":     nil,
+}
+
+// ksesURIAttributes hold URLs, which have to use an allowed protocol.
+var ksesURIAttributes = map[string]bool{"href": true, "cite": true}
+
+// ksesAllowedProtocols are wp_allowed_protocols().
+var ksesAllowedProtocols = []string{"http", "https", "ftp", "ftps", "mailto", "news", "irc", "irc6", "ircs", "gopher", "nntp", "feed", "telnet", "mms", "rtsp", "sms", "svn", "tel", "fax", "xmpp", "webcal", "urn"}
+
+var (
+	ksesSplitRegexp     = regexp.MustCompile(`(?s)<!--.*?(?:-->|$)|<[^>]*(?:>|$)|>`)
+	ksesElementRegexp   = regexp.MustCompile(`(?s)^<\s*(/\s*)?([a-zA-Z0-9-]+)([^>]*)>?$`)
+	ksesAttributeRegexp = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
+	entityRegexp        = regexp.MustCompile(`^&(?:[a-zA-Z][a-zA-Z0-9]*|#[0-9]+|#[xX][0-9a-fA-F]+);`)
+	lessThanRegexp      = regexp.MustCompile(`<[^<>]*>?`)
+	scriptStyleRegexp   = regexp.MustCompile(`(?is)<(script|style)[^>]*?>.*?</(?:script|style)>`)
+	lineBreakRegexp     = regexp.MustCompile(`[\r\n\t ]+`)
+	octetRegexp         = regexp.MustCompile(`%[a-fA-F0-9]{2}`)
+	spacesRegexp        = regexp.MustCompile(` +`)
+)
+
+// SanitizeTextField is sanitize_text_field(): invalid UTF-8 is dropped,
+// tags are stripped, line breaks and whitespace runs become one space and
+// percent-encoded octets are removed.
+func SanitizeTextField(s string) string {
+
+	if !utf8.ValidString(s) {
+		return ""
+	}
+
+	if strings.Contains(s, "<") {
+		s = preKsesLessThan(s)
+
+		// wp_strip_all_tags().
+		s = scriptStyleRegexp.ReplaceAllString(s, "")
+		s = tagRegexp.ReplaceAllString(s, "")
+	}
+
+	s = strings.TrimSpace(lineBreakRegexp.ReplaceAllString(s, " "))
+
+	found := false
+	for octetRegexp.MatchString(s) {
+		s = octetRegexp.ReplaceAllString(s, "")
+		found = true
+	}
+
+	if found {
+		s = strings.TrimSpace(spacesRegexp.ReplaceAllString(s, " "))
+	}
+
+	return s
+}
+
+// FilterKses is wp_filter_kses(): only the tags and attributes of
+// ksesAllowedTags are kept, URLs need an allowed protocol and stray
+// ampersands and > become entities.
+func FilterKses(s string) string {
+
+	s = preKsesLessThan(strings.Replace(s, "\x00", "", -1))
+
+	var b strings.Builder
+
+	last := 0
+	for _, loc := range ksesSplitRegexp.FindAllStringIndex(s, -1) {
+		b.WriteString(normalizeEntities(s[last:loc[0]]))
+		b.WriteString(ksesElement(s[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(normalizeEntities(s[last:]))
+
+	return b.String()
+}
+
+// preKsesLessThan is wp_pre_kses_less_than(): a < that opens no tag is
+// text.
+func preKsesLessThan(s string) string {
+
+	return lessThanRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		if !strings.Contains(match, ">") {
+			return EscapeHTML(match)
+		}
+		return match
+	})
+}
+
+// ksesElement is wp_kses_split2(): the markup of one tag, rebuilt from its
+// allowed parts, or nothing.
+func ksesElement(markup string) string {
+
+	if markup == ">" {
+		return "&gt;"
+	}
+
+	// Comments are dropped, unlike in post content.
+	if strings.HasPrefix(markup, "<!--") {
+		return ""
+	}
+
+	match := ksesElementRegexp.FindStringSubmatch(markup)
+	if match == nil {
+		return ""
+	}
+
+	name := strings.ToLower(match[2])
+	allowed, ok := ksesAllowedTags[name]
+	if !ok {
+		return ""
+	}
+
+	if len(match[1]) > 0 {
+		return "</" + name + ">"
+	}
+
+	var b strings.Builder
+	b.WriteString("<" + name)
+
+	for _, attr := range ksesAttributeRegexp.FindAllStringSubmatch(match[3], -1) {
+		attrName := strings.ToLower(attr[1])
+		if !containsFold(allowed, attrName) {
+			continue
+		}
+
+		value := html.UnescapeString(attr[2] + attr[3] + attr[4])
+		if ksesURIAttributes[attrName] && !ksesAllowedProtocol(value) {
+			continue
+		}
+
+		b.WriteString(" " + attrName + `="` + EscapeHTML(value) + `"`)
+	}
+
+	if strings.HasSuffix(strings.TrimSpace(match[3]), "/") {
+		b.WriteString(" /")
+	}
+	b.WriteString(">")
+
+	return b.String()
+}
+
+// ksesAllowedProtocol reports whether a URL is relative or uses one of the
+// allowed protocols, ignoring the whitespace and control characters
+// browsers skip.
+func ksesAllowedProtocol(value string) bool {
+
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+
+	colon := strings.IndexByte(value, ':')
+	if colon < 0 || strings.ContainsAny(value[:colon], "/?#") {
+		return true
+	}
+
+	return containsFold(ksesAllowedProtocols, value[:colon])
+}
+
+func containsFold(list []string, s string) bool {
+
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeEntities is wp_kses_normalize_entities() for text: an ampersand
+// that starts no entity is escaped.
+func normalizeEntities(text string) string {
+
+	var b strings.Builder
+
+	for {
+		i := strings.IndexByte(text, '&')
+		if i < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+
+		b.WriteString(text[:i])
+		if entity := entityRegexp.FindString(text[i:]); len(entity) > 0 {
+			b.WriteString(entity)
+			text = text[i+len(entity):]
+		} else {
+			b.WriteString("&amp;")
+			text = text[i+1:]
+		}
+	}
+}
+
+// EscapeHTML is esc_html(): the HTML special characters become entities,
+// existing entities are kept.
+func EscapeHTML(s string) string {
+
+	return strings.NewReplacer(`<`, "&lt;", `>`, "&gt;", `"`, "&quot;", `'`, "&#039;").Replace(normalizeEntities(s))
+}
+
+// SanitizeUserText is what the pre_user_display_name, pre_user_nickname,
+// pre_user_first_name and pre_user_last_name filters do: sanitize_text_field(),
+// wp_filter_kses() and _wp_specialchars().
+func SanitizeUserText(s string) string {
+
+	s = FilterKses(SanitizeTextField(s))
+
+	return strings.NewReplacer(`<`, "&lt;", `>`, "&gt;").Replace(s)
+}
@@ -0,0 +1,81 @@
+package helper
+
+import "testing"
+
+func TestSanitizeTextField(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Jane", "Jane"},
+		{"  Jane \n\t Doe  ", "Jane Doe"},
+		{"<b>Jane</b>", "Jane"},
+		{"Jane<script>alert(1)</script>", "Jane"},
+		{`<img src=x onerror="alert(1)">Jane`, "Jane"},
+		{"a < b", "a &lt; b"},
+		{"a <b", "a &lt;b"},
+		{"Jane%20Doe", "JaneDoe"},
+		{"Jane %2%525 Doe", "Jane Doe"},
+		{"Jane \xff", ""},
+	}
+
+	for _, tt := range tests {
+		if got := SanitizeTextField(tt.in); got != tt.want {
+			t.Errorf("SanitizeTextField(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFilterKses(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"Tom & Jerry &amp; friends &#169; &#x41;", "Tom &amp; Jerry &amp; friends &#169; &#x41;"},
+		{"<strong>bold</strong> <em>it</em>", "<strong>bold</strong> <em>it</em>"},
+		{"<STRONG class=x>bold</STRONG>", "<strong>bold</strong>"},
+		{"<script>alert(1)</script>", "alert(1)"},
+		{`<img src=x onerror=alert(1)>`, ""},
+		{`<a href="https://example.com" onclick="alert(1)" title='t'>x</a>`, `<a href="https://example.com" title="t">x</a>`},
+		{`<a href="javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{`<a href=" java&#x09;script:alert(1)">x</a>`, "<a>x</a>"},
+		{`<a href="&#106;avascript:alert(1)">x</a>`, "<a>x</a>"},
+		{`<a href="/path?a=b:c">x</a>`, `<a href="/path?a=b:c">x</a>`},
+		{`<a title='"&lt;script'>x</a>`, `<a title="&quot;&lt;script">x</a>`},
+		{`<a title="x>"onmouseover=alert(1)>y</a>`, `<a title="">"onmouseover=alert(1)&gt;y</a>`},
+		{"<!---->text", "text"},
+		{"<!-- <script> -->text", "&lt;!--  --&gt;text"},
+		{"a > b", "a &gt; b"},
+		{"a < b", "a &lt; b"},
+		{"<b", "&lt;b"},
+	}
+
+	for _, tt := range tests {
+		if got := FilterKses(tt.in); got != tt.want {
+			t.Errorf("FilterKses(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeUserText(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Jane Doe", "Jane Doe"},
+		{"Tom & Jerry", "Tom &amp; Jerry"},
+		{`"><script>alert(1)</script>`, `"&gt;`},
+		{"<b>Jane</b> <a", "Jane &lt;a"},
+		{"<em>Jane</em>", "Jane"},
+	}
+
+	for _, tt := range tests {
+		if got := SanitizeUserText(tt.in); got != tt.want {
+			t.Errorf("SanitizeUserText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Package logging writes the server's log as JSON lines, one object per
+// record, carrying the ID of the request a record was written for.
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a record. Loggers drop records below their level.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLevel reads a level name: debug, info, warn or error. The empty
+// name is info.
+func ParseLevel(name string) (Level, error) {
+
+	if len(name) == 0 {
+		return LevelInfo, nil
+	}
+
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("unknown log level %q", name)
+}
+
+// Fields are the attributes of a record, written after its message in the
+// order of their names.
+type Fields map[string]interface{}
+
+// Logger writes records at or above its level. Its methods are safe for
+// concurrent use, and a nil Logger writes to Default.
+type Logger struct {
+	mu    sync.Mutex
+	out   io.Writer
+	level Level
+}
+
+// Default is the logger of nil loggers: info records and above, to
+// standard error.
+var Default = New(os.Stderr, LevelInfo)
+
+// New returns a logger writing records at or above the level to out.
+func New(out io.Writer, level Level) *Logger {
+
+	return &Logger{out: out, level: level}
+}
+
+// Enabled tells whether records of the level are written.
+func (l *Logger) Enabled(level Level) bool {
+
+	if l == nil {
+		return Default.Enabled(level)
+	}
+
+	return level >= l.level
+}
+
+// Debug, Info, Warn and Error log a record of their level.
+func (l *Logger) Debug(ctx context.Context, msg string, fields Fields) {
+	l.Log(ctx, LevelDebug, msg, fields)
+}
+
+func (l *Logger) Info(ctx context.Context, msg string, fields Fields) {
+	l.Log(ctx, LevelInfo, msg, fields)
+}
+
+func (l *Logger) Warn(ctx context.Context, msg string, fields Fields) {
+	l.Log(ctx, LevelWarn, msg, fields)
+}
+
+func (l *Logger) Error(ctx context.Context, msg string, fields Fields) {
+	l.Log(ctx, LevelError, msg, fields)
+}
+
+// Log writes a record, with the request ID of the context when it has one.
+// Error values among the fields are written as their message, and values
+// that cannot be written as JSON as their %v formatting.
+func (l *Logger) Log(ctx context.Context, level Level, msg string, fields Fields) {
+
+	if l == nil {
+		Default.Log(ctx, level, msg, fields)
+		return
+	}
+
+	if !l.Enabled(level) {
+		return
+	}
+
+	var b bytes.Buffer
+
+	b.WriteString(`{"time":`)
+	writeValue(&b, time.Now().UTC().Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeValue(&b, level.String())
+	b.WriteString(`,"msg":`)
+	writeValue(&b, msg)
+
+	if ctx != nil {
+		if requestID := RequestID(ctx); len(requestID) > 0 {
+			b.WriteString(`,"request_id":`)
+			writeValue(&b, requestID)
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		b.WriteString(",")
+		writeValue(&b, name)
+		b.WriteString(":")
+		writeValue(&b, fields[name])
+	}
+
+	b.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.out.Write(b.Bytes())
+}
+
+func writeValue(b *bytes.Buffer, value interface{}) {
+
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%v", value))
+	}
+
+	b.Write(data)
+}
+
+// LogPanic logs a panic recovered while executing an operation, with the
+// stack of the resolver that panicked. It makes the logger a graphql-go
+// log.Logger.
+func (l *Logger) LogPanic(ctx context.Context, value interface{}) {
+
+	const size = 64 << 10
+	buf := make([]byte, size)
+	buf = buf[:runtime.Stack(buf, false)]
+
+	l.Error(ctx, "resolver panic", Fields{
+		"panic": fmt.Sprintf("%v", value),
+		"stack": string(buf),
+	})
+}
+
+// Writer returns a writer logging each line written to it as a record of
+// the level, for the standard log package: log.SetOutput(l.Writer(level))
+// with log.SetFlags(0).
+func (l *Logger) Writer(level Level) io.Writer {
+
+	return &lineWriter{l: l, level: level}
+}
+
+type lineWriter struct {
+	l     *Logger
+	level Level
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		w.l.Log(context.Background(), w.level, line, nil)
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader carries the ID of a request, from the client or a proxy
+// that set it, and back in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the IDs taken from clients.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID of the context, empty when there is none.
+func RequestID(ctx context.Context) string {
+
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+
+	return requestID
+}
+
+// NewRequestID returns a random request ID of 32 hex digits.
+func NewRequestID() string {
+
+	var b [16]byte
+	rand.Read(b[:])
+
+	return hex.EncodeToString(b[:])
+}
+
+// IncomingRequestID returns the X-Request-ID of the request when it is a
+// usable ID, or a new one. IDs are kept to printable ASCII without spaces or
+// quotes, so that they can be logged and echoed as they are.
+func IncomingRequestID(r *http.Request) string {
+
+	requestID := r.Header.Get(RequestIDHeader)
+	if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+		return NewRequestID()
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if c := requestID[i]; c <= ' ' || c >= 0x7f || c == '"' || c == '\\' {
+			return NewRequestID()
+		}
+	}
+
+	return requestID
+}
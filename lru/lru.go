@@ -0,0 +1,125 @@
+// Package lru keeps the most recently used values, within a number of entries
+// and of bytes.
+package lru
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache forgets its least recently used values beyond MaxEntries values or
+// MaxBytes bytes, as sized by the caller. A zero bound does not apply.
+type Cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	order      *list.List
+	items      map[string]*list.Element
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// Stats are the counters of a cache since it was made.
+type Stats struct {
+	Entries   int    `json:"entries"`
+	Bytes     int    `json:"bytes"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+type entry struct {
+	key   string
+	value interface{}
+	size  int
+}
+
+func New(maxEntries int, maxBytes int) *Cache {
+
+	return &Cache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (c *Cache) Get(key string) (interface{}, bool) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	c.hits++
+	c.order.MoveToFront(elem)
+
+	return elem.Value.(*entry).value, true
+}
+
+// Add keeps the value, of the given size in bytes.
+func (c *Cache) Add(key string, value interface{}, size int) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry)
+		c.bytes += size - e.size
+		e.value = value
+		e.size = size
+		c.order.MoveToFront(elem)
+	} else {
+		c.items[key] = c.order.PushFront(&entry{key: key, value: value, size: size})
+		c.bytes += size
+	}
+
+	for c.order.Len() > 0 && c.full() {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+// Purge forgets every value.
+func (c *Cache) Purge() {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	c.bytes = 0
+}
+
+func (c *Cache) Stats() Stats {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Entries:   c.order.Len(),
+		Bytes:     c.bytes,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
+
+func (c *Cache) full() bool {
+
+	return (c.maxEntries > 0 && c.order.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)
+}
+
+func (c *Cache) remove(elem *list.Element) {
+
+	e := c.order.Remove(elem).(*entry)
+	delete(c.items, e.key)
+	c.bytes -= e.size
+}
@@ -0,0 +1,87 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+
+	type add struct {
+		key  string
+		size int
+	}
+
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int
+		adds       []add
+		get        string
+		wantKeys   []string
+		wantBytes  int
+	}{
+		{"unbounded", 0, 0, []add{{"a", 1}, {"b", 2}, {"c", 3}}, "", []string{"a", "b", "c"}, 6},
+		{"entries", 2, 0, []add{{"a", 1}, {"b", 1}, {"c", 1}}, "", []string{"b", "c"}, 2},
+		{"entries after use", 2, 0, []add{{"a", 1}, {"b", 1}}, "a", []string{"a"}, 1},
+		{"bytes", 0, 10, []add{{"a", 4}, {"b", 4}, {"c", 4}}, "", []string{"b", "c"}, 8},
+		{"larger than the cache", 0, 10, []add{{"a", 4}, {"b", 11}}, "", []string{}, 0},
+		{"replaced", 0, 10, []add{{"a", 4}, {"b", 4}, {"a", 6}}, "", []string{"a", "b"}, 10},
+		{"replaced larger", 0, 10, []add{{"a", 4}, {"b", 4}, {"a", 7}}, "", []string{"a"}, 7},
+	}
+
+	for _, tt := range tests {
+
+		c := New(tt.maxEntries, tt.maxBytes)
+
+		for i, a := range tt.adds {
+			c.Add(a.key, i, a.size)
+		}
+
+		// A value used is kept over the older ones when z comes in.
+		if len(tt.get) > 0 {
+			c.Get(tt.get)
+			c.Add("z", -1, 1)
+			tt.wantKeys = append(tt.wantKeys, "z")
+			tt.wantBytes++
+		}
+
+		stats := c.Stats()
+		if stats.Entries != len(tt.wantKeys) || stats.Bytes != tt.wantBytes {
+			t.Errorf("%s: %d entries of %d bytes, want %d of %d", tt.name, stats.Entries, stats.Bytes, len(tt.wantKeys), tt.wantBytes)
+		}
+
+		for _, key := range tt.wantKeys {
+			if _, ok := c.Get(key); !ok {
+				t.Errorf("%s: %s forgotten", tt.name, key)
+			}
+		}
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+
+	c := New(1, 0)
+
+	c.Add("a", 1, 1)
+	c.Get("a")
+	c.Get("b")
+	c.Add("b", 2, 1)
+
+	if value, ok := c.Get("b"); !ok || value != 2 {
+		t.Errorf("got %v, %t, want 2", value, ok)
+	}
+
+	want := Stats{Entries: 1, Bytes: 1, Hits: 2, Misses: 1, Evictions: 1}
+	if stats := c.Stats(); stats != want {
+		t.Errorf("got %+v, want %+v", stats, want)
+	}
+
+	c.Purge()
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("purged value kept")
+	}
+	if stats := c.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("purged cache holds %d entries of %d bytes", stats.Entries, stats.Bytes)
+	}
+}
@@ -0,0 +1,113 @@
+// Package mail sends the emails of user management, like password resets.
+package mail
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// format renders the message as a plain text RFC 5322 email.
+func format(msg *Message, date time.Time) string {
+
+	var b strings.Builder
+
+	if len(msg.From) > 0 {
+		fmt.Fprintf(&b, "From: %s\r\n", msg.From)
+	}
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.Replace(msg.Body, "\n", "\r\n", -1))
+
+	return b.String()
+}
+
+/****
+*********************
+WRITER MAILER
+*********************
+****/
+
+// WriterMailer prints messages to a writer, for local development.
+type WriterMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterMailer(w io.Writer) *WriterMailer {
+
+	return &WriterMailer{w: w}
+}
+
+// NewStdoutMailer prints messages to standard output.
+func NewStdoutMailer() *WriterMailer {
+
+	return NewWriterMailer(os.Stdout)
+}
+
+func (m *WriterMailer) Send(msg *Message) error {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := io.WriteString(m.w, format(msg, time.Now())+"\r\n\r\n")
+
+	return err
+}
+
+/****
+*********************
+FILE MAILER
+*********************
+****/
+
+// FileMailer writes each message to its own .eml file in a directory.
+type FileMailer struct {
+	dir string
+}
+
+func NewFileMailer(dir string) (*FileMailer, error) {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{dir: dir}, nil
+}
+
+func (m *FileMailer) Send(msg *Message) error {
+
+	now := time.Now()
+
+	f, err := ioutil.TempFile(m.dir, strconv.FormatInt(now.UnixNano(), 10)+"-*.eml")
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(f, format(msg, now)); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	return f.Close()
+}
@@ -1,16 +1,35 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"expvar"
 	"fmt"
 	"io/ioutil"
+	"log"
+	"net"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/iyut/graphql-go/analysis"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/changes"
+	"github.com/iyut/graphql-go/events"
 	"github.com/iyut/graphql-go/handler"
+	"github.com/iyut/graphql-go/logging"
+	"github.com/iyut/graphql-go/mail"
+	"github.com/iyut/graphql-go/metafield"
+	"github.com/iyut/graphql-go/persisted"
+	"github.com/iyut/graphql-go/ratelimit"
+	"github.com/iyut/graphql-go/render"
 	"github.com/iyut/graphql-go/resolver"
+	"github.com/iyut/graphql-go/sdl"
+	"github.com/iyut/graphql-go/service"
+	"github.com/iyut/graphql-go/webhook"
 
 	_ "github.com/go-sql-driver/mysql"
 	graphql "github.com/graph-gophers/graphql-go"
@@ -22,14 +41,177 @@ GET THE SETTINGS INFO
 *********************
 ****/
 type Settings struct {
-	General General  `json:"general"`
-	DBInfo  []DBInfo `json:"database"`
+	General       General           `json:"general"`
+	DBInfo        []DBInfo          `json:"database"`
+	MetaFields    []metafield.Field `json:"meta_fields"`
+	Search        Search            `json:"search"`
+	Content       Content           `json:"content"`
+	Auth          Auth              `json:"auth"`
+	Mail          Mail              `json:"mail"`
+	UserMeta      UserMeta          `json:"user_meta"`
+	Subscriptions Subscriptions     `json:"subscriptions"`
+	Changes       Changes           `json:"changes"`
+	Webhooks      Webhooks          `json:"webhooks"`
+	Limits        Limits            `json:"limits"`
+	RateLimit     RateLimit         `json:"rate_limit"`
+	Persisted     Persisted         `json:"persisted_queries"`
+	DocumentCache DocumentCache     `json:"document_cache"`
+	CacheControl  CacheControl      `json:"cache_control"`
+	GraphiQL      GraphiQL          `json:"graphiql"`
+	Logging       Logging           `json:"logging"`
 }
 
 type General struct {
 	PrefixURL     string `json:"prefix_url"`
 	GraphqlURL    string `json:"graphql_url"`
 	GraphqlSchema string `json:"graphql_schema"`
+	DebugVarsURL  string `json:"debug_vars_url"`
+}
+
+type Search struct {
+	Backend         string `json:"backend"`
+	IndexDir        string `json:"index_dir"`
+	RebuildInterval string `json:"rebuild_interval"`
+}
+
+type Content struct {
+	ExcerptLength     int    `json:"excerpt_length"`
+	ExcerptMore       string `json:"excerpt_more"`
+	UnknownShortcodes string `json:"unknown_shortcodes"`
+}
+
+// Auth holds the secret signing the tokens of logged in users. settings.json
+// ships without one, and the server does not start until it is set, to a
+// long random string like the output of `openssl rand -hex 32`, here or in
+// the environment variable authSecretEnv, which takes precedence.
+type Auth struct {
+	Secret   string `json:"secret"`
+	TokenTTL string `json:"token_ttl"`
+}
+
+// authSecretEnv is the environment variable setting the token secret.
+const authSecretEnv = "GRAPHQL_AUTH_SECRET"
+
+type Mail struct {
+	Transport string `json:"transport"`
+	Dir       string `json:"dir"`
+	From      string `json:"from"`
+	ResetURL  string `json:"reset_url"`
+}
+
+type UserMeta struct {
+	EditableKeys []string `json:"editable_keys"`
+}
+
+type Subscriptions struct {
+	ConnectionInitTimeout string   `json:"connection_init_timeout"`
+	WriteTimeout          string   `json:"write_timeout"`
+	SSEHeartbeat          string   `json:"sse_heartbeat"`
+	AllowedOrigins        []string `json:"allowed_origins"`
+	MaxOperations         int      `json:"max_operations"`
+}
+
+type Changes struct {
+	Enabled       bool          `json:"enabled"`
+	Interval      string        `json:"interval"`
+	SweepInterval string        `json:"sweep_interval"`
+	WatermarkFile string        `json:"watermark_file"`
+	Sites         []ChangesSite `json:"sites"`
+}
+
+type ChangesSite struct {
+	Prefix        string `json:"prefix"`
+	Interval      string `json:"interval"`
+	SweepInterval string `json:"sweep_interval"`
+}
+
+type Webhooks struct {
+	QueueDir    string             `json:"queue_dir"`
+	MaxAttempts int                `json:"max_attempts"`
+	MinBackoff  string             `json:"min_backoff"`
+	MaxBackoff  string             `json:"max_backoff"`
+	Timeout     string             `json:"timeout"`
+	Endpoints   []webhook.Endpoint `json:"endpoints"`
+}
+
+type Limits struct {
+	MaxDepth        int            `json:"max_depth"`
+	MaxCost         int            `json:"max_cost"`
+	DefaultListSize int            `json:"default_list_size"`
+	FieldCosts      map[string]int `json:"field_costs"`
+	MaxBatchSize    int            `json:"max_batch_size"`
+	MaxBodyBytes    int64          `json:"max_body_bytes"`
+}
+
+type RateLimit struct {
+	Enabled        bool              `json:"enabled"`
+	Query          RateLimitBudget   `json:"query"`
+	Mutation       RateLimitBudget   `json:"mutation"`
+	ChargeCost     bool              `json:"charge_cost"`
+	TrustedProxies []string          `json:"trusted_proxies"`
+	APIKeys        []RateLimitAPIKey `json:"api_keys"`
+}
+
+type Persisted struct {
+	Automatic    bool   `json:"automatic"`
+	CacheSize    int    `json:"cache_size"`
+	ManifestFile string `json:"manifest_file"`
+	Strict       bool   `json:"strict"`
+}
+
+type DocumentCache struct {
+	Enabled    bool `json:"enabled"`
+	MaxEntries int  `json:"max_entries"`
+	MaxBytes   int  `json:"max_bytes"`
+}
+
+type CacheControl struct {
+	DefaultMaxAge int           `json:"default_max_age"`
+	ResponseCache ResponseCache `json:"response_cache"`
+}
+
+type ResponseCache struct {
+	Enabled    bool `json:"enabled"`
+	MaxEntries int  `json:"max_entries"`
+	MaxBytes   int  `json:"max_bytes"`
+}
+
+type GraphiQL struct {
+	Enabled   bool  `json:"enabled"`
+	DevUserID int64 `json:"dev_user_id"`
+}
+
+type Logging struct {
+	Level          string   `json:"level"`
+	AccessLog      bool     `json:"access_log"`
+	SensitiveNames []string `json:"sensitive_names"`
+}
+
+type RateLimitBudget struct {
+	Requests int    `json:"requests"`
+	Window   string `json:"window"`
+}
+
+type RateLimitAPIKey struct {
+	Key      string          `json:"key"`
+	Name     string          `json:"name"`
+	Query    RateLimitBudget `json:"query"`
+	Mutation RateLimitBudget `json:"mutation"`
+}
+
+func (b RateLimitBudget) limit() ratelimit.Limit {
+
+	limit := ratelimit.Limit{Requests: b.Requests}
+
+	if len(b.Window) > 0 {
+		window, err := time.ParseDuration(b.Window)
+		if err != nil {
+			panic(err)
+		}
+		limit.Window = window
+	}
+
+	return limit
 }
 
 type DBInfo struct {
@@ -48,8 +230,22 @@ MAIN FUNCTION
 ****/
 func main() {
 
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	settings := openJSONFile()
 
+	logLevel, err := logging.ParseLevel(settings.Logging.Level)
+	if err != nil {
+		panic(err)
+	}
+
+	// Packages logging with the standard logger write info records.
+	logger := logging.New(os.Stderr, logLevel)
+	log.SetFlags(0)
+	log.SetOutput(logger.Writer(logging.LevelInfo))
+
 	graphqlURL := settings.General.GraphqlURL
 	dbInfo := settings.DBInfo[0]
 
@@ -62,22 +258,363 @@ func main() {
 
 	defer db.Close()
 
-	bstr, err := ioutil.ReadFile(getMainPath() + "main-schema.graphql")
+	schemaSources, metaFields, err := effectiveSchema(settings)
+	if err != nil {
+		panic(err)
+	}
+
+	var searcher service.Searcher
+	var indexSearch *service.IndexSearch
+	switch settings.Search.Backend {
+	case "", "mysql":
+		searcher = service.NewSearchService(db, resolver.TablePrefix)
+	case "index":
+		indexSearch, err = service.NewIndexSearch(db, resolver.TablePrefix, settings.Search.IndexDir)
+		if err != nil {
+			panic(err)
+		}
+		searcher = indexSearch
+	default:
+		panic("unknown search backend " + settings.Search.Backend)
+	}
+
+	content := render.NewRenderer()
+	if settings.Content.ExcerptLength > 0 {
+		content.ExcerptLength = settings.Content.ExcerptLength
+	}
+	if len(settings.Content.ExcerptMore) > 0 {
+		content.ExcerptMore = settings.Content.ExcerptMore
+	}
+	switch settings.Content.UnknownShortcodes {
+	case "", render.UnknownPassthrough:
+	case render.UnknownStrip:
+		content.UnknownShortcodes = render.UnknownStrip
+	default:
+		panic("unknown unknown_shortcodes mode " + settings.Content.UnknownShortcodes)
+	}
+
+	if secret := os.Getenv(authSecretEnv); len(secret) > 0 {
+		settings.Auth.Secret = secret
+	}
+	authenticator, err := auth.NewAuthenticator(db, resolver.TablePrefix, settings.Auth.Secret)
+	if err == auth.ErrWeakSecret {
+		logger.Error(context.Background(), "no token secret: set auth.secret in settings.json or "+authSecretEnv+" to a long random string", logging.Fields{"error": err})
+		os.Exit(1)
+	}
 	if err != nil {
 		panic(err)
 	}
+	if len(settings.Auth.TokenTTL) > 0 {
+		authenticator.TokenTTL, err = time.ParseDuration(settings.Auth.TokenTTL)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	var mailer mail.Mailer
+	switch settings.Mail.Transport {
+	case "", "stdout":
+		mailer = mail.NewStdoutMailer()
+	case "file":
+		mailer, err = mail.NewFileMailer(settings.Mail.Dir)
+		if err != nil {
+			panic(err)
+		}
+	default:
+		panic("unknown mail transport " + settings.Mail.Transport)
+	}
+
+	bus := events.NewBus()
+
+	if indexSearch != nil {
+
+		var rebuildInterval time.Duration
+		if len(settings.Search.RebuildInterval) > 0 {
+			rebuildInterval, err = time.ParseDuration(settings.Search.RebuildInterval)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		go indexSearch.Run(context.Background(), bus, rebuildInterval)
+	}
+
+	if settings.Changes.Enabled {
+
+		sites := settings.Changes.Sites
+		if len(sites) == 0 {
+			sites = []ChangesSite{{Prefix: resolver.TablePrefix}}
+		}
 
-	schemaString := string(bstr)
+		var pollSites []changes.Site
+		for _, site := range sites {
+
+			interval := site.Interval
+			if len(interval) == 0 {
+				interval = settings.Changes.Interval
+			}
+
+			sweepInterval := site.SweepInterval
+			if len(sweepInterval) == 0 {
+				sweepInterval = settings.Changes.SweepInterval
+			}
+
+			pollSite := changes.Site{Prefix: site.Prefix}
+			if len(interval) > 0 {
+				pollSite.Interval, err = time.ParseDuration(interval)
+				if err != nil {
+					panic(err)
+				}
+			}
+			if len(sweepInterval) > 0 {
+				pollSite.Sweep, err = time.ParseDuration(sweepInterval)
+				if err != nil {
+					panic(err)
+				}
+			}
+
+			pollSites = append(pollSites, pollSite)
+		}
+
+		poller, err := changes.NewPoller(db, bus, settings.Changes.WatermarkFile, pollSites)
+		if err != nil {
+			panic(err)
+		}
+
+		go poller.Run(context.Background())
+	}
+
+	var webhooks *webhook.Dispatcher
+	if len(settings.Webhooks.Endpoints) > 0 {
+
+		webhooks, err = webhook.NewDispatcher(settings.Webhooks.QueueDir, settings.Webhooks.Endpoints)
+		if err != nil {
+			panic(err)
+		}
+
+		if settings.Webhooks.MaxAttempts > 0 {
+			webhooks.MaxAttempts = settings.Webhooks.MaxAttempts
+		}
+		if len(settings.Webhooks.MinBackoff) > 0 {
+			webhooks.MinBackoff, err = time.ParseDuration(settings.Webhooks.MinBackoff)
+			if err != nil {
+				panic(err)
+			}
+		}
+		if len(settings.Webhooks.MaxBackoff) > 0 {
+			webhooks.MaxBackoff, err = time.ParseDuration(settings.Webhooks.MaxBackoff)
+			if err != nil {
+				panic(err)
+			}
+		}
+		if len(settings.Webhooks.Timeout) > 0 {
+			webhooks.Timeout, err = time.ParseDuration(settings.Webhooks.Timeout)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		bus.Listen(webhooks.Enqueue)
+		go webhooks.Run(context.Background())
+	}
+
+	rootResolver := &resolver.RootResolver{
+		DB:         db,
+		MetaFields: metaFields,
+		Searcher:   searcher,
+		Content:    content,
+		Auth:       authenticator,
+		Mailer:     mailer,
+		Events:     bus,
+		Webhooks:   webhooks,
+		MailFrom:   settings.Mail.From,
+		ResetURL:   settings.Mail.ResetURL,
+
+		UserMetaKeys: settings.UserMeta.EditableKeys,
+	}
 
 	//params := r.URL.Query()
-	schema, err := graphql.ParseSchema(schemaString, &resolver.RootResolver{DB: db})
+	schemaOpts := []graphql.SchemaOpt{graphql.Logger(logger)}
+	if settings.Limits.MaxDepth > 0 {
+		schemaOpts = append(schemaOpts, graphql.MaxDepth(settings.Limits.MaxDepth))
+	}
+
+	metaFieldFuncs, err := rootResolver.MetaFieldFuncs()
+	if err != nil {
+		panic(err)
+	}
+	if metaFieldFuncs != nil {
+		schemaOpts = append(schemaOpts, graphql.FieldFuncs(metaFieldFuncs))
+	}
+
+	// Report all the resolvers out of step with the schema, rather than
+	// the first one graphql.ParseSchema panics on.
+	problems, err := sdl.CheckResolvers(schemaSources, rootResolver, metaFieldFuncs, modelPackage)
+	if err != nil {
+		panic(err)
+	}
+	failed := false
+	for _, problem := range problems {
+		if problem.Severity == sdl.Error {
+			fields := logging.Fields{"path": problem.Path}
+			if len(problem.Schema) > 0 {
+				fields["schema"] = problem.Schema
+			}
+			if len(problem.Source) > 0 {
+				fields["source"] = problem.Source
+			}
+			logger.Error(context.Background(), problem.Message, fields)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+
+	cacheHints, schemaString, err := analysis.ReadCacheHints(sdl.Join(schemaSources))
+	if err != nil {
+		panic(err)
+	}
+
+	schema, err := graphql.ParseSchema(schemaString, rootResolver, schemaOpts...)
 	if err != nil {
 		panic(err)
 	}
 
+	analyzer := analysis.NewAnalyzer(schema)
+	if settings.Limits.DefaultListSize > 0 {
+		analyzer.DefaultListSize = settings.Limits.DefaultListSize
+	}
+	for field, cost := range settings.Limits.FieldCosts {
+		analyzer.FieldCosts[field] = cost
+	}
+	analyzer.CacheHints = cacheHints
+	analyzer.DefaultMaxAge = settings.CacheControl.DefaultMaxAge
+
+	var responses *handler.ResponseCache
+	if settings.CacheControl.ResponseCache.Enabled {
+		responses = handler.NewResponseCache(settings.CacheControl.ResponseCache.MaxEntries, settings.CacheControl.ResponseCache.MaxBytes)
+		expvar.Publish("response_cache", expvar.Func(func() interface{} {
+			return responses.Stats()
+		}))
+
+		go responses.Run(context.Background(), bus)
+	}
+
+	var limiter *ratelimit.Limiter
+	if settings.RateLimit.Enabled {
+
+		limiter = ratelimit.NewLimiter(ratelimit.NewMemoryStore(), settings.RateLimit.Query.limit(), settings.RateLimit.Mutation.limit())
+		limiter.ChargeCost = settings.RateLimit.ChargeCost
+
+		for _, cidr := range settings.RateLimit.TrustedProxies {
+			if !strings.Contains(cidr, "/") {
+				if strings.Contains(cidr, ":") {
+					cidr += "/128"
+				} else {
+					cidr += "/32"
+				}
+			}
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				panic(err)
+			}
+			limiter.TrustedProxies = append(limiter.TrustedProxies, network)
+		}
+
+		for _, apiKey := range settings.RateLimit.APIKeys {
+			limiter.APIKeys[apiKey.Key] = &ratelimit.APIKey{
+				Name:     apiKey.Name,
+				Query:    apiKey.Query.limit(),
+				Mutation: apiKey.Mutation.limit(),
+			}
+		}
+	}
+
+	var documents *analysis.Cache
+	if settings.DocumentCache.Enabled {
+		documents = analysis.NewCache(settings.DocumentCache.MaxEntries, settings.DocumentCache.MaxBytes)
+		expvar.Publish("document_cache", expvar.Func(func() interface{} {
+			return documents.Stats()
+		}))
+	}
+
+	var persistedQueries *persisted.Registry
+	if settings.Persisted.Automatic || len(settings.Persisted.ManifestFile) > 0 {
+
+		var manifest persisted.Manifest
+		if len(settings.Persisted.ManifestFile) > 0 {
+			manifest, err = persisted.LoadManifest(settings.Persisted.ManifestFile)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		cacheSize := 0
+		if settings.Persisted.Automatic {
+			cacheSize = settings.Persisted.CacheSize
+		}
+
+		persistedQueries = persisted.NewRegistry(manifest, cacheSize)
+		persistedQueries.Strict = settings.Persisted.Strict
+
+		for id, query := range manifest {
+			if checked := analysis.Check(schema, query); len(checked.Errors) > 0 {
+				logger.Warn(context.Background(), "persisted query does not validate", logging.Fields{"id": id, "error": checked.Errors[0].Message})
+			}
+		}
+	}
+
 	r := mux.NewRouter()
-	r.PathPrefix(graphqlURL).Handler(&handler.GraphqlHandler{Schema: schema})
-	r.PathPrefix(graphqlURL + "/").Handler(&handler.GraphqlHandler{Schema: schema})
+	graphqlHandler := &handler.GraphqlHandler{
+		Schema:    schema,
+		Auth:      authenticator,
+		Analyzer:  analyzer,
+		MaxCost:   settings.Limits.MaxCost,
+		Limiter:   limiter,
+		Persisted: persistedQueries,
+		Documents: documents,
+		Responses: responses,
+		Loaders:   rootResolver.WithLoaders,
+
+		AllowedOrigins:          settings.Subscriptions.AllowedOrigins,
+		MaxConnectionOperations: settings.Subscriptions.MaxOperations,
+
+		MaxBatchSize: settings.Limits.MaxBatchSize,
+		MaxBodyBytes: settings.Limits.MaxBodyBytes,
+
+		GraphiQL:  settings.GraphiQL.Enabled,
+		DevUserID: settings.GraphiQL.DevUserID,
+
+		Log:            logger,
+		AccessLog:      settings.Logging.AccessLog,
+		SensitiveNames: settings.Logging.SensitiveNames,
+	}
+	if len(settings.Subscriptions.ConnectionInitTimeout) > 0 {
+		graphqlHandler.ConnectionInitTimeout, err = time.ParseDuration(settings.Subscriptions.ConnectionInitTimeout)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if len(settings.Subscriptions.WriteTimeout) > 0 {
+		graphqlHandler.WriteTimeout, err = time.ParseDuration(settings.Subscriptions.WriteTimeout)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if len(settings.Subscriptions.SSEHeartbeat) > 0 {
+		graphqlHandler.SSEHeartbeat, err = time.ParseDuration(settings.Subscriptions.SSEHeartbeat)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if len(settings.General.DebugVarsURL) > 0 {
+		r.Handle(settings.General.DebugVarsURL, expvar.Handler())
+	}
+
+	r.PathPrefix(graphqlURL).Handler(graphqlHandler)
+	r.PathPrefix(graphqlURL + "/").Handler(graphqlHandler)
 
 	http.ListenAndServe(":9990", r)
 }
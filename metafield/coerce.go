@@ -0,0 +1,263 @@
+package metafield
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iyut/graphql-go/phpserialize"
+)
+
+/****
+*********************
+VALUE COERCION
+*********************
+****/
+
+// Coerce converts a raw meta_value into the Go value of the field's kind:
+// string, int32, float64, bool, time.Time, interface{} (JSON), []string or
+// int64 (referenced post ID). Empty values coerce to nil.
+func Coerce(field *Field, raw string) (interface{}, error) {
+
+	if len(raw) == 0 && field.Type != KindString {
+		return nil, nil
+	}
+
+	value, err := decode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("meta field %q: %v", field.Name, err)
+	}
+
+	switch field.Type {
+	case KindString:
+		return toString(value), nil
+	case KindInt:
+		return toInt(field, value)
+	case KindFloat:
+		return toFloat(field, value)
+	case KindBoolean:
+		return toBool(value), nil
+	case KindDateTime:
+		return toTime(field, value)
+	case KindJSON:
+		return jsonValue(raw, value), nil
+	case KindList:
+		return toList(value), nil
+	case KindPost, KindMedia:
+		return toPostID(field, value)
+	}
+
+	return nil, fmt.Errorf("meta field %q: unknown type %q", field.Name, field.Type)
+}
+
+// decode unserializes PHP serialized values and leaves plain strings as is.
+func decode(raw string) (interface{}, error) {
+
+	if !phpserialize.IsSerialized(raw) {
+		return raw, nil
+	}
+
+	return phpserialize.Unserialize(raw)
+}
+
+// jsonValue keeps unserialized arrays, otherwise tries to read the value as
+// JSON before falling back to the plain string.
+func jsonValue(raw string, value interface{}) interface{} {
+
+	if _, ok := value.(string); !ok {
+		return value
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(raw), &decoded); err == nil {
+		return decoded
+	}
+
+	return raw
+}
+
+func toString(value interface{}) string {
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		if v {
+			return "1"
+		}
+		return ""
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	b, _ := json.Marshal(value)
+	return string(b)
+}
+
+// first unwraps single-element arrays, which ACF uses for many single values.
+func first(value interface{}) interface{} {
+
+	if list, ok := value.([]interface{}); ok {
+		if len(list) == 0 {
+			return nil
+		}
+		return list[0]
+	}
+
+	return value
+}
+
+func toFloat(field *Field, value interface{}) (interface{}, error) {
+
+	switch v := first(value).(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case bool:
+		if v {
+			return float64(1), nil
+		}
+		return float64(0), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("meta field %q: %q is not a number", field.Name, v)
+		}
+		return f, nil
+	}
+
+	return nil, fmt.Errorf("meta field %q: cannot convert %T to Float", field.Name, value)
+}
+
+func toInt(field *Field, value interface{}) (interface{}, error) {
+
+	f, err := toFloat(field, value)
+	if err != nil || f == nil {
+		return nil, err
+	}
+
+	i := math.Trunc(f.(float64))
+	if i > math.MaxInt32 || i < math.MinInt32 {
+		return nil, fmt.Errorf("meta field %q: %v overflows Int", field.Name, i)
+	}
+
+	return int32(i), nil
+}
+
+func toBool(value interface{}) interface{} {
+
+	switch v := first(value).(type) {
+	case nil:
+		return nil
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "", "0", "false", "no", "off":
+			return false
+		}
+		return true
+	}
+
+	return true
+}
+
+// Date formats used by ACF's date and date-time pickers and by core.
+var timeLayouts = []string{
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"2006-01-02",
+	"20060102",
+	"2006-01-02T15:04:05",
+}
+
+func toTime(field *Field, value interface{}) (interface{}, error) {
+
+	switch v := first(value).(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		return time.Unix(v, 0).UTC(), nil
+	case string:
+		v = strings.TrimSpace(v)
+
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+
+		if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(ts, 0).UTC(), nil
+		}
+
+		return nil, fmt.Errorf("meta field %q: %q is not a date", field.Name, v)
+	}
+
+	return nil, fmt.Errorf("meta field %q: cannot convert %T to DateTime", field.Name, value)
+}
+
+func toList(value interface{}) interface{} {
+
+	switch v := value.(type) {
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			list = append(list, toString(item))
+		}
+		return list
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		list := make([]string, 0, len(v))
+		for _, key := range keys {
+			list = append(list, toString(v[key]))
+		}
+		return list
+	case string:
+		var decoded []interface{}
+		if err := json.Unmarshal([]byte(v), &decoded); err == nil {
+			return toList(decoded)
+		}
+		return []string{v}
+	}
+
+	return []string{toString(value)}
+}
+
+func toPostID(field *Field, value interface{}) (interface{}, error) {
+
+	switch v := first(value).(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		return v, nil
+	case string:
+		id, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("meta field %q: %q is not a post ID", field.Name, v)
+		}
+		return id, nil
+	}
+
+	return nil, fmt.Errorf("meta field %q: cannot convert %T to a post reference", field.Name, value)
+}
@@ -0,0 +1,180 @@
+package metafield
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/****
+*********************
+FIELD CONFIGURATION
+*********************
+****/
+
+// Kinds a configured meta field can be exposed as.
+const (
+	KindString   = "String"
+	KindInt      = "Int"
+	KindFloat    = "Float"
+	KindBoolean  = "Boolean"
+	KindDateTime = "DateTime"
+	KindJSON     = "JSON"
+	KindList     = "List"
+	KindPost     = "Post"
+	KindMedia    = "Media"
+)
+
+var kinds = []string{KindString, KindInt, KindFloat, KindBoolean, KindDateTime, KindJSON, KindList, KindPost, KindMedia}
+
+var nameRegexp = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// Field maps a wp_postmeta key of one post type to a GraphQL field name.
+type Field struct {
+	PostType string `json:"post_type"`
+	MetaKey  string `json:"meta_key"`
+	Name     string `json:"field"`
+	Type     string `json:"type"`
+}
+
+// Registry holds the validated field configuration, indexed by post type.
+type Registry struct {
+	fields     []*Field
+	byPostType map[string]map[string]*Field
+	kinds      map[string]string
+}
+
+func NewRegistry(fields []Field) (*Registry, error) {
+
+	reg := &Registry{byPostType: make(map[string]map[string]*Field), kinds: make(map[string]string)}
+
+	for i := range fields {
+
+		field := fields[i]
+
+		if len(field.PostType) == 0 {
+			field.PostType = "post"
+		}
+
+		if len(field.MetaKey) == 0 {
+			return nil, fmt.Errorf("meta field %q: meta_key is required", field.Name)
+		}
+
+		if !nameRegexp.MatchString(field.Name) {
+			return nil, fmt.Errorf("meta field %q: invalid GraphQL field name", field.Name)
+		}
+
+		field.Type = normalizeKind(field.Type)
+		if len(field.Type) == 0 {
+			return nil, fmt.Errorf("meta field %q: unknown type %q", field.Name, fields[i].Type)
+		}
+
+		byName, ok := reg.byPostType[field.PostType]
+		if !ok {
+			byName = make(map[string]*Field)
+			reg.byPostType[field.PostType] = byName
+		}
+
+		if _, exists := byName[field.Name]; exists {
+			return nil, fmt.Errorf("meta field %q is defined twice for post type %q", field.Name, field.PostType)
+		}
+
+		// Every name is one field of Post, so of one type whatever the
+		// post type.
+		if kind, ok := reg.kinds[field.Name]; ok && kind != field.Type {
+			return nil, fmt.Errorf("meta field %q is %s for one post type and %s for another", field.Name, kind, field.Type)
+		}
+		reg.kinds[field.Name] = field.Type
+
+		byName[field.Name] = &field
+		reg.fields = append(reg.fields, &field)
+	}
+
+	return reg, nil
+}
+
+func normalizeKind(kind string) string {
+
+	switch strings.ToLower(kind) {
+	case "", "string", "text":
+		return KindString
+	case "post-reference", "post_reference":
+		return KindPost
+	case "media-reference", "media_reference", "image", "file":
+		return KindMedia
+	case "[string]", "list", "array":
+		return KindList
+	}
+
+	for _, k := range kinds {
+		if strings.EqualFold(k, kind) {
+			return k
+		}
+	}
+
+	return ""
+}
+
+// Empty reports whether no field is configured, in which case the schema is
+// left untouched.
+func (reg *Registry) Empty() bool {
+	return reg == nil || len(reg.fields) == 0
+}
+
+// Lookup returns the field configured under name for the post type.
+func (reg *Registry) Lookup(postType string, name string) (*Field, bool) {
+
+	if reg == nil {
+		return nil, false
+	}
+
+	field, ok := reg.byPostType[postType][name]
+	return field, ok
+}
+
+// ForPostType returns the fields of the post type, ordered by name.
+func (reg *Registry) ForPostType(postType string) []*Field {
+
+	if reg == nil {
+		return nil
+	}
+
+	var fields []*Field
+	for _, field := range reg.byPostType[postType] {
+		fields = append(fields, field)
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+
+	return fields
+}
+
+// Names returns every configured field name across all post types.
+func (reg *Registry) Names() []string {
+
+	seen := make(map[string]bool)
+	var names []string
+
+	for _, field := range reg.fields {
+		if !seen[field.Name] {
+			seen[field.Name] = true
+			names = append(names, field.Name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Kind returns the kind of the fields configured under name, the empty
+// string when there are none.
+func (reg *Registry) Kind(name string) string {
+
+	if reg == nil {
+		return ""
+	}
+
+	return reg.kinds[name]
+}
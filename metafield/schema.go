@@ -0,0 +1,48 @@
+package metafield
+
+import (
+	"strings"
+)
+
+/****
+*********************
+SCHEMA EXTENSION
+*********************
+****/
+
+// graphqlTypes are the GraphQL types fields of each kind resolve to.
+var graphqlTypes = map[string]string{
+	KindString:   "String",
+	KindInt:      "Int",
+	KindFloat:    "Float",
+	KindBoolean:  "Boolean",
+	KindDateTime: "Time",
+	KindJSON:     "JSON",
+	KindList:     "[String!]",
+	KindPost:     "Post",
+	KindMedia:    "Post",
+}
+
+// SDL returns the schema extension for the configured fields: a nullable
+// field of Post for every name, typed after its kind, so clients select
+// them as any other field:
+//
+//	post(postID: 1) { title subtitle heroImage { postID } }
+//
+// Fields are null on posts of a type they are not configured for.
+func (reg *Registry) SDL() string {
+
+	if reg.Empty() {
+		return ""
+	}
+
+	var b strings.Builder
+
+	b.WriteString("\nextend type Post{\n")
+	for _, name := range reg.Names() {
+		b.WriteString("\t" + name + ": " + graphqlTypes[reg.Kind(name)] + "\n")
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
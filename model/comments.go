@@ -7,7 +7,7 @@ import "time"
 type Comments struct {
 	CommentID          graphql.ID
 	CommentPostID      graphql.ID
-	CommentAuthor      bool
+	CommentAuthor      string
 	CommentAuthorEmail string
 	CommentAuthorURL   string
 	CommentAuthorIP    string
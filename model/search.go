@@ -0,0 +1,11 @@
+package model
+
+import "github.com/graph-gophers/graphql-go"
+
+type SearchHit struct {
+	ObjectType string
+	ObjectID   graphql.ID
+	Score      float64
+	Post       *Post
+	Comment    *Comments
+}
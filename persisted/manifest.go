@@ -0,0 +1,52 @@
+package persisted
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Manifest is an allow-list of documents by operation ID.
+type Manifest map[string]string
+
+const apolloManifestFormat = "apollo-persisted-query-manifest"
+
+// apolloManifest is the format of Apollo's generate-persisted-query-manifest.
+type apolloManifest struct {
+	Format     string `json:"format"`
+	Operations []struct {
+		ID   string `json:"id"`
+		Body string `json:"body"`
+	} `json:"operations"`
+}
+
+// LoadManifest reads a JSON object of documents by operation ID, or an Apollo
+// persisted query manifest.
+func LoadManifest(file string) (Manifest, error) {
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var apollo apolloManifest
+	if err := json.Unmarshal(b, &apollo); err == nil && apollo.Format == apolloManifestFormat {
+
+		manifest := make(Manifest)
+		for _, op := range apollo.Operations {
+			if len(op.ID) == 0 || len(op.Body) == 0 {
+				return nil, fmt.Errorf("%s: operation without id or body", file)
+			}
+			manifest[op.ID] = op.Body
+		}
+
+		return manifest, nil
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	return manifest, nil
+}
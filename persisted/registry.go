@@ -0,0 +1,112 @@
+// Package persisted keeps the queries that clients send by hash or ID instead
+// of in full: the allow-list of a manifest, and the queries registered by
+// clients through Automatic Persisted Queries.
+package persisted
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/iyut/graphql-go/lru"
+)
+
+var (
+	ErrNotFound     = errors.New("PersistedQueryNotFound")
+	ErrNotSupported = errors.New("PersistedQueryNotSupported")
+	ErrHashMismatch = errors.New("provided sha does not match query")
+	ErrNotAllowed   = errors.New("query is not in the list of persisted queries")
+)
+
+// Registry finds persisted queries by ID or SHA-256 hash.
+type Registry struct {
+	// Strict refuses the queries that are not in the manifest, and any
+	// registration.
+	Strict bool
+
+	manifest   map[string]string
+	registered *lru.Cache
+}
+
+// NewRegistry takes the queries of the manifest, which may be nil. Clients
+// may register up to cacheSize queries of their own, the least recently
+// used being forgotten; none when cacheSize is zero.
+func NewRegistry(manifest Manifest, cacheSize int) *Registry {
+
+	r := &Registry{manifest: make(map[string]string)}
+
+	if cacheSize > 0 {
+		r.registered = lru.New(cacheSize, 0)
+	}
+
+	for id, query := range manifest {
+		r.manifest[id] = query
+		r.manifest[Hash(query)] = query
+	}
+
+	return r
+}
+
+// Hash is the hex SHA-256 of the query, which Automatic Persisted Queries
+// know it by.
+func Hash(query string) string {
+
+	sum := sha256.Sum256([]byte(query))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Lookup finds a query by the ID of its manifest entry, or by hash.
+func (r *Registry) Lookup(key string) (string, bool) {
+
+	if query, ok := r.manifest[key]; ok {
+		return query, true
+	}
+
+	if r.registered == nil {
+		return "", false
+	}
+
+	query, ok := r.registered.Get(strings.ToLower(key))
+	if !ok {
+		return "", false
+	}
+
+	return query.(string), true
+}
+
+// Allowed tells whether a query sent in full is in the manifest.
+func (r *Registry) Allowed(query string) bool {
+
+	_, ok := r.manifest[Hash(query)]
+
+	return ok
+}
+
+// Register keeps a query sent along with its hash, for the requests that
+// will send only the hash.
+func (r *Registry) Register(hash string, query string) error {
+
+	hash = strings.ToLower(hash)
+
+	if Hash(query) != hash {
+		return ErrHashMismatch
+	}
+
+	if _, ok := r.Lookup(hash); ok {
+		return nil
+	}
+
+	if r.Strict {
+		return ErrNotAllowed
+	}
+
+	if r.registered == nil {
+		return ErrNotSupported
+	}
+
+	r.registered.Add(hash, query, len(query))
+
+	return nil
+}
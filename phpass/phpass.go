@@ -0,0 +1,116 @@
+// Package phpass implements the portable password hashes of the phpass
+// library WordPress uses for user and post passwords.
+package phpass
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// DefaultCost is the base-2 logarithm of the iteration count WordPress uses,
+// as in new PasswordHash(8, true).
+const DefaultCost = 8
+
+// Hash returns a portable "$P$" hash of the password.
+func Hash(password string) (string, error) {
+
+	salt := make([]byte, 6)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	setting := "$P$" + string(itoa64[DefaultCost+5]) + encode64(salt, 6)
+
+	return cryptPrivate(password, setting), nil
+}
+
+// Check reports whether the password matches the hash the way
+// wp_check_password() does, accepting portable hashes and the plain MD5
+// hashes of very old installs.
+func Check(password string, hash string) bool {
+
+	if len(hash) <= 32 {
+		sum := md5.Sum([]byte(password))
+		return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(hash)) == 1
+	}
+
+	computed := cryptPrivate(password, hash)
+	if strings.HasPrefix(computed, "*") {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(hash)) == 1
+}
+
+// cryptPrivate is a port of PasswordHash::crypt_private().
+func cryptPrivate(password string, setting string) string {
+
+	output := "*0"
+	if strings.HasPrefix(setting, output) {
+		output = "*1"
+	}
+
+	if len(setting) < 12 {
+		return output
+	}
+
+	id := setting[:3]
+	if id != "$P$" && id != "$H$" {
+		return output
+	}
+
+	countLog2 := strings.IndexByte(itoa64, setting[3])
+	if countLog2 < 7 || countLog2 > 30 {
+		return output
+	}
+
+	salt := setting[4:12]
+
+	hash := md5.Sum([]byte(salt + password))
+	for count := 1 << uint(countLog2); count > 0; count-- {
+		hash = md5.Sum(append(hash[:], password...))
+	}
+
+	return setting[:12] + encode64(hash[:], 16)
+}
+
+// encode64 is phpass' own base64 variant.
+func encode64(input []byte, count int) string {
+
+	var output strings.Builder
+
+	i := 0
+	for i < count {
+
+		value := int(input[i])
+		i++
+		output.WriteByte(itoa64[value&0x3f])
+
+		if i < count {
+			value |= int(input[i]) << 8
+		}
+		output.WriteByte(itoa64[(value>>6)&0x3f])
+		if i >= count {
+			break
+		}
+		i++
+
+		if i < count {
+			value |= int(input[i]) << 16
+		}
+		output.WriteByte(itoa64[(value>>12)&0x3f])
+		if i >= count {
+			break
+		}
+		i++
+
+		output.WriteByte(itoa64[(value>>18)&0x3f])
+	}
+
+	return output.String()
+}
@@ -0,0 +1,135 @@
+package phpserialize
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/****
+*********************
+PHP SERIALIZE
+*********************
+****/
+
+// Serialize encodes a value the way PHP's serialize() does, so WordPress can
+// read it back with maybe_unserialize(). It accepts what Unserialize returns,
+// plus ints, string slices and maps of bools or strings. Map keys are written
+// in sorted order, numeric keys as integers.
+func Serialize(value interface{}) (string, error) {
+
+	var b strings.Builder
+
+	if err := serialize(&b, value); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+func serialize(b *strings.Builder, value interface{}) error {
+
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("N;")
+	case bool:
+		if v {
+			b.WriteString("b:1;")
+		} else {
+			b.WriteString("b:0;")
+		}
+	case int:
+		fmt.Fprintf(b, "i:%d;", v)
+	case int32:
+		fmt.Fprintf(b, "i:%d;", v)
+	case int64:
+		fmt.Fprintf(b, "i:%d;", v)
+	case float64:
+		b.WriteString("d:" + strconv.FormatFloat(v, 'g', -1, 64) + ";")
+	case string:
+		fmt.Fprintf(b, "s:%d:\"%s\";", len(v), v)
+	case []string:
+		fmt.Fprintf(b, "a:%d:{", len(v))
+		for i, item := range v {
+			fmt.Fprintf(b, "i:%d;", i)
+			serialize(b, item)
+		}
+		b.WriteString("}")
+	case []interface{}:
+		fmt.Fprintf(b, "a:%d:{", len(v))
+		for i, item := range v {
+			fmt.Fprintf(b, "i:%d;", i)
+			if err := serialize(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteString("}")
+	case map[string]bool:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = item
+		}
+		return serialize(b, m)
+	case map[string]string:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = item
+		}
+		return serialize(b, m)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintf(b, "a:%d:{", len(v))
+		for _, key := range keys {
+			if n, err := strconv.ParseInt(key, 10, 64); err == nil && strconv.FormatInt(n, 10) == key {
+				fmt.Fprintf(b, "i:%d;", n)
+			} else {
+				serialize(b, key)
+			}
+			if err := serialize(b, v[key]); err != nil {
+				return err
+			}
+		}
+		b.WriteString("}")
+	default:
+		return fmt.Errorf("phpserialize: cannot serialize %T", value)
+	}
+
+	return nil
+}
+
+// MaybeSerialize is maybe_serialize() for the strings WordPress stores as
+// meta values: a string that looks serialized is serialized once more, so
+// maybe_unserialize() gives the string back rather than the value it spells.
+func MaybeSerialize(data string) string {
+
+	if !looksSerialized(data) {
+		return data
+	}
+
+	serialized, _ := Serialize(data)
+
+	return serialized
+}
+
+// MaybeUnserialize undoes MaybeSerialize, returning data itself when it is
+// not a serialized string.
+func MaybeUnserialize(data string) string {
+
+	if !IsSerialized(data) {
+		return data
+	}
+
+	if value, err := Unserialize(data); err == nil {
+		if s, ok := value.(string); ok {
+			return s
+		}
+	}
+
+	return data
+}
@@ -0,0 +1,306 @@
+package phpserialize
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/****
+*********************
+PHP UNSERIALIZE
+*********************
+****/
+
+// Unserialize decodes a value produced by PHP's serialize(), as stored by
+// WordPress in the meta and options tables. Scalars map to nil, bool, int64,
+// float64 and string. Arrays whose keys are 0..n-1 in order become
+// []interface{}, every other array or object becomes map[string]interface{}.
+func Unserialize(data string) (interface{}, error) {
+
+	d := &decoder{data: data}
+
+	value, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+
+	if d.pos != len(d.data) {
+		return nil, d.errorf("unexpected trailing data")
+	}
+
+	return value, nil
+}
+
+// IsSerialized reports whether data looks like a PHP serialized value, the
+// same check WordPress does in is_serialized() before calling unserialize().
+func IsSerialized(data string) bool {
+
+	if len(data) < 2 {
+		return false
+	}
+
+	if data == "N;" {
+		return true
+	}
+
+	if len(data) < 4 || data[1] != ':' {
+		return false
+	}
+
+	last := data[len(data)-1]
+
+	switch data[0] {
+	case 's':
+		return last == ';' && data[len(data)-2] == '"'
+	case 'a', 'O':
+		return last == '}'
+	case 'b', 'i', 'd':
+		return last == ';'
+	}
+
+	return false
+}
+
+var (
+	tokenLengthRegexp = regexp.MustCompile(`^[saOE]:[0-9]+:`)
+	tokenScalarRegexp = regexp.MustCompile(`^[bid]:[0-9.E+-]+;`)
+)
+
+// looksSerialized is is_serialized() in its loose mode, which
+// maybe_serialize() uses: it errs on the side of data being serialized.
+func looksSerialized(data string) bool {
+
+	data = strings.TrimSpace(data)
+
+	if data == "N;" {
+		return true
+	}
+
+	if len(data) < 4 || data[1] != ':' {
+		return false
+	}
+
+	semicolon := strings.IndexByte(data, ';')
+	brace := strings.IndexByte(data, '}')
+	if (semicolon < 0 && brace < 0) || (semicolon >= 0 && semicolon < 3) || (brace >= 0 && brace < 4) {
+		return false
+	}
+
+	switch data[0] {
+	case 's':
+		return strings.IndexByte(data, '"') >= 0 && tokenLengthRegexp.MatchString(data)
+	case 'a', 'O', 'E':
+		return tokenLengthRegexp.MatchString(data)
+	case 'b', 'i', 'd':
+		return tokenScalarRegexp.MatchString(data)
+	}
+
+	return false
+}
+
+type decoder struct {
+	data string
+	pos  int
+}
+
+func (d *decoder) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("phpserialize: "+format+" at offset %d", append(args, d.pos)...)
+}
+
+func (d *decoder) expect(c byte) error {
+
+	if d.pos >= len(d.data) || d.data[d.pos] != c {
+		return d.errorf("expected %q", c)
+	}
+
+	d.pos++
+	return nil
+}
+
+// readUntil returns the text up to the delimiter and moves past it.
+func (d *decoder) readUntil(delim byte) (string, error) {
+
+	start := d.pos
+	for d.pos < len(d.data) {
+		if d.data[d.pos] == delim {
+			s := d.data[start:d.pos]
+			d.pos++
+			return s, nil
+		}
+		d.pos++
+	}
+
+	return "", d.errorf("missing %q", delim)
+}
+
+func (d *decoder) readInt(delim byte) (int64, error) {
+
+	s, err := d.readUntil(delim)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// readString reads a length-prefixed, double-quoted string body. PHP counts
+// the length in bytes, so multi-byte characters are handled correctly.
+func (d *decoder) readString() (string, error) {
+
+	length, err := d.readInt(':')
+	if err != nil {
+		return "", err
+	}
+
+	if err := d.expect('"'); err != nil {
+		return "", err
+	}
+
+	if length < 0 || d.pos+int(length) > len(d.data) {
+		return "", d.errorf("string length %d out of range", length)
+	}
+
+	s := d.data[d.pos : d.pos+int(length)]
+	d.pos += int(length)
+
+	if err := d.expect('"'); err != nil {
+		return "", err
+	}
+
+	return s, nil
+}
+
+func (d *decoder) value() (interface{}, error) {
+
+	if d.pos+1 >= len(d.data) {
+		return nil, errors.New("phpserialize: unexpected end of data")
+	}
+
+	kind := d.data[d.pos]
+	d.pos++
+
+	if kind == 'N' {
+		return nil, d.expect(';')
+	}
+
+	if err := d.expect(':'); err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case 'b':
+		v, err := d.readInt(';')
+		if err != nil {
+			return nil, err
+		}
+		return v != 0, nil
+
+	case 'i':
+		return d.readInt(';')
+
+	case 'd':
+		s, err := d.readUntil(';')
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(s, 64)
+
+	case 's':
+		s, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		return s, d.expect(';')
+
+	case 'a':
+		return d.array()
+
+	case 'O':
+		// The class name is dropped, only the properties are kept.
+		if _, err := d.readString(); err != nil {
+			return nil, err
+		}
+		if err := d.expect(':'); err != nil {
+			return nil, err
+		}
+		return d.array()
+	}
+
+	return nil, d.errorf("unknown type %q", kind)
+}
+
+func (d *decoder) array() (interface{}, error) {
+
+	count, err := d.readInt(':')
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.expect('{'); err != nil {
+		return nil, err
+	}
+
+	if count < 0 {
+		return nil, d.errorf("negative array count %d", count)
+	}
+
+	// The count comes from the data, so it only sizes the allocations as
+	// far as the rest of the data can hold elements, each taking at least
+	// four bytes.
+	size := count
+	if remaining := int64(len(d.data)-d.pos) / 4; size > remaining {
+		size = remaining
+	}
+
+	keys := make([]string, 0, size)
+	values := make(map[string]interface{}, size)
+	isList := true
+
+	for i := int64(0); i < count; i++ {
+
+		key, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+
+		var keyStr string
+		switch k := key.(type) {
+		case int64:
+			keyStr = strconv.FormatInt(k, 10)
+			if k != i {
+				isList = false
+			}
+		case string:
+			keyStr = k
+			isList = false
+		default:
+			return nil, d.errorf("invalid array key %v", key)
+		}
+
+		value, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, keyStr)
+		values[keyStr] = value
+	}
+
+	if err := d.expect('}'); err != nil {
+		return nil, err
+	}
+
+	if !isList {
+		return values, nil
+	}
+
+	list := make([]interface{}, len(keys))
+	for i, key := range keys {
+		list[i] = values[key]
+	}
+
+	return list, nil
+}
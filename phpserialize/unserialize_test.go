@@ -0,0 +1,155 @@
+package phpserialize
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnserialize(t *testing.T) {
+
+	tests := []struct {
+		name string
+		in   string
+		want interface{}
+	}{
+		{"null", "N;", nil},
+		{"true", "b:1;", true},
+		{"false", "b:0;", false},
+		{"int", "i:-42;", int64(-42)},
+		{"float", "d:0.5;", 0.5},
+		{"string", `s:5:"hello";`, "hello"},
+		{"multi-byte string", `s:6:"héllo";`, "héllo"},
+		{"string holding quotes", `s:4:"a";b";`, `a";b`},
+		{"empty array", "a:0:{}", []interface{}{}},
+		{"list", `a:2:{i:0;s:1:"a";i:1;i:2;}`, []interface{}{"a", int64(2)}},
+		{"list out of order", `a:2:{i:1;s:1:"a";i:0;s:1:"b";}`, map[string]interface{}{"1": "a", "0": "b"}},
+		{"map", `a:1:{s:13:"administrator";b:1;}`, map[string]interface{}{"administrator": true}},
+		{"nested", `a:1:{s:1:"a";a:1:{i:0;N;}}`, map[string]interface{}{"a": []interface{}{nil}}},
+		{"object", `O:8:"stdClass":1:{s:1:"a";i:1;}`, map[string]interface{}{"a": int64(1)}},
+	}
+
+	for _, tt := range tests {
+		got, err := Unserialize(tt.in)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnserializeInvalid(t *testing.T) {
+
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"empty", ""},
+		{"unknown type", "x:1;"},
+		{"trailing data", "i:1;i:2;"},
+		{"missing terminator", "i:1"},
+		{"negative string length", `s:-1:"";`},
+		{"string length past the end", `s:99:"abc";`},
+		{"huge string length", `s:99999999999999:"abc";`},
+		{"negative array count", "a:-1:{}"},
+		{"huge array count", "a:99999999999:{}"},
+		{"huge array count with elements", "a:9223372036854775807:{i:0;i:0;}"},
+		{"huge object count", `O:8:"stdClass":99999999999:{}`},
+		{"array count past the elements", "a:2:{i:0;i:0;}"},
+		{"array count before the elements", "a:1:{i:0;i:0;i:1;i:1;}"},
+		{"array key of another type", "a:1:{d:0.5;i:0;}"},
+		{"unterminated array", "a:1:{i:0;i:0;"},
+		{"count out of int64 range", "a:99999999999999999999:{}"},
+	}
+
+	for _, tt := range tests {
+		if got, err := Unserialize(tt.in); err == nil {
+			t.Errorf("%s: got %#v, want an error", tt.name, got)
+		}
+	}
+}
+
+func TestIsSerialized(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"N;", true},
+		{"b:1;", true},
+		{"i:5;", true},
+		{"d:0.5;", true},
+		{`s:1:"a";`, true},
+		{"a:0:{}", true},
+		{`O:8:"stdClass":0:{}`, true},
+		{"", false},
+		{"hello", false},
+		{"i:5", false},
+		{`s:1:"a"`, false},
+		{"a:0:{", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsSerialized(tt.in); got != tt.want {
+			t.Errorf("IsSerialized(%q) = %t, want %t", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSerializeRoundTrip(t *testing.T) {
+
+	tests := []interface{}{
+		nil,
+		true,
+		int64(7),
+		0.25,
+		"héllo",
+		[]interface{}{"a", int64(1)},
+		map[string]interface{}{"administrator": true, "b": "c"},
+	}
+
+	for _, value := range tests {
+		data, err := Serialize(value)
+		if err != nil {
+			t.Errorf("Serialize(%#v): %v", value, err)
+			continue
+		}
+
+		got, err := Unserialize(data)
+		if err != nil || !reflect.DeepEqual(got, value) {
+			t.Errorf("Unserialize(%q) = %#v, %v, want %#v", data, got, err, value)
+		}
+	}
+}
+
+func TestMaybeSerialize(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Jane", "Jane"},
+		{"", ""},
+		{"a:b", "a:b"},
+		{"N;", `s:2:"N;";`},
+		{"i:1;", `s:4:"i:1;";`},
+		{`s:1:"a";`, `s:8:"s:1:"a";";`},
+		{`a:1:{i:0;N;}`, `s:12:"a:1:{i:0;N;}";`},
+		{`O:8:"stdClass":0:{}`, `s:19:"O:8:"stdClass":0:{}";`},
+		{` a:0:{} `, `s:8:" a:0:{} ";`},
+		{`a:1:{broken`, `a:1:{broken`},
+		{`O:8:"Evil":1:{s:1:"x";i:1;} trailing`, `s:36:"O:8:"Evil":1:{s:1:"x";i:1;} trailing";`},
+	}
+
+	for _, tt := range tests {
+		got := MaybeSerialize(tt.in)
+		if got != tt.want {
+			t.Errorf("MaybeSerialize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if back := MaybeUnserialize(got); back != tt.in {
+			t.Errorf("MaybeUnserialize(%q) = %q, want %q", got, back, tt.in)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// APIKeyHeader carries the API key of a client.
+const APIKeyHeader = "X-API-Key"
+
+// Operation kinds with their own budgets. Subscriptions are charged as
+// queries when they start.
+const (
+	OperationQuery    = "query"
+	OperationMutation = "mutation"
+)
+
+// APIKey is a known client with budgets of its own. Zero limits fall back
+// to the limiter's.
+type APIKey struct {
+	Name     string
+	Query    Limit
+	Mutation Limit
+}
+
+// Limiter charges operations to the budget of their client, known by API
+// key, else by the logged-in user, else by IP address, or by /64 network
+// for IPv6 as one host usually holds a whole /64. Queries and
+// mutations have separate budgets, charged one token per operation or,
+// with ChargeCost, the operation's cost.
+type Limiter struct {
+	Store      Store
+	Query      Limit
+	Mutation   Limit
+	ChargeCost bool
+
+	// APIKeys are the known keys. Unknown keys are ignored, lest a client
+	// get a fresh budget with every key it makes up.
+	APIKeys map[string]*APIKey
+
+	// TrustedProxies are the networks whose X-Forwarded-For is believed.
+	TrustedProxies []*net.IPNet
+}
+
+func NewLimiter(store Store, query Limit, mutation Limit) *Limiter {
+
+	return &Limiter{
+		Store:    store,
+		Query:    query,
+		Mutation: mutation,
+		APIKeys:  make(map[string]*APIKey),
+	}
+}
+
+// Allow charges the operation, returning its limit and the bucket's state.
+// Operations without a limit are always allowed, with a zero limit.
+func (l *Limiter) Allow(r *http.Request, userID int64, operation string, cost int) (Limit, Result, error) {
+
+	var client string
+
+	limit := l.Query
+	if operation == OperationMutation {
+		limit = l.Mutation
+	}
+
+	if key := r.Header.Get(APIKeyHeader); len(key) > 0 && l.APIKeys[key] != nil {
+
+		apiKey := l.APIKeys[key]
+		client = "key:" + apiKey.Name
+
+		keyLimit := apiKey.Query
+		if operation == OperationMutation {
+			keyLimit = apiKey.Mutation
+		}
+		if keyLimit.Requests > 0 && keyLimit.Window > 0 {
+			limit = keyLimit
+		}
+
+	} else if userID > 0 {
+		client = "user:" + strconv.FormatInt(userID, 10)
+	} else {
+		client = "ip:" + clientNetwork(l.ClientIP(r))
+	}
+
+	if limit.Requests <= 0 || limit.Window <= 0 {
+		return Limit{}, Result{Allowed: true}, nil
+	}
+
+	n := 1
+	if l.ChargeCost && cost > 1 {
+		n = cost
+	}
+
+	if operation != OperationMutation {
+		operation = OperationQuery
+	}
+
+	res, err := l.Store.Take(client+":"+operation, limit, n)
+
+	return limit, res, err
+}
+
+// ClientIP is the address of the client. Behind trusted proxies it is the
+// last address of X-Forwarded-For that no trusted proxy added.
+func (l *Limiter) ClientIP(r *http.Request) net.IP {
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !l.trusted(ip) {
+		return ip
+	}
+
+	var forwarded []string
+	for _, header := range r.Header["X-Forwarded-For"] {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			break
+		}
+
+		ip = hop
+		if !l.trusted(hop) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// ipv6ClientBits is the prefix of the IPv6 addresses of one client.
+const ipv6ClientBits = 64
+
+// clientNetwork is the IPv4 address, or the /64 network of the IPv6
+// address, a client is known by.
+func clientNetwork(ip net.IP) string {
+
+	if ip == nil || ip.To4() != nil {
+		return ip.String()
+	}
+
+	mask := net.CIDRMask(ipv6ClientBits, 8*net.IPv6len)
+	network := net.IPNet{IP: ip.Mask(mask), Mask: mask}
+
+	return network.String()
+}
+
+func (l *Limiter) trusted(ip net.IP) bool {
+
+	for _, network := range l.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetHeaders writes the RateLimit header fields of the IETF draft, and
+// Retry-After when the request was refused.
+func SetHeaders(header http.Header, limit Limit, res Result) {
+
+	header.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset.Seconds())))
+	header.Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+strconv.Itoa(ceilSeconds(limit.Window.Seconds())))
+
+	if !res.Allowed {
+		header.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter.Seconds())))
+	}
+}
+
+func ceilSeconds(s float64) int {
+
+	return int(math.Ceil(s))
+}
@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientIP(t *testing.T) {
+
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+
+	tests := []struct {
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"203.0.113.7:1234", nil, "203.0.113.7"},
+		{"203.0.113.7:1234", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"10.0.0.1:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"10.0.0.1:1234", []string{"198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"10.0.0.1:1234", []string{"198.51.100.1", "10.0.0.2"}, "198.51.100.1"},
+		{"10.0.0.1:1234", []string{"192.0.2.9, 198.51.100.1"}, "198.51.100.1"},
+		{"10.0.0.1:1234", []string{"not an address, 10.0.0.2"}, "10.0.0.2"},
+		{"10.0.0.1:1234", nil, "10.0.0.1"},
+		{"[2001:db8::1]:1234", nil, "2001:db8::1"},
+		{"garbage", nil, "<nil>"},
+	}
+
+	l := &Limiter{TrustedProxies: []*net.IPNet{proxies}}
+
+	for _, tt := range tests {
+
+		r := httptest.NewRequest("GET", "/graphql", nil)
+		r.RemoteAddr = tt.remoteAddr
+		for _, header := range tt.forwarded {
+			r.Header.Add("X-Forwarded-For", header)
+		}
+
+		if got := l.ClientIP(r).String(); got != tt.want {
+			t.Errorf("%s %v: got %s, want %s", tt.remoteAddr, tt.forwarded, got, tt.want)
+		}
+	}
+}
+
+func TestClientNetwork(t *testing.T) {
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"203.0.113.7", "203.0.113.7"},
+		{"::ffff:203.0.113.7", "203.0.113.7"},
+		{"2001:db8:1:2:3:4:5:6", "2001:db8:1:2::/64"},
+		{"2001:db8:1:2:ffff:ffff:ffff:ffff", "2001:db8:1:2::/64"},
+		{"2001:db8:1:3::1", "2001:db8:1:3::/64"},
+		{"::1", "::/64"},
+	}
+
+	for _, tt := range tests {
+		if got := clientNetwork(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestAllow(t *testing.T) {
+
+	limit := Limit{Requests: 2, Window: time.Hour}
+
+	tests := []struct {
+		name   string
+		first  string
+		second string
+		userID int64
+		shared bool
+	}{
+		{"same IPv4", "203.0.113.7:1", "203.0.113.7:2", 0, true},
+		{"other IPv4", "203.0.113.7:1", "203.0.113.8:1", 0, false},
+		{"same IPv6 /64", "[2001:db8:1:2::1]:1", "[2001:db8:1:2:aaaa::9]:1", 0, true},
+		{"other IPv6 /64", "[2001:db8:1:2::1]:1", "[2001:db8:1:3::1]:1", 0, false},
+		{"logged in", "203.0.113.7:1", "203.0.113.8:1", 5, true},
+	}
+
+	for _, tt := range tests {
+
+		l := NewLimiter(NewMemoryStore(), limit, limit)
+
+		for _, addr := range []string{tt.first, tt.first} {
+			r := httptest.NewRequest("GET", "/graphql", nil)
+			r.RemoteAddr = addr
+			if _, res, err := l.Allow(r, tt.userID, OperationQuery, 1); err != nil || !res.Allowed {
+				t.Fatalf("%s: refused %s: %v", tt.name, addr, err)
+			}
+		}
+
+		r := httptest.NewRequest("GET", "/graphql", nil)
+		r.RemoteAddr = tt.second
+		_, res, err := l.Allow(r, tt.userID, OperationQuery, 1)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if res.Allowed == tt.shared {
+			t.Errorf("%s: allowed %t, want the budget shared %t", tt.name, res.Allowed, tt.shared)
+		}
+	}
+}
+
+func TestTake(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		limit         Limit
+		takes         []int
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{"within", Limit{Requests: 3, Window: time.Hour}, []int{1, 1}, true, 1},
+		{"exhausted", Limit{Requests: 3, Window: time.Hour}, []int{1, 1, 1, 1}, false, 0},
+		{"costly", Limit{Requests: 10, Window: time.Hour}, []int{7, 4}, false, 3},
+		{"more than the bucket", Limit{Requests: 3, Window: time.Hour}, []int{100}, true, 0},
+		{"more than the bucket twice", Limit{Requests: 3, Window: time.Hour}, []int{100, 100}, false, 0},
+	}
+
+	for _, tt := range tests {
+
+		s := NewMemoryStore()
+
+		var res Result
+		for _, n := range tt.takes {
+			var err error
+			if res, err = s.Take("key", tt.limit, n); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if res.Allowed != tt.wantAllowed || res.Remaining != tt.wantRemaining {
+			t.Errorf("%s: allowed %t, %d remaining, want %t, %d", tt.name, res.Allowed, res.Remaining, tt.wantAllowed, tt.wantRemaining)
+		}
+		if !res.Allowed && res.RetryAfter <= 0 {
+			t.Errorf("%s: refused without a retry delay", tt.name)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package ratelimit holds clients to budgets of requests with token buckets.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limit allows Requests per Window, all at once at most.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Result is the state of a bucket after taking from it.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+
+	// RetryAfter is how long until the request would be allowed, when it
+	// was not.
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets by key. MemoryStore keeps them in the process;
+// a shared store, on Redis say, would let several servers enforce one
+// budget.
+type Store interface {
+	Take(key string, limit Limit, n int) (Result, error)
+}
+
+// sweepInterval is how often MemoryStore forgets the buckets that filled up.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// MemoryStore is the in-process Store.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+
+	return &MemoryStore{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// Take removes n tokens from the bucket of the key when it holds that many.
+// Requests costing more than the whole bucket are charged the whole bucket.
+func (s *MemoryStore) Take(key string, limit Limit, n int) (Result, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(s.lastSweep) > sweepInterval {
+		s.sweep(now)
+	}
+
+	capacity := float64(limit.Requests)
+	rate := capacity / limit.Window.Seconds()
+
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: capacity, last: now, limit: limit}
+		s.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > capacity {
+		b.tokens = capacity
+	}
+	b.last = now
+
+	want := float64(n)
+	if want > capacity {
+		want = capacity
+	}
+
+	res := Result{Limit: limit.Requests}
+
+	if b.tokens >= want {
+		b.tokens -= want
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((want - b.tokens) / rate)
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((capacity - b.tokens) / rate)
+
+	return res, nil
+}
+
+// sweep forgets the buckets that are full by now, which is where they would
+// start anyway.
+func (s *MemoryStore) sweep(now time.Time) {
+
+	for key, b := range s.buckets {
+		if now.Sub(b.last) >= b.limit.Window {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.lastSweep = now
+}
+
+func seconds(s float64) time.Duration {
+
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,245 @@
+package render
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/****
+*********************
+WPAUTOP
+*********************
+****/
+
+const allBlocks = `(?:table|thead|tfoot|caption|col|colgroup|tbody|tr|td|th|div|dl|dd|dt|ul|ol|li|pre|form|map|area|blockquote|address|math|style|p|h[1-6]|hr|fieldset|legend|section|article|aside|hgroup|header|footer|nav|figure|figcaption|details|menu|summary)`
+
+var (
+	autopDoubleBr        = regexp.MustCompile(`<br\s*/?>\s*<br\s*/?>`)
+	autopBlockOpen       = regexp.MustCompile(`(<` + allBlocks + `[\s/>])`)
+	autopBlockClose      = regexp.MustCompile(`(</` + allBlocks + `>)`)
+	autopHr              = regexp.MustCompile(`(<hr\s*?/?>)`)
+	autopOptionOpen      = regexp.MustCompile(`\s*<option`)
+	autopOptionClose     = regexp.MustCompile(`</option>\s*`)
+	autopObjectOpen      = regexp.MustCompile(`(<object[^>]*>)\s*`)
+	autopObjectClose     = regexp.MustCompile(`\s*</object>`)
+	autopParamEmbed      = regexp.MustCompile(`\s*(</?(?:param|embed)[^>]*>)\s*`)
+	autopMediaOpen       = regexp.MustCompile(`([<\[](?:audio|video)[^>\]]*[>\]])\s*`)
+	autopMediaClose      = regexp.MustCompile(`\s*([<\[]/(?:audio|video)[>\]])`)
+	autopSourceTrack     = regexp.MustCompile(`\s*(<(?:source|track)[^>]*>)\s*`)
+	autopFigcaptionOpen  = regexp.MustCompile(`\s*(<figcaption[^>]*>)`)
+	autopFigcaptionClose = regexp.MustCompile(`</figcaption>\s*`)
+	autopManyNewlines    = regexp.MustCompile(`\n\n+`)
+	autopParagraphSplit  = regexp.MustCompile(`\n\s*\n`)
+	autopEmptyP          = regexp.MustCompile(`<p>\s*</p>`)
+	autopPInBlock        = regexp.MustCompile(`<p>([^<]+)</(div|address|form)>`)
+	autopPAroundBlock    = regexp.MustCompile(`<p>\s*(</?` + allBlocks + `[^>]*>)\s*</p>`)
+	autopPAroundLi       = regexp.MustCompile(`<p>(<li.+?)</p>`)
+	autopPBlockquote     = regexp.MustCompile(`(?i)<p><blockquote([^>]*)>`)
+	autopPBeforeBlock    = regexp.MustCompile(`<p>\s*(</?` + allBlocks + `[^>]*>)`)
+	autopPAfterBlock     = regexp.MustCompile(`(</?` + allBlocks + `[^>]*>)\s*</p>`)
+	autopBrAfterBlock    = regexp.MustCompile(`(</?` + allBlocks + `[^>]*>)\s*<br />`)
+	autopBrBeforeBlock   = regexp.MustCompile(`<br />(\s*</?(?:p|li|div|dl|dd|dt|th|pre|td|ul|ol)[^>]*>)`)
+	autopTrailingP       = regexp.MustCompile(`\n</p>$`)
+
+	// Go regexps have no back references, so each raw text element gets its own.
+	autopPreserveNewlines = []*regexp.Regexp{
+		regexp.MustCompile(`(?s)<script.*?</script>`),
+		regexp.MustCompile(`(?s)<style.*?</style>`),
+		regexp.MustCompile(`(?s)<svg.*?</svg>`),
+	}
+)
+
+// Autop is a port of wpautop(): it replaces double line breaks with paragraph
+// elements and, when br is set, the remaining single line breaks with <br />.
+func Autop(pee string, br bool) string {
+
+	if strings.TrimSpace(pee) == "" {
+		return ""
+	}
+
+	// Just to make things a little easier, pad the end.
+	pee = pee + "\n"
+
+	// Pre tags are left alone and restored at the end.
+	preTags := make(map[string]string)
+	var preNames []string
+
+	if strings.Contains(pee, "<pre") {
+
+		parts := strings.Split(pee, "</pre>")
+		last := parts[len(parts)-1]
+		pee = ""
+
+		i := 0
+		for _, part := range parts[:len(parts)-1] {
+
+			start := strings.Index(part, "<pre")
+			if start < 0 {
+				pee += part
+				continue
+			}
+
+			name := "<pre wp-pre-tag-" + strconv.Itoa(i) + "></pre>"
+			preTags[name] = part[start:] + "</pre>"
+			preNames = append(preNames, name)
+
+			pee += part[:start] + name
+			i++
+		}
+
+		pee += last
+	}
+
+	pee = autopDoubleBr.ReplaceAllString(pee, "\n\n")
+
+	// Space things out a little around block elements.
+	pee = autopBlockOpen.ReplaceAllString(pee, "\n\n$1")
+	pee = autopBlockClose.ReplaceAllString(pee, "$1\n\n")
+	pee = autopHr.ReplaceAllString(pee, "$1\n\n")
+
+	pee = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(pee)
+
+	// Newlines inside tags must not become paragraphs.
+	pee = replaceInHTMLTags(pee, "\n", " <!-- wpnl --> ")
+
+	if strings.Contains(pee, "<option") {
+		pee = autopOptionOpen.ReplaceAllString(pee, "<option")
+		pee = autopOptionClose.ReplaceAllString(pee, "</option>")
+	}
+
+	if strings.Contains(pee, "</object>") {
+		pee = autopObjectOpen.ReplaceAllString(pee, "$1")
+		pee = autopObjectClose.ReplaceAllString(pee, "</object>")
+		pee = autopParamEmbed.ReplaceAllString(pee, "$1")
+	}
+
+	if strings.Contains(pee, "<source") || strings.Contains(pee, "<track") {
+		pee = autopMediaOpen.ReplaceAllString(pee, "$1")
+		pee = autopMediaClose.ReplaceAllString(pee, "$1")
+		pee = autopSourceTrack.ReplaceAllString(pee, "$1")
+	}
+
+	if strings.Contains(pee, "<figcaption") {
+		pee = autopFigcaptionOpen.ReplaceAllString(pee, "$1")
+		pee = autopFigcaptionClose.ReplaceAllString(pee, "</figcaption>")
+	}
+
+	pee = autopManyNewlines.ReplaceAllString(pee, "\n\n")
+
+	var b strings.Builder
+	for _, tinkle := range autopParagraphSplit.Split(pee, -1) {
+		if len(tinkle) == 0 {
+			continue
+		}
+		b.WriteString("<p>" + strings.Trim(tinkle, "\n") + "</p>\n")
+	}
+	pee = b.String()
+
+	pee = autopEmptyP.ReplaceAllString(pee, "")
+	pee = autopPInBlock.ReplaceAllString(pee, "<p>$1</p></$2>")
+	pee = autopPAroundBlock.ReplaceAllString(pee, "$1")
+	pee = autopPAroundLi.ReplaceAllString(pee, "$1")
+	pee = autopPBlockquote.ReplaceAllString(pee, "<blockquote$1><p>")
+	pee = strings.Replace(pee, "</blockquote></p>", "</p></blockquote>", -1)
+	pee = autopPBeforeBlock.ReplaceAllString(pee, "$1")
+	pee = autopPAfterBlock.ReplaceAllString(pee, "$1")
+
+	if br {
+
+		for _, re := range autopPreserveNewlines {
+			pee = re.ReplaceAllStringFunc(pee, func(m string) string {
+				return strings.Replace(m, "\n", "<WPPreserveNewline />", -1)
+			})
+		}
+
+		pee = strings.NewReplacer("<br>", "<br />", "<br/>", "<br />").Replace(pee)
+		pee = newlinesToBr(pee)
+		pee = strings.Replace(pee, "<WPPreserveNewline />", "\n", -1)
+	}
+
+	pee = autopBrAfterBlock.ReplaceAllString(pee, "$1")
+	pee = autopBrBeforeBlock.ReplaceAllString(pee, "$1")
+	pee = autopTrailingP.ReplaceAllString(pee, "</p>")
+
+	for _, name := range preNames {
+		pee = strings.Replace(pee, name, preTags[name], -1)
+	}
+
+	if strings.Contains(pee, "<!-- wpnl -->") {
+		pee = strings.NewReplacer(" <!-- wpnl --> ", "\n", "<!-- wpnl -->", "\n").Replace(pee)
+	}
+
+	return pee
+}
+
+// newlinesToBr does preg_replace('|(?<!<br />)\s*\n|', "<br />\n"), which
+// needs a look-behind that Go regexps do not have.
+func newlinesToBr(pee string) string {
+
+	var b strings.Builder
+
+	i := 0
+	for i < len(pee) {
+
+		if !isSpace(pee[i]) || strings.HasSuffix(pee[:i], "<br />") {
+			b.WriteByte(pee[i])
+			i++
+			continue
+		}
+
+		// \s* is greedy, so the match ends at the last newline of the run.
+		end := i
+		for end < len(pee) && isSpace(pee[end]) {
+			end++
+		}
+
+		nl := strings.LastIndexByte(pee[i:end], '\n')
+		if nl < 0 {
+			b.WriteString(pee[i:end])
+			i = end
+			continue
+		}
+
+		b.WriteString("<br />\n")
+		i = i + nl + 1
+	}
+
+	return b.String()
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+// htmlSplitRegexp separates comments, CDATA and tags from text, a simplified
+// version of the one in get_html_split_regex().
+var htmlSplitRegexp = regexp.MustCompile(`(?s)<!--.*?(?:-->|$)|<!\[CDATA\[.*?(?:\]\]>|$)|<[^>]*>?`)
+
+// splitHTML returns the document as alternating text and tag chunks, starting
+// with text.
+func splitHTML(text string) []string {
+
+	var chunks []string
+
+	last := 0
+	for _, m := range htmlSplitRegexp.FindAllStringIndex(text, -1) {
+		chunks = append(chunks, text[last:m[0]], text[m[0]:m[1]])
+		last = m[1]
+	}
+
+	return append(chunks, text[last:])
+}
+
+// replaceInHTMLTags replaces needle only inside tags and comments, like
+// wp_replace_in_html_tags().
+func replaceInHTMLTags(text string, needle string, replacement string) string {
+
+	chunks := splitHTML(text)
+
+	for i := 1; i < len(chunks); i += 2 {
+		chunks[i] = strings.Replace(chunks[i], needle, replacement, -1)
+	}
+
+	return strings.Join(chunks, "")
+}
@@ -0,0 +1,81 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+// Cases from WordPress core's tests/phpunit/tests/formatting/wpAutop.php.
+func TestAutop(t *testing.T) {
+
+	var blocks, inlineIn, inlineOut []string
+
+	for _, block := range []string{"table", "thead", "tfoot", "caption", "col", "colgroup", "tbody", "tr", "td", "th", "div", "dl", "dd", "dt", "ul", "ol", "li", "pre", "form", "map", "area", "address", "math", "p", "h1", "h2", "h3", "h4", "h5", "h6", "fieldset", "legend", "section", "article", "aside", "hgroup", "header", "footer", "nav", "figure", "details", "menu", "summary"} {
+		blocks = append(blocks, "<"+block+">foo</"+block+">")
+	}
+
+	for _, inline := range []string{"a", "em", "strong", "small", "s", "cite", "q", "dfn", "abbr", "data", "time", "code", "var", "samp", "kbd", "sub", "sup", "i", "b", "u", "mark", "span", "del", "ins", "noscript", "select"} {
+		inlineIn = append(inlineIn, "<"+inline+">foo</"+inline+">")
+		inlineOut = append(inlineOut, "<p><"+inline+">foo</"+inline+"></p>")
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"blocks separated by blank lines", strings.Join(blocks, "\n\n"), strings.Join(blocks, "\n")},
+		{"blocks without separators", strings.Join(blocks, ""), strings.Join(blocks, "\n")},
+		{"inline elements", strings.Join(inlineIn, "\n\n"), strings.Join(inlineOut, "\n")},
+
+		{"input elements", `Username: <input type="text" id="username" name="username" /><br />Password: <input type="password" id="password1" name="password1" />`,
+			`<p>Username: <input type="text" id="username" name="username" /><br />Password: <input type="password" id="password1" name="password1" /></p>`},
+
+		{"line breaks after br", "\nline 1<br>\nline 2<br/>\nline 3<br />\nline 4\nline 5\n",
+			"<p>line 1<br />\nline 2<br />\nline 3<br />\nline 4<br />\nline 5</p>"},
+		{"paragraph after multiple br", "\nline 1<br>\n<br/>\nline 2<br/>\n<br/>\n",
+			"<p>line 1</p>\n<p>line 2</p>"},
+
+		{"text before blocks", "a<div>b</div>", "<p>a</p>\n<div>b</div>"},
+		{"blockquote contents", "<blockquote>foo</blockquote>", "<blockquote><p>foo</p></blockquote>"},
+		{"hr", "paragraph1<hr>paragraph2", "<p>paragraph1</p>\n<hr>\n<p>paragraph2</p>"},
+
+		{"inline script", "line 1<br>\n<script>\nvar a = \"b\";\n</script>\nline 2",
+			"<p>line 1<br />\n<script>\nvar a = \"b\";\n</script><br />\nline 2</p>"},
+
+		{"figure on one line", `<figure><img src="example.jpg" /><figcaption>Caption</figcaption></figure>`,
+			`<figure><img src="example.jpg" /><figcaption>Caption</figcaption></figure>`},
+		{"figure on several lines", "<figure>\n<img src=\"example.jpg\" />\n<figcaption>Caption</figcaption>\n</figure>",
+			"<figure>\n<img src=\"example.jpg\" /><figcaption>Caption</figcaption></figure>"},
+
+		{"pre", "<pre>line 1\n\nline 2</pre>", "<pre>line 1\n\nline 2</pre>"},
+		{"empty", " \n ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := strings.TrimSpace(Autop(tt.in, true)); got != tt.want {
+			t.Errorf("%s: Autop(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+// Cases from data_element_sanity in wpAutop.php, compared untrimmed.
+func TestAutopElementSanity(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Hello <a\nhref='world'>", "<p>Hello <a\nhref='world'></p>\n"},
+		{"Hello <!-- a\nhref='world' -->", "<p>Hello <!-- a\nhref='world' --></p>\n"},
+		{"Hello <!-- <object>\n<param>\n<param>\n<embed>\n</embed>\n</object>\n -->", "<p>Hello <!-- <object>\n<param>\n<param>\n<embed>\n</embed>\n</object>\n --></p>\n"},
+		{"Hello <!-- <object>\n<param/>\n<param/>\n<embed>\n</embed>\n</object>\n -->", "<p>Hello <!-- <object>\n<param/>\n<param/>\n<embed>\n</embed>\n</object>\n --></p>\n"},
+		{"Hello <![CDATA[ a\nhref='world' ]]>", "<p>Hello <![CDATA[ a\nhref='world' ]]></p>\n"},
+	}
+
+	for _, tt := range tests {
+		if got := Autop(tt.in, true); got != tt.want {
+			t.Errorf("Autop(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/****
+*********************
+BUILT-IN SHORTCODES
+*********************
+****/
+
+var captionImageRegexp = regexp.MustCompile(`(?is)^((?:<a [^>]+>\s*)?<img [^>]+>(?:\s*</a>)?)(.*)$`)
+
+var htmlClassRegexp = regexp.MustCompile(`%[a-fA-F0-9][a-fA-F0-9]|[^A-Za-z0-9_-]`)
+
+// captionShortcode is a port of img_caption_shortcode() with HTML5 output.
+func captionShortcode(ctx *Context, sc *Shortcode) string {
+
+	content := sc.Content
+	caption := sc.Attr("caption", "")
+
+	// New-style captions keep the text after the image in the content.
+	if len(caption) == 0 {
+		if m := captionImageRegexp.FindStringSubmatch(content); m != nil {
+			content = m[1]
+			caption = strings.TrimSpace(m[2])
+		}
+	}
+
+	width, _ := strconv.Atoi(sc.Attr("width", ""))
+	if width < 1 || len(caption) == 0 {
+		return content
+	}
+
+	id := ""
+	captionID := ""
+	if v := htmlClassRegexp.ReplaceAllString(sc.Attr("id", ""), ""); len(v) > 0 {
+		id = `id="` + html.EscapeString(v) + `" `
+		captionID = "caption-" + v
+	}
+
+	describedBy := ""
+	captionIDAttr := ""
+	if len(captionID) > 0 {
+		describedBy = `aria-describedby="` + html.EscapeString(captionID) + `" `
+		captionIDAttr = `id="` + html.EscapeString(captionID) + `" `
+	}
+
+	class := strings.TrimSpace("wp-caption " + sc.Attr("align", "alignnone") + " " + sc.Attr("class", ""))
+
+	return fmt.Sprintf(`<figure %s%sstyle="width: %dpx" class="%s">%s<figcaption %sclass="wp-caption-text">%s</figcaption></figure>`,
+		id, describedBy, width, html.EscapeString(class), ctx.DoShortcodes(content), captionIDAttr, caption)
+}
+
+// galleryShortcode renders [gallery] like gallery_shortcode() with HTML5
+// markup, linking each image to its file.
+func galleryShortcode(ctx *Context, sc *Shortcode) string {
+
+	if ctx.r.Attachments == nil {
+		return ""
+	}
+
+	var ids []int64
+	for _, id := range strings.Split(sc.Attr("ids", sc.Attr("include", "")), ",") {
+		if n, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64); err == nil && n > 0 {
+			ids = append(ids, n)
+		}
+	}
+
+	parentID := ctx.PostID
+	if id, err := strconv.ParseInt(sc.Attr("id", ""), 10, 64); err == nil && id > 0 {
+		parentID = id
+	}
+
+	attachments, err := ctx.r.Attachments(ids, parentID)
+	if err != nil {
+		log.Printf("gallery shortcode: %s", err)
+		return ""
+	}
+
+	if len(attachments) == 0 {
+		return ""
+	}
+
+	columns, err := strconv.Atoi(sc.Attr("columns", "3"))
+	if err != nil || columns < 0 {
+		columns = 3
+	}
+
+	size := htmlClassRegexp.ReplaceAllString(sc.Attr("size", "thumbnail"), "")
+
+	ctx.galleryCount++
+	selector := "gallery-" + strconv.Itoa(ctx.galleryCount)
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "<div id='%s' class='gallery galleryid-%d gallery-columns-%d gallery-size-%s'>", selector, parentID, columns, size)
+
+	for _, a := range attachments {
+
+		b.WriteString("<figure class='gallery-item'>")
+		fmt.Fprintf(&b, "\n\t\t\t<div class='gallery-icon'>\n\t\t\t\t<a href='%s'><img src=\"%s\" alt=\"%s\" /></a>\n\t\t\t</div>",
+			html.EscapeString(a.URL), html.EscapeString(a.URL), html.EscapeString(a.Alt))
+
+		if len(strings.TrimSpace(a.Caption)) > 0 {
+			fmt.Fprintf(&b, "\n\t\t\t\t<figcaption class='wp-caption-text gallery-caption' id='%s-%d'>\n\t\t\t\t%s\n\t\t\t\t</figcaption>",
+				selector, a.ID, strings.TrimSpace(a.Caption))
+		}
+
+		b.WriteString("</figure>")
+	}
+
+	b.WriteString("\n\t\t</div>\n")
+
+	return b.String()
+}
+
+var (
+	youtubeRegexp = regexp.MustCompile(`^https?://(?:www\.|m\.)?(?:youtube\.com/watch\?(?:.*&)?v=|youtu\.be/)([\w-]+)`)
+	vimeoRegexp   = regexp.MustCompile(`^https?://(?:www\.)?vimeo\.com/(\d+)`)
+)
+
+// embedShortcode renders [embed]URL[/embed]. Without oEmbed discovery only
+// YouTube and Vimeo become players, other URLs fall back to a link the way
+// WP_Embed::maybe_make_link() does.
+func embedShortcode(ctx *Context, sc *Shortcode) string {
+
+	rawURL := strings.TrimSpace(sc.Content)
+	if len(rawURL) == 0 {
+		rawURL = sc.Attr("src", "")
+	}
+
+	if _, err := url.ParseRequestURI(rawURL); err != nil {
+		return ""
+	}
+
+	width := sc.Attr("width", "640")
+	height := sc.Attr("height", "360")
+
+	var src string
+	if m := youtubeRegexp.FindStringSubmatch(rawURL); m != nil {
+		src = "https://www.youtube.com/embed/" + m[1] + "?feature=oembed"
+	} else if m := vimeoRegexp.FindStringSubmatch(rawURL); m != nil {
+		src = "https://player.vimeo.com/video/" + m[1]
+	}
+
+	if len(src) == 0 {
+		escaped := html.EscapeString(rawURL)
+		return `<a href="` + escaped + `">` + escaped + `</a>`
+	}
+
+	return fmt.Sprintf(`<iframe width="%s" height="%s" src="%s" frameborder="0" allowfullscreen></iframe>`,
+		html.EscapeString(width), html.EscapeString(height), html.EscapeString(src))
+}
@@ -0,0 +1,195 @@
+package render
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/iyut/graphql-go/blocks"
+	"github.com/iyut/graphql-go/model"
+)
+
+// Formats content and excerpts can be returned in.
+const (
+	FormatRendered = "RENDERED"
+	FormatRaw      = "RAW"
+)
+
+// Defaults of WordPress' excerpt_length and excerpt_more filters.
+const (
+	DefaultExcerptLength = 55
+	DefaultExcerptMore   = " [&hellip;]"
+)
+
+// Attachment is an image shown by the gallery shortcode.
+type Attachment struct {
+	ID      int64
+	URL     string
+	Alt     string
+	Caption string
+}
+
+// Renderer turns post_content into the HTML WordPress shows visitors, running
+// the default the_content filters: blocks, wptexturize, wpautop,
+// shortcode_unautop and do_shortcode.
+type Renderer struct {
+	ExcerptLength     int
+	ExcerptMore       string
+	UnknownShortcodes string
+
+	// Attachments loads the images for [gallery], either the given IDs or the
+	// images attached to the parent post when ids is empty.
+	Attachments func(ids []int64, parentID int64) ([]Attachment, error)
+
+	// ReusableBlock loads the content of a wp_block post, see
+	// blocks.ExpandReusable.
+	ReusableBlock func(ref int64) (string, bool, error)
+
+	shortcodes map[string]ShortcodeFunc
+}
+
+// Context is the state of rendering one post.
+type Context struct {
+	PostID int64
+
+	r            *Renderer
+	galleryCount int
+}
+
+func NewRenderer() *Renderer {
+
+	r := &Renderer{
+		ExcerptLength:     DefaultExcerptLength,
+		ExcerptMore:       DefaultExcerptMore,
+		UnknownShortcodes: UnknownPassthrough,
+		shortcodes:        make(map[string]ShortcodeFunc),
+	}
+
+	r.AddShortcode("caption", captionShortcode)
+	r.AddShortcode("wp_caption", captionShortcode)
+	r.AddShortcode("gallery", galleryShortcode)
+	r.AddShortcode("embed", embedShortcode)
+
+	return r
+}
+
+// AddShortcode registers the handler of a shortcode, replacing any previous one.
+func (r *Renderer) AddShortcode(tag string, handler ShortcodeFunc) {
+	r.shortcodes[tag] = handler
+}
+
+func (r *Renderer) newContext(post *model.Post) *Context {
+
+	postID, _ := strconv.ParseInt(string(post.PostID), 10, 64)
+
+	return &Context{PostID: postID, r: r}
+}
+
+var (
+	moreRegexp     = regexp.MustCompile(`<!--more(.*?)?-->`)
+	nextpageRegexp = regexp.MustCompile(`\n?<!--nextpage-->\n?`)
+)
+
+// Content returns the post content as seen on the post's own page, or the
+// stored post_content for FormatRaw.
+func (r *Renderer) Content(post *model.Post, format string) string {
+
+	if format == FormatRaw {
+		return post.PostContent
+	}
+
+	ctx := r.newContext(post)
+
+	text := nextpageRegexp.ReplaceAllString(post.PostContent, "\n")
+	text = moreRegexp.ReplaceAllStringFunc(text, func(string) string {
+		return `<span id="more-` + strconv.FormatInt(ctx.PostID, 10) + `"></span>`
+	})
+
+	return ctx.theContent(text)
+}
+
+// theContent applies the default the_content filters in WordPress' order.
+// wpautop is skipped for block content, as do_blocks() arranges.
+func (ctx *Context) theContent(text string) string {
+
+	hasBlocks := strings.Contains(text, "<!-- wp:")
+	if hasBlocks {
+
+		parsed := blocks.Parse(text)
+
+		if ctx.r.ReusableBlock != nil {
+			if err := blocks.ExpandReusable(parsed, ctx.r.ReusableBlock); err != nil {
+				log.Printf("reusable blocks: %s", err)
+			}
+		}
+
+		text = blocks.Render(parsed)
+	}
+
+	text = Texturize(text)
+
+	if !hasBlocks {
+		text = Autop(text, true)
+	}
+
+	text = ctx.ShortcodeUnautop(text)
+	text = ctx.DoShortcodes(text)
+
+	return text
+}
+
+// Excerpt returns the hand-written excerpt or, when there is none, one made
+// from the teaser part of the content like wp_trim_excerpt(). FormatRaw
+// returns post_excerpt as stored.
+func (r *Renderer) Excerpt(post *model.Post, format string) string {
+
+	if format == FormatRaw {
+		return post.PostExcerpt
+	}
+
+	ctx := r.newContext(post)
+
+	text := post.PostExcerpt
+
+	if strings.TrimSpace(text) == "" {
+
+		teaser := post.PostContent
+		if loc := moreRegexp.FindStringIndex(teaser); loc != nil {
+			teaser = teaser[:loc[0]]
+		}
+
+		teaser = ctx.StripShortcodes(teaser)
+		teaser = ctx.theContent(teaser)
+		teaser = strings.Replace(teaser, "]]>", "]]&gt;", -1)
+
+		text = TrimWords(teaser, r.ExcerptLength, r.ExcerptMore)
+	}
+
+	// The default the_excerpt filters.
+	text = Texturize(text)
+	text = Autop(text, true)
+	text = ctx.ShortcodeUnautop(text)
+
+	return text
+}
+
+var (
+	scriptStyleRegexp = regexp.MustCompile(`(?is)<script[^>]*?>.*?</script>|<style[^>]*?>.*?</style>`)
+	allTagsRegexp     = regexp.MustCompile(`<[^>]*>`)
+)
+
+// TrimWords is a port of wp_trim_words(): it strips all tags and keeps the
+// first length words, appending more when words were cut off.
+func TrimWords(text string, length int, more string) string {
+
+	text = scriptStyleRegexp.ReplaceAllString(text, "")
+	text = allTagsRegexp.ReplaceAllString(text, "")
+
+	words := strings.Fields(text)
+	if len(words) <= length {
+		return strings.Join(words, " ")
+	}
+
+	return strings.Join(words[:length], " ") + more
+}
@@ -0,0 +1,262 @@
+package render
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/****
+*********************
+SHORTCODES
+*********************
+****/
+
+// Shortcode is one parsed [tag attr="value"]content[/tag] occurrence.
+// Positional attributes are stored under their index ("0", "1", ...).
+type Shortcode struct {
+	Tag      string
+	Attrs    map[string]string
+	Content  string
+	Enclosed bool
+}
+
+// Attr returns the attribute or def when it is not set.
+func (sc *Shortcode) Attr(name string, def string) string {
+
+	if v, ok := sc.Attrs[name]; ok {
+		return v
+	}
+
+	return def
+}
+
+// ShortcodeFunc renders a shortcode. Handlers of enclosing shortcodes call
+// ctx.DoShortcodes on the content when nested shortcodes should be expanded.
+type ShortcodeFunc func(ctx *Context, sc *Shortcode) string
+
+// How shortcodes without a registered handler are output.
+const (
+	UnknownPassthrough = "passthrough"
+	UnknownStrip       = "strip"
+)
+
+var shortcodeNameRegexp = regexp.MustCompile(`^[a-zA-Z][\w-]*$`)
+
+// DoShortcodes replaces every registered shortcode with its handler output, as
+// do_shortcode() does. Doubled brackets ([[tag]]) escape a shortcode.
+func (ctx *Context) DoShortcodes(text string) string {
+
+	if !strings.Contains(text, "[") {
+		return text
+	}
+
+	var b strings.Builder
+
+	i := 0
+	for i < len(text) {
+
+		open := strings.IndexByte(text[i:], '[')
+		if open < 0 {
+			break
+		}
+		open += i
+
+		sc, end, escaped, ok := parseShortcode(text, open)
+		if !ok {
+			b.WriteString(text[i : open+1])
+			i = open + 1
+			continue
+		}
+
+		handler, registered := ctx.r.shortcodes[sc.Tag]
+		if !registered && (ctx.r.UnknownShortcodes != UnknownStrip || !shortcodeNameRegexp.MatchString(sc.Tag)) {
+			b.WriteString(text[i : open+1])
+			i = open + 1
+			continue
+		}
+
+		b.WriteString(text[i:open])
+
+		switch {
+		case escaped:
+			b.WriteString(text[open+1 : end-1])
+		case registered:
+			b.WriteString(handler(ctx, sc))
+		default:
+			b.WriteString(ctx.DoShortcodes(sc.Content))
+		}
+
+		i = end
+	}
+
+	b.WriteString(text[i:])
+
+	return b.String()
+}
+
+// StripShortcodes removes registered shortcodes and their content, like
+// strip_shortcodes(). Unknown ones are handled as configured.
+func (ctx *Context) StripShortcodes(text string) string {
+
+	if !strings.Contains(text, "[") {
+		return text
+	}
+
+	var b strings.Builder
+
+	i := 0
+	for i < len(text) {
+
+		open := strings.IndexByte(text[i:], '[')
+		if open < 0 {
+			break
+		}
+		open += i
+
+		sc, end, escaped, ok := parseShortcode(text, open)
+		_, registered := ctx.r.shortcodes[sc.Tag]
+		strip := registered || (ctx.r.UnknownShortcodes == UnknownStrip && shortcodeNameRegexp.MatchString(sc.Tag))
+
+		if !ok || !strip {
+			b.WriteString(text[i : open+1])
+			i = open + 1
+			continue
+		}
+
+		b.WriteString(text[i:open])
+
+		if escaped {
+			b.WriteString(text[open+1 : end-1])
+		} else if !registered {
+			b.WriteString(ctx.StripShortcodes(sc.Content))
+		}
+
+		i = end
+	}
+
+	b.WriteString(text[i:])
+
+	return b.String()
+}
+
+// parseShortcode reads the shortcode starting at the "[" at start, following
+// the structure of get_shortcode_regex(). It returns the offset right after
+// the shortcode and whether it was escaped with doubled brackets.
+func parseShortcode(text string, start int) (*Shortcode, int, bool, bool) {
+
+	sc := &Shortcode{}
+
+	pos := start + 1
+
+	escapedOpen := pos < len(text) && text[pos] == '['
+	if escapedOpen {
+		pos++
+	}
+
+	nameStart := pos
+	for pos < len(text) && !strings.ContainsRune("<>&/[]=\x7f", rune(text[pos])) && text[pos] > ' ' {
+		pos++
+	}
+
+	if pos == nameStart {
+		return sc, 0, false, false
+	}
+	sc.Tag = text[nameStart:pos]
+
+	// The attributes run to the first "]", a "/]" closing a self-closing tag.
+	attrsStart := pos
+	closeAt := strings.IndexByte(text[pos:], ']')
+	if closeAt < 0 {
+		return sc, 0, false, false
+	}
+	closeAt += pos
+
+	attrs := text[attrsStart:closeAt]
+	selfClosing := strings.HasSuffix(attrs, "/")
+	if selfClosing {
+		attrs = attrs[:len(attrs)-1]
+	}
+
+	if strings.ContainsRune(attrs, '[') {
+		return sc, 0, false, false
+	}
+
+	sc.Attrs = ParseShortcodeAttrs(attrs)
+	pos = closeAt + 1
+
+	if !selfClosing {
+		closing := "[/" + sc.Tag + "]"
+		if end := strings.Index(text[pos:], closing); end >= 0 {
+			sc.Content = text[pos : pos+end]
+			sc.Enclosed = true
+			pos = pos + end + len(closing)
+		}
+	}
+
+	escaped := false
+	if escapedOpen {
+		if pos < len(text) && text[pos] == ']' {
+			escaped = true
+			pos++
+		} else {
+			// "[[tag]" is a literal "[" followed by the shortcode.
+			return sc, 0, false, false
+		}
+	}
+
+	return sc, pos, escaped, true
+}
+
+var shortcodeAttrRegexp = regexp.MustCompile(`([\w-]+)\s*=\s*"([^"]*)"(?:\s|$)|([\w-]+)\s*=\s*'([^']*)'(?:\s|$)|([\w-]+)\s*=\s*([^\s'"]+)(?:\s|$)|"([^"]*)"(?:\s|$)|'([^']*)'(?:\s|$)|(\S+)(?:\s|$)`)
+
+// ParseShortcodeAttrs is a port of shortcode_parse_atts(). Names are lower
+// cased and positional values are keyed by their position.
+func ParseShortcodeAttrs(text string) map[string]string {
+
+	attrs := make(map[string]string)
+
+	text = strings.NewReplacer("\u00a0", " ", "\u200b", " ").Replace(text)
+
+	positional := 0
+	for _, m := range shortcodeAttrRegexp.FindAllStringSubmatch(text+" ", -1) {
+
+		switch {
+		case len(m[1]) > 0:
+			attrs[strings.ToLower(m[1])] = m[2]
+		case len(m[3]) > 0:
+			attrs[strings.ToLower(m[3])] = m[4]
+		case len(m[5]) > 0:
+			attrs[strings.ToLower(m[5])] = m[6]
+		default:
+			value := m[7] + m[8] + m[9]
+			attrs[strconv.Itoa(positional)] = value
+			positional++
+		}
+	}
+
+	return attrs
+}
+
+var unautopRegexp = regexp.MustCompile(`<p>\s*(\[[^\[\]]+\](?:[^\[]*\[/[^\[\]]+\])?)\s*</p>`)
+
+// ShortcodeUnautop removes the paragraph wpautop puts around a shortcode that
+// stands alone on its line, like shortcode_unautop().
+func (ctx *Context) ShortcodeUnautop(text string) string {
+
+	return unautopRegexp.ReplaceAllStringFunc(text, func(m string) string {
+
+		inner := unautopRegexp.FindStringSubmatch(m)[1]
+
+		sc, _, _, ok := parseShortcode(inner, 0)
+		if !ok {
+			return m
+		}
+
+		if _, registered := ctx.r.shortcodes[sc.Tag]; !registered {
+			return m
+		}
+
+		return inner
+	})
+}
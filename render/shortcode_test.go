@@ -0,0 +1,173 @@
+package render
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/iyut/graphql-go/model"
+)
+
+// newTestContext registers the shortcodes of WordPress core's
+// tests/phpunit/tests/shortcode.php. The last shortcode given to
+// test-shortcode-tag is stored in captured.
+func newTestContext(captured **Shortcode) *Context {
+
+	r := NewRenderer()
+
+	r.AddShortcode("test-shortcode-tag", func(ctx *Context, sc *Shortcode) string {
+		*captured = sc
+		return ""
+	})
+
+	r.AddShortcode("footag", func(ctx *Context, sc *Shortcode) string {
+		return "foo = " + sc.Attr("foo", "")
+	})
+
+	r.AddShortcode("bartag", func(ctx *Context, sc *Shortcode) string {
+		return "foo = " + sc.Attr("foo", "no foo")
+	})
+
+	r.AddShortcode("baztag", func(ctx *Context, sc *Shortcode) string {
+		return "content = " + ctx.DoShortcodes(sc.Content)
+	})
+
+	// WordPress dumps the attributes in source order, maps have none.
+	r.AddShortcode("dumptag", func(ctx *Context, sc *Shortcode) string {
+
+		var names []string
+		for name := range sc.Attrs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		out := ""
+		for _, name := range names {
+			out += name + " = " + sc.Attrs[name] + "\n"
+		}
+
+		return out
+	})
+
+	for _, tag := range []string{"hyphen", "hyphen-foo", "hyphen-foo-bar"} {
+		tag := tag
+		r.AddShortcode(tag, func(ctx *Context, sc *Shortcode) string {
+			return tag
+		})
+	}
+
+	return r.newContext(&model.Post{PostID: "1"})
+}
+
+func TestShortcodeAttrs(t *testing.T) {
+
+	tests := []struct {
+		in      string
+		attrs   map[string]string
+		content string
+	}{
+		{"[test-shortcode-tag /]", map[string]string{}, ""},
+		{`[test-shortcode-tag foo="asdf" /]`, map[string]string{"foo": "asdf"}, ""},
+		{`[test-shortcode-tag foo="asdf" bar="bing" /]`, map[string]string{"foo": "asdf", "bar": "bing"}, ""},
+		{"[test-shortcode-tag]content[/test-shortcode-tag]", map[string]string{}, "content"},
+		{`[test-shortcode-tag foo="bar"]content[/test-shortcode-tag]`, map[string]string{"foo": "bar"}, "content"},
+		{"[test-shortcode-tag]", map[string]string{}, ""},
+		{"[test-shortcode-tag 123]", map[string]string{"0": "123"}, ""},
+		{`[test-shortcode-tag "something in quotes" "something else"]`, map[string]string{"0": "something in quotes", "1": "something else"}, ""},
+		{`[test-shortcode-tag 123 https://wordpress.org/ 0 "foo" bar]`, map[string]string{"0": "123", "1": "https://wordpress.org/", "2": "0", "3": "foo", "4": "bar"}, ""},
+		{`[test-shortcode-tag 123 url=https://wordpress.org/ foo bar="baz"]`, map[string]string{"0": "123", "url": "https://wordpress.org/", "1": "foo", "bar": "baz"}, ""},
+		{"[test-shortcode-tag foo=\"bar\" \u00a0baz=\"123\"]", map[string]string{"foo": "bar", "baz": "123"}, ""},
+		{"[test-shortcode-tag foo=\"bar\" \u200babc=\"def\"]", map[string]string{"foo": "bar", "abc": "def"}, ""},
+	}
+
+	for _, tt := range tests {
+
+		var sc *Shortcode
+		newTestContext(&sc).DoShortcodes(tt.in)
+
+		if sc == nil {
+			t.Errorf("DoShortcodes(%q) did not call the handler", tt.in)
+			continue
+		}
+		if !reflect.DeepEqual(sc.Attrs, tt.attrs) || sc.Content != tt.content {
+			t.Errorf("DoShortcodes(%q) got attrs %v and content %q, want %v and %q", tt.in, sc.Attrs, sc.Content, tt.attrs, tt.content)
+		}
+	}
+}
+
+func TestDoShortcodes(t *testing.T) {
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"not a tag", "[not-a-shortcode-tag]", "[not-a-shortcode-tag]"},
+		{"hyphen not a tag", "[dumptag-notreal]", "[dumptag-notreal]"},
+		{"underscore not a tag", "[dumptag_notreal]", "[dumptag_notreal]"},
+		{"hyphen", "[hyphen]", "hyphen"},
+		{"hyphen-foo", "[hyphen-foo]", "hyphen-foo"},
+		{"hyphen-foo-bar", "[hyphen-foo-bar]", "hyphen-foo-bar"},
+		{"footag default", "[footag]", "foo = "},
+		{"footag value", `[footag foo="x y z"]`, "foo = x y z"},
+		{"bartag default", "[bartag]", "foo = no foo"},
+		{"nested tags", "[baztag][dumptag abc=\"foo\" def=123 https://wordpress.org/][/baztag]",
+			"content = 0 = https://wordpress.org\nabc = foo\ndef = 123\n"},
+
+		{"escaped", `[[footag]] [[bartag foo="bar"]]`, `[footag] [bartag foo="bar"]`},
+		{"escaped self-closing", `[[footag /]] [[bartag foo="bar" /]]`, `[footag /] [bartag foo="bar" /]`},
+		{"escaped enclosing", `[[baztag foo="bar"]the content[/baztag]]`, `[baztag foo="bar"]the content[/baztag]`},
+		{"double escaped", `[[[footag]]] [[[bartag foo="bar"]]]`, `[[footag]] [[bartag foo="bar"]]`},
+
+		{"not escaped", `[[footag] [bartag foo="bar"]]`, "[foo =  foo = bar]"},
+		{"not escaped self-closing", `[[footag /] [bartag foo="bar" /]]`, "[foo =  foo = bar]"},
+		{"not escaped enclosing", `[[baztag foo="bar"]the content[/baztag]`, "[content = the content"},
+		{"not escaped unknown", "[[not-a-tag]]", "[[not-a-tag]]"},
+		{"not double escaped", `[[[footag] [bartag foo="bar"]]]`, "[[foo =  foo = bar]]"},
+	}
+
+	for _, tt := range tests {
+		var sc *Shortcode
+		if got := newTestContext(&sc).DoShortcodes(tt.in); got != tt.want {
+			t.Errorf("%s: DoShortcodes(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripShortcodes(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"before[gallery]", "before"},
+		{"[gallery]after", "after"},
+		{"before[gallery]after", "beforeafter"},
+		{"before[after", "before[after"},
+		{"beforeafter", "beforeafter"},
+		{`before[gallery id="123" size="medium"]after`, "beforeafter"},
+		{"before[unregistered_shortcode]after", "before[unregistered_shortcode]after"},
+		{"before[footag]after", "beforeafter"},
+		{"before [footag]content[/footag] after", "before  after"},
+		{`before [footag foo="123"]content[/footag] after`, "before  after"},
+	}
+
+	for _, tt := range tests {
+		var sc *Shortcode
+		if got := newTestContext(&sc).StripShortcodes(tt.in); got != tt.want {
+			t.Errorf("StripShortcodes(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestShortcodeUnautop(t *testing.T) {
+
+	var sc *Shortcode
+	ctx := newTestContext(&sc)
+
+	// wpautop adds a line break at the end, so the input already has one.
+	in := "[footag]\n"
+	if got := ctx.ShortcodeUnautop(Autop(in, true)); got != in {
+		t.Errorf("ShortcodeUnautop(Autop(%q)) = %q, want %q", in, got, in)
+	}
+}
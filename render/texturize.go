@@ -0,0 +1,449 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+)
+
+/****
+*********************
+WPTEXTURIZE
+*********************
+****/
+
+// noTexturizeTags and noTexturizeShortcodes hold text that must be left
+// exactly as typed.
+var (
+	noTexturizeTags = map[string]bool{
+		"pre": true, "code": true, "kbd": true, "style": true, "script": true, "tt": true,
+	}
+	noTexturizeShortcodes = map[string]bool{
+		"code": true,
+	}
+)
+
+// texturizeStatic are replaced one after the other, like str_replace() does
+// with arrays.
+var texturizeStatic = [][2]string{
+	{"...", "&#8230;"},
+	{"``", "&#8220;"},
+	{"''", "&#8221;"},
+	{" (tm)", " &#8482;"},
+	{"'tain't", "&#8217;tain&#8217;t"},
+	{"'twere", "&#8217;twere"},
+	{"'twas", "&#8217;twas"},
+	{"'tis", "&#8217;tis"},
+	{"'twill", "&#8217;twill"},
+	{"'til", "&#8217;til"},
+	{"'bout", "&#8217;bout"},
+	{"'nuff", "&#8217;nuff"},
+	{"'round", "&#8217;round"},
+	{"'cause", "&#8217;cause"},
+	{"'em", "&#8217;em"},
+}
+
+// Placeholders of wptexturize() for quotes that are only told apart from
+// primes later on.
+const (
+	texturizeAposFlag       = "<!--apos-->"
+	texturizeOpenSingleFlag = "<!--oq-->"
+	texturizeOpenDoubleFlag = "<!--oqq-->"
+	texturizePrimeFlag      = "<!--wp-prime-or-quote-->"
+)
+
+// texturizeSpaces are what wp_spaces_regexp() matches by default.
+var texturizeSpaces = []string{" ", "\t", "\n", "\r", "\u00a0", "&nbsp;"}
+
+var (
+	texturizeMultiply = regexp.MustCompile(`\b(0[\d.,]+|[1-9][\d.,]*)x(\d[\d.,]*)\b`)
+	texturizeEntity   = regexp.MustCompile(`^(?i:#(?:\d+|x[a-f0-9]+);|[a-z1-4]{1,8};)`)
+)
+
+// Texturize is a port of wptexturize(): curly quotes, apostrophes and
+// primes, dashes, ellipses, the trademark and multiplication signs, and
+// ampersands as entities. Text inside pre, code, kbd, style, script and tt
+// elements and [code] shortcodes, HTML comments and shortcodes is not
+// changed.
+func Texturize(text string) string {
+
+	chunks := splitHTML(text)
+	var tagStack, shortcodeStack []string
+
+	for i, chunk := range chunks {
+
+		if i%2 == 1 {
+			if !strings.HasPrefix(chunk, "<!--") {
+				chunks[i] = texturizeAmpersands(chunk)
+				tagStack = pushPopNoTexturize(chunk, tagStack, noTexturizeTags)
+			}
+			continue
+		}
+
+		if len(strings.Trim(chunk, " \t\n\r\x00\x0b")) == 0 {
+			continue
+		}
+
+		chunks[i] = texturizeOutsideShortcodes(chunk, len(tagStack) > 0, &shortcodeStack)
+	}
+
+	return strings.Join(chunks, "")
+}
+
+// pushPopNoTexturize pushes and pops the element the tag or shortcode opens
+// or closes when it is a disabled one, as _wptexturize_pushpop_element()
+// does: names are compared as typed.
+func pushPopNoTexturize(tag string, stack []string, disabled map[string]bool) []string {
+
+	opening := len(tag) > 1 && tag[1] != '/'
+	if !opening && len(stack) == 0 {
+		return stack
+	}
+
+	nameOffset := 1
+	if !opening {
+		nameOffset = 2
+	}
+
+	nameEnd := len(tag) - 1
+	if space := strings.IndexByte(tag, ' '); space >= 0 {
+		nameEnd = space
+	}
+
+	name := ""
+	if nameEnd > nameOffset {
+		name = tag[nameOffset:nameEnd]
+	}
+
+	if !disabled[name] {
+		return stack
+	}
+
+	if opening {
+		return append(stack, name)
+	}
+
+	if stack[len(stack)-1] == name {
+		return stack[:len(stack)-1]
+	}
+
+	return stack
+}
+
+var shortcodeTagRegexp = regexp.MustCompile(`\[[^\[\]<>]*\]`)
+
+// texturizeOutsideShortcodes texturizes the text between shortcodes, unless
+// it is inside a disabled element or shortcode.
+func texturizeOutsideShortcodes(text string, inNoTexturizeTag bool, shortcodeStack *[]string) string {
+
+	var b strings.Builder
+
+	texturize := func(text string) string {
+		if inNoTexturizeTag || len(*shortcodeStack) > 0 || len(strings.Trim(text, " \t\n\r\x00\x0b")) == 0 {
+			return text
+		}
+		return texturizeText(text)
+	}
+
+	last := 0
+	for _, m := range shortcodeTagRegexp.FindAllStringIndex(text, -1) {
+
+		b.WriteString(texturize(text[last:m[0]]))
+		b.WriteString(text[m[0]:m[1]])
+
+		escaped := m[0] > 0 && text[m[0]-1] == '[' && m[1] < len(text) && text[m[1]] == ']'
+		if !escaped {
+			*shortcodeStack = pushPopNoTexturize(text[m[0]:m[1]], *shortcodeStack, noTexturizeShortcodes)
+		}
+
+		last = m[1]
+	}
+	b.WriteString(texturize(text[last:]))
+
+	return b.String()
+}
+
+// texturizeText texturizes text outside tags and shortcodes, pass by pass as
+// wptexturize() does. Go regexps have no look-arounds, so every pass with
+// them is a texturizePass.
+func texturizeText(text string) string {
+
+	for _, r := range texturizeStatic {
+		text = strings.Replace(text, r[0], r[1], -1)
+	}
+
+	if strings.Contains(text, "'") {
+
+		// '99' and '99" are taken for abbreviated years closing a quotation.
+		text = texturizePass(text, func(s string, i int) (string, int, bool) {
+			if s[i] != '\'' || i+3 >= len(s) || !isDigit(s[i+1]) || !isDigit(s[i+2]) || !closingQuoteContext(s, i+4) {
+				return "", 0, false
+			}
+			switch s[i+3] {
+			case '\'':
+				return texturizeAposFlag + s[i+1:i+3] + "&#8217;", 4, true
+			case '"':
+				return texturizeAposFlag + s[i+1:i+3] + "&#8221;", 4, true
+			}
+			return "", 0, false
+		})
+
+		// '99, '99s and '99's, but never '9, '99%, '999 or '99.0.
+		text = texturizePass(text, func(s string, i int) (string, int, bool) {
+			if s[i] != '\'' || i+2 >= len(s) || !isDigit(s[i+1]) || !isDigit(s[i+2]) {
+				return "", 0, false
+			}
+			if k := i + 3; k < len(s) && (s[k] == '%' || isDigit(s[k]) || ((s[k] == '.' || s[k] == ',') && k+1 < len(s) && isDigit(s[k+1]))) {
+				return "", 0, false
+			}
+			return texturizeAposFlag, 1, true
+		})
+
+		// Quoted numbers like '0.42'.
+		text = texturizePass(text, func(s string, i int) (string, int, bool) {
+			if s[i] != '\'' || (i > 0 && !spaceBefore(s, i)) {
+				return "", 0, false
+			}
+			j := i + 1
+			if j >= len(s) || !isDigit(s[j]) {
+				return "", 0, false
+			}
+			for j < len(s) && (isDigit(s[j]) || s[j] == '.' || s[j] == ',') {
+				j++
+			}
+			if j >= len(s) || s[j] != '\'' {
+				return "", 0, false
+			}
+			return texturizeOpenSingleFlag + s[i+1:j] + "&#8217;", j + 1 - i, true
+		})
+
+		// Opening quotes: at the start, or after (, [, {, ", -, < or a space.
+		text = texturizePass(text, func(s string, i int) (string, int, bool) {
+			if s[i] != '\'' || !(i == 0 || strings.IndexByte(`([{"-`, s[i-1]) >= 0 || strings.HasSuffix(s[:i], "&lt;") || spaceBefore(s, i)) {
+				return "", 0, false
+			}
+			return texturizeOpenSingleFlag, 1, true
+		})
+
+		// Apostrophes in a word.
+		text = texturizePass(text, func(s string, i int) (string, int, bool) {
+			if s[i] != '\'' || spaceBefore(s, i) || i+1 >= len(s) || strings.IndexByte(`.,:;!?"'(){}[]-`, s[i+1]) >= 0 ||
+				strings.HasPrefix(s[i+1:], "&lt;") || strings.HasPrefix(s[i+1:], "&gt;") || spaceAt(s, i+1) {
+				return "", 0, false
+			}
+			return texturizeAposFlag, 1, true
+		})
+
+		text = texturizePrimes(text, "'", "&#8242;", texturizeOpenSingleFlag, "&#8217;")
+		text = strings.Replace(text, texturizeAposFlag, "&#8217;", -1)
+		text = strings.Replace(text, texturizeOpenSingleFlag, "&#8216;", -1)
+	}
+
+	if strings.Contains(text, `"`) {
+
+		// Quoted numbers like "42".
+		text = texturizePass(text, func(s string, i int) (string, int, bool) {
+			if s[i] != '"' || (i > 0 && !spaceBefore(s, i)) {
+				return "", 0, false
+			}
+			j := i + 1
+			if j >= len(s) || !isDigit(s[j]) {
+				return "", 0, false
+			}
+			for j < len(s) && (isDigit(s[j]) || s[j] == '.' || s[j] == ',') {
+				j++
+			}
+			if j >= len(s) || s[j] != '"' {
+				return "", 0, false
+			}
+			return texturizeOpenDoubleFlag + s[i+1:j] + "&#8221;", j + 1 - i, true
+		})
+
+		// Opening quotes: at the start, or after (, [, {, -, < or a space,
+		// and not before a space.
+		text = texturizePass(text, func(s string, i int) (string, int, bool) {
+			if s[i] != '"' || !(i == 0 || strings.IndexByte(`([{-`, s[i-1]) >= 0 || strings.HasSuffix(s[:i], "&lt;") || spaceBefore(s, i)) || spaceAt(s, i+1) {
+				return "", 0, false
+			}
+			return texturizeOpenDoubleFlag, 1, true
+		})
+
+		text = texturizePrimes(text, `"`, "&#8243;", texturizeOpenDoubleFlag, "&#8221;")
+		text = strings.Replace(text, texturizeOpenDoubleFlag, "&#8220;", -1)
+	}
+
+	if strings.Contains(text, "-") {
+
+		text = strings.Replace(text, "---", "&#8212;", -1)
+
+		// -- between spaces is an em dash, other -- an en dash, except in
+		// the xn-- of punycode domains, and - between spaces an en dash.
+		text = texturizePass(text, func(s string, i int) (string, int, bool) {
+			if !strings.HasPrefix(s[i:], "--") || !(i == 0 || spaceBefore(s, i)) || !(i+2 == len(s) || spaceAt(s, i+2)) {
+				return "", 0, false
+			}
+			return "&#8212;", 2, true
+		})
+		text = texturizePass(text, func(s string, i int) (string, int, bool) {
+			if !strings.HasPrefix(s[i:], "--") || strings.HasSuffix(s[:i], "xn") {
+				return "", 0, false
+			}
+			return "&#8211;", 2, true
+		})
+		text = texturizePass(text, func(s string, i int) (string, int, bool) {
+			if s[i] != '-' || !(i == 0 || spaceBefore(s, i)) || !(i+1 == len(s) || spaceAt(s, i+1)) {
+				return "", 0, false
+			}
+			return "&#8211;", 1, true
+		})
+	}
+
+	// 9x9, but never 0x9999.
+	text = texturizeMultiply.ReplaceAllString(text, "$1&#215;$2")
+
+	return texturizeAmpersands(text)
+}
+
+// texturizePrimes is wptexturize_primes(): it tells the closing quotes of
+// the sentences opened by openQuote apart from the primes after digits.
+func texturizePrimes(text string, needle string, prime string, openQuote string, closeQuote string) string {
+
+	isQuote := func(s string, i int) bool { return closingQuoteContext(s, i+len(needle)) }
+	afterDigit := func(s string, i int) bool { return i > 0 && isDigit(s[i-1]) }
+	notAfterDigit := func(s string, i int) bool { return !afterDigit(s, i) }
+
+	sentences := strings.Split(text, openQuote)
+
+	for key, sentence := range sentences {
+
+		if !strings.Contains(sentence, needle) {
+			continue
+		}
+
+		if key != 0 && !strings.Contains(sentence, closeQuote) {
+
+			var count int
+			sentence, count = replaceWhere(sentence, needle, texturizePrimeFlag, isQuote)
+
+			switch {
+			case count > 1:
+				// Several closing quote candidates: the one not after a
+				// digit, else the rightmost before a period, else the
+				// rightmost.
+				var count2 int
+				sentence, count2 = replaceWhere(sentence, texturizePrimeFlag, closeQuote, notAfterDigit)
+				if count2 == 0 {
+					pos := strings.LastIndex(sentence, texturizePrimeFlag+".")
+					if pos < 0 {
+						pos = strings.LastIndex(sentence, texturizePrimeFlag)
+					}
+					sentence = sentence[:pos] + closeQuote + sentence[pos+len(texturizePrimeFlag):]
+				}
+				sentence, _ = replaceWhere(sentence, needle, prime, afterDigit)
+				sentence, _ = replaceWhere(sentence, texturizePrimeFlag, prime, afterDigit)
+				sentence = strings.Replace(sentence, texturizePrimeFlag, closeQuote, -1)
+
+			case count == 1:
+				sentence = strings.Replace(sentence, texturizePrimeFlag, closeQuote, -1)
+				sentence, _ = replaceWhere(sentence, needle, prime, afterDigit)
+
+			default:
+				sentence, _ = replaceWhere(sentence, needle, prime, afterDigit)
+			}
+
+		} else {
+			sentence, _ = replaceWhere(sentence, needle, prime, afterDigit)
+			sentence, _ = replaceWhere(sentence, needle, closeQuote, isQuote)
+		}
+
+		if needle == `"` {
+			sentence = strings.Replace(sentence, `"`, closeQuote, -1)
+		}
+
+		sentences[key] = sentence
+	}
+
+	return strings.Join(sentences, openQuote)
+}
+
+// texturizeAmpersands turns every & that does not start an entity into
+// &#038;.
+func texturizeAmpersands(text string) string {
+
+	return texturizePass(text, func(s string, i int) (string, int, bool) {
+		if s[i] != '&' || texturizeEntity.MatchString(s[i+1:]) {
+			return "", 0, false
+		}
+		return "&#038;", 1, true
+	})
+}
+
+// texturizePass replaces, left to right, what match finds at each offset of
+// the text: one preg_replace() with look-arounds, which look at the text as
+// it was before the pass.
+func texturizePass(text string, match func(s string, i int) (string, int, bool)) string {
+
+	var b strings.Builder
+
+	for i := 0; i < len(text); {
+
+		if replacement, n, ok := match(text, i); ok {
+			b.WriteString(replacement)
+			i += n
+			continue
+		}
+
+		b.WriteByte(text[i])
+		i++
+	}
+
+	return b.String()
+}
+
+// replaceWhere replaces the occurrences of needle at which cond holds, and
+// returns how many it replaced.
+func replaceWhere(text string, needle string, replacement string, cond func(s string, i int) bool) (string, int) {
+
+	count := 0
+
+	text = texturizePass(text, func(s string, i int) (string, int, bool) {
+		if !strings.HasPrefix(s[i:], needle) || !cond(s, i) {
+			return "", 0, false
+		}
+		count++
+		return replacement, len(needle), true
+	})
+
+	return text, count
+}
+
+// closingQuoteContext tells whether a quote ending before i closes: it is
+// followed by the end, punctuation, &gt; or a space.
+func closingQuoteContext(s string, i int) bool {
+	return i >= len(s) || strings.IndexByte(".,:;!?)}-]", s[i]) >= 0 || strings.HasPrefix(s[i:], "&gt;") || spaceAt(s, i)
+}
+
+func spaceBefore(s string, i int) bool {
+
+	for _, space := range texturizeSpaces {
+		if strings.HasSuffix(s[:i], space) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func spaceAt(s string, i int) bool {
+
+	for _, space := range texturizeSpaces {
+		if strings.HasPrefix(s[i:], space) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
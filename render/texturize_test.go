@@ -0,0 +1,144 @@
+package render
+
+import "testing"
+
+// Cases from WordPress core's tests/phpunit/tests/formatting/wpTexturize.php.
+func TestTexturize(t *testing.T) {
+
+	nbsp := "\u00a0"
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"dashes", "Hey -- boo?", "Hey &#8212; boo?"},
+		{"dashes in attributes", `<a href="http://xx--xx">Hey -- boo?</a>`, `<a href="http://xx--xx">Hey &#8212; boo?</a>`},
+		{"dashes between words", "a--b", "a&#8211;b"},
+		{"punycode", "xn--", "xn--"},
+
+		{"disable pre", "<pre>---&nbsp;</pre>", "<pre>---&nbsp;</pre>"},
+		{"disable code shortcode", "[a]a--b[code]---[/code]a--b[/a]", "[a]a&#8211;b[code]---[/code]a&#8211;b[/a]"},
+		{"disable after nested code", "<pre><code></code>--</pre>", "<pre><code></code>--</pre>"},
+		{"disable code", "<code>---</code>", "<code>---</code>"},
+		{"disable kbd", "<kbd>---</kbd>", "<kbd>---</kbd>"},
+		{"disable style", "<style>---</style>", "<style>---</style>"},
+		{"disable script", "<script>---</script>", "<script>---</script>"},
+		{"disable tt", "<tt>---</tt>", "<tt>---</tt>"},
+		{"disable ends with element", `<code>href="baba"</code> "baba"`, `<code>href="baba"</code> &#8220;baba&#8221;`},
+		{"code inside pre", `<pre>"baba"<code>"baba"<pre></pre></code>"baba"</pre>`, `<pre>"baba"<code>"baba"<pre></pre></code>"baba"</pre>`},
+		{"pre inside code", `<code>"baba"<pre>"baba"<code></code></pre>"baba"</code>`, `<code>"baba"<pre>"baba"<code></code></pre>"baba"</code>`},
+		{"invalid nesting", `<pre></code>"baba"</pre>`, `<pre></code>"baba"</pre>`},
+
+		{"bracketed quotes 1418", `("test")`, "(&#8220;test&#8221;)"},
+		{"bracketed quotes 3810", `A dog ("Hubertus") was sent out.`, "A dog (&#8220;Hubertus&#8221;) was sent out."},
+
+		{"basic apostrophe", "test's", "test&#8217;s"},
+		{"basic single quotes", "'quoted'", "&#8216;quoted&#8217;"},
+		{"basic double quotes", `"quoted"`, "&#8220;quoted&#8221;"},
+		{"single quotes between spaces", "space before 'quoted' space after", "space before &#8216;quoted&#8217; space after"},
+		{"double quotes between spaces", `space before "quoted" space after`, "space before &#8220;quoted&#8221; space after"},
+		{"single quotes in parentheses", "('quoted')", "(&#8216;quoted&#8217;)"},
+		{"double quotes in braces", `{"quoted"}`, "{&#8220;quoted&#8221;}"},
+		{"single quotes around parentheses", "'qu(ot)ed'", "&#8216;qu(ot)ed&#8217;"},
+		{"double quotes around braces", `"qu{ot}ed"`, "&#8220;qu{ot}ed&#8221;"},
+		{"apostrophe in single quotes", " 'test's quoted' ", " &#8216;test&#8217;s quoted&#8217; "},
+		{"apostrophe in double quotes", ` "test's quoted" `, " &#8220;test&#8217;s quoted&#8221; "},
+
+		{"unmatched single quotes", "That means every moment you're working on something without it being in the public it's actually dying.",
+			"That means every moment you&#8217;re working on something without it being in the public it&#8217;s actually dying."},
+
+		{"quoted string", `"Quoted String"`, "&#8220;Quoted String&#8221;"},
+		{"quoted link and space", `Here is "<a href="http://example.com">a test with a link</a>" and a space.`,
+			`Here is &#8220;<a href="http://example.com">a test with a link</a>&#8221; and a space.`},
+		{"quoted link and text", `Here is "<a href="http://example.com">a test with a link</a> and some text quoted"`,
+			`Here is &#8220;<a href="http://example.com">a test with a link</a> and some text quoted&#8221;`},
+		{"finishing number", `A test with a finishing number, "like 23".`, "A test with a finishing number, &#8220;like 23&#8221;."},
+		{"number before comma", `A test with a number, "like 62", is nice to have.`, "A test with a number, &#8220;like 62&#8221;, is nice to have."},
+
+		{"quotes before s", "'test's", "&#8216;test&#8217;s"},
+		{"quotes around s", "'test's'", "&#8216;test&#8217;s&#8217;"},
+		{"quoted string single", "'string'", "&#8216;string&#8217;"},
+		{"quoted string's", "'string's'", "&#8216;string&#8217;s&#8217;"},
+
+		{"year", "Class of '99", "Class of &#8217;99"},
+		{"year's", "Class of '99's", "Class of &#8217;99&#8217;s"},
+		{"year in quotes", "'Class of '99'", "&#8216;Class of &#8217;99&#8217;"},
+		{"year in quotes and space", "'Class of '99' ", "&#8216;Class of &#8217;99&#8217; "},
+		{"year in quotes and period", "'Class of '99'.", "&#8216;Class of &#8217;99&#8217;."},
+		{"year in quotes and comma", "'Class of '99', she said", "&#8216;Class of &#8217;99&#8217;, she said"},
+		{"year in quotes and colon", "'Class of '99':", "&#8216;Class of &#8217;99&#8217;:"},
+		{"year in quotes and semicolon", "'Class of '99';", "&#8216;Class of &#8217;99&#8217;;"},
+		{"year in quotes and exclamation", "'Class of '99'!", "&#8216;Class of &#8217;99&#8217;!"},
+		{"year in quotes and question", "'Class of '99'?", "&#8216;Class of &#8217;99&#8217;?"},
+		{"year's in quotes", "'Class of '99's'", "&#8216;Class of &#8217;99&#8217;s&#8217;"},
+		{"year's entity in quotes", "'Class of '99&#8217;s'", "&#8216;Class of &#8217;99&#8217;s&#8217;"},
+		{"number in double quotes", `"Class of 99"`, "&#8220;Class of 99&#8221;"},
+		{"year in double quotes", `"Class of '99"`, "&#8220;Class of &#8217;99&#8221;"},
+		{"year in double quotes in braces", `{"Class of '99"}`, "{&#8220;Class of &#8217;99&#8221;}"},
+		{"year in double quotes and space", ` "Class of '99" `, " &#8220;Class of &#8217;99&#8221; "},
+		{"year in double quotes and period", ` "Class of '99".`, " &#8220;Class of &#8217;99&#8221;."},
+		{"year in double quotes and comma", ` "Class of '99", she said`, " &#8220;Class of &#8217;99&#8221;, she said"},
+		{"year in double quotes and colon", ` "Class of '99":`, " &#8220;Class of &#8217;99&#8221;:"},
+		{"year in double quotes and semicolon", ` "Class of '99";`, " &#8220;Class of &#8217;99&#8221;;"},
+		{"year in double quotes and exclamation", ` "Class of '99"!`, " &#8220;Class of &#8217;99&#8221;!"},
+		{"year in double quotes and question", ` "Class of '99"?`, " &#8220;Class of &#8217;99&#8221;?"},
+		{"not a quotation", `}"Class of '99"{`, "}&#8221;Class of &#8217;99&#8243;{"},
+
+		{"quote before tag", "'<strong>", "&#8216;<strong>"},
+
+		{"multiplication", "14x24", "14&#215;24"},
+		{"hexadecimal", "0x9999", "0x9999"},
+
+		{"prime", "9'", "9&#8242;"},
+		{"double prime", `9"`, "9&#8243;"},
+		{"prime between spaces", "a 9' b", "a 9&#8242; b"},
+		{"double prime between spaces", `a 9" b`, "a 9&#8243; b"},
+		{"prime in double quotes", `"a 9' b"`, "&#8220;a 9&#8242; b&#8221;"},
+		{"double prime in single quotes", `'a 9" b'`, "&#8216;a 9&#8243; b&#8217;"},
+
+		{"quoted number", `"12345"`, "&#8220;12345&#8221;"},
+		{"single quoted number", "'12345'", "&#8216;12345&#8217;"},
+		{"primes and quoted numbers", `"a 9' plus a '9', maybe a 9' '9'"`, "&#8220;a 9&#8242; plus a &#8216;9&#8217;, maybe a 9&#8242; &#8216;9&#8217;&#8221;"},
+
+		{"conditional comments", "<!--[if !IE]>--><!--<![endif]-->", "<!--[if !IE]>--><!--<![endif]-->"},
+		{"comment", `<!--[if !IE]>"a 9' plus a '9', maybe a 9' '9' "<![endif]-->`, `<!--[if !IE]>"a 9' plus a '9', maybe a 9' '9' "<![endif]-->`},
+		{"comment in list", "<ul><li>Hello.</li><!--<li>Goodbye.</li>--></ul>", "<ul><li>Hello.</li><!--<li>Goodbye.</li>--></ul>"},
+
+		{"entity quote cuddling", `&nbsp;"Testing"`, "&nbsp;&#8220;Testing&#8221;"},
+		{"apostrophe before prime", "WordPress 3.5's release date", "WordPress 3.5&#8217;s release date"},
+
+		{"hyphen between spaces", " - ", " &#8211; "},
+		{"hyphen between &nbsp;", "&nbsp;-&nbsp;", "&nbsp;&#8211;&nbsp;"},
+		{"hyphen before &nbsp;", " -&nbsp;", " &#8211;&nbsp;"},
+		{"hyphen after &nbsp;", "&nbsp;- ", "&nbsp;&#8211; "},
+		{"hyphen between no-break spaces", nbsp + "-" + nbsp, nbsp + "&#8211;" + nbsp},
+		{"hyphen before no-break space", " -" + nbsp, " &#8211;" + nbsp},
+		{"hyphen after no-break space", nbsp + "- ", nbsp + "&#8211; "},
+		{"double hyphen between spaces", " -- ", " &#8212; "},
+		{"double hyphen between &nbsp;", "&nbsp;--&nbsp;", "&nbsp;&#8212;&nbsp;"},
+		{"double hyphen before &nbsp;", " --&nbsp;", " &#8212;&nbsp;"},
+		{"double hyphen after &nbsp;", "&nbsp;-- ", "&nbsp;&#8212; "},
+		{"double hyphen between no-break spaces", nbsp + "--" + nbsp, nbsp + "&#8212;" + nbsp},
+
+		{"hyphen at start", "- ", "&#8211; "},
+		{"hyphens at start and end", "- -", "&#8211; &#8211;"},
+		{"hyphen at end", " -", " &#8211;"},
+		{"double hyphen at start", "-- ", "&#8212; "},
+		{"double hyphens at start and end", "-- --", "&#8212; &#8212;"},
+		{"double hyphen at end", " --", " &#8212;"},
+
+		{"ellipsis", "Wait...", "Wait&#8230;"},
+		{"trademark", "WordPress (tm)", "WordPress &#8482;"},
+		{"cockney", "'tis 'twas", "&#8217;tis &#8217;twas"},
+		{"ampersand", "Tom & Jerry &amp; &#38; &#x26;", "Tom &#038; Jerry &amp; &#38; &#x26;"},
+		{"ampersand in attribute", `<a href="?a=1&b=2">x</a>`, `<a href="?a=1&#038;b=2">x</a>`},
+		{"shortcode attributes", `[gallery ids="1,2"] "x"`, `[gallery ids="1,2"] &#8220;x&#8221;`},
+	}
+
+	for _, tt := range tests {
+		if got := Texturize(tt.in); got != tt.want {
+			t.Errorf("%s: Texturize(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
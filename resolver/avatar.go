@@ -0,0 +1,112 @@
+package resolver
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultAvatarSize = 96
+	maxAvatarSize     = 2048
+)
+
+type AvatarArgs struct {
+	Size    *int32
+	Default *string
+	Rating  *string
+}
+
+/*
+ * AvatarResolver
+ *
+ * type Avatar {
+ * 	url: String!
+ * 	size: Int!
+ * 	default: String!
+ * 	rating: AvatarRating!
+ * }
+ */
+
+type AvatarResolver struct {
+	url         string
+	size        int32
+	defaultType string
+	rating      string
+}
+
+func (r *AvatarResolver) URL() string {
+	return r.url
+}
+
+func (r *AvatarResolver) Size() int32 {
+	return r.size
+}
+
+func (r *AvatarResolver) Default() string {
+	return r.defaultType
+}
+
+func (r *AvatarResolver) Rating() string {
+	return r.rating
+}
+
+// Avatar returns the user's Gravatar like get_avatar_url(), defaulting to
+// the avatar_default and avatar_rating options, which the avatars of a
+// request read once. It is null when show_avatars is off.
+func (r *UserResolver) Avatar(ctx context.Context, args AvatarArgs) (*AvatarResolver, error) {
+
+	showAvatars, err := r.Root.option(ctx, "show_avatars", "1")
+	if err != nil {
+		return nil, err
+	}
+
+	if showAvatars != "1" {
+		return nil, nil
+	}
+
+	avatar := &AvatarResolver{size: defaultAvatarSize}
+
+	if args.Size != nil && *args.Size > 0 {
+		avatar.size = *args.Size
+		if avatar.size > maxAvatarSize {
+			avatar.size = maxAvatarSize
+		}
+	}
+
+	if args.Default != nil {
+		avatar.defaultType = *args.Default
+	} else if avatar.defaultType, err = r.Root.option(ctx, "avatar_default", "mystery"); err != nil {
+		return nil, err
+	}
+
+	if args.Rating != nil {
+		avatar.rating = *args.Rating
+	} else if avatar.rating, err = r.Root.option(ctx, "avatar_rating", "G"); err != nil {
+		return nil, err
+	}
+	avatar.rating = strings.ToUpper(avatar.rating)
+
+	hash := md5.Sum([]byte(strings.ToLower(strings.TrimSpace(r.U.UserEmail))))
+
+	query := url.Values{}
+	query.Set("s", strconv.Itoa(int(avatar.size)))
+
+	// The same mapping of WordPress' default names to Gravatar's.
+	switch avatar.defaultType {
+	case "mystery", "mm", "mysteryman":
+		query.Set("d", "mm")
+	case "gravatar_default":
+	default:
+		query.Set("d", avatar.defaultType)
+	}
+
+	query.Set("r", strings.ToLower(avatar.rating))
+
+	avatar.url = "https://secure.gravatar.com/avatar/" + hex.EncodeToString(hash[:]) + "?" + query.Encode()
+
+	return avatar, nil
+}
@@ -0,0 +1,364 @@
+package resolver
+
+import (
+	"database/sql"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/blocks"
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/service"
+)
+
+/*
+ * BlockResolver
+ *
+ * interface Block {
+ * 	name: String
+ * 	attributes: JSON!
+ * 	innerHTML: String!
+ * 	innerBlocks: [Block!]!
+ * }
+ */
+
+type BlockResolver struct {
+	B    *blocks.Block
+	Root *RootResolver
+}
+
+// newBlockResolvers wraps parsed blocks, leaving out the whitespace between
+// blocks that the parser returns as freeform blocks.
+func newBlockResolvers(bs []*blocks.Block, root *RootResolver) []*BlockResolver {
+
+	blockRxs := []*BlockResolver{}
+
+	for _, b := range bs {
+		if len(b.Name) == 0 && len(strings.TrimSpace(b.InnerHTML)) == 0 {
+			continue
+		}
+		blockRxs = append(blockRxs, &BlockResolver{B: b, Root: root})
+	}
+
+	return blockRxs
+}
+
+// parseBlocks parses post content and expands the reusable blocks in it.
+func (r *RootResolver) parseBlocks(content string) ([]*BlockResolver, error) {
+
+	parsed := blocks.Parse(content)
+
+	err := blocks.ExpandReusable(parsed, r.reusableBlock)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newBlockResolvers(parsed, r), nil
+}
+
+// reusableBlock loads the content of a published wp_block post.
+func (r *RootResolver) reusableBlock(ref int64) (string, bool, error) {
+
+	postService := service.NewPostService(r.DB, TablePrefix)
+
+	post, err := postService.FindByID(ref, nil)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	if post.PostType != "wp_block" || post.PostStatus != "publish" {
+		return "", false, nil
+	}
+
+	return post.PostContent, true, nil
+}
+
+func (r *BlockResolver) Name() *string {
+	return nullString(r.B.Name)
+}
+
+func (r *BlockResolver) Attributes() JSON {
+
+	if r.B.Attrs == nil {
+		return JSON{Value: map[string]interface{}{}}
+	}
+
+	return JSON{Value: r.B.Attrs}
+}
+
+func (r *BlockResolver) InnerHTML() string {
+	return r.B.InnerHTML
+}
+
+func (r *BlockResolver) InnerBlocks() []*BlockResolver {
+	return newBlockResolvers(r.B.InnerBlocks, r.Root)
+}
+
+func (r *BlockResolver) ToParagraphBlock() (*ParagraphBlockResolver, bool) {
+	if r.B.Name != "core/paragraph" {
+		return nil, false
+	}
+	return &ParagraphBlockResolver{r, blocks.ParagraphOf(r.B)}, true
+}
+
+func (r *BlockResolver) ToHeadingBlock() (*HeadingBlockResolver, bool) {
+	if r.B.Name != "core/heading" {
+		return nil, false
+	}
+	return &HeadingBlockResolver{r, blocks.HeadingOf(r.B)}, true
+}
+
+func (r *BlockResolver) ToImageBlock() (*ImageBlockResolver, bool) {
+	if r.B.Name != "core/image" {
+		return nil, false
+	}
+	return &ImageBlockResolver{r, blocks.ImageOf(r.B)}, true
+}
+
+func (r *BlockResolver) ToListBlock() (*ListBlockResolver, bool) {
+	if r.B.Name != "core/list" {
+		return nil, false
+	}
+	return &ListBlockResolver{r, blocks.ListOf(r.B)}, true
+}
+
+func (r *BlockResolver) ToQuoteBlock() (*QuoteBlockResolver, bool) {
+	if r.B.Name != "core/quote" {
+		return nil, false
+	}
+	return &QuoteBlockResolver{r, blocks.QuoteOf(r.B)}, true
+}
+
+func (r *BlockResolver) ToGalleryBlock() (*GalleryBlockResolver, bool) {
+	if r.B.Name != "core/gallery" {
+		return nil, false
+	}
+	return &GalleryBlockResolver{r, blocks.GalleryOf(r.B)}, true
+}
+
+func (r *BlockResolver) ToEmbedBlock() (*EmbedBlockResolver, bool) {
+	if !r.B.IsEmbed() {
+		return nil, false
+	}
+	return &EmbedBlockResolver{r, blocks.EmbedOf(r.B)}, true
+}
+
+func (r *BlockResolver) ToGenericBlock() (*BlockResolver, bool) {
+
+	switch r.B.Name {
+	case "core/paragraph", "core/heading", "core/image", "core/list", "core/quote", "core/gallery":
+		return nil, false
+	}
+
+	if r.B.IsEmbed() {
+		return nil, false
+	}
+
+	return r, true
+}
+
+type ParagraphBlockResolver struct {
+	*BlockResolver
+	p blocks.Paragraph
+}
+
+func (r *ParagraphBlockResolver) Content() string {
+	return r.p.Content
+}
+
+func (r *ParagraphBlockResolver) Align() *string {
+	return nullString(r.p.Align)
+}
+
+func (r *ParagraphBlockResolver) DropCap() bool {
+	return r.p.DropCap
+}
+
+type HeadingBlockResolver struct {
+	*BlockResolver
+	h blocks.Heading
+}
+
+func (r *HeadingBlockResolver) Content() string {
+	return r.h.Content
+}
+
+func (r *HeadingBlockResolver) Level() int32 {
+	return int32(r.h.Level)
+}
+
+func (r *HeadingBlockResolver) Align() *string {
+	return nullString(r.h.Align)
+}
+
+type ImageBlockResolver struct {
+	*BlockResolver
+	i blocks.Image
+}
+
+func (r *ImageBlockResolver) MediaID() *graphql.ID {
+	return nullID(r.i.ID)
+}
+
+func (r *ImageBlockResolver) URL() *string {
+	return nullString(r.i.URL)
+}
+
+func (r *ImageBlockResolver) Alt() string {
+	return r.i.Alt
+}
+
+func (r *ImageBlockResolver) Caption() string {
+	return r.i.Caption
+}
+
+func (r *ImageBlockResolver) Href() *string {
+	return nullString(r.i.Href)
+}
+
+func (r *ImageBlockResolver) SizeSlug() *string {
+	return nullString(r.i.SizeSlug)
+}
+
+func (r *ImageBlockResolver) LinkDestination() *string {
+	return nullString(r.i.LinkDestination)
+}
+
+func (r *ImageBlockResolver) Width() *int32 {
+	return nullInt(r.i.Width)
+}
+
+func (r *ImageBlockResolver) Height() *int32 {
+	return nullInt(r.i.Height)
+}
+
+type ListBlockResolver struct {
+	*BlockResolver
+	l blocks.List
+}
+
+func (r *ListBlockResolver) Ordered() bool {
+	return r.l.Ordered
+}
+
+func (r *ListBlockResolver) Values() string {
+	return r.l.Values
+}
+
+func (r *ListBlockResolver) Items() []string {
+	if r.l.Items == nil {
+		return []string{}
+	}
+	return r.l.Items
+}
+
+type QuoteBlockResolver struct {
+	*BlockResolver
+	q blocks.Quote
+}
+
+func (r *QuoteBlockResolver) Value() string {
+	return r.q.Value
+}
+
+func (r *QuoteBlockResolver) Citation() string {
+	return r.q.Citation
+}
+
+type GalleryBlockResolver struct {
+	*BlockResolver
+	g blocks.Gallery
+}
+
+func (r *GalleryBlockResolver) Columns() *int32 {
+	return nullInt(r.g.Columns)
+}
+
+func (r *GalleryBlockResolver) Images() []*GalleryImageResolver {
+
+	imageRxs := []*GalleryImageResolver{}
+
+	for _, image := range r.g.Images {
+		imageRxs = append(imageRxs, &GalleryImageResolver{image})
+	}
+
+	return imageRxs
+}
+
+/*
+ * GalleryImageResolver
+ *
+ * type GalleryImage {
+ * 	mediaID: ID
+ * 	url: String!
+ * 	alt: String!
+ * 	caption: String!
+ * }
+ */
+
+type GalleryImageResolver struct {
+	i blocks.Image
+}
+
+func (r *GalleryImageResolver) MediaID() *graphql.ID {
+	return nullID(r.i.ID)
+}
+
+func (r *GalleryImageResolver) URL() string {
+	return r.i.URL
+}
+
+func (r *GalleryImageResolver) Alt() string {
+	return r.i.Alt
+}
+
+func (r *GalleryImageResolver) Caption() string {
+	return r.i.Caption
+}
+
+type EmbedBlockResolver struct {
+	*BlockResolver
+	e blocks.Embed
+}
+
+func (r *EmbedBlockResolver) URL() *string {
+	return nullString(r.e.URL)
+}
+
+func (r *EmbedBlockResolver) Type() *string {
+	return nullString(r.e.Type)
+}
+
+func (r *EmbedBlockResolver) ProviderNameSlug() *string {
+	return nullString(r.e.ProviderNameSlug)
+}
+
+func (r *EmbedBlockResolver) Caption() string {
+	return r.e.Caption
+}
+
+func nullString(s string) *string {
+	if len(s) == 0 {
+		return nil
+	}
+	return &s
+}
+
+func nullInt(i int) *int32 {
+	if i == 0 {
+		return nil
+	}
+	v := int32(i)
+	return &v
+}
+
+func nullID(id int64) *graphql.ID {
+	if id <= 0 {
+		return nil
+	}
+	v := helper.IntToGraphqlID(id)
+	return &v
+}
@@ -0,0 +1,59 @@
+package resolver
+
+import (
+	"context"
+	"database/sql"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/model"
+)
+
+/*
+ * CommentResolver
+ *
+ * type Comment {
+ * 	commentID: ID!
+ * 	postID: ID!
+ * 	author: String!
+ * 	content: String!
+ * 	date: Time!
+ * 	post: Post
+ * }
+ */
+
+type CommentResolver struct {
+	C    *model.Comments
+	DB   *sql.DB
+	Root *RootResolver
+}
+
+func (r *CommentResolver) CommentID() graphql.ID {
+	return r.C.CommentID
+}
+
+func (r *CommentResolver) PostID() graphql.ID {
+	return r.C.CommentPostID
+}
+
+func (r *CommentResolver) Author() string {
+	return r.C.CommentAuthor
+}
+
+func (r *CommentResolver) Content() string {
+	return r.C.CommentContent
+}
+
+func (r *CommentResolver) Date() graphql.Time {
+	return graphql.Time{Time: r.C.CommentDateGMT}
+}
+
+func (r *CommentResolver) Post(ctx context.Context) (*PostResolver, error) {
+
+	postRx, err := r.Root.findPost(ctx, helper.GraphqlIDToInt(r.C.CommentPostID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return postRx, err
+}
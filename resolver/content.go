@@ -0,0 +1,76 @@
+package resolver
+
+import (
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/render"
+	"github.com/iyut/graphql-go/service"
+)
+
+// renderer returns the content renderer, wiring the loaders it needs from
+// the database on first use.
+func (r *RootResolver) renderer() *render.Renderer {
+
+	r.contentOnce.Do(func() {
+
+		if r.Content == nil {
+			r.Content = render.NewRenderer()
+		}
+
+		if r.Content.Attachments == nil {
+			r.Content.Attachments = r.attachments
+		}
+
+		if r.Content.ReusableBlock == nil {
+			r.Content.ReusableBlock = r.reusableBlock
+		}
+	})
+
+	return r.Content
+}
+
+// attachments loads the images shown by the gallery shortcode.
+func (r *RootResolver) attachments(ids []int64, parentID int64) ([]render.Attachment, error) {
+
+	var attachments []render.Attachment
+
+	postService := service.NewPostService(r.DB, TablePrefix)
+
+	posts, err := postService.GetAttachments(ids, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, post := range posts {
+
+		attachment := render.Attachment{
+			ID:      helper.GraphqlIDToInt(post.PostID),
+			URL:     post.GUID,
+			Caption: post.PostExcerpt,
+		}
+
+		metas, err := postService.GetMeta(post.PostID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, meta := range metas {
+			if meta.MetaKey == "_wp_attachment_image_alt" {
+				attachment.Alt = meta.MetaValue
+			}
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// contentFormat returns the requested ContentFormat, RENDERED by default.
+func contentFormat(format *string) string {
+
+	if format == nil {
+		return render.FormatRendered
+	}
+
+	return *format
+}
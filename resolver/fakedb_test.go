@@ -0,0 +1,130 @@
+package resolver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+)
+
+// fakeDB is a database/sql driver answering queries from a function and
+// recording every statement, for resolvers to be tested without MySQL.
+type fakeDB struct {
+	mu         sync.Mutex
+	statements []fakeStatement
+
+	// rows answers a query with its columns and rows, none when nil.
+	rows func(query string, args []driver.Value) ([]string, [][]driver.Value)
+}
+
+func (f *fakeDB) open() *sql.DB {
+
+	return sql.OpenDB(f)
+}
+
+// fakeStatement is a statement run against a fakeDB.
+type fakeStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// executed returns the recorded statements containing the fragment.
+func (f *fakeDB) executed(fragment string) []fakeStatement {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var matching []fakeStatement
+	for _, statement := range f.statements {
+		if strings.Contains(statement.query, fragment) {
+			matching = append(matching, statement)
+		}
+	}
+
+	return matching
+}
+
+func (f *fakeDB) record(query string, args []driver.Value) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.statements = append(f.statements, fakeStatement{query: query, args: args})
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, driver.ErrSkip }
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+
+	s.db.record(s.query, args)
+
+	return fakeResult{}, nil
+}
+
+// fakeResult is the result of every statement: one row, inserted as ID 1.
+type fakeResult struct{}
+
+func (fakeResult) LastInsertId() (int64, error) { return 1, nil }
+func (fakeResult) RowsAffected() (int64, error) { return 1, nil }
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+
+	s.db.record(s.query, args)
+
+	rows := &fakeRows{}
+	if s.db.rows != nil {
+		rows.columns, rows.rows = s.db.rows(s.query, args)
+	}
+
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package resolver
+
+import (
+	"context"
+	"sync"
+
+	"github.com/iyut/graphql-go/service"
+)
+
+// Loaders are the data loaders of one request. They load what many
+// resolvers of the request ask for, like the options every avatar reads,
+// once, and keep it for the request only. The operations of a batch share
+// them. Resolvers without loaders in their context load for themselves.
+type Loaders struct {
+	root *RootResolver
+
+	mu      sync.Mutex
+	options map[optionKey]*loadedOption
+}
+
+type optionKey struct {
+	name string
+	def  string
+}
+
+type loadedOption struct {
+	once  sync.Once
+	value string
+	err   error
+}
+
+type loadersKey struct{}
+
+// WithLoaders returns the context with a new set of loaders, for the
+// operations of one request.
+func (r *RootResolver) WithLoaders(ctx context.Context) context.Context {
+
+	loaders := &Loaders{root: r, options: make(map[optionKey]*loadedOption)}
+
+	return context.WithValue(ctx, loadersKey{}, loaders)
+}
+
+// loadersFrom returns the loaders of the request, nil when it has none.
+func loadersFrom(ctx context.Context) *Loaders {
+
+	loaders, _ := ctx.Value(loadersKey{}).(*Loaders)
+
+	return loaders
+}
+
+// option returns the value of an option, or def when it is not set, like
+// service.Option.Get, reading each option once per request.
+func (r *RootResolver) option(ctx context.Context, name string, def string) (string, error) {
+
+	loaders := loadersFrom(ctx)
+	if loaders == nil || loaders.root != r {
+		return service.NewOptionService(r.DB, TablePrefix).Get(name, def)
+	}
+
+	key := optionKey{name, def}
+
+	loaders.mu.Lock()
+	loaded, ok := loaders.options[key]
+	if !ok {
+		loaded = &loadedOption{}
+		loaders.options[key] = loaded
+	}
+	loaders.mu.Unlock()
+
+	loaded.once.Do(func() {
+		loaded.value, loaded.err = service.NewOptionService(r.DB, TablePrefix).Get(name, def)
+	})
+
+	return loaded.value, loaded.err
+}
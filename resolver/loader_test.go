@@ -0,0 +1,77 @@
+package resolver
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iyut/graphql-go/model"
+)
+
+// avatarOptions answers the option lookups of avatars.
+func avatarOptions(query string, args []driver.Value) ([]string, [][]driver.Value) {
+
+	if !strings.Contains(query, "option_name = ?") {
+		return nil, nil
+	}
+
+	values := map[string]string{"show_avatars": "1", "avatar_default": "identicon"}
+
+	value, ok := values[args[0].(string)]
+	if !ok {
+		return []string{"option_value"}, nil
+	}
+
+	return []string{"option_value"}, [][]driver.Value{{value}}
+}
+
+func TestLoadersShareOptions(t *testing.T) {
+
+	tests := []struct {
+		loaders   bool
+		wantReads int
+	}{
+		{false, 30},
+		{true, 3},
+	}
+
+	for _, tt := range tests {
+
+		fake := &fakeDB{rows: avatarOptions}
+		db := fake.open()
+
+		r := &RootResolver{DB: db}
+
+		ctx := context.Background()
+		if tt.loaders {
+			ctx = r.WithLoaders(ctx)
+		}
+
+		// The operations of a batch resolve avatars concurrently.
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				user := &UserResolver{U: &model.User{UserEmail: "jane@example.com"}, DB: db, Root: r}
+				avatar, err := user.Avatar(ctx, AvatarArgs{})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if avatar.Default() != "identicon" || avatar.Rating() != "G" {
+					t.Errorf("avatar default %q, rating %q", avatar.Default(), avatar.Rating())
+				}
+			}()
+		}
+		wg.Wait()
+
+		if reads := len(fake.executed("option_name = ?")); reads != tt.wantReads {
+			t.Errorf("loaders %t: %d option reads, want %d", tt.loaders, reads, tt.wantReads)
+		}
+
+		db.Close()
+	}
+}
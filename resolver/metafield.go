@@ -0,0 +1,174 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/metafield"
+)
+
+/*
+ * Meta fields
+ *
+ * extend type Post {
+ * 	<field>: <type>
+ * }
+ *
+ * The fields of the meta_fields settings have no method on PostResolver.
+ * MetaFieldFuncs resolves them, through graphql.FieldFuncs.
+ */
+
+// MetaFieldFuncs returns the functions resolving the configured meta fields
+// of Post, keyed as graphql.FieldFuncs takes them.
+func (r *RootResolver) MetaFieldFuncs() (map[string]interface{}, error) {
+
+	if r.MetaFields.Empty() {
+		return nil, nil
+	}
+
+	postType := reflect.TypeOf(&PostResolver{})
+
+	funcs := make(map[string]interface{})
+	for _, name := range r.MetaFields.Names() {
+
+		if hasMethod(postType, name) {
+			return nil, fmt.Errorf("meta field %q clashes with a field of Post", name)
+		}
+
+		funcs["Post."+name] = metaFieldFunc(name, r.MetaFields.Kind(name))
+	}
+
+	return funcs, nil
+}
+
+func metaFieldFunc(name string, kind string) interface{} {
+
+	switch kind {
+	case metafield.KindInt:
+		return func(r *PostResolver) (*int32, error) {
+			value, err := r.metaValue(name)
+			if v, ok := value.(int32); ok {
+				return &v, err
+			}
+			return nil, err
+		}
+	case metafield.KindFloat:
+		return func(r *PostResolver) (*float64, error) {
+			value, err := r.metaValue(name)
+			if v, ok := value.(float64); ok {
+				return &v, err
+			}
+			return nil, err
+		}
+	case metafield.KindBoolean:
+		return func(r *PostResolver) (*bool, error) {
+			value, err := r.metaValue(name)
+			if v, ok := value.(bool); ok {
+				return &v, err
+			}
+			return nil, err
+		}
+	case metafield.KindDateTime:
+		return func(r *PostResolver) (*graphql.Time, error) {
+			value, err := r.metaValue(name)
+			if v, ok := value.(time.Time); ok {
+				return &graphql.Time{Time: v}, err
+			}
+			return nil, err
+		}
+	case metafield.KindJSON:
+		return func(r *PostResolver) (*JSON, error) {
+			value, err := r.metaValue(name)
+			if value == nil {
+				return nil, err
+			}
+			return &JSON{Value: value}, err
+		}
+	case metafield.KindList:
+		return func(r *PostResolver) (*[]string, error) {
+			value, err := r.metaValue(name)
+			if v, ok := value.([]string); ok {
+				return &v, err
+			}
+			return nil, err
+		}
+	case metafield.KindPost:
+		return func(r *PostResolver, ctx context.Context) (*PostResolver, error) {
+			value, err := r.metaValue(name)
+			if err != nil {
+				return nil, err
+			}
+			return r.Root.referencedPost(ctx, value, "")
+		}
+	case metafield.KindMedia:
+		return func(r *PostResolver, ctx context.Context) (*PostResolver, error) {
+			value, err := r.metaValue(name)
+			if err != nil {
+				return nil, err
+			}
+			return r.Root.referencedPost(ctx, value, "attachment")
+		}
+	}
+
+	return func(r *PostResolver) (*string, error) {
+		value, err := r.metaValue(name)
+		if v, ok := value.(string); ok {
+			return &v, err
+		}
+		return nil, err
+	}
+}
+
+// metaValue returns the value of the meta field for the post, nil when the
+// field is not configured for its post type, the post has no such meta or
+// its value does not coerce to the field's kind, which is logged: a bad
+// value nulls its own field only.
+func (r *PostResolver) metaValue(name string) (interface{}, error) {
+
+	field, ok := r.Root.MetaFields.Lookup(r.P.PostType, name)
+	if !ok {
+		return nil, nil
+	}
+
+	postMeta, err := r.meta()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, meta := range postMeta {
+		if meta.MetaKey == field.MetaKey {
+
+			value, err := metafield.Coerce(field, meta.MetaValue)
+			if err != nil {
+				log.Printf("post %s: %v", r.P.PostID, err)
+				return nil, nil
+			}
+
+			return value, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// hasMethod tells whether the resolver type has a method graphql-go would
+// resolve the field with, matching as it does.
+func hasMethod(t reflect.Type, name string) bool {
+
+	for i := 0; i < t.NumMethod(); i++ {
+		if strings.EqualFold(stripUnderscore(name), stripUnderscore(t.Method(i).Name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func stripUnderscore(s string) string {
+	return strings.Replace(s, "_", "", -1)
+}
@@ -0,0 +1,56 @@
+package resolver
+
+import "github.com/iyut/graphql-go/helper"
+
+/*
+ * PageInfoResolver
+ *
+ * type PageInfo {
+ * 	hasNextPage: Boolean!
+ * 	hasPreviousPage: Boolean!
+ * 	startCursor: String
+ * 	endCursor: String
+ * }
+ */
+
+type PageInfoResolver struct {
+	startCursor     *string
+	endCursor       *string
+	hasNextPage     bool
+	hasPreviousPage bool
+}
+
+func (r *PageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+func (r *PageInfoResolver) HasPreviousPage() bool {
+	return r.hasPreviousPage
+}
+
+func (r *PageInfoResolver) StartCursor() *string {
+	return r.startCursor
+}
+
+func (r *PageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
+
+// newPageInfo describes a page of count items starting at offset out of
+// total, with offset cursors.
+func newPageInfo(offset int, count int, total int) *PageInfoResolver {
+
+	pageInfo := &PageInfoResolver{
+		hasPreviousPage: offset > 0,
+		hasNextPage:     offset+count < total,
+	}
+
+	if count > 0 {
+		startCursor := helper.EncodeCursor(offset)
+		endCursor := helper.EncodeCursor(offset + count - 1)
+		pageInfo.startCursor = &startCursor
+		pageInfo.endCursor = &endCursor
+	}
+
+	return pageInfo
+}
@@ -1,24 +1,44 @@
 package resolver
 
 import (
+	"context"
+	"crypto/subtle"
 	"database/sql"
+	"sync"
 
 	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/helper"
 	"github.com/iyut/graphql-go/model"
+	"github.com/iyut/graphql-go/phpass"
+	"github.com/iyut/graphql-go/service"
 )
 
 /*
  * PostResolver
  *
  * type Post {
- * 	PostID: ID!
+ * 	postID: ID!
  * 	title: String!
+ * 	content(format: ContentFormat, password: String): String
+ * 	excerpt(format: ContentFormat, password: String): String
+ * 	isPasswordProtected: Boolean!
+ * 	hasPasswordAccess(password: String): Boolean!
+ * 	previewToken: String
+ * 	blocks: [Block!]!
  * }
  */
 
 type PostResolver struct {
-	P  *model.Post
-	DB *sql.DB
+	P    *model.Post
+	DB   *sql.DB
+	Root *RootResolver
+
+	// The post's meta, loaded once for all its meta fields, which resolve
+	// concurrently.
+	metaOnce sync.Once
+	postMeta []*model.PostMeta
+	metaErr  error
 }
 
 func (r *PostResolver) PostID() graphql.ID {
@@ -28,3 +48,105 @@ func (r *PostResolver) PostID() graphql.ID {
 func (r *PostResolver) Title() string {
 	return r.P.PostTitle
 }
+
+type ContentArgs struct {
+	Format   *string
+	Password *string
+}
+
+func (r *PostResolver) Content(ctx context.Context, args ContentArgs) *string {
+
+	if !r.Root.hasPasswordAccess(ctx, r.P, args.Password) {
+		return nil
+	}
+
+	content := r.Root.renderer().Content(r.P, contentFormat(args.Format))
+
+	return &content
+}
+
+func (r *PostResolver) Excerpt(ctx context.Context, args ContentArgs) *string {
+
+	if !r.Root.hasPasswordAccess(ctx, r.P, args.Password) {
+		return nil
+	}
+
+	excerpt := r.Root.renderer().Excerpt(r.P, contentFormat(args.Format))
+
+	return &excerpt
+}
+
+func (r *PostResolver) IsPasswordProtected() bool {
+	return len(r.P.PostPassword) > 0
+}
+
+func (r *PostResolver) HasPasswordAccess(ctx context.Context, args struct{ Password *string }) bool {
+	return r.Root.hasPasswordAccess(ctx, r.P, args.Password)
+}
+
+// PreviewToken lets others read the post as a preview. Only viewers who can
+// edit the post get one.
+func (r *PostResolver) PreviewToken(ctx context.Context) *string {
+
+	if !auth.ViewerFrom(ctx).CanEditPost(r.P) {
+		return nil
+	}
+
+	token := r.Root.Auth.PreviewToken(helper.GraphqlIDToInt(r.P.PostID))
+
+	return &token
+}
+
+// Blocks are empty for password protected posts the viewer has no access to.
+func (r *PostResolver) Blocks(ctx context.Context) ([]*BlockResolver, error) {
+
+	if !r.Root.hasPasswordAccess(ctx, r.P, nil) {
+		return []*BlockResolver{}, nil
+	}
+
+	return r.Root.parseBlocks(r.P.PostContent)
+}
+
+// hasPasswordAccess is the inverse of post_password_required(), also letting
+// through viewers who can edit the post. The password is checked against the
+// argument or else the hashed wp-postpass cookie.
+func (r *RootResolver) hasPasswordAccess(ctx context.Context, post *model.Post, password *string) bool {
+
+	if len(post.PostPassword) == 0 {
+		return true
+	}
+
+	viewer := auth.ViewerFrom(ctx)
+
+	if viewer.CanEditPost(post) {
+		return true
+	}
+
+	if password != nil {
+		return subtle.ConstantTimeCompare([]byte(*password), []byte(post.PostPassword)) == 1
+	}
+
+	if len(viewer.PostPass) > 0 {
+		return phpass.Check(post.PostPassword, viewer.PostPass)
+	}
+
+	return false
+}
+
+// meta returns the post's meta, loading it on first use.
+func (r *PostResolver) meta() ([]*model.PostMeta, error) {
+
+	r.metaOnce.Do(func() {
+
+		if r.P.PostMeta != nil {
+			r.postMeta = r.P.PostMeta
+			return
+		}
+
+		postService := service.NewPostService(r.DB, TablePrefix)
+
+		r.postMeta, r.metaErr = postService.GetMeta(r.P.PostID)
+	})
+
+	return r.postMeta, r.metaErr
+}
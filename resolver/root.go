@@ -1,171 +1,189 @@
 package resolver
 
 import (
+	"context"
 	"database/sql"
-	"strconv"
+	"sync"
 
 	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/events"
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/mail"
+	"github.com/iyut/graphql-go/metafield"
 	"github.com/iyut/graphql-go/model"
+	"github.com/iyut/graphql-go/render"
 	"github.com/iyut/graphql-go/service"
+	"github.com/iyut/graphql-go/webhook"
 )
 
+//go:generate go run .. generate -schema ../main-schema.graphql -dir . -models ../model
+
 /*
  * RootResolver
  *
- * type User {
- * 	userID: ID!
- * 	username: String!
- * 	emoji: String!
- * 	notes: [Note!]!
- * }
+ * resolves the fields of Query, Mutation and Subscription, which
+ * QueryFields, MutationFields and SubscriptionFields of schema_gen.go list.
  */
 type RootResolver struct {
-	DB *sql.DB
+	DB         *sql.DB
+	MetaFields *metafield.Registry
+	Searcher   service.Searcher
+	Content    *render.Renderer
+	Auth       *auth.Authenticator
+	Mailer     mail.Mailer
+	Events     *events.Bus
+	Webhooks   *webhook.Dispatcher
+	MailFrom   string
+	ResetURL   string
+
+	// UserMetaKeys are user meta keys editable on top of EditableUserMeta.
+	UserMetaKeys []string
+
+	contentOnce sync.Once
 }
 
-func (r *RootResolver) Users() ([]*UserResolver, error) {
-
-	var userRxs []*UserResolver
+const TablePrefix = "wpa_"
 
-	userService := service.NewUserService(r.DB, "wpa_")
+// User returns the user when the viewer may see them, by the rule of Users:
+// viewers without list_users only see themselves and users with published
+// posts. Other users answer like missing ones.
+func (r *RootResolver) User(ctx context.Context, args struct{ UserID graphql.ID }) (*UserResolver, error) {
 
-	argsUser := service.ArgsUser{}
-	users, err := userService.GetUsers(argsUser)
+	userID := helper.GraphqlIDToInt(args.UserID)
+	viewer := auth.ViewerFrom(ctx)
 
-	if err != nil {
-		return nil, err
+	argsUser := service.ArgsUser{UserID: userID}
+	if !viewer.Can("list_users") && viewer.UserID != userID {
+		argsUser.PublishedPosts = true
 	}
 
-	for _, user := range users {
-		userRxs = append(userRxs, &UserResolver{U: user, DB: r.DB})
+	return r.findUser(argsUser)
+}
+
+// findUser returns the user matching args without checking whether the
+// viewer may see them.
+func (r *RootResolver) findUser(args service.ArgsUser) (*UserResolver, error) {
+
+	if args.UserID <= 0 {
+		return nil, sql.ErrNoRows
 	}
-	/*
-		rows, err := r.DB.Query(`
-			SELECT
-				ID,
-				user_login,
-				user_email
-			FROM
-				wpa_users
-		`)
 
-		if err != nil {
-			panic(err)
-		}
+	userService := service.NewUserService(r.DB, TablePrefix)
 
-		defer rows.Close()
+	users, err := userService.GetUsers(args)
+	if err != nil {
+		return nil, err
+	}
 
-		for rows.Next() {
+	if len(users) == 0 {
+		return nil, sql.ErrNoRows
+	}
 
-			user := &model.User{}
-			err := rows.Scan(&user.UserID, &user.Username, &user.Email)
+	return &UserResolver{U: users[0], DB: r.DB, Root: r}, nil
+}
 
-			if err != nil {
-				return nil, err
-			}
+func (r *RootResolver) Posts(ctx context.Context, args struct{ UserID graphql.ID }) ([]*PostResolver, error) {
 
-			userRxs = append(userRxs, &UserResolver{U: user, DB: r.DB})
-		}
+	var postRxs []*PostResolver
 
-		err = rows.Err()
-		if err != nil {
-			return nil, err
-		}
-	*/
+	postService := service.NewPostService(r.DB, TablePrefix)
 
-	return userRxs, nil
-}
+	argsPost := service.ArgsPost{AuthorID: helper.GraphqlIDToInt(args.UserID), Visibility: r.visibility(ctx)}
+	posts, err := postService.GetPosts(argsPost)
 
-func (r *RootResolver) User(args struct{ UserID graphql.ID }) (*UserResolver, error) {
-
-	var useridInt int64
-	user := &model.User{}
-	err := r.DB.QueryRow(`
-		SELECT
-			ID,
-			user_login,
-			user_email
-		FROM
-			wpa_users
-		WHERE
-			ID = ?
-	`, args.UserID).Scan(&useridInt, &user.Username, &user.Email)
-
-	useridStr := strconv.FormatInt(useridInt, 10)
-	user.UserID = graphql.ID(useridStr)
 	if err != nil {
 		return nil, err
 	}
 
-	return &UserResolver{U: user, DB: r.DB}, nil
+	for _, post := range posts {
+		postRxs = append(postRxs, &PostResolver{P: post, DB: r.DB, Root: r})
+	}
+
+	return postRxs, nil
 }
 
-func (r *RootResolver) Posts(args struct{ UserID graphql.ID }) ([]*PostResolver, error) {
+type PostArgs struct {
+	PostID       graphql.ID
+	AsPreview    *bool
+	PreviewToken *string
+}
 
-	var postIDInt int64
-	var postRxs []*PostResolver
-	rows, err := r.DB.Query(`
-		SELECT
-			ID,
-			post_title
-		FROM
-			wpa_posts
-		WHERE
-			post_author = ?
-	`, args.UserID)
+// Post returns a post the viewer may read. As a preview, drafts are readable
+// with a preview token, and viewers who can edit the post or hold a token see
+// its latest autosave.
+func (r *RootResolver) Post(ctx context.Context, args PostArgs) (*PostResolver, error) {
 
-	if err != nil {
-		return nil, err
+	postID := helper.GraphqlIDToInt(args.PostID)
+	visibility := r.visibility(ctx)
+
+	asPreview := args.AsPreview != nil && *args.AsPreview
+	if asPreview && args.PreviewToken != nil && r.Auth.CheckPreviewToken(postID, *args.PreviewToken) {
+		visibility.PreviewID = postID
 	}
 
-	defer rows.Close()
+	postService := service.NewPostService(r.DB, TablePrefix)
 
-	for rows.Next() {
+	post, err := postService.FindByID(postID, visibility)
+	if err != nil {
+		return nil, err
+	}
 
-		post := &model.Post{}
-		err := rows.Scan(&postIDInt, &post.PostTitle)
+	if asPreview && (visibility.PreviewID == postID || auth.ViewerFrom(ctx).CanEditPost(post)) {
 
+		autosave, err := postService.FindAutosave(postID)
 		if err != nil {
 			return nil, err
 		}
 
-		postIDStr := strconv.FormatInt(postIDInt, 10)
-		post.PostID = graphql.ID(postIDStr)
-
-		postRxs = append(postRxs, &PostResolver{P: post, DB: r.DB})
+		if autosave != nil && autosave.PostModifiedGMT.After(post.PostModifiedGMT) {
+			preview := *post
+			preview.PostTitle = autosave.PostTitle
+			preview.PostContent = autosave.PostContent
+			preview.PostExcerpt = autosave.PostExcerpt
+			post = &preview
+		}
 	}
 
-	err = rows.Err()
+	return &PostResolver{P: post, DB: r.DB, Root: r}, nil
+
+}
+
+// findPost resolves a post the viewer may read, or returns sql.ErrNoRows.
+func (r *RootResolver) findPost(ctx context.Context, postID int64) (*PostResolver, error) {
+
+	postService := service.NewPostService(r.DB, TablePrefix)
+
+	post, err := postService.FindByID(postID, r.visibility(ctx))
 	if err != nil {
 		return nil, err
 	}
 
-	return postRxs, nil
+	return &PostResolver{P: post, DB: r.DB, Root: r}, nil
 }
 
-func (r *RootResolver) Post(args struct{ PostID graphql.ID }) (*PostResolver, error) {
+// referencedPost resolves a post ID stored in meta, returning nil when the
+// reference is empty, dangling or not of the expected post type.
+func (r *RootResolver) referencedPost(ctx context.Context, value interface{}, postType string) (*PostResolver, error) {
 
-	var postIDInt int64
-	post := &model.Post{}
-	err := r.DB.QueryRow(`
-		SELECT
-			ID,
-			post_title
-		FROM
-			wpa_posts
-		WHERE
-			ID = ?
-	`, args.PostID).Scan(&postIDInt, &post.PostTitle)
+	postID, ok := value.(int64)
+	if !ok || postID <= 0 {
+		return nil, nil
+	}
 
+	postRx, err := r.findPost(ctx, postID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	postIDStr := strconv.FormatInt(postIDInt, 10)
-	post.PostID = graphql.ID(postIDStr)
-
-	return &PostResolver{P: post, DB: r.DB}, nil
+	if len(postType) > 0 && postRx.P.PostType != postType {
+		return nil, nil
+	}
 
+	return postRx, nil
 }
 
 type CreatePostArgs struct {
@@ -173,7 +191,19 @@ type CreatePostArgs struct {
 	Post   model.PostInput
 }
 
-func (r *RootResolver) CreatePost(args CreatePostArgs) (*PostResolver, error) {
+// CreatePost inserts a published post, which takes publish_posts, and
+// edit_others_posts to write it in the name of another author.
+func (r *RootResolver) CreatePost(ctx context.Context, args CreatePostArgs) (*PostResolver, error) {
+
+	viewer := auth.ViewerFrom(ctx)
+
+	if !viewer.Can("publish_posts") {
+		return nil, ErrForbidden
+	}
+
+	if helper.GraphqlIDToInt(args.UserID) != viewer.UserID && !viewer.Can("edit_others_posts") {
+		return nil, ErrForbidden
+	}
 
 	res, err := r.DB.Exec(`
 		INSERT INTO wpa_posts (
@@ -191,8 +221,26 @@ func (r *RootResolver) CreatePost(args CreatePostArgs) (*PostResolver, error) {
 		return nil, err
 	}
 
-	postIDStr := strconv.FormatInt(lastid, 10)
+	postRx, err := r.findPost(ctx, lastid)
+	if err != nil {
+		return nil, err
+	}
+
+	r.publish(events.PostCreated, lastid, 0)
+	if postRx.P.PostStatus == "publish" {
+		r.publish(events.PostPublished, lastid, 0)
+	}
+
+	return postRx, nil
 
-	return r.Post(struct{ PostID graphql.ID }{PostID: graphql.ID(postIDStr)})
+}
+
+type LoginArgs struct {
+	Username string
+	Password string
+}
 
+// Login returns a bearer token for the Authorization header.
+func (r *RootResolver) Login(args LoginArgs) (string, error) {
+	return r.Auth.Login(args.Username, args.Password)
 }
@@ -0,0 +1,51 @@
+package resolver
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/model"
+)
+
+func TestCreatePostCapability(t *testing.T) {
+
+	tests := []struct {
+		role   string
+		userID string
+		want   error
+	}{
+		{"administrator", "2", nil},
+		{"editor", "2", nil},
+		{"author", "2", nil},
+		{"author", "3", ErrForbidden},
+		{"contributor", "2", ErrForbidden},
+		{"subscriber", "2", ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		fake := &fakeDB{}
+		db := fake.open()
+
+		r := &RootResolver{DB: db}
+		ctx := auth.WithViewer(context.Background(), &auth.Viewer{UserID: 2, Roles: []string{tt.role}})
+
+		_, err := r.CreatePost(ctx, CreatePostArgs{UserID: graphql.ID(tt.userID), Post: model.PostInput{Title: "Hello"}})
+
+		// The fake database has no rows to read the new post back from.
+		want := tt.want
+		if want == nil {
+			want = sql.ErrNoRows
+		}
+
+		inserted := len(fake.executed("INSERT INTO wpa_posts")) > 0
+
+		if err != want || inserted != (tt.want == nil) {
+			t.Errorf("%s creating a post for user %s: %v, inserted %t, want %v", tt.role, tt.userID, err, inserted, tt.want)
+		}
+
+		db.Close()
+	}
+}
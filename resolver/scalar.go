@@ -0,0 +1,30 @@
+package resolver
+
+import (
+	"encoding/json"
+)
+
+/*
+ * JSON
+ *
+ * scalar JSON
+ */
+
+// JSON is an arbitrary JSON value, for data that has no fixed shape such as
+// unserialized meta arrays.
+type JSON struct {
+	Value interface{}
+}
+
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+func (j *JSON) UnmarshalGraphQL(input interface{}) error {
+	j.Value = input
+	return nil
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Value)
+}
@@ -0,0 +1,644 @@
+// Code generated by graphql-go generate. DO NOT EDIT.
+
+package resolver
+
+import (
+	"context"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// AvatarFields are the methods of AvatarResolver resolving Avatar:
+//
+//	type Avatar {
+//	  url: String!
+//	  size: Int!
+//	  default: String!
+//	  rating: AvatarRating!
+//	}
+type AvatarFields interface {
+	URL() string
+	Size() int32
+	Default() string
+	Rating() string
+}
+
+var _ AvatarFields = (*AvatarResolver)(nil)
+
+// AvatarRating is enum AvatarRating.
+type AvatarRating string
+
+const (
+	AvatarRatingG  AvatarRating = "G"
+	AvatarRatingPg AvatarRating = "PG"
+	AvatarRatingR  AvatarRating = "R"
+	AvatarRatingX  AvatarRating = "X"
+)
+
+// BlockFields are the methods of BlockResolver resolving Block:
+//
+//	interface Block {
+//	  name: String
+//	  attributes: JSON!
+//	  innerHTML: String!
+//	  innerBlocks: [Block!]!
+//	}
+type BlockFields interface {
+	Name() *string
+	Attributes() JSON
+	InnerHTML() string
+	InnerBlocks() []*BlockResolver
+	ToParagraphBlock() (*ParagraphBlockResolver, bool)
+	ToHeadingBlock() (*HeadingBlockResolver, bool)
+	ToImageBlock() (*ImageBlockResolver, bool)
+	ToListBlock() (*ListBlockResolver, bool)
+	ToQuoteBlock() (*QuoteBlockResolver, bool)
+	ToGalleryBlock() (*GalleryBlockResolver, bool)
+	ToEmbedBlock() (*EmbedBlockResolver, bool)
+	ToGenericBlock() (*BlockResolver, bool)
+}
+
+var _ BlockFields = (*BlockResolver)(nil)
+
+// CacheControlScope is enum CacheControlScope.
+type CacheControlScope string
+
+const (
+	CacheControlScopePublic  CacheControlScope = "PUBLIC"
+	CacheControlScopePrivate CacheControlScope = "PRIVATE"
+)
+
+// CommentFields are the methods of CommentResolver resolving Comment:
+//
+//	type Comment {
+//	  commentID: ID!
+//	  postID: ID!
+//	  author: String!
+//	  content: String!
+//	  date: Time!
+//	  post: Post
+//	}
+type CommentFields interface {
+	CommentID() graphql.ID
+	PostID() graphql.ID
+	Author() string
+	Content() string
+	Date() graphql.Time
+	Post(ctx context.Context) (*PostResolver, error)
+}
+
+var _ CommentFields = (*CommentResolver)(nil)
+
+// ContentFormat is enum ContentFormat.
+type ContentFormat string
+
+const (
+	ContentFormatRendered ContentFormat = "RENDERED"
+	ContentFormatRaw      ContentFormat = "RAW"
+)
+
+// EmbedBlockFields are the methods of EmbedBlockResolver resolving EmbedBlock:
+//
+//	type EmbedBlock implements Block {
+//	  name: String
+//	  attributes: JSON!
+//	  innerHTML: String!
+//	  innerBlocks: [Block!]!
+//	  url: String
+//	  type: String
+//	  providerNameSlug: String
+//	  caption: String!
+//	}
+type EmbedBlockFields interface {
+	Name() *string
+	Attributes() JSON
+	InnerHTML() string
+	InnerBlocks() []*BlockResolver
+	URL() *string
+	Type() *string
+	ProviderNameSlug() *string
+	Caption() string
+}
+
+var _ EmbedBlockFields = (*EmbedBlockResolver)(nil)
+
+// GalleryBlockFields are the methods of GalleryBlockResolver resolving GalleryBlock:
+//
+//	type GalleryBlock implements Block {
+//	  name: String
+//	  attributes: JSON!
+//	  innerHTML: String!
+//	  innerBlocks: [Block!]!
+//	  columns: Int
+//	  images: [GalleryImage!]!
+//	}
+type GalleryBlockFields interface {
+	Name() *string
+	Attributes() JSON
+	InnerHTML() string
+	InnerBlocks() []*BlockResolver
+	Columns() *int32
+	Images() []*GalleryImageResolver
+}
+
+var _ GalleryBlockFields = (*GalleryBlockResolver)(nil)
+
+// GalleryImageFields are the methods of GalleryImageResolver resolving GalleryImage:
+//
+//	type GalleryImage {
+//	  mediaID: ID
+//	  url: String!
+//	  alt: String!
+//	  caption: String!
+//	}
+type GalleryImageFields interface {
+	MediaID() *graphql.ID
+	URL() string
+	Alt() string
+	Caption() string
+}
+
+var _ GalleryImageFields = (*GalleryImageResolver)(nil)
+
+// GenericBlockFields are the methods of BlockResolver resolving GenericBlock:
+//
+//	type GenericBlock implements Block {
+//	  name: String
+//	  attributes: JSON!
+//	  innerHTML: String!
+//	  innerBlocks: [Block!]!
+//	}
+type GenericBlockFields interface {
+	Name() *string
+	Attributes() JSON
+	InnerHTML() string
+	InnerBlocks() []*BlockResolver
+}
+
+var _ GenericBlockFields = (*BlockResolver)(nil)
+
+// HeadingBlockFields are the methods of HeadingBlockResolver resolving HeadingBlock:
+//
+//	type HeadingBlock implements Block {
+//	  name: String
+//	  attributes: JSON!
+//	  innerHTML: String!
+//	  innerBlocks: [Block!]!
+//	  content: String!
+//	  level: Int!
+//	  align: String
+//	}
+type HeadingBlockFields interface {
+	Name() *string
+	Attributes() JSON
+	InnerHTML() string
+	InnerBlocks() []*BlockResolver
+	Content() string
+	Level() int32
+	Align() *string
+}
+
+var _ HeadingBlockFields = (*HeadingBlockResolver)(nil)
+
+// ImageBlockFields are the methods of ImageBlockResolver resolving ImageBlock:
+//
+//	type ImageBlock implements Block {
+//	  name: String
+//	  attributes: JSON!
+//	  innerHTML: String!
+//	  innerBlocks: [Block!]!
+//	  mediaID: ID
+//	  url: String
+//	  alt: String!
+//	  caption: String!
+//	  href: String
+//	  sizeSlug: String
+//	  linkDestination: String
+//	  width: Int
+//	  height: Int
+//	}
+type ImageBlockFields interface {
+	Name() *string
+	Attributes() JSON
+	InnerHTML() string
+	InnerBlocks() []*BlockResolver
+	MediaID() *graphql.ID
+	URL() *string
+	Alt() string
+	Caption() string
+	Href() *string
+	SizeSlug() *string
+	LinkDestination() *string
+	Width() *int32
+	Height() *int32
+}
+
+var _ ImageBlockFields = (*ImageBlockResolver)(nil)
+
+// ListBlockFields are the methods of ListBlockResolver resolving ListBlock:
+//
+//	type ListBlock implements Block {
+//	  name: String
+//	  attributes: JSON!
+//	  innerHTML: String!
+//	  innerBlocks: [Block!]!
+//	  ordered: Boolean!
+//	  values: String!
+//	  items: [String!]!
+//	}
+type ListBlockFields interface {
+	Name() *string
+	Attributes() JSON
+	InnerHTML() string
+	InnerBlocks() []*BlockResolver
+	Ordered() bool
+	Values() string
+	Items() []string
+}
+
+var _ ListBlockFields = (*ListBlockResolver)(nil)
+
+// MutationFields are the methods of RootResolver resolving Mutation:
+//
+//	type Mutation {
+//	  createPost(userID: ID!, post: PostInput!): Post!
+//	  login(username: String!, password: String!): String!
+//	  createUser(input: CreateUserInput!): User!
+//	  updateUser(userID: ID!, input: UpdateUserInput!): User!
+//	  deleteUser(userID: ID!, reassignTo: ID): Boolean!
+//	  sendPasswordResetEmail(username: String!): Boolean!
+//	  resetUserPassword(key: String!, login: String!, password: String!): Boolean!
+//	  updateUserMeta(userID: ID!, key: String!, value: String!): User!
+//	  deleteUserMeta(userID: ID!, key: String!): User!
+//	}
+type MutationFields interface {
+	CreatePost(ctx context.Context, args CreatePostArgs) (*PostResolver, error)
+	Login(args LoginArgs) (string, error)
+	CreateUser(ctx context.Context, args struct{ Input CreateUserInput }) (*UserResolver, error)
+	UpdateUser(ctx context.Context, args struct {
+		UserID graphql.ID
+		Input  UpdateUserInput
+	}) (*UserResolver, error)
+	DeleteUser(ctx context.Context, args struct {
+		UserID     graphql.ID
+		ReassignTo *graphql.ID
+	}) (bool, error)
+	SendPasswordResetEmail(args struct{ Username string }) (bool, error)
+	ResetUserPassword(args struct {
+		Key      string
+		Login    string
+		Password string
+	}) (bool, error)
+	UpdateUserMeta(ctx context.Context, args UserMetaArgs) (*UserResolver, error)
+	DeleteUserMeta(ctx context.Context, args struct {
+		UserID graphql.ID
+		Key    string
+	}) (*UserResolver, error)
+}
+
+var _ MutationFields = (*RootResolver)(nil)
+
+// OrderDirection is enum OrderDirection.
+type OrderDirection string
+
+const (
+	OrderDirectionAsc  OrderDirection = "ASC"
+	OrderDirectionDesc OrderDirection = "DESC"
+)
+
+// PageInfoFields are the methods of PageInfoResolver resolving PageInfo:
+//
+//	type PageInfo {
+//	  hasNextPage: Boolean!
+//	  hasPreviousPage: Boolean!
+//	  startCursor: String
+//	  endCursor: String
+//	}
+type PageInfoFields interface {
+	HasNextPage() bool
+	HasPreviousPage() bool
+	StartCursor() *string
+	EndCursor() *string
+}
+
+var _ PageInfoFields = (*PageInfoResolver)(nil)
+
+// ParagraphBlockFields are the methods of ParagraphBlockResolver resolving ParagraphBlock:
+//
+//	type ParagraphBlock implements Block {
+//	  name: String
+//	  attributes: JSON!
+//	  innerHTML: String!
+//	  innerBlocks: [Block!]!
+//	  content: String!
+//	  align: String
+//	  dropCap: Boolean!
+//	}
+type ParagraphBlockFields interface {
+	Name() *string
+	Attributes() JSON
+	InnerHTML() string
+	InnerBlocks() []*BlockResolver
+	Content() string
+	Align() *string
+	DropCap() bool
+}
+
+var _ ParagraphBlockFields = (*ParagraphBlockResolver)(nil)
+
+// PostFields are the methods of PostResolver resolving Post:
+//
+//	type Post {
+//	  postID: ID!
+//	  title: String!
+//	  content(format: ContentFormat, password: String): String
+//	  excerpt(format: ContentFormat, password: String): String
+//	  isPasswordProtected: Boolean!
+//	  hasPasswordAccess(password: String): Boolean!
+//	  previewToken: String
+//	  blocks: [Block!]!
+//	}
+type PostFields interface {
+	PostID() graphql.ID
+	Title() string
+	Content(ctx context.Context, args ContentArgs) *string
+	Excerpt(ctx context.Context, args ContentArgs) *string
+	IsPasswordProtected() bool
+	HasPasswordAccess(ctx context.Context, args struct{ Password *string }) bool
+	PreviewToken(ctx context.Context) *string
+	Blocks(ctx context.Context) ([]*BlockResolver, error)
+}
+
+var _ PostFields = (*PostResolver)(nil)
+
+// QueryFields are the methods of RootResolver resolving Query:
+//
+//	type Query {
+//	  users(where: UsersWhere, orderBy: UsersOrderBy, first: Int, after: String): UserConnection!
+//	  user(userID: ID!): User!
+//	  userMetas(userID: ID!): [UserMeta!]!
+//	  userMeta(uMetaID: ID!): UserMeta!
+//	  posts(userID: ID!): [Post!]!
+//	  post(postID: ID!, asPreview: Boolean, previewToken: String): Post!
+//	  search(query: String!, types: [SearchType!], first: Int, after: String): SearchConnection!
+//	  webhookDeliveries(status: WebhookDeliveryStatus, endpoint: String, first: Int): [WebhookDelivery!]!
+//	}
+type QueryFields interface {
+	Users(ctx context.Context, args UsersArgs) (*UserConnectionResolver, error)
+	User(ctx context.Context, args struct{ UserID graphql.ID }) (*UserResolver, error)
+	UserMetas(ctx context.Context, args struct{ UserID graphql.ID }) ([]*UserMetaResolver, error)
+	UserMeta(ctx context.Context, args struct{ UMetaID graphql.ID }) (*UserMetaResolver, error)
+	Posts(ctx context.Context, args struct{ UserID graphql.ID }) ([]*PostResolver, error)
+	Post(ctx context.Context, args PostArgs) (*PostResolver, error)
+	Search(ctx context.Context, args SearchArgs) (*SearchConnectionResolver, error)
+	WebhookDeliveries(ctx context.Context, args WebhookDeliveriesArgs) ([]*WebhookDeliveryResolver, error)
+}
+
+var _ QueryFields = (*RootResolver)(nil)
+
+// QuoteBlockFields are the methods of QuoteBlockResolver resolving QuoteBlock:
+//
+//	type QuoteBlock implements Block {
+//	  name: String
+//	  attributes: JSON!
+//	  innerHTML: String!
+//	  innerBlocks: [Block!]!
+//	  value: String!
+//	  citation: String!
+//	}
+type QuoteBlockFields interface {
+	Name() *string
+	Attributes() JSON
+	InnerHTML() string
+	InnerBlocks() []*BlockResolver
+	Value() string
+	Citation() string
+}
+
+var _ QuoteBlockFields = (*QuoteBlockResolver)(nil)
+
+// SearchConnectionFields are the methods of SearchConnectionResolver resolving SearchConnection:
+//
+//	type SearchConnection {
+//	  totalCount: Int!
+//	  edges: [SearchEdge!]!
+//	  pageInfo: PageInfo!
+//	}
+type SearchConnectionFields interface {
+	TotalCount() int32
+	Edges() []*SearchEdgeResolver
+	PageInfo() *PageInfoResolver
+}
+
+var _ SearchConnectionFields = (*SearchConnectionResolver)(nil)
+
+// SearchEdgeFields are the methods of SearchEdgeResolver resolving SearchEdge:
+//
+//	type SearchEdge {
+//	  cursor: String!
+//	  score: Float!
+//	  node: SearchResult!
+//	  highlights: [SearchHighlight!]!
+//	}
+type SearchEdgeFields interface {
+	Cursor() string
+	Score() float64
+	Node() *SearchResultResolver
+	Highlights(ctx context.Context) []*SearchHighlightResolver
+}
+
+var _ SearchEdgeFields = (*SearchEdgeResolver)(nil)
+
+// SearchHighlightFields are the methods of SearchHighlightResolver resolving SearchHighlight:
+//
+//	type SearchHighlight {
+//	  field: String!
+//	  snippet: String!
+//	}
+type SearchHighlightFields interface {
+	Field() string
+	Snippet() string
+}
+
+var _ SearchHighlightFields = (*SearchHighlightResolver)(nil)
+
+// SearchResultFields are the methods of SearchResultResolver resolving SearchResult:
+//
+//	union SearchResult = Post | Comment
+type SearchResultFields interface {
+	ToPost() (*PostResolver, bool)
+	ToComment() (*CommentResolver, bool)
+}
+
+var _ SearchResultFields = (*SearchResultResolver)(nil)
+
+// SearchType is enum SearchType.
+type SearchType string
+
+const (
+	SearchTypePost    SearchType = "POST"
+	SearchTypePage    SearchType = "PAGE"
+	SearchTypeComment SearchType = "COMMENT"
+)
+
+// SubscriptionFields are the methods of RootResolver resolving Subscription:
+//
+//	type Subscription {
+//	  postPublished: Post!
+//	  postUpdated(id: ID): Post!
+//	  commentAdded(postID: ID): Comment!
+//	}
+type SubscriptionFields interface {
+	PostPublished(ctx context.Context) (<-chan *PostResolver, error)
+	PostUpdated(ctx context.Context, args struct{ ID *graphql.ID }) (<-chan *PostResolver, error)
+	CommentAdded(ctx context.Context, args struct{ PostID *graphql.ID }) (<-chan *CommentResolver, error)
+}
+
+var _ SubscriptionFields = (*RootResolver)(nil)
+
+// UserFields are the methods of UserResolver resolving User:
+//
+//	type User {
+//	  userID: ID!
+//	  username: String!
+//	  email: String
+//	  nicename: String!
+//	  status: Int!
+//	  displayName: String!
+//	  firstName: String
+//	  lastName: String
+//	  nickname: String
+//	  description: String
+//	  url: String
+//	  registeredDate: Time!
+//	  roles: [String!]!
+//	  meta(keys: [String!]): [UserMeta!]!
+//	  avatar(size: Int, default: String, rating: AvatarRating): Avatar
+//	  posts: [Post!]!
+//	}
+type UserFields interface {
+	UserID() graphql.ID
+	Username() string
+	Email(ctx context.Context) *string
+	Nicename() string
+	Status() int32
+	DisplayName() string
+	FirstName() *string
+	LastName() *string
+	Nickname() *string
+	Description() *string
+	URL() *string
+	RegisteredDate() graphql.Time
+	Roles() ([]string, error)
+	Meta(args struct{ Keys *[]string }) []*UserMetaResolver
+	Avatar(ctx context.Context, args AvatarArgs) (*AvatarResolver, error)
+	Posts(ctx context.Context) ([]*PostResolver, error)
+}
+
+var _ UserFields = (*UserResolver)(nil)
+
+// UserConnectionFields are the methods of UserConnectionResolver resolving UserConnection:
+//
+//	type UserConnection {
+//	  totalCount: Int!
+//	  edges: [UserEdge!]!
+//	  nodes: [User!]!
+//	  pageInfo: PageInfo!
+//	}
+type UserConnectionFields interface {
+	TotalCount() int32
+	Edges() []*UserEdgeResolver
+	Nodes() []*UserResolver
+	PageInfo() *PageInfoResolver
+}
+
+var _ UserConnectionFields = (*UserConnectionResolver)(nil)
+
+// UserEdgeFields are the methods of UserEdgeResolver resolving UserEdge:
+//
+//	type UserEdge {
+//	  cursor: String!
+//	  node: User!
+//	}
+type UserEdgeFields interface {
+	Cursor() string
+	Node() *UserResolver
+}
+
+var _ UserEdgeFields = (*UserEdgeResolver)(nil)
+
+// UserMetaFields are the methods of UserMetaResolver resolving UserMeta:
+//
+//	type UserMeta {
+//	  uMetaID: ID!
+//	  userID: ID!
+//	  metaKey: String!
+//	  metaValue: String!
+//	}
+type UserMetaFields interface {
+	UMetaID() graphql.ID
+	UserID() graphql.ID
+	MetaKey() string
+	MetaValue() string
+}
+
+var _ UserMetaFields = (*UserMetaResolver)(nil)
+
+// UsersOrderField is enum UsersOrderField.
+type UsersOrderField string
+
+const (
+	UsersOrderFieldID          UsersOrderField = "ID"
+	UsersOrderFieldLogin       UsersOrderField = "LOGIN"
+	UsersOrderFieldNicename    UsersOrderField = "NICENAME"
+	UsersOrderFieldEmail       UsersOrderField = "EMAIL"
+	UsersOrderFieldDisplayName UsersOrderField = "DISPLAY_NAME"
+	UsersOrderFieldRegistered  UsersOrderField = "REGISTERED"
+	UsersOrderFieldPostCount   UsersOrderField = "POST_COUNT"
+)
+
+// WebhookDeliveryFields are the methods of WebhookDeliveryResolver resolving WebhookDelivery:
+//
+//	type WebhookDelivery {
+//	  id: ID!
+//	  endpoint: String!
+//	  url: String!
+//	  event: String!
+//	  objectID: ID!
+//	  postID: ID
+//	  site: String
+//	  status: WebhookDeliveryStatus!
+//	  attempts: Int!
+//	  lastStatusCode: Int
+//	  lastError: String
+//	  createdAt: Time!
+//	  nextAttemptAt: Time
+//	  finishedAt: Time
+//	}
+type WebhookDeliveryFields interface {
+	ID() graphql.ID
+	Endpoint() string
+	URL() string
+	Event() string
+	ObjectID() graphql.ID
+	PostID() *graphql.ID
+	Site() *string
+	Status() string
+	Attempts() int32
+	LastStatusCode() *int32
+	LastError() *string
+	CreatedAt() graphql.Time
+	NextAttemptAt() *graphql.Time
+	FinishedAt() *graphql.Time
+}
+
+var _ WebhookDeliveryFields = (*WebhookDeliveryResolver)(nil)
+
+// WebhookDeliveryStatus is enum WebhookDeliveryStatus.
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "DELIVERED"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "FAILED"
+)
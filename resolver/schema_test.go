@@ -0,0 +1,46 @@
+package resolver
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/iyut/graphql-go/metafield"
+	"github.com/iyut/graphql-go/model"
+	"github.com/iyut/graphql-go/sdl"
+)
+
+// TestSchemaResolvers checks every field of main-schema.graphql, and of meta
+// fields of every kind, against its resolver.
+func TestSchemaResolvers(t *testing.T) {
+
+	sources, err := sdl.ReadSources("../main-schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg, err := metafield.NewRegistry([]metafield.Field{
+		{MetaKey: "_subtitle", Name: "subtitle", Type: metafield.KindString},
+		{MetaKey: "_rating", Name: "rating", Type: metafield.KindInt},
+		{MetaKey: "_price", Name: "price", Type: metafield.KindFloat},
+		{MetaKey: "_featured", Name: "featured", Type: metafield.KindBoolean},
+		{MetaKey: "_event_date", Name: "eventDate", Type: metafield.KindDateTime},
+		{MetaKey: "_settings", Name: "settings", Type: metafield.KindJSON},
+		{MetaKey: "_tags", Name: "keywords", Type: metafield.KindList},
+		{MetaKey: "_related", Name: "related", Type: metafield.KindPost},
+		{PostType: "page", MetaKey: "_hero", Name: "hero", Type: metafield.KindMedia},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources = append(sources, sdl.Source{Name: "meta_fields", Text: reg.SDL()})
+
+	r := &RootResolver{MetaFields: reg}
+
+	funcs, err := r.MetaFieldFuncs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sdl.AssertResolvers(t, sources, r, funcs, reflect.TypeOf(model.User{}).PkgPath())
+}
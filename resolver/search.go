@@ -0,0 +1,246 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/model"
+	"github.com/iyut/graphql-go/search"
+	"github.com/iyut/graphql-go/service"
+)
+
+/*
+ * SearchConnectionResolver
+ *
+ * type SearchConnection {
+ * 	totalCount: Int!
+ * 	edges: [SearchEdge!]!
+ * 	pageInfo: PageInfo!
+ * }
+ */
+
+const (
+	defaultSearchFirst = 10
+	maxSearchFirst     = 100
+)
+
+var (
+	ErrNegativeFirst = errors.New("first cannot be negative")
+	ErrSearchCursor  = fmt.Errorf("search pages through the first %d hits only", service.MaxSearchWindow)
+)
+
+type SearchArgs struct {
+	Query string
+	Types *[]string
+	First *int32
+	After *string
+}
+
+func (r *RootResolver) Search(ctx context.Context, args SearchArgs) (*SearchConnectionResolver, error) {
+
+	first := defaultSearchFirst
+	if args.First != nil {
+		first = int(*args.First)
+	}
+	if first < 0 {
+		return nil, ErrNegativeFirst
+	}
+	if first > maxSearchFirst {
+		first = maxSearchFirst
+	}
+
+	offset := 0
+	if args.After != nil {
+		after, err := helper.DecodeCursor(*args.After)
+		if err != nil {
+			return nil, err
+		}
+		if after >= service.MaxSearchWindow-1 {
+			return nil, ErrSearchCursor
+		}
+		offset = after + 1
+	}
+
+	types := []string{service.SearchTypePost, service.SearchTypePage, service.SearchTypeComment}
+	if args.Types != nil {
+		types = nil
+		for _, t := range *args.Types {
+			types = append(types, strings.ToLower(t))
+		}
+	}
+
+	searcher := r.Searcher
+	if searcher == nil {
+		searcher = service.NewSearchService(r.DB, TablePrefix)
+	}
+
+	hits, total, err := searcher.Search(service.ArgsSearch{
+		Query:      args.Query,
+		Types:      types,
+		Offset:     offset,
+		Limit:      first,
+		Visibility: r.visibility(ctx),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &SearchConnectionResolver{
+		hits:   hits,
+		total:  total,
+		offset: offset,
+		terms:  search.ParseTerms(args.Query).Include,
+		root:   r,
+	}, nil
+}
+
+type SearchConnectionResolver struct {
+	hits   []*model.SearchHit
+	total  int
+	offset int
+	terms  []string
+	root   *RootResolver
+}
+
+func (r *SearchConnectionResolver) TotalCount() int32 {
+	return int32(r.total)
+}
+
+func (r *SearchConnectionResolver) Edges() []*SearchEdgeResolver {
+
+	var edgeRxs []*SearchEdgeResolver
+
+	for i, hit := range r.hits {
+		edgeRxs = append(edgeRxs, &SearchEdgeResolver{
+			hit:    hit,
+			cursor: helper.EncodeCursor(r.offset + i),
+			terms:  r.terms,
+			root:   r.root,
+		})
+	}
+
+	return edgeRxs
+}
+
+// PageInfo has no next page past service.MaxSearchWindow.
+func (r *SearchConnectionResolver) PageInfo() *PageInfoResolver {
+
+	total := r.total
+	if total > service.MaxSearchWindow {
+		total = service.MaxSearchWindow
+	}
+
+	return newPageInfo(r.offset, len(r.hits), total)
+}
+
+/*
+ * SearchEdgeResolver
+ *
+ * type SearchEdge {
+ * 	cursor: String!
+ * 	score: Float!
+ * 	node: SearchResult!
+ * 	highlights: [SearchHighlight!]!
+ * }
+ */
+
+type SearchEdgeResolver struct {
+	hit    *model.SearchHit
+	cursor string
+	terms  []string
+	root   *RootResolver
+}
+
+func (r *SearchEdgeResolver) Cursor() string {
+	return r.cursor
+}
+
+func (r *SearchEdgeResolver) Score() float64 {
+	return r.hit.Score
+}
+
+func (r *SearchEdgeResolver) Node() *SearchResultResolver {
+	return &SearchResultResolver{hit: r.hit, root: r.root}
+}
+
+func (r *SearchEdgeResolver) Highlights(ctx context.Context) []*SearchHighlightResolver {
+
+	var fields []string
+	var texts []string
+
+	if r.hit.Post != nil {
+		fields = append(fields, "title")
+		texts = append(texts, r.hit.Post.PostTitle)
+
+		if r.root.hasPasswordAccess(ctx, r.hit.Post, nil) {
+			fields = append(fields, "excerpt", "content")
+			texts = append(texts, r.hit.Post.PostExcerpt, r.hit.Post.PostContent)
+		}
+	}
+
+	if r.hit.Comment != nil {
+		fields = append(fields, "content")
+		texts = append(texts, r.hit.Comment.CommentContent)
+	}
+
+	highlightRxs := []*SearchHighlightResolver{}
+
+	for i, text := range texts {
+		if snippet := search.Highlight(text, r.terms); len(snippet) > 0 {
+			highlightRxs = append(highlightRxs, &SearchHighlightResolver{field: fields[i], snippet: snippet})
+		}
+	}
+
+	return highlightRxs
+}
+
+/*
+ * SearchResultResolver
+ *
+ * union SearchResult = Post | Comment
+ */
+
+type SearchResultResolver struct {
+	hit  *model.SearchHit
+	root *RootResolver
+}
+
+func (r *SearchResultResolver) ToPost() (*PostResolver, bool) {
+	if r.hit.Post == nil {
+		return nil, false
+	}
+	return &PostResolver{P: r.hit.Post, DB: r.root.DB, Root: r.root}, true
+}
+
+func (r *SearchResultResolver) ToComment() (*CommentResolver, bool) {
+	if r.hit.Comment == nil {
+		return nil, false
+	}
+	return &CommentResolver{C: r.hit.Comment, DB: r.root.DB, Root: r.root}, true
+}
+
+/*
+ * SearchHighlightResolver
+ *
+ * type SearchHighlight {
+ * 	field: String!
+ * 	snippet: String!
+ * }
+ */
+
+type SearchHighlightResolver struct {
+	field   string
+	snippet string
+}
+
+func (r *SearchHighlightResolver) Field() string {
+	return r.field
+}
+
+func (r *SearchHighlightResolver) Snippet() string {
+	return r.snippet
+}
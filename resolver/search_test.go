@@ -0,0 +1,131 @@
+package resolver
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/iyut/graphql-go/auth"
+)
+
+// countNothing answers every COUNT(*) with 0 and other queries with no rows.
+func countNothing(query string, args []driver.Value) ([]string, [][]driver.Value) {
+
+	if strings.Contains(query, "COUNT(*)") {
+		return []string{"count"}, [][]driver.Value{{int64(0)}}
+	}
+
+	return nil, nil
+}
+
+func TestSearchLeavesOutProtectedPosts(t *testing.T) {
+
+	tests := []struct {
+		roles []string
+		want  string
+	}{
+		{nil, " post_password = '' "},
+		{[]string{"subscriber"}, " post_password = '' "},
+		{[]string{"author"}, " (post_password = '' OR post_author = ?) "},
+		{[]string{"editor"}, ""},
+	}
+
+	for _, tt := range tests {
+
+		fake := &fakeDB{rows: countNothing}
+		db := fake.open()
+
+		r := &RootResolver{DB: db}
+		ctx := context.Background()
+		if tt.roles != nil {
+			ctx = auth.WithViewer(ctx, &auth.Viewer{UserID: 2, Roles: tt.roles})
+		}
+
+		if _, err := r.Search(ctx, SearchArgs{Query: "secret"}); err != nil {
+			t.Fatalf("%v: %v", tt.roles, err)
+		}
+
+		for _, table := range []string{"wpa_posts", "wpa_comments"} {
+
+			counts := fake.executed("FROM\n\t" + table)
+			if len(counts) == 0 {
+				t.Fatalf("%v: %s not searched", tt.roles, table)
+			}
+
+			query := counts[0].query
+			if len(tt.want) > 0 && !strings.Contains(query, tt.want) {
+				t.Errorf("%v: %s searched without %q", tt.roles, table, tt.want)
+			}
+			if len(tt.want) == 0 && strings.Contains(query, "post_password") {
+				t.Errorf("%v: %s searched without protected posts", tt.roles, table)
+			}
+		}
+
+		db.Close()
+	}
+}
+
+func TestSearchCursor(t *testing.T) {
+
+	cursor := func(after string) *string {
+		encoded := base64.StdEncoding.EncodeToString([]byte("cursor:" + after))
+		return &encoded
+	}
+
+	garbage := "not a cursor"
+	first := int32(100)
+
+	tests := []struct {
+		name       string
+		after      *string
+		wantErr    bool
+		wantWindow int64
+	}{
+		{"none", nil, false, 100},
+		{"first page", cursor("9"), false, 110},
+		{"last page", cursor("998"), false, 1000},
+		{"past the window", cursor("999"), true, 0},
+		{"far past the window", cursor("1000000000"), true, 0},
+		{"largest int", cursor(strconv.FormatInt(math.MaxInt64, 10)), true, 0},
+		{"overflowing", cursor("99999999999999999999"), true, 0},
+		{"negative", cursor("-2"), true, 0},
+		{"not a number", cursor("x"), true, 0},
+		{"not base64", &garbage, true, 0},
+	}
+
+	for _, tt := range tests {
+
+		fake := &fakeDB{rows: countNothing}
+		db := fake.open()
+
+		r := &RootResolver{DB: db}
+
+		_, err := r.Search(context.Background(), SearchArgs{Query: "hello", First: &first, After: tt.after})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+			if len(fake.executed("LIMIT ?")) > 0 {
+				t.Errorf("%s: searched", tt.name)
+			}
+			db.Close()
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		for _, search := range fake.executed("LIMIT ?") {
+			if window := search.args[len(search.args)-1]; window != tt.wantWindow {
+				t.Errorf("%s: searched for %v hits, want %d", tt.name, window, tt.wantWindow)
+			}
+		}
+
+		db.Close()
+	}
+}
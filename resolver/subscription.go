@@ -0,0 +1,199 @@
+package resolver
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/events"
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/service"
+)
+
+/*
+ * Subscription
+ *
+ * type Subscription {
+ * 	postPublished: Post!
+ * 	postUpdated(id: ID): Post!
+ * 	commentAdded(postID: ID): Comment!
+ * }
+ */
+
+func (r *RootResolver) PostPublished(ctx context.Context) (<-chan *PostResolver, error) {
+
+	return r.postEvents(ctx, func(event events.Event) bool {
+		return event.Type == events.PostPublished
+	})
+}
+
+func (r *RootResolver) PostUpdated(ctx context.Context, args struct{ ID *graphql.ID }) (<-chan *PostResolver, error) {
+
+	return r.postEvents(ctx, func(event events.Event) bool {
+		return event.Type == events.PostUpdated && (args.ID == nil || helper.GraphqlIDToInt(*args.ID) == event.ObjectID)
+	})
+}
+
+// CommentAdded sends comments once they are approved, whether they were
+// approved when created or later.
+func (r *RootResolver) CommentAdded(ctx context.Context, args struct{ PostID *graphql.ID }) (<-chan *CommentResolver, error) {
+
+	c := make(chan *CommentResolver)
+
+	if r.Events == nil {
+		close(c)
+		return c, nil
+	}
+
+	sub, unsubscribe, cursor, err := r.subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+
+		defer close(c)
+		defer unsubscribe()
+
+		commentService := service.NewCommentService(r.DB, TablePrefix)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case event, ok := <-sub:
+				if !ok {
+					return
+				}
+
+				if event.Site != TablePrefix {
+					continue
+				}
+
+				if event.Type != events.CommentCreated && event.Type != events.CommentApproved {
+					continue
+				}
+
+				if args.PostID != nil && helper.GraphqlIDToInt(*args.PostID) != event.PostID {
+					continue
+				}
+
+				comment, err := commentService.FindByID(event.ObjectID)
+				if err != nil {
+					if err != sql.ErrNoRows {
+						log.Printf("subscription: comment %d: %s", event.ObjectID, err)
+					}
+					continue
+				}
+
+				if comment.CommentApproved != "1" {
+					continue
+				}
+
+				// Comments on posts the viewer cannot read stay hidden.
+				if _, err := r.findPost(ctx, helper.GraphqlIDToInt(comment.CommentPostID)); err != nil {
+					continue
+				}
+
+				if cursor != nil {
+					cursor.Deliver(event.ID)
+				}
+
+				select {
+				case c <- &CommentResolver{C: comment, DB: r.DB, Root: r}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return c, nil
+}
+
+// postEvents sends the posts of the matching events that the viewer may
+// read, until the subscription's context ends.
+func (r *RootResolver) postEvents(ctx context.Context, match func(events.Event) bool) (<-chan *PostResolver, error) {
+
+	c := make(chan *PostResolver)
+
+	if r.Events == nil {
+		close(c)
+		return c, nil
+	}
+
+	sub, unsubscribe, cursor, err := r.subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+
+		defer close(c)
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case event, ok := <-sub:
+				if !ok {
+					return
+				}
+
+				if event.Site != TablePrefix || !match(event) {
+					continue
+				}
+
+				postRx, err := r.findPost(ctx, event.ObjectID)
+				if err != nil {
+					if err != sql.ErrNoRows {
+						log.Printf("subscription: post %d: %s", event.ObjectID, err)
+					}
+					continue
+				}
+
+				if cursor != nil {
+					cursor.Deliver(event.ID)
+				}
+
+				select {
+				case c <- postRx:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return c, nil
+}
+
+// subscribe listens to the bus, resuming after the event of the context's
+// cursor when the transport has one.
+func (r *RootResolver) subscribe(ctx context.Context) (<-chan events.Event, func(), *events.Cursor, error) {
+
+	cursor := events.CursorFrom(ctx)
+	if cursor != nil && len(cursor.After()) > 0 {
+		sub, unsubscribe, err := r.Events.SubscribeSince(cursor.After())
+		return sub, unsubscribe, cursor, err
+	}
+
+	sub, unsubscribe := r.Events.Subscribe()
+
+	return sub, unsubscribe, cursor, nil
+}
+
+// publish tells the rest of the server about a change made by a mutation.
+func (r *RootResolver) publish(eventType string, objectID int64, postID int64) {
+	r.Events.Publish(events.Event{
+		Type:     eventType,
+		ObjectID: objectID,
+		PostID:   postID,
+		Site:     TablePrefix,
+		Source:   events.SourceAPI,
+	})
+}
@@ -1,9 +1,12 @@
 package resolver
 
 import (
+	"context"
 	"database/sql"
 
 	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/helper"
 	"github.com/iyut/graphql-go/model"
 )
 
@@ -13,14 +16,27 @@ import (
  * type User {
  * 	userID: ID!
  * 	username: String!
- * 	emoji: String!
- * 	notes: [Note!]!
+ * 	email: String
+ * 	nicename: String!
+ * 	status: Int!
+ * 	displayName: String!
+ * 	firstName: String
+ * 	lastName: String
+ * 	nickname: String
+ * 	description: String
+ * 	url: String
+ * 	registeredDate: Time!
+ * 	roles: [String!]!
+ * 	meta(keys: [String!]): [UserMeta!]!
+ * 	avatar(size: Int, default: String, rating: AvatarRating): Avatar
+ * 	posts: [Post!]!
  * }
  */
 
 type UserResolver struct {
-	U  *model.User
-	DB *sql.DB
+	U    *model.User
+	DB   *sql.DB
+	Root *RootResolver
 }
 
 func (r *UserResolver) UserID() graphql.ID {
@@ -37,14 +53,20 @@ func (r *UserResolver) Username() string {
 
 }
 
-func (r *UserResolver) Email() string {
+// Email is only shown to the user and to viewers with list_users.
+func (r *UserResolver) Email(ctx context.Context) *string {
 
+	viewer := auth.ViewerFrom(ctx)
+	if viewer.UserID != helper.GraphqlIDToInt(r.U.UserID) && !viewer.Can("list_users") {
+		return nil
+	}
+
+	email := r.U.UserEmail
 	if len(r.U.Email) > 0 {
-		return r.U.Email
-	} else {
-		return r.U.UserEmail
+		email = r.U.Email
 	}
 
+	return &email
 }
 
 func (r *UserResolver) Nicename() string {
@@ -55,8 +77,87 @@ func (r *UserResolver) Status() int32 {
 	return r.U.UserStatus
 }
 
-func (r *UserResolver) Posts() ([]*PostResolver, error) {
-	rootRxs := &RootResolver{DB: r.DB}
+func (r *UserResolver) Posts(ctx context.Context) ([]*PostResolver, error) {
+	return r.Root.Posts(ctx, struct{ UserID graphql.ID }{UserID: r.U.UserID})
+}
+
+func (r *UserResolver) DisplayName() string {
+	return r.U.DisplayName
+}
+
+func (r *UserResolver) FirstName() *string {
+	return r.metaValue("first_name")
+}
+
+func (r *UserResolver) LastName() *string {
+	return r.metaValue("last_name")
+}
+
+func (r *UserResolver) Nickname() *string {
+	return r.metaValue("nickname")
+}
+
+func (r *UserResolver) Description() *string {
+	return r.metaValue("description")
+}
+
+func (r *UserResolver) URL() *string {
+	return nullString(r.U.UserURL)
+}
+
+func (r *UserResolver) RegisteredDate() graphql.Time {
+	return graphql.Time{Time: r.U.UserRegistered}
+}
+
+func (r *UserResolver) Roles() ([]string, error) {
+
+	roles := []string{}
+
+	capabilities := r.metaValue(TablePrefix + "capabilities")
+	if capabilities == nil {
+		return roles, nil
+	}
+
+	parsed, err := auth.ParseRoles(*capabilities)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(roles, parsed...), nil
+}
+
+// Meta returns the user's meta, all of it or the given keys, leaving out
+// the sensitive keys.
+func (r *UserResolver) Meta(args struct{ Keys *[]string }) []*UserMetaResolver {
+
+	userMetaRxs := []*UserMetaResolver{}
+
+	for _, userMeta := range r.U.UserMeta {
+
+		if isSensitiveUserMeta(userMeta.MetaKey) {
+			continue
+		}
+
+		if args.Keys != nil && !containsString(*args.Keys, userMeta.MetaKey) {
+			continue
+		}
+
+		userMetaRxs = append(userMetaRxs, &UserMetaResolver{M: userMeta, DB: r.DB, Root: r.Root})
+	}
+
+	return userMetaRxs
+}
+
+// metaValue returns the first value of the user meta key, or nil when the
+// user does not have it.
+func (r *UserResolver) metaValue(key string) *string {
+
+	for _, userMeta := range r.U.UserMeta {
+		if userMeta.MetaKey == key {
+			value := userMeta.MetaValue
+			return &value
+		}
+	}
 
-	return rootRxs.Posts(struct{ UserID graphql.ID }{UserID: r.U.UserID})
+	return nil
 }
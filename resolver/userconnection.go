@@ -0,0 +1,218 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/model"
+	"github.com/iyut/graphql-go/service"
+)
+
+const (
+	defaultUsersFirst = 10
+	maxUsersFirst     = 100
+)
+
+var (
+	ErrFilterByRole = errors.New("sorry, you are not allowed to filter users by role")
+	ErrOrderUsersBy = errors.New("sorry, you are not allowed to order users by this field")
+)
+
+type UsersWhere struct {
+	Search            *string
+	Roles             *[]string
+	HasPublishedPosts *bool
+	Include           *[]graphql.ID
+	Exclude           *[]graphql.ID
+	RegisteredAfter   *graphql.Time
+}
+
+type UsersOrderBy struct {
+	Field     string
+	Direction *string
+}
+
+type UsersArgs struct {
+	Where   *UsersWhere
+	OrderBy *UsersOrderBy
+	First   *int32
+	After   *string
+}
+
+// Users lists users like the REST API's users endpoint: viewers without
+// list_users only see users with published posts, cannot filter by role,
+// order by email or registration, or search emails and URLs.
+func (r *RootResolver) Users(ctx context.Context, args UsersArgs) (*UserConnectionResolver, error) {
+
+	viewer := auth.ViewerFrom(ctx)
+	canList := viewer.Can("list_users")
+
+	first := defaultUsersFirst
+	if args.First != nil {
+		first = int(*args.First)
+	}
+	if first < 0 {
+		return nil, ErrNegativeFirst
+	}
+	if first > maxUsersFirst {
+		first = maxUsersFirst
+	}
+
+	offset := 0
+	if args.After != nil {
+		after, err := helper.DecodeCursor(*args.After)
+		if err != nil {
+			return nil, err
+		}
+		offset = after + 1
+	}
+
+	argsUser := service.ArgsUser{Offset: offset, Limit: first}
+
+	if where := args.Where; where != nil {
+
+		if where.Search != nil {
+			argsUser.Search = *where.Search
+		}
+
+		if where.Roles != nil {
+
+			if !canList {
+				return nil, ErrFilterByRole
+			}
+
+			argsUser.Roles = *where.Roles
+		}
+
+		if where.HasPublishedPosts != nil {
+			argsUser.PublishedPosts = *where.HasPublishedPosts
+		}
+
+		if where.Include != nil {
+			for _, id := range *where.Include {
+				argsUser.Include = append(argsUser.Include, helper.GraphqlIDToInt(id))
+			}
+		}
+
+		if where.Exclude != nil {
+			for _, id := range *where.Exclude {
+				argsUser.Exclude = append(argsUser.Exclude, helper.GraphqlIDToInt(id))
+			}
+		}
+
+		if where.RegisteredAfter != nil {
+			argsUser.RegisteredAfter = where.RegisteredAfter.Time
+		}
+	}
+
+	if !canList {
+		argsUser.PublishedPosts = true
+		argsUser.SearchColumns = []string{"user_login", "user_nicename", "display_name"}
+	}
+
+	if args.OrderBy != nil {
+
+		argsUser.OrderBy = strings.ToLower(args.OrderBy.Field)
+
+		if !canList && (argsUser.OrderBy == "email" || argsUser.OrderBy == "registered") {
+			return nil, ErrOrderUsersBy
+		}
+
+		argsUser.Desc = args.OrderBy.Direction != nil && *args.OrderBy.Direction == "DESC"
+	}
+
+	userService := service.NewUserService(r.DB, TablePrefix)
+
+	// GetUsers takes a Limit of 0 for its maximum.
+	var users []*model.User
+	if first > 0 {
+		var err error
+		if users, err = userService.GetUsers(argsUser); err != nil {
+			return nil, err
+		}
+	}
+
+	total, err := userService.CountUsers(argsUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserConnectionResolver{users: users, total: total, offset: offset, root: r}, nil
+}
+
+/*
+ * UserConnectionResolver
+ *
+ * type UserConnection {
+ * 	totalCount: Int!
+ * 	edges: [UserEdge!]!
+ * 	nodes: [User!]!
+ * 	pageInfo: PageInfo!
+ * }
+ */
+
+type UserConnectionResolver struct {
+	users  []*model.User
+	total  int
+	offset int
+	root   *RootResolver
+}
+
+func (r *UserConnectionResolver) TotalCount() int32 {
+	return int32(r.total)
+}
+
+func (r *UserConnectionResolver) Edges() []*UserEdgeResolver {
+
+	edgeRxs := []*UserEdgeResolver{}
+
+	for i, user := range r.users {
+		edgeRxs = append(edgeRxs, &UserEdgeResolver{
+			cursor: helper.EncodeCursor(r.offset + i),
+			node:   &UserResolver{U: user, DB: r.root.DB, Root: r.root},
+		})
+	}
+
+	return edgeRxs
+}
+
+func (r *UserConnectionResolver) Nodes() []*UserResolver {
+
+	userRxs := []*UserResolver{}
+
+	for _, user := range r.users {
+		userRxs = append(userRxs, &UserResolver{U: user, DB: r.root.DB, Root: r.root})
+	}
+
+	return userRxs
+}
+
+func (r *UserConnectionResolver) PageInfo() *PageInfoResolver {
+	return newPageInfo(r.offset, len(r.users), r.total)
+}
+
+/*
+ * UserEdgeResolver
+ *
+ * type UserEdge {
+ * 	cursor: String!
+ * 	node: User!
+ * }
+ */
+
+type UserEdgeResolver struct {
+	cursor string
+	node   *UserResolver
+}
+
+func (r *UserEdgeResolver) Cursor() string {
+	return r.cursor
+}
+
+func (r *UserEdgeResolver) Node() *UserResolver {
+	return r.node
+}
@@ -0,0 +1,55 @@
+package resolver
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/iyut/graphql-go/auth"
+)
+
+func TestUsersFirst(t *testing.T) {
+
+	tests := []struct {
+		first     int32
+		wantErr   error
+		wantLimit interface{}
+	}{
+		{5, nil, int64(5)},
+		{100, nil, int64(100)},
+		{1000, nil, int64(100)},
+		{0, nil, nil},
+		{-1, ErrNegativeFirst, nil},
+	}
+
+	for _, tt := range tests {
+		fake := &fakeDB{rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			if strings.Contains(query, "COUNT(*)") {
+				return []string{"count"}, [][]driver.Value{{int64(7)}}
+			}
+			return nil, nil
+		}}
+		db := fake.open()
+
+		r := &RootResolver{DB: db}
+		ctx := auth.WithViewer(context.Background(), &auth.Viewer{UserID: 1, Roles: []string{"administrator"}})
+
+		first := tt.first
+		_, err := r.Users(ctx, UsersArgs{First: &first})
+		if err != tt.wantErr {
+			t.Errorf("first %d: %v, want %v", tt.first, err, tt.wantErr)
+		}
+
+		var limit interface{}
+		if selects := fake.executed("LIMIT ? OFFSET ?"); len(selects) > 0 {
+			limit = selects[0].args[len(selects[0].args)-2]
+		}
+
+		if limit != tt.wantLimit {
+			t.Errorf("first %d: queried with limit %v, want %v", tt.first, limit, tt.wantLimit)
+		}
+
+		db.Close()
+	}
+}
@@ -0,0 +1,200 @@
+package resolver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/events"
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/model"
+	"github.com/iyut/graphql-go/phpserialize"
+	"github.com/iyut/graphql-go/service"
+)
+
+var ErrUserMetaKeyNotAllowed = errors.New("the user meta key cannot be changed through the API")
+
+// EditableUserMeta are the user meta keys the userMeta mutations may change,
+// on top of RootResolver.UserMetaKeys.
+var EditableUserMeta = []string{"first_name", "last_name", "nickname", "description", "locale"}
+
+// sensitiveUserMeta are user meta keys never exposed, next to the protected
+// keys starting with an underscore. Roles are exposed by User.roles instead.
+var sensitiveUserMeta = []string{
+	"session_tokens",
+	"default_password_nag",
+	"community-events-location",
+	TablePrefix + "capabilities",
+	TablePrefix + "user_level",
+	TablePrefix + "user-settings",
+	TablePrefix + "user-settings-time",
+	TablePrefix + "dashboard_quick_press_last_post_id",
+}
+
+func isSensitiveUserMeta(key string) bool {
+	return strings.HasPrefix(key, "_") || containsString(sensitiveUserMeta, key)
+}
+
+func containsString(list []string, s string) bool {
+
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+ * UserMetaResolver
+ *
+ * type UserMeta {
+ * 	uMetaID: ID!
+ * 	userID: ID!
+ * 	metaKey: String!
+ * 	metaValue: String!
+ * }
+ */
+
+type UserMetaResolver struct {
+	M    *model.UserMeta
+	DB   *sql.DB
+	Root *RootResolver
+}
+
+func (r *UserMetaResolver) UMetaID() graphql.ID {
+	return r.M.UMetaID
+}
+
+func (r *UserMetaResolver) UserID() graphql.ID {
+	return r.M.UserID
+}
+
+func (r *UserMetaResolver) MetaKey() string {
+	return r.M.MetaKey
+}
+
+// MetaValue is the stored value, with a string SetMeta serialized given
+// back as it was set.
+func (r *UserMetaResolver) MetaValue() string {
+	return phpserialize.MaybeUnserialize(r.M.MetaValue)
+}
+
+// UserMetas are the meta of a user the viewer may see, without the
+// sensitive keys.
+func (r *RootResolver) UserMetas(ctx context.Context, args struct{ UserID graphql.ID }) ([]*UserMetaResolver, error) {
+
+	userRx, err := r.User(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return userRx.Meta(struct{ Keys *[]string }{}), nil
+}
+
+// UserMeta is a meta row of a user the viewer may see.
+func (r *RootResolver) UserMeta(ctx context.Context, args struct{ UMetaID graphql.ID }) (*UserMetaResolver, error) {
+
+	userService := service.NewUserService(r.DB, TablePrefix)
+
+	userMeta, err := userService.FindMetaByID(helper.GraphqlIDToInt(args.UMetaID))
+	if err != nil {
+		return nil, err
+	}
+
+	// Sensitive rows answer like missing ones.
+	if isSensitiveUserMeta(userMeta.MetaKey) {
+		return nil, sql.ErrNoRows
+	}
+
+	if _, err := r.User(ctx, struct{ UserID graphql.ID }{UserID: userMeta.UserID}); err != nil {
+		return nil, err
+	}
+
+	return &UserMetaResolver{M: userMeta, DB: r.DB, Root: r}, nil
+}
+
+type UserMetaArgs struct {
+	UserID graphql.ID
+	Key    string
+	Value  string
+}
+
+// UpdateUserMeta sets an editable meta key of the viewer, or of anybody for
+// viewers with edit_users.
+func (r *RootResolver) UpdateUserMeta(ctx context.Context, args UserMetaArgs) (*UserResolver, error) {
+
+	userID, err := r.checkUserMetaEdit(ctx, args.UserID, args.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	userService := service.NewUserService(r.DB, TablePrefix)
+
+	if err := userService.SetMeta(userID, args.Key, sanitizeUserMeta(args.Key, args.Value)); err != nil {
+		return nil, err
+	}
+
+	r.publish(events.UserUpdated, userID, 0)
+
+	return r.findUser(service.ArgsUser{UserID: userID})
+}
+
+func (r *RootResolver) DeleteUserMeta(ctx context.Context, args struct {
+	UserID graphql.ID
+	Key    string
+}) (*UserResolver, error) {
+
+	userID, err := r.checkUserMetaEdit(ctx, args.UserID, args.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	userService := service.NewUserService(r.DB, TablePrefix)
+
+	if err := userService.DeleteMeta(userID, args.Key); err != nil {
+		return nil, err
+	}
+
+	r.publish(events.UserUpdated, userID, 0)
+
+	return r.findUser(service.ArgsUser{UserID: userID})
+}
+
+// sanitizeUserMeta filters the profile fields WordPress filters before
+// storing them: the names like its pre_user_first_name, pre_user_last_name
+// and pre_user_nickname filters, the biography with kses like
+// pre_user_description, and the locale as edit_user() does.
+func sanitizeUserMeta(key string, value string) string {
+
+	switch key {
+	case "first_name", "last_name", "nickname":
+		return helper.SanitizeUserText(value)
+	case "description":
+		return helper.FilterKses(value)
+	case "locale":
+		return helper.SanitizeTextField(value)
+	}
+
+	return value
+}
+
+func (r *RootResolver) checkUserMetaEdit(ctx context.Context, id graphql.ID, key string) (int64, error) {
+
+	userID := helper.GraphqlIDToInt(id)
+	viewer := auth.ViewerFrom(ctx)
+
+	if viewer.IsAnonymous() || (viewer.UserID != userID && !viewer.Can("edit_users")) {
+		return 0, ErrForbidden
+	}
+
+	if isSensitiveUserMeta(key) || (!containsString(EditableUserMeta, key) && !containsString(r.UserMetaKeys, key)) {
+		return 0, ErrUserMetaKeyNotAllowed
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,48 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/iyut/graphql-go/auth"
+)
+
+func TestUpdateUserMetaStoresSafeValues(t *testing.T) {
+
+	tests := []struct {
+		key   string
+		value string
+		want  string
+	}{
+		{"first_name", "Jane", "Jane"},
+		{"first_name", `O:8:"stdClass":0:{}`, `s:19:"O:8:"stdClass":0:{}";`},
+		{"last_name", "<script>alert(1)</script>Doe", "Doe"},
+		{"nickname", "Tom & Jerry", "Tom &amp; Jerry"},
+		{"description", `a:1:{i:0;s:1:"x";}`, `s:18:"a:1:{i:0;s:1:"x";}";`},
+		{"description", `<em>Hi</em> <img src=x onerror=alert(1)>`, "<em>Hi</em> "},
+		{"description", `<a href="javascript:alert(1)">me</a>`, "<a>me</a>"},
+		{"locale", "de_DE\n", "de_DE"},
+	}
+
+	for _, tt := range tests {
+		fake := &fakeDB{}
+		db := fake.open()
+
+		r := &RootResolver{DB: db}
+		ctx := auth.WithViewer(context.Background(), &auth.Viewer{UserID: 2, Roles: []string{"subscriber"}})
+
+		// The fake database has no user to read back.
+		r.UpdateUserMeta(ctx, UserMetaArgs{UserID: "2", Key: tt.key, Value: tt.value})
+
+		updates := fake.executed("SET\n\t\t\tmeta_value = ?")
+		if len(updates) != 1 {
+			t.Fatalf("%s: %d updates", tt.key, len(updates))
+		}
+
+		if got := updates[0].args[0]; got != tt.want {
+			t.Errorf("%s set to %q: stored %q, want %q", tt.key, tt.value, got, tt.want)
+		}
+
+		db.Close()
+	}
+}
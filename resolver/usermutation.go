@@ -0,0 +1,494 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	netmail "net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/events"
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/mail"
+	"github.com/iyut/graphql-go/model"
+	"github.com/iyut/graphql-go/phpass"
+	"github.com/iyut/graphql-go/phpserialize"
+	"github.com/iyut/graphql-go/service"
+)
+
+var (
+	ErrForbidden         = errors.New("sorry, you are not allowed to do that")
+	ErrUserNotFound      = errors.New("invalid user ID")
+	ErrRegistrationOff   = errors.New("user registration is currently not allowed")
+	ErrInvalidUsername   = errors.New("the username is invalid")
+	ErrUsernameExists    = errors.New("sorry, that username already exists")
+	ErrInvalidEmail      = errors.New("the email address is not correct")
+	ErrEmailExists       = errors.New("this email address is already registered")
+	ErrNicenameExists    = errors.New("the nicename is already used by another user")
+	ErrInvalidURL        = errors.New("the website URL is invalid")
+	ErrInvalidRole       = errors.New("the role is invalid")
+	ErrEmptyPassword     = errors.New("the password cannot be empty")
+	ErrDeleteCurrentUser = errors.New("you cannot delete the current user")
+)
+
+type CreateUserInput struct {
+	Username    string
+	Email       string
+	Password    *string
+	DisplayName *string
+	URL         *string
+	Role        *string
+}
+
+// CreateUser registers a user like register_new_user() for anonymous callers,
+// who need users_can_register and get default_role, or adds one like
+// wp_insert_user() for viewers with create_users. Without a password the
+// user is mailed a link to set one.
+func (r *RootResolver) CreateUser(ctx context.Context, args struct{ Input CreateUserInput }) (*UserResolver, error) {
+
+	viewer := auth.ViewerFrom(ctx)
+	input := args.Input
+
+	optionService := service.NewOptionService(r.DB, TablePrefix)
+	userService := service.NewUserService(r.DB, TablePrefix)
+
+	role, err := optionService.Get("default_role", "subscriber")
+	if err != nil {
+		return nil, err
+	}
+
+	if viewer.Can("create_users") {
+
+		if input.Role != nil {
+			role = *input.Role
+		}
+
+	} else {
+
+		canRegister, err := optionService.Get("users_can_register", "0")
+		if err != nil {
+			return nil, err
+		}
+
+		if canRegister != "1" {
+			return nil, ErrRegistrationOff
+		}
+
+		if input.Role != nil && *input.Role != role {
+			return nil, ErrForbidden
+		}
+	}
+
+	if !auth.IsRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	login := helper.SanitizeUser(input.Username)
+	if len(login) == 0 || login != input.Username || len(login) > 60 {
+		return nil, ErrInvalidUsername
+	}
+
+	if taken, err := userService.IsTaken("user_login", login, 0); err != nil || taken {
+		return nil, firstError(err, ErrUsernameExists)
+	}
+
+	email, err := validateEmail(input.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if taken, err := userService.IsTaken("user_email", email, 0); err != nil || taken {
+		return nil, firstError(err, ErrEmailExists)
+	}
+
+	user := &model.User{
+		UserLogin:      login,
+		UserEmail:      email,
+		UserRegistered: time.Now().UTC(),
+		DisplayName:    login,
+	}
+
+	if input.DisplayName != nil {
+		user.DisplayName = helper.SanitizeUserText(*input.DisplayName)
+	}
+
+	if input.URL != nil {
+		if user.UserURL, err = validateURL(*input.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.UserNicename, err = r.uniqueNicename(login, 0); err != nil {
+		return nil, err
+	}
+
+	password := ""
+	if input.Password != nil {
+		password = *input.Password
+	}
+
+	setPassword := len(password) == 0
+	if setPassword {
+		if password, err = helper.RandomString(24); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.UserPassword, err = phpass.Hash(password); err != nil {
+		return nil, err
+	}
+
+	capabilities, err := phpserialize.Serialize(map[string]bool{role: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var metas []*model.UserMeta
+	for _, meta := range []struct{ key, value string }{
+		{"nickname", login},
+		{"first_name", ""},
+		{"last_name", ""},
+		{"description", ""},
+		{"rich_editing", "true"},
+		{"syntax_highlighting", "true"},
+		{"comment_shortcuts", "false"},
+		{"admin_color", "fresh"},
+		{"use_ssl", "0"},
+		{"show_admin_bar_front", "true"},
+		{"locale", ""},
+		{TablePrefix + "capabilities", capabilities},
+		{TablePrefix + "user_level", strconv.Itoa(auth.UserLevel(role))},
+	} {
+		metas = append(metas, &model.UserMeta{MetaKey: meta.key, MetaValue: meta.value})
+	}
+
+	userID, err := userService.Create(user, metas)
+	if err != nil {
+		return nil, err
+	}
+
+	r.publish(events.UserCreated, userID, 0)
+
+	if setPassword {
+		if err := r.mailPasswordLink(userID, login, email, "Login Details", "Username: %s\n\nTo set your password, visit the following address:\n\n%s\n"); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.findUser(service.ArgsUser{UserID: userID})
+}
+
+type UpdateUserInput struct {
+	DisplayName *string
+	Nicename    *string
+	URL         *string
+	Email       *string
+}
+
+// UpdateUser changes the profile of the viewer, or of anybody for viewers
+// with edit_users.
+func (r *RootResolver) UpdateUser(ctx context.Context, args struct {
+	UserID graphql.ID
+	Input  UpdateUserInput
+}) (*UserResolver, error) {
+
+	userID := helper.GraphqlIDToInt(args.UserID)
+	viewer := auth.ViewerFrom(ctx)
+
+	if viewer.IsAnonymous() || (viewer.UserID != userID && !viewer.Can("edit_users")) {
+		return nil, ErrForbidden
+	}
+
+	userService := service.NewUserService(r.DB, TablePrefix)
+
+	if exists, err := userService.Exists(userID); err != nil || !exists {
+		return nil, firstError(err, ErrUserNotFound)
+	}
+
+	var update service.ArgsUserUpdate
+
+	if args.Input.DisplayName != nil {
+		displayName := helper.SanitizeUserText(*args.Input.DisplayName)
+		update.DisplayName = &displayName
+	}
+
+	if args.Input.Nicename != nil {
+
+		nicename := helper.SanitizeTitle(*args.Input.Nicename)
+		if len(nicename) == 0 || len(nicename) > 50 {
+			return nil, errors.New("the nicename is invalid")
+		}
+
+		if taken, err := userService.IsTaken("user_nicename", nicename, userID); err != nil || taken {
+			return nil, firstError(err, ErrNicenameExists)
+		}
+
+		update.Nicename = &nicename
+	}
+
+	if args.Input.URL != nil {
+
+		userURL, err := validateURL(*args.Input.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		update.URL = &userURL
+	}
+
+	if args.Input.Email != nil {
+
+		email, err := validateEmail(*args.Input.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		if taken, err := userService.IsTaken("user_email", email, userID); err != nil || taken {
+			return nil, firstError(err, ErrEmailExists)
+		}
+
+		update.Email = &email
+	}
+
+	if err := userService.Update(userID, update); err != nil {
+		return nil, err
+	}
+
+	r.publish(events.UserUpdated, userID, 0)
+
+	return r.findUser(service.ArgsUser{UserID: userID})
+}
+
+// DeleteUser removes a user for viewers with delete_users, giving their
+// posts to reassignTo or deleting them.
+func (r *RootResolver) DeleteUser(ctx context.Context, args struct {
+	UserID     graphql.ID
+	ReassignTo *graphql.ID
+}) (bool, error) {
+
+	userID := helper.GraphqlIDToInt(args.UserID)
+	viewer := auth.ViewerFrom(ctx)
+
+	if !viewer.Can("delete_users") {
+		return false, ErrForbidden
+	}
+
+	if viewer.UserID == userID {
+		return false, ErrDeleteCurrentUser
+	}
+
+	userService := service.NewUserService(r.DB, TablePrefix)
+
+	if exists, err := userService.Exists(userID); err != nil || !exists {
+		return false, firstError(err, ErrUserNotFound)
+	}
+
+	// A reassignTo of 0 would delete the posts instead.
+	var reassignTo int64
+	if args.ReassignTo != nil {
+
+		reassignTo = helper.GraphqlIDToInt(*args.ReassignTo)
+		if reassignTo <= 0 {
+			return false, ErrUserNotFound
+		}
+
+		if reassignTo == userID {
+			return false, errors.New("cannot reassign posts to the user being deleted")
+		}
+
+		if exists, err := userService.Exists(reassignTo); err != nil || !exists {
+			return false, firstError(err, ErrUserNotFound)
+		}
+	}
+
+	postIDs, err := userService.Delete(userID, reassignTo)
+	if err != nil {
+		return false, err
+	}
+
+	for _, postID := range postIDs {
+		r.publish(events.PostDeleted, postID, 0)
+	}
+	r.publish(events.UserDeleted, userID, 0)
+
+	return true, nil
+}
+
+// SendPasswordResetEmail mails a reset link like retrieve_password(). It
+// answers true whether or not the account exists, so it cannot be used to
+// find out who is registered.
+func (r *RootResolver) SendPasswordResetEmail(args struct{ Username string }) (bool, error) {
+
+	userService := service.NewUserService(r.DB, TablePrefix)
+
+	argsUser := service.ArgsUser{Username: strings.TrimSpace(args.Username)}
+	if strings.Contains(args.Username, "@") {
+		argsUser = service.ArgsUser{Email: strings.TrimSpace(args.Username)}
+	}
+
+	users, err := userService.GetUsers(argsUser)
+	if err != nil {
+		return false, err
+	}
+
+	if len(users) != 1 {
+		return true, nil
+	}
+
+	user := users[0]
+
+	err = r.mailPasswordLink(helper.GraphqlIDToInt(user.UserID), user.UserLogin, user.UserEmail, "Password Reset",
+		"Someone has requested a password reset for the following account:\n\nUsername: %s\n\nIf this was a mistake, ignore this email and nothing will happen.\n\nTo reset your password, visit the following address:\n\n%s\n")
+
+	// A failure would only be possible for accounts that exist.
+	if err != nil {
+		log.Printf("password reset of user %s: %s", user.UserID, err)
+	}
+
+	return true, nil
+}
+
+// ResetUserPassword sets a new password with the key from the reset email.
+func (r *RootResolver) ResetUserPassword(args struct {
+	Key      string
+	Login    string
+	Password string
+}) (bool, error) {
+
+	if len(args.Password) == 0 {
+		return false, ErrEmptyPassword
+	}
+
+	userService := service.NewUserService(r.DB, TablePrefix)
+
+	user, err := userService.CheckResetKey(args.Login, args.Key)
+	if err != nil {
+		return false, err
+	}
+
+	if err := userService.SetPassword(helper.GraphqlIDToInt(user.UserID), args.Password); err != nil {
+		return false, err
+	}
+
+	r.publish(events.UserUpdated, helper.GraphqlIDToInt(user.UserID), 0)
+
+	return true, nil
+}
+
+// mailPasswordLink stores a new reset key for the user and mails the link
+// to use it. body gets the login and the link.
+func (r *RootResolver) mailPasswordLink(userID int64, login string, email string, subject string, body string) error {
+
+	if r.Mailer == nil {
+		return errors.New("no mailer is configured")
+	}
+
+	userService := service.NewUserService(r.DB, TablePrefix)
+	optionService := service.NewOptionService(r.DB, TablePrefix)
+
+	key, err := userService.CreateResetKey(userID)
+	if err != nil {
+		return err
+	}
+
+	blogname, err := optionService.Get("blogname", "")
+	if err != nil {
+		return err
+	}
+
+	link := r.ResetURL
+	if len(link) == 0 {
+
+		siteURL, err := optionService.Get("siteurl", "")
+		if err != nil {
+			return err
+		}
+
+		link = strings.TrimSuffix(siteURL, "/") + "/wp-login.php?action=rp&key={key}&login={login}"
+	}
+
+	link = strings.NewReplacer("{key}", key, "{login}", url.QueryEscape(login)).Replace(link)
+
+	return r.Mailer.Send(&mail.Message{
+		From:    r.MailFrom,
+		To:      email,
+		Subject: "[" + blogname + "] " + subject,
+		Body:    fmt.Sprintf(body, login, link),
+	})
+}
+
+// uniqueNicename makes a nicename from the login, adding -2, -3... when it
+// is taken, like wp_insert_user().
+func (r *RootResolver) uniqueNicename(login string, userID int64) (string, error) {
+
+	userService := service.NewUserService(r.DB, TablePrefix)
+
+	base := helper.SanitizeTitle(login)
+	if len(base) > 50 {
+		base = base[:50]
+	}
+
+	nicename := base
+	for suffix := 2; ; suffix++ {
+
+		taken, err := userService.IsTaken("user_nicename", nicename, userID)
+		if err != nil {
+			return "", err
+		}
+
+		if !taken {
+			return nicename, nil
+		}
+
+		end := strconv.Itoa(suffix)
+		if len(base) > 49-len(end) {
+			nicename = base[:49-len(end)] + "-" + end
+		} else {
+			nicename = base + "-" + end
+		}
+	}
+}
+
+func validateEmail(email string) (string, error) {
+
+	email = strings.TrimSpace(email)
+
+	address, err := netmail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > 100 {
+		return "", ErrInvalidEmail
+	}
+
+	return email, nil
+}
+
+// validateURL accepts empty or http(s) URLs, as esc_url_raw() keeps them.
+func validateURL(rawURL string) (string, error) {
+
+	rawURL = strings.TrimSpace(rawURL)
+	if len(rawURL) == 0 {
+		return "", nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 || len(rawURL) > 100 {
+		return "", ErrInvalidURL
+	}
+
+	return rawURL, nil
+}
+
+// firstError returns err when there is one, otherwise fallback.
+func firstError(err error, fallback error) error {
+
+	if err != nil {
+		return err
+	}
+
+	return fallback
+}
@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/auth"
+)
+
+// existingUsers answers the user existence checks for users 1 to 3.
+func existingUsers(query string, args []driver.Value) ([]string, [][]driver.Value) {
+
+	if !strings.Contains(query, "COUNT(*)") {
+		return nil, nil
+	}
+
+	count := int64(0)
+	if id, ok := args[0].(int64); ok && id >= 1 && id <= 3 {
+		count = 1
+	}
+
+	return []string{"count"}, [][]driver.Value{{count}}
+}
+
+func TestDeleteUserReassignTo(t *testing.T) {
+
+	tests := []struct {
+		userID     string
+		reassignTo string
+		wantErr    bool
+		reassigned bool
+	}{
+		{"2", "3", false, true},
+		{"2", "", false, false},
+		{"2", "0", true, false},
+		{"2", "-1", true, false},
+		{"2", "abc", true, false},
+		{"2", "2", true, false},
+		{"2", "9", true, false},
+		{"0", "3", true, false},
+		{"9", "", true, false},
+	}
+
+	for _, tt := range tests {
+		fake := &fakeDB{rows: existingUsers}
+		db := fake.open()
+
+		r := &RootResolver{DB: db}
+		ctx := auth.WithViewer(context.Background(), &auth.Viewer{UserID: 1, Roles: []string{"administrator"}})
+
+		var reassignTo *graphql.ID
+		if len(tt.reassignTo) > 0 {
+			id := graphql.ID(tt.reassignTo)
+			reassignTo = &id
+		}
+
+		_, err := r.DeleteUser(ctx, struct {
+			UserID     graphql.ID
+			ReassignTo *graphql.ID
+		}{graphql.ID(tt.userID), reassignTo})
+
+		deleted := len(fake.executed("DELETE FROM wpa_users")) > 0
+		reassigned := len(fake.executed("SET post_author = ?")) > 0
+
+		if (err != nil) != tt.wantErr || deleted == tt.wantErr || reassigned != tt.reassigned {
+			t.Errorf("deleting %s reassigning to %q: %v, deleted %t, reassigned %t", tt.userID, tt.reassignTo, err, deleted, reassigned)
+		}
+
+		db.Close()
+	}
+}
@@ -0,0 +1,23 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/service"
+)
+
+// visibility maps the viewer's capabilities to the post service's policy.
+func (r *RootResolver) visibility(ctx context.Context) *service.Visibility {
+
+	viewer := auth.ViewerFrom(ctx)
+
+	visibility := &service.Visibility{
+		UserID:      viewer.UserID,
+		ReadOwn:     viewer.Can("edit_posts"),
+		ReadPrivate: viewer.Can("read_private_posts"),
+		ReadAll:     viewer.Can("edit_others_posts"),
+	}
+
+	return visibility
+}
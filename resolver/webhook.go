@@ -0,0 +1,169 @@
+package resolver
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/auth"
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/webhook"
+)
+
+// DefaultWebhookDeliveries is how many deliveries webhookDeliveries returns
+// when first is not given.
+const DefaultWebhookDeliveries = 50
+
+type WebhookDeliveriesArgs struct {
+	Status   *string
+	Endpoint *string
+	First    *int32
+}
+
+// WebhookDeliveries lists the newest deliveries for administrators, to
+// inspect the failing ones.
+func (r *RootResolver) WebhookDeliveries(ctx context.Context, args WebhookDeliveriesArgs) ([]*WebhookDeliveryResolver, error) {
+
+	if !auth.ViewerFrom(ctx).Can("manage_options") {
+		return nil, ErrForbidden
+	}
+
+	if r.Webhooks == nil {
+		return []*WebhookDeliveryResolver{}, nil
+	}
+
+	argsDeliveries := webhook.ArgsDeliveries{Limit: DefaultWebhookDeliveries}
+
+	if args.Status != nil {
+		argsDeliveries.Status = strings.ToLower(*args.Status)
+	}
+	if args.Endpoint != nil {
+		argsDeliveries.Endpoint = *args.Endpoint
+	}
+	if args.First != nil && *args.First > 0 {
+		argsDeliveries.Limit = int(*args.First)
+	}
+
+	deliveryRxs := []*WebhookDeliveryResolver{}
+
+	for _, delivery := range r.Webhooks.Deliveries(argsDeliveries) {
+		deliveryRxs = append(deliveryRxs, &WebhookDeliveryResolver{D: delivery})
+	}
+
+	return deliveryRxs, nil
+}
+
+/*
+ * WebhookDeliveryResolver
+ *
+ * type WebhookDelivery {
+ * 	id: ID!
+ * 	endpoint: String!
+ * 	url: String!
+ * 	event: String!
+ * 	objectID: ID!
+ * 	postID: ID
+ * 	site: String
+ * 	status: WebhookDeliveryStatus!
+ * 	attempts: Int!
+ * 	lastStatusCode: Int
+ * 	lastError: String
+ * 	createdAt: Time!
+ * 	nextAttemptAt: Time
+ * 	finishedAt: Time
+ * }
+ */
+
+type WebhookDeliveryResolver struct {
+	D webhook.Delivery
+}
+
+func (r *WebhookDeliveryResolver) ID() graphql.ID {
+	return graphql.ID(r.D.ID)
+}
+
+func (r *WebhookDeliveryResolver) Endpoint() string {
+	return r.D.Endpoint
+}
+
+func (r *WebhookDeliveryResolver) URL() string {
+	return r.D.URL
+}
+
+func (r *WebhookDeliveryResolver) Event() string {
+	return r.D.Event.Type
+}
+
+func (r *WebhookDeliveryResolver) ObjectID() graphql.ID {
+	return helper.IntToGraphqlID(r.D.Event.ObjectID)
+}
+
+func (r *WebhookDeliveryResolver) PostID() *graphql.ID {
+
+	if r.D.Event.PostID == 0 {
+		return nil
+	}
+
+	postID := helper.IntToGraphqlID(r.D.Event.PostID)
+
+	return &postID
+}
+
+func (r *WebhookDeliveryResolver) Site() *string {
+
+	if len(r.D.Event.Site) == 0 {
+		return nil
+	}
+
+	return &r.D.Event.Site
+}
+
+func (r *WebhookDeliveryResolver) Status() string {
+	return strings.ToUpper(r.D.Status)
+}
+
+func (r *WebhookDeliveryResolver) Attempts() int32 {
+	return int32(r.D.Attempts)
+}
+
+func (r *WebhookDeliveryResolver) LastStatusCode() *int32 {
+
+	if r.D.LastStatusCode == 0 {
+		return nil
+	}
+
+	code := int32(r.D.LastStatusCode)
+
+	return &code
+}
+
+func (r *WebhookDeliveryResolver) LastError() *string {
+
+	if len(r.D.LastError) == 0 {
+		return nil
+	}
+
+	return &r.D.LastError
+}
+
+func (r *WebhookDeliveryResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.D.CreatedAt}
+}
+
+func (r *WebhookDeliveryResolver) NextAttemptAt() *graphql.Time {
+	return optionalTime(r.D.NextAttemptAt)
+}
+
+func (r *WebhookDeliveryResolver) FinishedAt() *graphql.Time {
+	return optionalTime(r.D.FinishedAt)
+}
+
+func optionalTime(t time.Time) *graphql.Time {
+
+	if t.IsZero() {
+		return nil
+	}
+
+	return &graphql.Time{Time: t}
+}
@@ -0,0 +1,756 @@
+package sdl
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// Severities of problems. Errors keep the schema from being served, or
+// fail the requests reaching them; warnings are code the schema does not
+// reach.
+const (
+	Error   = "ERROR"
+	Warning = "WARNING"
+)
+
+// Source is a part of a schema, the file it is read from, or what generates
+// it.
+type Source struct {
+	Name string
+	Text string
+}
+
+// ReadSources reads the files of a schema.
+func ReadSources(files ...string) ([]Source, error) {
+
+	var sources []Source
+
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, Source{Name: file, Text: string(b)})
+	}
+
+	return sources, nil
+}
+
+// Join is the schema of the sources.
+func Join(sources []Source) string {
+
+	var b strings.Builder
+	for _, source := range sources {
+		b.WriteString(source.Text)
+		if !strings.HasSuffix(source.Text, "\n") {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+// Problem is a disagreement between the schema and its resolvers, at a
+// path such as "User.email", with where it is in the schema and in the Go
+// source when known.
+type Problem struct {
+	Severity string
+	Path     string
+	Message  string
+	Schema   string
+	Source   string
+}
+
+func (p Problem) String() string {
+
+	var at []string
+	if len(p.Schema) > 0 {
+		at = append(at, p.Schema)
+	}
+	if len(p.Source) > 0 {
+		at = append(at, p.Source)
+	}
+
+	s := fmt.Sprintf("%-7s  %s: %s", p.Severity, p.Path, p.Message)
+	if len(at) > 0 {
+		s += " (" + strings.Join(at, ", ") + ")"
+	}
+
+	return s
+}
+
+// CheckResolvers compares the schema of the sources with the resolver
+// serving it, by the rules of graphql.ParseSchema, and reports every
+// problem rather than the first. It also reports the scalar arguments that
+// graphql-go would only fail to read at request time, the exported methods
+// of resolvers that no field resolves with, and the fields of the models
+// wrapped by resolvers that no field of theirs is named after. Models is
+// the import path of the package of models, none are looked at when empty.
+// Funcs are the functions given to graphql.FieldFuncs, checked as the
+// methods of the fields they resolve.
+func CheckResolvers(sources []Source, resolver interface{}, funcs map[string]interface{}, models string) ([]Problem, error) {
+
+	schema, _, err := Load(Join(sources))
+	if err != nil {
+		return nil, err
+	}
+
+	s := schema.Inspect()
+
+	c := &checker{
+		types:    make(map[string]*introspection.Type),
+		lines:    locate(sources),
+		funcs:    funcs,
+		models:   models,
+		visited:  make(map[visit]bool),
+		resolves: make(map[reflect.Type]map[string]bool),
+		serves:   make(map[reflect.Type][]string),
+	}
+	for _, t := range s.Types() {
+		c.types[typeName(t)] = t
+	}
+	if t := s.SubscriptionType(); t != nil {
+		c.subscription = typeName(t)
+	}
+
+	resolverType := reflect.TypeOf(resolver)
+	for _, root := range []*introspection.Type{s.QueryType(), s.MutationType(), s.SubscriptionType()} {
+		if root != nil {
+			c.object(typeName(root), root, true, resolverType)
+		}
+	}
+
+	c.unreachable()
+
+	sort.SliceStable(c.problems, func(i, j int) bool {
+		return c.problems[i].Severity == Error && c.problems[j].Severity != Error
+	})
+
+	return c.problems, nil
+}
+
+// TB is the part of testing.TB that AssertResolvers reports to.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Logf(format string, args ...interface{})
+}
+
+// AssertResolvers fails a test for each error CheckResolvers finds, and
+// logs its warnings.
+func AssertResolvers(t TB, sources []Source, resolver interface{}, funcs map[string]interface{}, models string) {
+
+	t.Helper()
+
+	problems, err := CheckResolvers(sources, resolver, funcs, models)
+	if err != nil {
+		t.Errorf("%s", err)
+		return
+	}
+
+	for _, problem := range problems {
+		if problem.Severity == Error {
+			t.Errorf("%s", problem)
+		} else {
+			t.Logf("%s", problem)
+		}
+	}
+}
+
+type visit struct {
+	typeName     string
+	resolverType reflect.Type
+}
+
+type checker struct {
+	types        map[string]*introspection.Type
+	subscription string
+	lines        map[string]string
+	funcs        map[string]interface{}
+	models       string
+	visited      map[visit]bool
+
+	// resolves are the methods of each resolver type that resolve fields,
+	// or type assertions, and serves the schema types it resolves.
+	resolves map[reflect.Type]map[string]bool
+	serves   map[reflect.Type][]string
+
+	problems []Problem
+}
+
+var (
+	contextType     = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType       = reflect.TypeOf((*error)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*interface {
+		ImplementsGraphQLType(name string) bool
+		UnmarshalGraphQL(input interface{}) error
+	})(nil)).Elem()
+)
+
+func (c *checker) add(severity string, path string, source string, format string, args ...interface{}) {
+
+	c.problems = append(c.problems, Problem{
+		Severity: severity,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+		Schema:   c.lines[strings.SplitN(path, "(", 2)[0]],
+		Source:   source,
+	})
+}
+
+// output checks the Go type that resolves a value of type t.
+func (c *checker) output(path string, t *introspection.Type, goType reflect.Type, source string) {
+
+	nonNull := t.Kind() == "NON_NULL"
+	if nonNull {
+		t = t.OfType()
+	}
+
+	switch t.Kind() {
+	case "OBJECT", "INTERFACE", "UNION":
+		if !nonNull && goType.Kind() != reflect.Ptr && goType.Kind() != reflect.Interface {
+			c.add(Error, path, source, "%s is not a pointer or interface, for nullable %s", goType, typeName(t))
+			return
+		}
+		c.object(typeName(t), c.types[typeName(t)], nonNull, goType)
+		return
+	}
+
+	if !nonNull {
+		if goType.Kind() != reflect.Ptr {
+			c.add(Error, path, source, "%s is not a pointer, for nullable %s", goType, TypeString(t))
+			return
+		}
+		goType = goType.Elem()
+	}
+
+	switch t.Kind() {
+	case "SCALAR":
+		if !outputScalar(typeName(t), goType) {
+			c.add(Error, path, source, "cannot use %s as %s", goType, typeName(t))
+		}
+
+	case "LIST":
+		if goType.Kind() != reflect.Slice {
+			c.add(Error, path, source, "%s is not a slice, for %s", goType, TypeString(t))
+			return
+		}
+		c.output(path, t.OfType(), goType.Elem(), source)
+	}
+}
+
+func outputScalar(name string, goType reflect.Type) bool {
+
+	switch reflect.New(goType).Interface().(type) {
+	case *int32:
+		return name == "Int"
+	case *float64:
+		return name == "Float"
+	case *string:
+		return name == "String"
+	case *bool:
+		return name == "Boolean"
+	}
+
+	ptr := reflect.PtrTo(goType)
+	if ptr.Implements(unmarshalerType) {
+		return reflect.New(goType).Interface().(interface{ ImplementsGraphQLType(string) bool }).ImplementsGraphQLType(name)
+	}
+
+	return false
+}
+
+// object checks the methods of a Go type resolving an object, interface or
+// union.
+func (c *checker) object(name string, t *introspection.Type, nonNull bool, goType reflect.Type) {
+
+	if !nonNull && goType.Kind() != reflect.Ptr && goType.Kind() != reflect.Interface {
+		c.add(Error, name, "", "%s is not a pointer or interface", goType)
+		return
+	}
+
+	v := visit{name, goType}
+	if c.visited[v] {
+		return
+	}
+	c.visited[v] = true
+
+	if c.resolves[goType] == nil {
+		c.resolves[goType] = make(map[string]bool)
+	}
+	c.serves[goType] = append(c.serves[goType], name)
+
+	receiver := goType.Kind() != reflect.Interface
+
+	if fields := t.Fields(&struct{ IncludeDeprecated bool }{true}); fields != nil {
+		for _, f := range *fields {
+
+			path := name + "." + f.Name()
+
+			i := findMethod(goType, f.Name())
+			if fn, ok := c.funcs[path]; ok && i < 0 {
+				c.fieldFunc(path, name, f, goType, fn)
+				continue
+			}
+			if i < 0 {
+				hint := ""
+				if goType.Kind() != reflect.Ptr && goType.Kind() != reflect.Interface && findMethod(reflect.PtrTo(goType), f.Name()) >= 0 {
+					hint = ", it is on the pointer type"
+				}
+				c.add(Error, path, typeSource(goType), "%s has no method for field %s%s", goType, f.Name(), hint)
+				continue
+			}
+
+			m := goType.Method(i)
+			c.resolves[goType][m.Name] = true
+			c.field(path, name, f, m, receiver)
+		}
+	}
+
+	if possibleTypes := t.PossibleTypes(); possibleTypes != nil {
+		for _, possible := range *possibleTypes {
+
+			method := "To" + typeName(possible)
+
+			i := findMethod(goType, method)
+			if i < 0 {
+				c.add(Error, name, typeSource(goType), "%s has no method %s, to resolve %s", goType, method, typeName(possible))
+				continue
+			}
+
+			m := goType.Method(i)
+			c.resolves[goType][m.Name] = true
+			if m.Type.NumOut() != 2 {
+				c.add(Error, name, methodSource(m), "%s.%s should return the %s and whether it is one", goType, m.Name, typeName(possible))
+				continue
+			}
+
+			c.object(typeName(possible), possible, false, m.Type.Out(0))
+		}
+	}
+}
+
+// fieldFunc checks a function resolving a field, which takes the resolver
+// first.
+func (c *checker) fieldFunc(path string, typeName string, f *introspection.Field, goType reflect.Type, fn interface{}) {
+
+	fnType := reflect.TypeOf(fn)
+	m := reflect.Method{Name: path, Type: fnType, Func: reflect.ValueOf(fn)}
+
+	if fnType.Kind() != reflect.Func || fnType.NumIn() == 0 || !goType.AssignableTo(fnType.In(0)) {
+		c.add(Error, path, methodSource(m), "function for field %s does not take %s first", f.Name(), goType)
+		return
+	}
+
+	c.field(path, typeName, f, m, true)
+}
+
+// field checks the parameters and results of the method resolving a field.
+func (c *checker) field(path string, typeName string, f *introspection.Field, m reflect.Method, receiver bool) {
+
+	source := methodSource(m)
+
+	var in []reflect.Type
+	for i := 0; i < m.Type.NumIn(); i++ {
+		in = append(in, m.Type.In(i))
+	}
+	if receiver {
+		in = in[1:]
+	}
+
+	if len(in) > 0 && in[0] == contextType {
+		in = in[1:]
+	}
+
+	if len(f.Args()) > 0 {
+		if len(in) == 0 {
+			c.add(Error, path, source, "%s takes no arguments struct", m.Name)
+			return
+		}
+		c.inputStruct(path, f.Args(), in[0], source, "argument")
+		in = in[1:]
+	}
+
+	if len(in) > 0 {
+		c.add(Error, path, source, "%s takes too many parameters", m.Name)
+		return
+	}
+
+	switch {
+	case m.Type.NumOut() == 0:
+		c.add(Error, path, source, "%s returns nothing", m.Name)
+		return
+	case m.Type.NumOut() > 2:
+		c.add(Error, path, source, "%s returns too many values", m.Name)
+		return
+	case m.Type.NumOut() == 2 && m.Type.Out(1) != errorType:
+		c.add(Error, path, source, "%s should return an error last", m.Name)
+		return
+	}
+
+	out := m.Type.Out(0)
+	if typeName == c.subscription && out.Kind() == reflect.Chan {
+		out = out.Elem()
+	}
+
+	c.output(path, f.Type(), out, source)
+}
+
+// inputStruct checks the struct that arguments, or the fields of an input
+// type, are read into.
+func (c *checker) inputStruct(path string, values []*introspection.InputValue, goType reflect.Type, source string, what string) {
+
+	structType := goType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		c.add(Error, path, source, "%s is not a struct, to read %ss into", goType, what)
+		return
+	}
+
+	used := make(map[string]bool)
+
+	for _, v := range values {
+
+		valuePath := path + "(" + v.Name() + ":)"
+		if what != "argument" {
+			valuePath = path + "." + v.Name()
+		}
+
+		name := v.Name()
+		sf, ok := structType.FieldByNameFunc(func(n string) bool {
+			return strings.EqualFold(stripUnderscore(n), stripUnderscore(name))
+		})
+		if !ok {
+			c.add(Error, valuePath, source, "%s has no field for %s %s", structType, what, v.Name())
+			continue
+		}
+		used[sf.Name] = true
+
+		if len(sf.PkgPath) > 0 {
+			c.add(Error, valuePath, source, "field %s of %s is not exported", sf.Name, structType)
+			continue
+		}
+
+		t := v.Type()
+		nonNull := t.Kind() == "NON_NULL" || v.DefaultValue() != nil
+		if t.Kind() == "NON_NULL" {
+			t = t.OfType()
+		}
+
+		c.input(valuePath, t, nonNull, sf.Type, source)
+	}
+
+	for i := 0; i < structType.NumField(); i++ {
+		sf := structType.Field(i)
+		if len(sf.PkgPath) == 0 && !sf.Anonymous && !used[sf.Name] {
+			c.add(Warning, path, source, "field %s of %s matches no %s of the schema", sf.Name, structType, what)
+		}
+	}
+}
+
+// input checks the Go type that a value of type t, non-null or not, is read
+// into.
+func (c *checker) input(path string, t *introspection.Type, nonNull bool, goType reflect.Type, source string) {
+
+	if !nonNull {
+		if goType.Kind() != reflect.Ptr {
+			c.add(Error, path, source, "%s is not a pointer, for nullable %s", goType, TypeString(t))
+			return
+		}
+		if t.Kind() != "INPUT_OBJECT" {
+			goType = goType.Elem()
+		}
+	}
+
+	if reflect.PtrTo(goType).Implements(unmarshalerType) {
+		name := TypeString(t)
+		if !reflect.New(goType).Interface().(interface{ ImplementsGraphQLType(string) bool }).ImplementsGraphQLType(name) {
+			c.add(Error, path, source, "cannot read %s into %s", name, goType)
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case "SCALAR":
+		if !inputScalar(typeName(t), goType) {
+			c.add(Error, path, source, "cannot read %s into %s, requests will fail", typeName(t), goType)
+		}
+
+	case "ENUM":
+		if goType.Kind() != reflect.String {
+			c.add(Error, path, source, "cannot read %s into %s, not a string", typeName(t), goType)
+		}
+
+	case "INPUT_OBJECT":
+		v := visit{typeName(t), goType}
+		if !c.visited[v] {
+			c.visited[v] = true
+			c.inputStruct(typeName(t), *c.types[typeName(t)].InputFields(), goType, source, "input field")
+		}
+
+	case "LIST":
+		if goType.Kind() != reflect.Slice {
+			c.add(Error, path, source, "%s is not a slice, for %s", goType, TypeString(t))
+			return
+		}
+		elem := t.OfType()
+		elemNonNull := elem.Kind() == "NON_NULL"
+		if elemNonNull {
+			elem = elem.OfType()
+		}
+		c.input(path, elem, elemNonNull, goType.Elem(), source)
+
+	default:
+		c.add(Error, path, source, "%s cannot be used as input", typeName(t))
+	}
+}
+
+// inputScalar tells whether graphql-go reads values of a built-in scalar
+// into the Go type.
+func inputScalar(name string, goType reflect.Type) bool {
+
+	switch name {
+	case "Int":
+		return goType.Kind() == reflect.Int32
+	case "Float":
+		return goType.Kind() == reflect.Float64
+	case "String":
+		return goType.Kind() == reflect.String
+	case "Boolean":
+		return goType.Kind() == reflect.Bool
+	}
+
+	return false
+}
+
+// unreachable reports the exported methods of resolvers that resolve no
+// field, and the fields of models that their resolvers expose no field of
+// the name of.
+func (c *checker) unreachable() {
+
+	var goTypes []reflect.Type
+	for goType := range c.resolves {
+		goTypes = append(goTypes, goType)
+	}
+	sort.Slice(goTypes, func(i, j int) bool {
+		return goTypes[i].String() < goTypes[j].String()
+	})
+
+	models := &modelUse{fields: make(map[reflect.Type]map[string]bool), served: make(map[reflect.Type][]string)}
+
+	for _, goType := range goTypes {
+
+		if goType.Kind() == reflect.Interface {
+			continue
+		}
+
+		for i := 0; i < goType.NumMethod(); i++ {
+			m := goType.Method(i)
+			if !c.resolves[goType][m.Name] && isResolverMethod(m) {
+				c.add(Warning, goType.String()+"."+m.Name, methodSource(m), "method resolves no field of %s", strings.Join(c.serves[goType], ", "))
+			}
+		}
+
+		c.modelFields(goType, models)
+	}
+
+	for _, modelType := range models.types {
+		for i := 0; i < modelType.NumField(); i++ {
+			mf := modelType.Field(i)
+			if len(mf.PkgPath) == 0 && !models.fields[modelType][mf.Name] {
+				c.add(Warning, modelType.String()+"."+mf.Name, "", "no field of %s is named after it", strings.Join(models.served[modelType], ", "))
+			}
+		}
+	}
+}
+
+// isResolverMethod tells whether a method looks like one resolving a field,
+// rather than a helper of the resolver.
+func isResolverMethod(m reflect.Method) bool {
+
+	switch {
+	case m.Type.NumOut() == 0 || m.Type.NumOut() > 2:
+		return false
+	case m.Type.NumOut() == 2 && m.Type.Out(1) != errorType:
+		return false
+	}
+
+	return true
+}
+
+// modelUse gathers, for each model wrapped by resolvers, the fields named
+// after fields of the schema types they serve.
+type modelUse struct {
+	types  []reflect.Type
+	fields map[reflect.Type]map[string]bool
+	served map[reflect.Type][]string
+}
+
+// modelFields marks the fields of the models a resolver wraps that are
+// named after fields of the schema types it serves. Models are often named
+// after their table columns, so the name of the type may prefix them, as
+// PostTitle for Post.title.
+func (c *checker) modelFields(goType reflect.Type, models *modelUse) {
+
+	if len(c.models) == 0 {
+		return
+	}
+
+	structType := goType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < structType.NumField(); i++ {
+
+		modelType := structType.Field(i).Type
+		if modelType.Kind() == reflect.Ptr {
+			modelType = modelType.Elem()
+		}
+		if modelType.Kind() != reflect.Struct || modelType.PkgPath() != c.models {
+			continue
+		}
+
+		if models.fields[modelType] == nil {
+			models.types = append(models.types, modelType)
+			models.fields[modelType] = make(map[string]bool)
+		}
+
+		for _, name := range c.serves[goType] {
+
+			models.served[modelType] = append(models.served[modelType], name)
+
+			fields := c.types[name].Fields(&struct{ IncludeDeprecated bool }{true})
+			if fields == nil {
+				continue
+			}
+
+			for j := 0; j < modelType.NumField(); j++ {
+				mf := modelType.Field(j)
+				for _, f := range *fields {
+					if namedAfter(mf.Name, f.Name(), name) {
+						models.fields[modelType][mf.Name] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// namedAfter tells whether a model field is named after a field of a type,
+// maybe with the name of the type before.
+func namedAfter(modelField string, field string, typeName string) bool {
+
+	modelField = strings.ToLower(stripUnderscore(modelField))
+	field = strings.ToLower(stripUnderscore(field))
+
+	return modelField == field || modelField == strings.ToLower(typeName)+field
+}
+
+func findMethod(t reflect.Type, name string) int {
+
+	for i := 0; i < t.NumMethod(); i++ {
+		if strings.EqualFold(stripUnderscore(name), stripUnderscore(t.Method(i).Name)) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func stripUnderscore(s string) string {
+
+	return strings.Replace(s, "_", "", -1)
+}
+
+// methodSource is where a method is declared, as "resolver/user.go:42".
+func methodSource(m reflect.Method) string {
+
+	if !m.Func.IsValid() {
+		return ""
+	}
+
+	fn := runtime.FuncForPC(m.Func.Pointer())
+	if fn == nil {
+		return ""
+	}
+
+	file, line := fn.FileLine(fn.Entry())
+	if line == 0 || strings.HasPrefix(filepath.Base(file), "<") {
+		return ""
+	}
+
+	return filepath.Join(filepath.Base(filepath.Dir(file)), filepath.Base(file)) + ":" + strconv.Itoa(line)
+}
+
+// typeSource is the file of a type's first method, as a hint of where it
+// is declared.
+func typeSource(t reflect.Type) string {
+
+	for i := 0; i < t.NumMethod(); i++ {
+		if source := methodSource(t.Method(i)); len(source) > 0 {
+			return strings.SplitN(source, ":", 2)[0]
+		}
+	}
+
+	return ""
+}
+
+var (
+	definitionPattern = regexp.MustCompile(`^\s*(?:extend\s+)?(?:type|interface|input|enum|union|scalar)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+	fieldPattern      = regexp.MustCompile(`^\s*([_A-Za-z][_0-9A-Za-z]*)\s*[(:]`)
+)
+
+// locate finds the lines of the types and fields of the sources, by "Type"
+// and "Type.field", as "main-schema.graphql:17".
+func locate(sources []Source) map[string]string {
+
+	lines := make(map[string]string)
+
+	for _, source := range sources {
+
+		typeName := ""
+		braces, parens := 0, 0
+
+		for i, line := range strings.Split(source.Text, "\n") {
+
+			if j := strings.Index(line, "#"); j >= 0 {
+				line = line[:j]
+			}
+
+			at := source.Name + ":" + strconv.Itoa(i+1)
+
+			switch {
+			case braces == 0 && parens == 0:
+				if m := definitionPattern.FindStringSubmatch(line); m != nil {
+					typeName = m[1]
+					lines[typeName] = at
+				}
+			case braces == 1 && parens == 0 && len(typeName) > 0:
+				if m := fieldPattern.FindStringSubmatch(line); m != nil {
+					lines[typeName+"."+m[1]] = at
+				}
+			}
+
+			braces += strings.Count(line, "{") - strings.Count(line, "}")
+			parens += strings.Count(line, "(") - strings.Count(line, ")")
+		}
+	}
+
+	return lines
+}
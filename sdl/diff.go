@@ -0,0 +1,428 @@
+package sdl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// Severities of changes. Breaking changes fail operations that used to
+// work, dangerous ones may change how they behave, as an enum value that
+// clients do not know of. Safe changes break nothing.
+const (
+	Breaking  = "BREAKING"
+	Dangerous = "DANGEROUS"
+	Safe      = "SAFE"
+)
+
+// Change is a difference between two versions of a schema, at a path such
+// as "Query.users(first:)".
+type Change struct {
+	Severity string
+	Path     string
+	Message  string
+}
+
+func (c Change) String() string {
+
+	return fmt.Sprintf("%-9s  %s: %s", c.Severity, c.Path, c.Message)
+}
+
+// Diff lists the changes from the old schema to the new one, breaking ones
+// first.
+func Diff(oldSchema *graphql.Schema, newSchema *graphql.Schema) []Change {
+
+	d := &differ{}
+	o, n := oldSchema.Inspect(), newSchema.Inspect()
+
+	for _, root := range []struct {
+		operation string
+		old, new  *introspection.Type
+	}{{"query", o.QueryType(), n.QueryType()}, {"mutation", o.MutationType(), n.MutationType()}, {"subscription", o.SubscriptionType(), n.SubscriptionType()}} {
+		oldName, newName := typeName(root.old), typeName(root.new)
+		switch {
+		case oldName == newName:
+		case len(newName) == 0:
+			d.add(Breaking, "schema", "%s type %s was removed", root.operation, oldName)
+		case len(oldName) == 0:
+			d.add(Safe, "schema", "%s type %s was added", root.operation, newName)
+		default:
+			d.add(Breaking, "schema", "%s type changed from %s to %s", root.operation, oldName, newName)
+		}
+	}
+
+	d.types(o.Types(), n.Types())
+	d.directives(o.Directives(), n.Directives())
+
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		return severityRank(d.changes[i].Severity) < severityRank(d.changes[j].Severity)
+	})
+
+	return d.changes
+}
+
+func severityRank(severity string) int {
+
+	switch severity {
+	case Breaking:
+		return 0
+	case Dangerous:
+		return 1
+	default:
+		return 2
+	}
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(severity string, path string, format string, args ...interface{}) {
+
+	d.changes = append(d.changes, Change{Severity: severity, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func typeName(t *introspection.Type) string {
+
+	if t == nil || t.Name() == nil {
+		return ""
+	}
+
+	return *t.Name()
+}
+
+func (d *differ) types(oldTypes []*introspection.Type, newTypes []*introspection.Type) {
+
+	newByName := make(map[string]*introspection.Type)
+	for _, t := range newTypes {
+		newByName[typeName(t)] = t
+	}
+
+	oldByName := make(map[string]*introspection.Type)
+	for _, t := range oldTypes {
+
+		name := typeName(t)
+		oldByName[name] = t
+
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+
+		n, ok := newByName[name]
+		switch {
+		case !ok:
+			d.add(Breaking, name, "%s was removed", kindName(t.Kind()))
+		case n.Kind() != t.Kind():
+			d.add(Breaking, name, "changed from %s to %s", kindName(t.Kind()), kindName(n.Kind()))
+		default:
+			d.namedType(name, t, n)
+		}
+	}
+
+	for _, t := range newTypes {
+		name := typeName(t)
+		if _, ok := oldByName[name]; !ok {
+			d.add(Safe, name, "%s was added", kindName(t.Kind()))
+		}
+	}
+}
+
+func kindName(kind string) string {
+
+	switch kind {
+	case "INPUT_OBJECT":
+		return "input type"
+	case "OBJECT":
+		return "type"
+	default:
+		return strings.ToLower(kind)
+	}
+}
+
+func (d *differ) namedType(name string, o *introspection.Type, n *introspection.Type) {
+
+	switch o.Kind() {
+	case "OBJECT", "INTERFACE":
+		d.fields(name, o, n)
+		if o.Kind() == "OBJECT" {
+			d.members(name, "interface", *o.Interfaces(), *n.Interfaces())
+		}
+
+	case "UNION":
+		d.members(name, "member", *o.PossibleTypes(), *n.PossibleTypes())
+
+	case "ENUM":
+		d.enumValues(name, o, n)
+
+	case "INPUT_OBJECT":
+		d.inputFields(name, *o.InputFields(), *n.InputFields())
+	}
+}
+
+func (d *differ) fields(typeName string, o *introspection.Type, n *introspection.Type) {
+
+	all := &struct{ IncludeDeprecated bool }{true}
+
+	newFields := make(map[string]*introspection.Field)
+	for _, f := range *n.Fields(all) {
+		newFields[f.Name()] = f
+	}
+
+	oldFields := make(map[string]bool)
+	for _, f := range *o.Fields(all) {
+
+		path := typeName + "." + f.Name()
+		oldFields[f.Name()] = true
+
+		nf, ok := newFields[f.Name()]
+		if !ok {
+			if f.IsDeprecated() {
+				d.add(Breaking, path, "deprecated field was removed")
+			} else {
+				d.add(Breaking, path, "field was removed")
+			}
+			continue
+		}
+
+		oldType, newType := TypeString(f.Type()), TypeString(nf.Type())
+		switch {
+		case oldType == newType:
+		case outputChangeSafe(f.Type(), nf.Type()):
+			d.add(Safe, path, "type changed from %s to %s", oldType, newType)
+		default:
+			d.add(Breaking, path, "type changed from %s to %s", oldType, newType)
+		}
+
+		if !f.IsDeprecated() && nf.IsDeprecated() {
+			d.add(Safe, path, "field was deprecated")
+		}
+
+		d.arguments(path, f.Args(), nf.Args())
+	}
+
+	for _, f := range *n.Fields(all) {
+		if !oldFields[f.Name()] {
+			d.add(Safe, typeName+"."+f.Name(), "field was added")
+		}
+	}
+}
+
+// arguments compares the arguments of a field or directive.
+func (d *differ) arguments(path string, oldArgs []*introspection.InputValue, newArgs []*introspection.InputValue) {
+
+	newByName := make(map[string]*introspection.InputValue)
+	for _, arg := range newArgs {
+		newByName[arg.Name()] = arg
+	}
+
+	oldByName := make(map[string]bool)
+	for _, arg := range oldArgs {
+
+		argPath := path + "(" + arg.Name() + ":)"
+		oldByName[arg.Name()] = true
+
+		n, ok := newByName[arg.Name()]
+		if !ok {
+			d.add(Breaking, argPath, "argument was removed")
+			continue
+		}
+
+		d.inputValue(argPath, "argument", arg, n)
+	}
+
+	for _, arg := range newArgs {
+		if oldByName[arg.Name()] {
+			continue
+		}
+		argPath := path + "(" + arg.Name() + ":)"
+		if required(arg) {
+			d.add(Breaking, argPath, "required argument was added")
+		} else {
+			d.add(Dangerous, argPath, "optional argument was added")
+		}
+	}
+}
+
+func (d *differ) inputFields(typeName string, oldFields []*introspection.InputValue, newFields []*introspection.InputValue) {
+
+	newByName := make(map[string]*introspection.InputValue)
+	for _, f := range newFields {
+		newByName[f.Name()] = f
+	}
+
+	oldByName := make(map[string]bool)
+	for _, f := range oldFields {
+
+		path := typeName + "." + f.Name()
+		oldByName[f.Name()] = true
+
+		n, ok := newByName[f.Name()]
+		if !ok {
+			d.add(Breaking, path, "input field was removed")
+			continue
+		}
+
+		d.inputValue(path, "input field", f, n)
+	}
+
+	for _, f := range newFields {
+		if oldByName[f.Name()] {
+			continue
+		}
+		path := typeName + "." + f.Name()
+		if required(f) {
+			d.add(Breaking, path, "required input field was added")
+		} else {
+			d.add(Dangerous, path, "optional input field was added")
+		}
+	}
+}
+
+// inputValue compares an argument or input field present in both schemas.
+func (d *differ) inputValue(path string, what string, o *introspection.InputValue, n *introspection.InputValue) {
+
+	oldType, newType := TypeString(o.Type()), TypeString(n.Type())
+	switch {
+	case oldType == newType:
+	case inputChangeSafe(o.Type(), n.Type()):
+		d.add(Safe, path, "%s type changed from %s to %s", what, oldType, newType)
+	default:
+		d.add(Breaking, path, "%s type changed from %s to %s", what, oldType, newType)
+	}
+
+	oldDefault, newDefault := o.DefaultValue(), n.DefaultValue()
+	switch {
+	case oldDefault == nil && newDefault == nil:
+	case oldDefault == nil:
+		d.add(Dangerous, path, "default value %s was added", *newDefault)
+	case newDefault == nil:
+		d.add(Dangerous, path, "default value %s was removed", *oldDefault)
+	case *oldDefault != *newDefault:
+		d.add(Dangerous, path, "default value changed from %s to %s", *oldDefault, *newDefault)
+	}
+}
+
+// required tells whether an argument or input field must be given.
+func required(v *introspection.InputValue) bool {
+
+	return v.Type().Kind() == "NON_NULL" && v.DefaultValue() == nil
+}
+
+// members compares the interfaces of an object, or the members of a union.
+func (d *differ) members(typeName string, what string, oldTypes []*introspection.Type, newTypes []*introspection.Type) {
+
+	oldNames := make(map[string]bool)
+	for _, t := range oldTypes {
+		oldNames[*t.Name()] = true
+	}
+
+	newNames := make(map[string]bool)
+	for _, t := range newTypes {
+		newNames[*t.Name()] = true
+		if !oldNames[*t.Name()] {
+			d.add(Dangerous, typeName, "%s %s was added", what, *t.Name())
+		}
+	}
+
+	for _, t := range oldTypes {
+		if !newNames[*t.Name()] {
+			d.add(Breaking, typeName, "%s %s was removed", what, *t.Name())
+		}
+	}
+}
+
+func (d *differ) enumValues(typeName string, o *introspection.Type, n *introspection.Type) {
+
+	all := &struct{ IncludeDeprecated bool }{true}
+
+	newValues := make(map[string]*introspection.EnumValue)
+	for _, v := range *n.EnumValues(all) {
+		newValues[v.Name()] = v
+	}
+
+	oldValues := make(map[string]bool)
+	for _, v := range *o.EnumValues(all) {
+
+		path := typeName + "." + v.Name()
+		oldValues[v.Name()] = true
+
+		nv, ok := newValues[v.Name()]
+		switch {
+		case !ok:
+			d.add(Breaking, path, "enum value was removed")
+		case !v.IsDeprecated() && nv.IsDeprecated():
+			d.add(Safe, path, "enum value was deprecated")
+		}
+	}
+
+	for _, v := range *n.EnumValues(all) {
+		if !oldValues[v.Name()] {
+			d.add(Dangerous, typeName+"."+v.Name(), "enum value was added")
+		}
+	}
+}
+
+func (d *differ) directives(oldDirectives []*introspection.Directive, newDirectives []*introspection.Directive) {
+
+	newByName := make(map[string]*introspection.Directive)
+	for _, directive := range newDirectives {
+		newByName[directive.Name()] = directive
+	}
+
+	for _, directive := range oldDirectives {
+
+		path := "@" + directive.Name()
+
+		n, ok := newByName[directive.Name()]
+		if !ok {
+			d.add(Breaking, path, "directive was removed")
+			continue
+		}
+
+		locations := make(map[string]bool)
+		for _, location := range n.Locations() {
+			locations[location] = true
+		}
+		for _, location := range directive.Locations() {
+			if !locations[location] {
+				d.add(Breaking, path, "location %s was removed", location)
+			}
+		}
+
+		d.arguments(path, directive.Args(), n.Args())
+	}
+}
+
+// outputChangeSafe tells whether a field may return the new type without
+// breaking clients: it may become non-null, not nullable.
+func outputChangeSafe(o *introspection.Type, n *introspection.Type) bool {
+
+	switch o.Kind() {
+	case "LIST":
+		return (n.Kind() == "LIST" && outputChangeSafe(o.OfType(), n.OfType())) ||
+			(n.Kind() == "NON_NULL" && outputChangeSafe(o, n.OfType()))
+	case "NON_NULL":
+		return n.Kind() == "NON_NULL" && outputChangeSafe(o.OfType(), n.OfType())
+	default:
+		return (n.Kind() != "LIST" && n.Kind() != "NON_NULL" && typeName(o) == typeName(n)) ||
+			(n.Kind() == "NON_NULL" && outputChangeSafe(o, n.OfType()))
+	}
+}
+
+// inputChangeSafe tells whether an argument or input field may take the new
+// type without breaking clients: it may become nullable, not non-null.
+func inputChangeSafe(o *introspection.Type, n *introspection.Type) bool {
+
+	switch o.Kind() {
+	case "LIST":
+		return n.Kind() == "LIST" && inputChangeSafe(o.OfType(), n.OfType())
+	case "NON_NULL":
+		return (n.Kind() == "NON_NULL" && inputChangeSafe(o.OfType(), n.OfType())) ||
+			(n.Kind() != "NON_NULL" && inputChangeSafe(o.OfType(), n))
+	default:
+		return n.Kind() != "LIST" && n.Kind() != "NON_NULL" && typeName(o) == typeName(n)
+	}
+}
@@ -0,0 +1,804 @@
+package sdl
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	goprinter "go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/iyut/graphql-go/analysis"
+)
+
+// Files written by Generate into the package of resolvers.
+const (
+	GeneratedFile = "schema_gen.go"
+	StubsFile     = "stubs.go"
+)
+
+const generatedHeader = "// Code generated by graphql-go generate. DO NOT EDIT.\n\n"
+
+const graphqlImport = "github.com/graph-gophers/graphql-go"
+
+// Generate writes the Go code of a schema into the package of its
+// resolvers, in dir:
+//
+// GeneratedFile, written anew each time, has an interface for each object,
+// interface and union type, listing the methods resolving it, which the
+// resolver of the type is asserted to implement. It also has the input
+// types and enums that neither the resolvers nor the models, in
+// modelsDir, declare yet.
+//
+// StubsFile gets stubs for the resolvers and methods missing, to be filled
+// in there or moved out. It is only ever added to, so stubs once written
+// are never overwritten.
+//
+// The resolver of type T is TResolver, the root types' is RootResolver.
+func Generate(schema *graphql.Schema, dir string, modelsDir string) (int, error) {
+
+	resolvers, err := readPackage(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	models, err := readPackage(modelsDir)
+	if err != nil {
+		return 0, err
+	}
+
+	g := &generator{
+		schema:    schema.Inspect(),
+		resolvers: resolvers,
+		models:    models,
+		types:     make(map[string]*introspection.Type),
+		imports:   make(map[string]string),
+		stubs:     &goFile{imports: make(map[string]string)},
+	}
+	for _, t := range g.schema.Types() {
+		g.types[typeName(t)] = t
+	}
+	for _, root := range []*introspection.Type{g.schema.QueryType(), g.schema.MutationType(), g.schema.SubscriptionType()} {
+		if root != nil {
+			g.roots = append(g.roots, typeName(root))
+		}
+	}
+	if t := g.schema.SubscriptionType(); t != nil {
+		g.subscription = typeName(t)
+	}
+	g.assertedTypes()
+
+	source, err := g.generated()
+	if err != nil {
+		return 0, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, GeneratedFile), source, 0644); err != nil {
+		return 0, err
+	}
+
+	if len(g.stubs.decls) == 0 {
+		return 0, nil
+	}
+
+	if err := g.writeStubs(filepath.Join(dir, StubsFile)); err != nil {
+		return 0, err
+	}
+
+	return len(g.stubs.decls), nil
+}
+
+type generator struct {
+	schema       *introspection.Schema
+	resolvers    *goPackage
+	models       *goPackage
+	types        map[string]*introspection.Type
+	roots        []string
+	subscription string
+
+	// resolvedBy are the resolvers of the types that their interfaces and
+	// unions convert to a resolver not named after them.
+	resolvedBy map[string]string
+
+	// imports of GeneratedFile, by name.
+	imports map[string]string
+	decls   []string
+
+	stubs *goFile
+}
+
+// goFile is Go code to write, with the imports it needs by name.
+type goFile struct {
+	imports map[string]string
+	decls   []string
+}
+
+func (g *generator) generated() ([]byte, error) {
+
+	var b bytes.Buffer
+
+	for _, t := range g.schema.Types() {
+
+		name := typeName(t)
+		if builtinTypes[name] || strings.HasPrefix(name, "__") {
+			continue
+		}
+
+		switch t.Kind() {
+		case "OBJECT", "INTERFACE", "UNION":
+			g.resolverInterface(t)
+		case "INPUT_OBJECT":
+			g.inputType(t)
+		case "ENUM":
+			g.enum(t)
+		}
+	}
+
+	b.WriteString(generatedHeader)
+	b.WriteString("package " + g.resolvers.name + "\n\n")
+	writeImports(&b, g.imports)
+	for _, decl := range g.decls {
+		b.WriteString(decl)
+		b.WriteString("\n")
+	}
+
+	return format.Source(b.Bytes())
+}
+
+// resolverType is the Go type resolving a schema type.
+func (g *generator) resolverType(name string) string {
+
+	for _, root := range g.roots {
+		if root == name {
+			return "RootResolver"
+		}
+	}
+
+	if resolver, ok := g.resolvedBy[name]; ok {
+		return resolver
+	}
+
+	return name + "Resolver"
+}
+
+// assertedTypes finds the resolvers that the To methods of interfaces and
+// unions return, such as BlockResolver for a GenericBlock.
+func (g *generator) assertedTypes() {
+
+	g.resolvedBy = make(map[string]string)
+
+	for _, t := range g.schema.Types() {
+		possibleTypes := t.PossibleTypes()
+		if possibleTypes == nil {
+			continue
+		}
+		for _, possible := range *possibleTypes {
+			if m := g.resolvers.method(g.resolverType(typeName(t)), "To"+typeName(possible)); m != nil && len(m.result) > 0 {
+				g.resolvedBy[typeName(possible)] = m.result
+			}
+		}
+	}
+}
+
+// resolverInterface writes the interface of the methods resolving a type,
+// with stubs for those missing.
+func (g *generator) resolverInterface(t *introspection.Type) {
+
+	name := typeName(t)
+	resolver := g.resolverType(name)
+
+	if !g.resolvers.declares(resolver) {
+		g.stubs.decls = append(g.stubs.decls, fmt.Sprintf("// %s resolves type %s.\ntype %s struct{}\n", resolver, name, resolver))
+		g.resolvers.names[resolver] = true
+	}
+
+	var methods []string
+
+	if fields := t.Fields(&struct{ IncludeDeprecated bool }{true}); fields != nil {
+		for _, f := range *fields {
+			methods = append(methods, g.method(name, resolver, f.Name(), func() (string, string) {
+				return g.fieldSignature(name, f)
+			}))
+		}
+	}
+
+	if possibleTypes := t.PossibleTypes(); possibleTypes != nil {
+		for _, possible := range *possibleTypes {
+			possibleName := typeName(possible)
+			methods = append(methods, g.method(name, resolver, "To"+possibleName, func() (string, string) {
+				return "() (*" + g.resolverType(possibleName) + ", bool)", "return nil, false"
+			}))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("// %sFields are the methods of %s resolving %s:\n//\n", name, resolver, name))
+	for _, line := range strings.Split(strings.TrimSuffix(typeSDL(t), "\n"), "\n") {
+		b.WriteString("//\t" + line + "\n")
+	}
+	b.WriteString(fmt.Sprintf("type %sFields interface {\n", name))
+	for _, method := range methods {
+		b.WriteString("\t" + method + "\n")
+	}
+	b.WriteString("}\n\n")
+	b.WriteString(fmt.Sprintf("var _ %sFields = (*%s)(nil)\n", name, resolver))
+
+	g.decls = append(g.decls, b.String())
+}
+
+// method is the interface method resolving a field, by the signature of
+// the resolver's method when there is one, else by the signature of the
+// stub written for it.
+func (g *generator) method(typeName string, resolver string, field string, stub func() (string, string)) string {
+
+	if m := g.resolvers.method(resolver, field); m != nil {
+		for name, importPath := range m.imports {
+			g.imports[name] = importPath
+		}
+		return m.name + m.signature
+	}
+
+	methodName := exportedName(field)
+	signature, body := stub()
+
+	g.qualifiers(signature, g.imports)
+	g.qualifiers(signature+body, g.stubs.imports)
+
+	g.stubs.decls = append(g.stubs.decls, fmt.Sprintf("func (r *%s) %s%s {\n\t%s\n}\n", resolver, methodName, signature, body))
+	g.resolvers.addMethod(resolver, &goMethod{name: methodName, signature: signature})
+
+	return methodName + signature
+}
+
+// fieldSignature is the signature and body of the stub of a field.
+func (g *generator) fieldSignature(typeName string, f *introspection.Field) (string, string) {
+
+	params := []string{"ctx context.Context"}
+	if len(f.Args()) > 0 {
+		var fields []string
+		for _, arg := range f.Args() {
+			fields = append(fields, exportedName(arg.Name())+" "+g.inputGoType(arg.Type(), arg.DefaultValue() != nil))
+		}
+		params = append(params, "args struct{ "+strings.Join(fields, "; ")+" }")
+	}
+
+	result, zero := g.outputGoType(f.Type())
+	if typeName == g.subscription {
+		result, zero = "<-chan "+result, "nil"
+	}
+
+	signature := "(" + strings.Join(params, ", ") + ") (" + result + ", error)"
+	body := "return " + zero + ", errors.New(" + strconv.Quote(typeName+"."+f.Name()+" is not implemented") + ")"
+
+	return signature, body
+}
+
+// outputGoType is the Go type a method returns for a type, and its zero
+// value.
+func (g *generator) outputGoType(t *introspection.Type) (string, string) {
+
+	nonNull := t.Kind() == "NON_NULL"
+	if nonNull {
+		t = t.OfType()
+	}
+
+	switch t.Kind() {
+	case "OBJECT", "INTERFACE", "UNION":
+		return "*" + g.resolverType(typeName(t)), "nil"
+	case "LIST":
+		elem, _ := g.outputGoType(t.OfType())
+		if nonNull {
+			return "[]" + elem, "nil"
+		}
+		return "*[]" + elem, "nil"
+	}
+
+	goType, zero := g.scalarGoType(t)
+	if !nonNull {
+		return "*" + goType, "nil"
+	}
+
+	return goType, zero
+}
+
+// inputGoType is the Go type that a value of a type is read into. Values
+// with a default are never null.
+func (g *generator) inputGoType(t *introspection.Type, hasDefault bool) string {
+
+	nonNull := t.Kind() == "NON_NULL" || hasDefault
+	if t.Kind() == "NON_NULL" {
+		t = t.OfType()
+	}
+
+	goType := ""
+	switch t.Kind() {
+	case "LIST":
+		goType = "[]" + g.inputGoType(t.OfType(), false)
+	case "INPUT_OBJECT":
+		goType = g.named(typeName(t))
+	default:
+		goType, _ = g.scalarGoType(t)
+	}
+
+	if !nonNull {
+		return "*" + goType
+	}
+
+	return goType
+}
+
+// scalarGoType is the Go type of a scalar or enum, and its zero value.
+func (g *generator) scalarGoType(t *introspection.Type) (string, string) {
+
+	switch name := typeName(t); name {
+	case "Int":
+		return "int32", "0"
+	case "Float":
+		return "float64", "0"
+	case "String":
+		return "string", `""`
+	case "Boolean":
+		return "bool", "false"
+	case "ID":
+		return "graphql.ID", `""`
+	case "Time":
+		return "graphql.Time", "graphql.Time{}"
+	default:
+		if t.Kind() == "ENUM" {
+			return g.named(name), `""`
+		}
+		return g.named(name), g.named(name) + "{}"
+	}
+}
+
+// named is the Go type of an input type, enum or scalar, qualified when
+// the models declare it.
+func (g *generator) named(name string) string {
+
+	if !g.resolvers.declares(name) && g.models.declares(name) {
+		return g.models.name + "." + name
+	}
+
+	return name
+}
+
+// qualifiers adds the imports that generated code refers to.
+func (g *generator) qualifiers(code string, imports map[string]string) {
+
+	for name, importPath := range map[string]string{
+		"context":     "context",
+		"errors":      "errors",
+		"graphql":     graphqlImport,
+		g.models.name: g.models.importPath,
+	} {
+		if strings.Contains(code, name+".") {
+			imports[name] = importPath
+		}
+	}
+}
+
+// inputType writes the struct of an input type, unless it is declared.
+func (g *generator) inputType(t *introspection.Type) {
+
+	name := typeName(t)
+	if g.resolvers.declares(name) || g.models.declares(name) {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("// %s is input %s.\ntype %s struct {\n", name, name, name))
+	for _, v := range *t.InputFields() {
+		goType := g.inputGoType(v.Type(), v.DefaultValue() != nil)
+		g.qualifiers(goType, g.imports)
+		b.WriteString("\t" + exportedName(v.Name()) + " " + goType + "\n")
+	}
+	b.WriteString("}\n")
+
+	g.decls = append(g.decls, b.String())
+}
+
+// enum writes an enum as a string type with a constant for each value,
+// unless it is declared.
+func (g *generator) enum(t *introspection.Type) {
+
+	name := typeName(t)
+	if g.resolvers.declares(name) || g.models.declares(name) {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("// %s is enum %s.\ntype %s string\n\n", name, name, name))
+
+	var values []string
+	for _, v := range *t.EnumValues(&struct{ IncludeDeprecated bool }{true}) {
+		constName := name + enumValueName(v.Name())
+		if g.resolvers.declares(constName) {
+			continue
+		}
+		values = append(values, fmt.Sprintf("\t%s %s = %s\n", constName, name, strconv.Quote(v.Name())))
+	}
+
+	if len(values) > 0 {
+		b.WriteString("const (\n")
+		for _, value := range values {
+			b.WriteString(value)
+		}
+		b.WriteString(")\n")
+	}
+
+	g.decls = append(g.decls, b.String())
+}
+
+// initialisms are the words Go names keep in upper case.
+var initialisms = map[string]bool{
+	"API":  true,
+	"GMT":  true,
+	"HTML": true,
+	"ID":   true,
+	"JSON": true,
+	"URL":  true,
+	"UTC":  true,
+}
+
+// enumValueName is the Go name of an enum value, PUBLISHED_AT as
+// PublishedAt and USER_ID as UserID.
+func enumValueName(value string) string {
+
+	var b strings.Builder
+	for _, word := range strings.Split(value, "_") {
+		if initialisms[strings.ToUpper(word)] {
+			b.WriteString(strings.ToUpper(word))
+		} else {
+			b.WriteString(exportedName(strings.ToLower(word)))
+		}
+	}
+
+	return b.String()
+}
+
+func exportedName(name string) string {
+
+	if len(name) == 0 {
+		return name
+	}
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// typeSDL is the SDL of a type.
+func typeSDL(t *introspection.Type) string {
+
+	p := &printer{hints: &analysis.CacheHints{}}
+	p.namedType(t)
+
+	return p.b.String()
+}
+
+// writeStubs adds the stubs to the stubs file, creating it when needed, and
+// the imports they need to its own.
+func (g *generator) writeStubs(file string) error {
+
+	existing, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	imports := g.stubs.imports
+	var body []byte
+
+	if len(existing) == 0 {
+		body = []byte("package " + g.resolvers.name + "\n")
+	} else {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, file, existing, parser.ParseComments|parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+
+		for _, spec := range f.Imports {
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			imports[importName(spec, importPath)] = importPath
+		}
+
+		// Take the imports out, to write them back along with those of the
+		// stubs.
+		body = existing
+		for i := len(f.Decls) - 1; i >= 0; i-- {
+			if decl, ok := f.Decls[i].(*ast.GenDecl); ok && decl.Tok == token.IMPORT {
+				start, end := fset.Position(decl.Pos()).Offset, fset.Position(decl.End()).Offset
+				body = append(append([]byte{}, body[:start]...), body[end:]...)
+			}
+		}
+	}
+
+	// The package clause ends the first line that is not a comment.
+	clause := bytes.Index(body, []byte("package "))
+	clauseEnd := clause + bytes.IndexByte(body[clause:], '\n') + 1
+	if clauseEnd <= clause {
+		body = append(body, '\n')
+		clauseEnd = len(body)
+	}
+
+	var b bytes.Buffer
+	b.Write(body[:clauseEnd])
+	b.WriteString("\n")
+	writeImports(&b, imports)
+	b.Write(bytes.TrimRight(body[clauseEnd:], "\n"))
+	b.WriteString("\n")
+	for _, decl := range g.stubs.decls {
+		b.WriteString("\n" + decl)
+	}
+
+	source, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, source, 0644)
+}
+
+func writeImports(b *bytes.Buffer, imports map[string]string) {
+
+	if len(imports) == 0 {
+		return
+	}
+
+	// The standard library first, then the others.
+	var std, others []string
+	for name, importPath := range imports {
+		spec := strconv.Quote(importPath)
+		if path.Base(importPath) != name {
+			spec = name + " " + spec
+		}
+		if strings.Contains(strings.SplitN(importPath, "/", 2)[0], ".") {
+			others = append(others, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	sort.Slice(std, func(i, j int) bool { return importSpecPath(std[i]) < importSpecPath(std[j]) })
+	sort.Slice(others, func(i, j int) bool { return importSpecPath(others[i]) < importSpecPath(others[j]) })
+
+	b.WriteString("import (\n")
+	for _, spec := range std {
+		b.WriteString("\t" + spec + "\n")
+	}
+	if len(std) > 0 && len(others) > 0 {
+		b.WriteString("\n")
+	}
+	for _, spec := range others {
+		b.WriteString("\t" + spec + "\n")
+	}
+	b.WriteString(")\n\n")
+}
+
+func importSpecPath(spec string) string {
+
+	return spec[strings.Index(spec, `"`):]
+}
+
+// goPackage is what a Go package declares: its types and other top-level
+// names, and the methods of its types by lower case name.
+type goPackage struct {
+	name       string
+	importPath string
+	types      map[string]bool
+	names      map[string]bool
+	methods    map[string]map[string]*goMethod
+	embeds     map[string][]string
+}
+
+type goMethod struct {
+	name      string
+	signature string
+
+	// result is the type of the first result, without pointer or package.
+	result string
+
+	// imports the signature refers to, by name.
+	imports map[string]string
+}
+
+// readPackage reads the declarations of the package in dir, but for its
+// tests and GeneratedFile.
+func readPackage(dir string) (*goPackage, error) {
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != GeneratedFile
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%s: want one package, found %d", dir, len(pkgs))
+	}
+
+	p := &goPackage{
+		types:   make(map[string]bool),
+		names:   make(map[string]bool),
+		methods: make(map[string]map[string]*goMethod),
+		embeds:  make(map[string][]string),
+	}
+
+	for name, pkg := range pkgs {
+
+		p.name = name
+		p.importPath = packageImportPath(dir)
+
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				switch decl := decl.(type) {
+				case *ast.GenDecl:
+					p.genDecl(decl)
+				case *ast.FuncDecl:
+					p.funcDecl(fset, f, decl)
+				}
+			}
+		}
+	}
+
+	return p, nil
+}
+
+func (p *goPackage) genDecl(decl *ast.GenDecl) {
+
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			p.types[spec.Name.Name] = true
+			p.names[spec.Name.Name] = true
+			if s, ok := spec.Type.(*ast.StructType); ok {
+				for _, field := range s.Fields.List {
+					if len(field.Names) == 0 {
+						p.embeds[spec.Name.Name] = append(p.embeds[spec.Name.Name], baseTypeName(field.Type))
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for _, name := range spec.Names {
+				p.names[name.Name] = true
+			}
+		}
+	}
+}
+
+func (p *goPackage) funcDecl(fset *token.FileSet, f *ast.File, decl *ast.FuncDecl) {
+
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		p.names[decl.Name.Name] = true
+		return
+	}
+
+	var b bytes.Buffer
+	goprinter.Fprint(&b, fset, decl.Type)
+
+	m := &goMethod{
+		name:      decl.Name.Name,
+		signature: strings.TrimPrefix(b.String(), "func"),
+		imports:   make(map[string]string),
+	}
+	if results := decl.Type.Results; results != nil && len(results.List) > 0 {
+		m.result = baseTypeName(results.List[0].Type)
+	}
+
+	ast.Inspect(decl.Type, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				for _, spec := range f.Imports {
+					importPath, _ := strconv.Unquote(spec.Path.Value)
+					if importName(spec, importPath) == x.Name {
+						m.imports[x.Name] = importPath
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	p.addMethod(baseTypeName(decl.Recv.List[0].Type), m)
+}
+
+func (p *goPackage) addMethod(receiver string, m *goMethod) {
+
+	if p.methods[receiver] == nil {
+		p.methods[receiver] = make(map[string]*goMethod)
+	}
+	p.methods[receiver][strings.ToLower(stripUnderscore(m.name))] = m
+}
+
+func (p *goPackage) declares(name string) bool {
+
+	return p.names[name]
+}
+
+// method finds the method of a type resolving a field, as graphql-go does,
+// among the methods of its embedded types too.
+func (p *goPackage) method(typeName string, field string) *goMethod {
+
+	if m, ok := p.methods[typeName][strings.ToLower(stripUnderscore(field))]; ok {
+		return m
+	}
+
+	for _, embedded := range p.embeds[typeName] {
+		if m := p.method(embedded, field); m != nil {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// baseTypeName is the name of a type, without pointer or package.
+func baseTypeName(expr ast.Expr) string {
+
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return baseTypeName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	}
+
+	return ""
+}
+
+func importName(spec *ast.ImportSpec, importPath string) string {
+
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+
+	return defaultImportName(importPath)
+}
+
+// defaultImportName guesses the name of a package by its import path, as
+// goimports does: graphql for github.com/graph-gophers/graphql-go.
+func defaultImportName(importPath string) string {
+
+	name := path.Base(importPath)
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+	name = strings.TrimSuffix(name, ".go")
+
+	return name
+}
+
+// packageImportPath is the import path of the package in dir, by the
+// go.mod of the module it is in.
+func packageImportPath(dir string) string {
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for root := abs; ; root = filepath.Dir(root) {
+
+		if b, err := ioutil.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			for _, line := range strings.Split(string(b), "\n") {
+				if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "module" {
+					rel, err := filepath.Rel(root, abs)
+					if err != nil {
+						return ""
+					}
+					return path.Join(fields[1], filepath.ToSlash(rel))
+				}
+			}
+		}
+
+		if filepath.Dir(root) == root {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Package sdl reads schemas with the graphql-go parser, to print them back,
+// tell how two versions differ, and check and generate the resolvers that
+// serve them.
+package sdl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/iyut/graphql-go/analysis"
+)
+
+// defaultDeprecationReason is the reason of @deprecated when none is given.
+const defaultDeprecationReason = "No longer supported"
+
+var builtinTypes = map[string]bool{
+	"Int":     true,
+	"Float":   true,
+	"String":  true,
+	"Boolean": true,
+	"ID":      true,
+}
+
+var builtinDirectives = map[string]bool{
+	"include":    true,
+	"skip":       true,
+	"deprecated": true,
+}
+
+// Load reads a schema to inspect it, without resolvers. The @cacheControl
+// hints of the schema, which graphql-go does not keep, are returned along.
+func Load(text string) (*graphql.Schema, *analysis.CacheHints, error) {
+
+	hints, text, err := analysis.ReadCacheHints(text)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schema, err := graphql.ParseSchema(text, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return schema, hints, nil
+}
+
+// Print writes the schema as SDL, types in the order of their names, and
+// fields in the order they are declared in. Descriptions are written as
+// comments, which is how graphql-go reads them by default. The hints, which
+// may be nil, are written as @cacheControl directives.
+func Print(schema *graphql.Schema, hints *analysis.CacheHints) string {
+
+	if hints == nil {
+		hints = &analysis.CacheHints{}
+	}
+
+	p := &printer{hints: hints}
+	s := schema.Inspect()
+
+	p.line("schema {")
+	for _, root := range []struct {
+		operation string
+		t         *introspection.Type
+	}{{"query", s.QueryType()}, {"mutation", s.MutationType()}, {"subscription", s.SubscriptionType()}} {
+		if root.t != nil {
+			p.line("  " + root.operation + ": " + *root.t.Name())
+		}
+	}
+	p.line("}")
+
+	for _, d := range s.Directives() {
+		if builtinDirectives[d.Name()] {
+			continue
+		}
+		p.line("")
+		p.description(d.Description(), "")
+		p.line("directive @" + d.Name() + p.arguments(d.Args()) + " on " + strings.Join(d.Locations(), " | "))
+	}
+
+	for _, t := range s.Types() {
+		name := *t.Name()
+		if builtinTypes[name] || strings.HasPrefix(name, "__") {
+			continue
+		}
+		p.line("")
+		p.description(t.Description(), "")
+		p.namedType(t)
+	}
+
+	return p.b.String()
+}
+
+type printer struct {
+	b     strings.Builder
+	hints *analysis.CacheHints
+}
+
+func (p *printer) line(s string) {
+
+	p.b.WriteString(s)
+	p.b.WriteString("\n")
+}
+
+func (p *printer) description(description *string, indent string) {
+
+	if description == nil {
+		return
+	}
+
+	for _, line := range strings.Split(*description, "\n") {
+		p.line(strings.TrimRight(indent+"# "+line, " "))
+	}
+}
+
+func (p *printer) namedType(t *introspection.Type) {
+
+	name := *t.Name()
+
+	switch t.Kind() {
+	case "SCALAR":
+		p.line("scalar " + name)
+
+	case "OBJECT", "INTERFACE":
+		header := "type " + name
+		if t.Kind() == "INTERFACE" {
+			header = "interface " + name
+		}
+		if interfaces := t.Interfaces(); interfaces != nil && len(*interfaces) > 0 {
+			var names []string
+			for _, i := range *interfaces {
+				names = append(names, *i.Name())
+			}
+			header += " implements " + strings.Join(names, " & ")
+		}
+		p.line(header + cacheControl(p.hints.Types[name]) + " {")
+		for _, f := range *t.Fields(&struct{ IncludeDeprecated bool }{true}) {
+			p.description(f.Description(), "  ")
+			p.line("  " + f.Name() + p.arguments(f.Args()) + ": " + TypeString(f.Type()) + deprecated(f.IsDeprecated(), f.DeprecationReason()) + cacheControl(p.hints.Fields[name+"."+f.Name()]))
+		}
+		p.line("}")
+
+	case "UNION":
+		var names []string
+		for _, member := range *t.PossibleTypes() {
+			names = append(names, *member.Name())
+		}
+		p.line("union " + name + cacheControl(p.hints.Types[name]) + " = " + strings.Join(names, " | "))
+
+	case "ENUM":
+		p.line("enum " + name + " {")
+		for _, v := range *t.EnumValues(&struct{ IncludeDeprecated bool }{true}) {
+			p.description(v.Description(), "  ")
+			p.line("  " + v.Name() + deprecated(v.IsDeprecated(), v.DeprecationReason()))
+		}
+		p.line("}")
+
+	case "INPUT_OBJECT":
+		p.line("input " + name + " {")
+		for _, v := range *t.InputFields() {
+			p.description(v.Description(), "  ")
+			p.line("  " + inputValue(v))
+		}
+		p.line("}")
+	}
+}
+
+// arguments writes arguments on one line, or one by line when any has a
+// description.
+func (p *printer) arguments(args []*introspection.InputValue) string {
+
+	if len(args) == 0 {
+		return ""
+	}
+
+	described := false
+	var values []string
+	for _, arg := range args {
+		described = described || arg.Description() != nil
+		values = append(values, inputValue(arg))
+	}
+
+	if !described {
+		return "(" + strings.Join(values, ", ") + ")"
+	}
+
+	var b strings.Builder
+	b.WriteString("(\n")
+	for i, arg := range args {
+		if description := arg.Description(); description != nil {
+			for _, line := range strings.Split(*description, "\n") {
+				b.WriteString(strings.TrimRight("    # "+line, " ") + "\n")
+			}
+		}
+		b.WriteString("    " + values[i] + "\n")
+	}
+	b.WriteString("  )")
+
+	return b.String()
+}
+
+func inputValue(v *introspection.InputValue) string {
+
+	s := v.Name() + ": " + TypeString(v.Type())
+	if defaultValue := v.DefaultValue(); defaultValue != nil {
+		s += " = " + *defaultValue
+	}
+
+	return s
+}
+
+func deprecated(isDeprecated bool, reason *string) string {
+
+	if !isDeprecated {
+		return ""
+	}
+
+	if reason == nil || *reason == defaultDeprecationReason {
+		return " @deprecated"
+	}
+
+	return " @deprecated(reason: " + strconv.Quote(*reason) + ")"
+}
+
+func cacheControl(hint *analysis.CacheHint) string {
+
+	if hint == nil {
+		return ""
+	}
+
+	var args []string
+	if hint.MaxAge != nil {
+		args = append(args, fmt.Sprintf("maxAge: %d", *hint.MaxAge))
+	}
+	if len(hint.Scope) > 0 {
+		args = append(args, "scope: "+hint.Scope)
+	}
+	if hint.InheritMaxAge {
+		args = append(args, "inheritMaxAge: true")
+	}
+
+	if len(args) == 0 {
+		return " @cacheControl"
+	}
+
+	return " @cacheControl(" + strings.Join(args, ", ") + ")"
+}
+
+// TypeString writes a type reference as in SDL, as [Post!]!.
+func TypeString(t *introspection.Type) string {
+
+	switch t.Kind() {
+	case "NON_NULL":
+		return TypeString(t.OfType()) + "!"
+	case "LIST":
+		return "[" + TypeString(t.OfType()) + "]"
+	default:
+		return *t.Name()
+	}
+}
@@ -0,0 +1,110 @@
+package search
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// SnippetLength is the number of characters of context kept around a match.
+const SnippetLength = 160
+
+var tagRegexp = regexp.MustCompile(`(?s)<!--.*?-->|<[^>]*>`)
+var spaceRegexp = regexp.MustCompile(`\s+`)
+
+// PlainText strips HTML tags and block comments and collapses whitespace.
+func PlainText(content string) string {
+
+	text := tagRegexp.ReplaceAllString(content, " ")
+	text = html.UnescapeString(text)
+
+	return strings.TrimSpace(spaceRegexp.ReplaceAllString(text, " "))
+}
+
+// Highlight returns an HTML-escaped excerpt of text around the first match of
+// any term, with every match wrapped in <mark>. It returns "" when no term
+// occurs in the text.
+func Highlight(text string, terms []string) string {
+
+	text = PlainText(text)
+
+	var quoted []string
+	for _, term := range terms {
+		if len(term) > 0 {
+			quoted = append(quoted, regexp.QuoteMeta(term))
+		}
+	}
+
+	if len(quoted) == 0 {
+		return ""
+	}
+
+	termsRegexp := regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+
+	loc := termsRegexp.FindStringIndex(text)
+	if loc == nil {
+		return ""
+	}
+
+	start, end := window(text, loc[0], loc[1])
+	snippet := text[start:end]
+
+	var b strings.Builder
+
+	if start > 0 {
+		b.WriteString("…")
+	}
+
+	last := 0
+	for _, m := range termsRegexp.FindAllStringIndex(snippet, -1) {
+		b.WriteString(html.EscapeString(snippet[last:m[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(snippet[m[0]:m[1]]))
+		b.WriteString("</mark>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(snippet[last:]))
+
+	if end < len(text) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
+
+// window picks byte offsets of about SnippetLength characters centered on the
+// match, moved to word boundaries.
+func window(text string, matchStart int, matchEnd int) (int, int) {
+
+	context := (SnippetLength - utf8.RuneCountInString(text[matchStart:matchEnd])) / 2
+	if context < 0 {
+		context = 0
+	}
+
+	start := matchStart
+	for n := 0; start > 0 && n < context; n++ {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+
+	end := matchEnd
+	for n := 0; end < len(text) && n < context; n++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+
+	if start > 0 {
+		if i := strings.IndexByte(text[start:matchStart], ' '); i >= 0 {
+			start += i + 1
+		}
+	}
+
+	if end < len(text) {
+		if i := strings.LastIndexByte(text[matchEnd:end], ' '); i >= 0 {
+			end = matchEnd + i
+		}
+	}
+
+	return start, end
+}
@@ -0,0 +1,425 @@
+package search
+
+import (
+	"encoding/gob"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+/****
+*********************
+INVERTED INDEX
+*********************
+****/
+
+// Indexed fields and their default boosts.
+const (
+	FieldTitle   = "title"
+	FieldExcerpt = "excerpt"
+	FieldContent = "content"
+	FieldTerms   = "terms"
+)
+
+var DefaultBoosts = map[string]float64{
+	FieldTitle:   3,
+	FieldExcerpt: 1.5,
+	FieldContent: 1,
+	FieldTerms:   2,
+}
+
+// BM25 parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Weights of terms that only match a query token by prefix or edit distance.
+const (
+	prefixWeight = 0.5
+	fuzzyWeight  = 0.3
+)
+
+const snapshotFile = "index.gob"
+
+// saveDelay batches the writes of consecutive updates into one snapshot.
+const saveDelay = 5 * time.Second
+
+// Document is one indexable object, a post or a comment.
+type Document struct {
+	ID     string
+	Type   string
+	Date   time.Time
+	Fields map[string]string
+}
+
+// Hit is a matching document with its BM25 score.
+type Hit struct {
+	ID    string
+	Type  string
+	Score float64
+}
+
+type docEntry struct {
+	Type    string
+	Date    time.Time
+	Lengths map[string]int
+	Terms   []string
+}
+
+// snapshot is the persisted form of the index.
+type snapshot struct {
+	Docs     map[string]*docEntry
+	Postings map[string]map[string]map[string]int
+	Lengths  map[string]int
+}
+
+// Index is an in-process inverted index scored with BM25, persisted to a
+// directory as a gob snapshot.
+type Index struct {
+	Boosts map[string]float64
+
+	mu       sync.RWMutex
+	dir      string
+	docs     map[string]*docEntry
+	postings map[string]map[string]map[string]int // term -> doc ID -> field -> frequency
+	lengths  map[string]int                       // field -> total length
+	terms    *trie
+	timer    *time.Timer
+}
+
+// OpenIndex loads the index persisted in dir, or returns an empty index when
+// there is none yet. The directory is created if needed.
+func OpenIndex(dir string) (*Index, bool, error) {
+
+	idx := &Index{
+		Boosts:   DefaultBoosts,
+		dir:      dir,
+		docs:     make(map[string]*docEntry),
+		postings: make(map[string]map[string]map[string]int),
+		lengths:  make(map[string]int),
+		terms:    newTrie(),
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, false, err
+	}
+
+	file, err := os.Open(filepath.Join(dir, snapshotFile))
+	if os.IsNotExist(err) {
+		return idx, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer file.Close()
+
+	var snap snapshot
+	if err := gob.NewDecoder(file).Decode(&snap); err != nil {
+		return nil, false, err
+	}
+
+	idx.docs = snap.Docs
+	idx.postings = snap.Postings
+	idx.lengths = snap.Lengths
+
+	for term := range idx.postings {
+		idx.terms.insert(term)
+	}
+
+	return idx, true, nil
+}
+
+// Len returns the number of indexed documents.
+func (idx *Index) Len() int {
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.docs)
+}
+
+// IDs returns the IDs of the indexed documents.
+func (idx *Index) IDs() []string {
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	ids := make([]string, 0, len(idx.docs))
+	for id := range idx.docs {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// Put adds or replaces a document and schedules a snapshot.
+func (idx *Index) Put(doc Document) {
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(doc.ID)
+
+	entry := &docEntry{Type: doc.Type, Date: doc.Date, Lengths: make(map[string]int)}
+	seen := make(map[string]bool)
+
+	for field, text := range doc.Fields {
+
+		tokens := Tokenize(text)
+
+		entry.Lengths[field] = len(tokens)
+		idx.lengths[field] += len(tokens)
+
+		for _, token := range tokens {
+
+			byDoc, ok := idx.postings[token]
+			if !ok {
+				byDoc = make(map[string]map[string]int)
+				idx.postings[token] = byDoc
+				idx.terms.insert(token)
+			}
+
+			freqs, ok := byDoc[doc.ID]
+			if !ok {
+				freqs = make(map[string]int)
+				byDoc[doc.ID] = freqs
+			}
+			freqs[field]++
+
+			if !seen[token] {
+				seen[token] = true
+				entry.Terms = append(entry.Terms, token)
+			}
+		}
+	}
+
+	idx.docs[doc.ID] = entry
+	idx.scheduleSave()
+}
+
+// Delete removes a document and schedules a snapshot.
+func (idx *Index) Delete(id string) {
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.remove(id) {
+		idx.scheduleSave()
+	}
+}
+
+func (idx *Index) remove(id string) bool {
+
+	entry, ok := idx.docs[id]
+	if !ok {
+		return false
+	}
+
+	for _, term := range entry.Terms {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+			idx.terms.remove(term)
+		}
+	}
+
+	for field, length := range entry.Lengths {
+		idx.lengths[field] -= length
+	}
+
+	delete(idx.docs, id)
+
+	return true
+}
+
+// Search returns the documents of the given types matching every include
+// token and none of the exclude tokens, best first. Query tokens also match
+// index terms they are a prefix of, or that are within a small edit distance,
+// at a lower weight.
+func (idx *Index) Search(terms Terms, types []string) []*Hit {
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	allowed := make(map[string]bool)
+	for _, t := range types {
+		allowed[t] = true
+	}
+
+	var tokens []string
+	for _, term := range terms.Include {
+		tokens = append(tokens, Tokenize(term)...)
+	}
+
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	scores := make(map[string]float64)
+
+	for i, token := range tokens {
+
+		tokenScores := make(map[string]float64)
+
+		for term, weight := range idx.expand(token) {
+			for id, freqs := range idx.postings[term] {
+				if !allowed[idx.docs[id].Type] {
+					continue
+				}
+				score := weight * idx.bm25(term, id, freqs)
+				if score > tokenScores[id] {
+					tokenScores[id] = score
+				}
+			}
+		}
+
+		// Every token has to match, so only documents matched so far survive.
+		for id := range scores {
+			if _, ok := tokenScores[id]; !ok {
+				delete(scores, id)
+			}
+		}
+
+		for id, score := range tokenScores {
+			if _, ok := scores[id]; ok || i == 0 {
+				scores[id] += score
+			}
+		}
+
+		if len(scores) == 0 {
+			return nil
+		}
+	}
+
+	for _, term := range terms.Exclude {
+		for _, token := range Tokenize(term) {
+			for id := range idx.postings[token] {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]*Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, &Hit{ID: id, Type: idx.docs[id].Type, Score: score})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return idx.docs[hits[i].ID].Date.After(idx.docs[hits[j].ID].Date)
+	})
+
+	return hits
+}
+
+// bm25 scores one term of a document, summing the fields weighted by boost.
+func (idx *Index) bm25(term string, id string, freqs map[string]int) float64 {
+
+	n := float64(len(idx.docs))
+	df := float64(len(idx.postings[term]))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+	score := 0.0
+	for field, tf := range freqs {
+
+		boost, ok := idx.Boosts[field]
+		if !ok {
+			boost = 1
+		}
+
+		avgLength := float64(idx.lengths[field]) / n
+		if avgLength == 0 {
+			avgLength = 1
+		}
+
+		length := float64(idx.docs[id].Lengths[field])
+		f := float64(tf)
+
+		score += boost * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*length/avgLength))
+	}
+
+	return idf * score
+}
+
+// expand returns the index terms matching a query token with their weight.
+func (idx *Index) expand(token string) map[string]float64 {
+
+	terms := make(map[string]float64)
+
+	if _, ok := idx.postings[token]; ok {
+		terms[token] = 1
+	}
+
+	if len(token) >= 3 {
+		idx.terms.withPrefix(token, func(term string) {
+			if _, ok := terms[term]; !ok {
+				terms[term] = prefixWeight
+			}
+		})
+	}
+
+	maxDistance := 0
+	switch {
+	case len(token) >= 8:
+		maxDistance = 2
+	case len(token) >= 4:
+		maxDistance = 1
+	}
+
+	if maxDistance > 0 {
+		idx.terms.within(token, maxDistance, func(term string) {
+			if _, ok := terms[term]; !ok {
+				terms[term] = fuzzyWeight
+			}
+		})
+	}
+
+	return terms
+}
+
+// scheduleSave writes a snapshot once no update happened for saveDelay.
+func (idx *Index) scheduleSave() {
+
+	if idx.timer != nil {
+		idx.timer.Stop()
+	}
+
+	idx.timer = time.AfterFunc(saveDelay, func() {
+		idx.Save()
+	})
+}
+
+// Save writes the snapshot, replacing the previous one atomically.
+func (idx *Index) Save() error {
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	tmp, err := ioutil.TempFile(idx.dir, snapshotFile+".*")
+	if err != nil {
+		return err
+	}
+
+	snap := snapshot{Docs: idx.docs, Postings: idx.postings, Lengths: idx.lengths}
+
+	if err := gob.NewEncoder(tmp).Encode(&snap); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(idx.dir, snapshotFile))
+}
@@ -0,0 +1,279 @@
+package search
+
+import "strings"
+
+/****
+*********************
+PORTER STEMMER
+*********************
+****/
+
+// Stem reduces an English word to its stem with the Porter algorithm, so that
+// "connected", "connecting" and "connections" all index as "connect". The word
+// must already be lower case; words of two letters or less are kept as is.
+func Stem(word string) string {
+
+	if len(word) <= 2 || !isASCIILetters(word) {
+		return word
+	}
+
+	w := []byte(word)
+
+	w = step1a(w)
+	w = step1b(w)
+	w = step1c(w)
+	w = step2(w)
+	w = step3(w)
+	w = step4(w)
+	w = step5(w)
+
+	return string(w)
+}
+
+func isASCIILetters(word string) bool {
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// isConsonant reports whether w[i] is a consonant; "y" is one when it follows
+// a vowel or starts the word.
+func isConsonant(w []byte, i int) bool {
+
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(w, i-1)
+	}
+
+	return true
+}
+
+// measure counts the VC sequences of w, the m of the Porter paper.
+func measure(w []byte) int {
+
+	m := 0
+	i := 0
+	n := len(w)
+
+	for i < n && isConsonant(w, i) {
+		i++
+	}
+
+	for i < n {
+		for i < n && !isConsonant(w, i) {
+			i++
+		}
+		if i >= n {
+			break
+		}
+		for i < n && isConsonant(w, i) {
+			i++
+		}
+		m++
+	}
+
+	return m
+}
+
+func hasVowel(w []byte) bool {
+	for i := range w {
+		if !isConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func endsDoubleConsonant(w []byte) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && isConsonant(w, n-1)
+}
+
+// endsCVC reports a consonant-vowel-consonant ending where the last consonant
+// is not w, x or y, as in "hop" but not "snow".
+func endsCVC(w []byte) bool {
+
+	n := len(w)
+	if n < 3 || !isConsonant(w, n-1) || isConsonant(w, n-2) || !isConsonant(w, n-3) {
+		return false
+	}
+
+	switch w[n-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+
+	return true
+}
+
+func hasSuffix(w []byte, suffix string) bool {
+	return strings.HasSuffix(string(w), suffix)
+}
+
+// replaceSuffix swaps suffix for replacement when the remaining stem has a
+// measure greater than minMeasure. It reports whether the suffix matched.
+func replaceSuffix(w []byte, suffix string, replacement string, minMeasure int) ([]byte, bool) {
+
+	if !hasSuffix(w, suffix) {
+		return w, false
+	}
+
+	stem := w[:len(w)-len(suffix)]
+	if measure(stem) > minMeasure {
+		return append(stem[:len(stem):len(stem)], replacement...), true
+	}
+
+	return w, true
+}
+
+func step1a(w []byte) []byte {
+
+	switch {
+	case hasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ss"):
+		return w
+	case hasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+
+	return w
+}
+
+func step1b(w []byte) []byte {
+
+	if hasSuffix(w, "eed") {
+		if measure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+
+	var stem []byte
+	switch {
+	case hasSuffix(w, "ed") && hasVowel(w[:len(w)-2]):
+		stem = w[:len(w)-2]
+	case hasSuffix(w, "ing") && hasVowel(w[:len(w)-3]):
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+
+	stem = stem[:len(stem):len(stem)]
+
+	switch {
+	case hasSuffix(stem, "at"), hasSuffix(stem, "bl"), hasSuffix(stem, "iz"):
+		return append(stem, 'e')
+	case endsDoubleConsonant(stem):
+		switch stem[len(stem)-1] {
+		case 'l', 's', 'z':
+			return stem
+		}
+		return stem[:len(stem)-1]
+	case measure(stem) == 1 && endsCVC(stem):
+		return append(stem, 'e')
+	}
+
+	return stem
+}
+
+func step1c(w []byte) []byte {
+
+	if hasSuffix(w, "y") && hasVowel(w[:len(w)-1]) {
+		return append(w[:len(w)-1:len(w)-1], 'i')
+	}
+
+	return w
+}
+
+var step2Suffixes = [][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+}
+
+func step2(w []byte) []byte {
+
+	for _, s := range step2Suffixes {
+		if out, matched := replaceSuffix(w, s[0], s[1], 0); matched {
+			return out
+		}
+	}
+
+	return w
+}
+
+var step3Suffixes = [][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+func step3(w []byte) []byte {
+
+	for _, s := range step3Suffixes {
+		if out, matched := replaceSuffix(w, s[0], s[1], 0); matched {
+			return out
+		}
+	}
+
+	return w
+}
+
+var step4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+func step4(w []byte) []byte {
+
+	// Longest suffix first, so "ement" wins over "ment" and "ent".
+	best := ""
+	for _, suffix := range step4Suffixes {
+		if hasSuffix(w, suffix) && len(suffix) > len(best) {
+			best = suffix
+		}
+	}
+
+	if len(best) == 0 {
+		return w
+	}
+
+	stem := w[:len(w)-len(best)]
+	if measure(stem) <= 1 {
+		return w
+	}
+
+	if best == "ion" {
+		if len(stem) == 0 || (stem[len(stem)-1] != 's' && stem[len(stem)-1] != 't') {
+			return w
+		}
+	}
+
+	return stem
+}
+
+func step5(w []byte) []byte {
+
+	if hasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		m := measure(stem)
+		if m > 1 || (m == 1 && !endsCVC(stem)) {
+			w = stem
+		}
+	}
+
+	if measure(w) > 1 && endsDoubleConsonant(w) && w[len(w)-1] == 'l' {
+		w = w[:len(w)-1]
+	}
+
+	return w
+}
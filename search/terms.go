@@ -0,0 +1,79 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Terms is a search string split the way WordPress splits the s= query var.
+type Terms struct {
+	// Full is the whole search string, used for exact sentence ranking.
+	Full string
+	// Include are the terms that must all match.
+	Include []string
+	// Exclude are the "-word" terms that must not match.
+	Exclude []string
+}
+
+// maxTerms mirrors WordPress: more than nine terms are searched as one phrase.
+const maxTerms = 9
+
+var termRegexp = regexp.MustCompile(`"[^"]*("|$)|[^\t ",+]+`)
+
+// Stopwords are dropped from searches with several terms, as in
+// WP_Query::get_search_stopwords().
+var Stopwords = map[string]bool{
+	"about": true, "an": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "com": true, "for": true, "from": true, "how": true, "in": true,
+	"is": true, "it": true, "of": true, "on": true, "or": true, "that": true,
+	"the": true, "this": true, "to": true, "was": true, "what": true, "when": true,
+	"where": true, "who": true, "will": true, "with": true, "www": true,
+}
+
+// ParseTerms splits a search string into terms. Quoted phrases stay together,
+// terms prefixed with "-" are excluded and, when there is more than one term,
+// stopwords and single letters are ignored.
+func ParseTerms(query string) Terms {
+
+	query = strings.TrimSpace(strings.Replace(query, "\\", "", -1))
+
+	terms := Terms{Full: strings.Trim(query, `"`)}
+
+	matches := termRegexp.FindAllString(query, -1)
+	if len(matches) > maxTerms {
+		matches = []string{query}
+	}
+
+	for _, match := range matches {
+
+		quoted := strings.HasPrefix(match, `"`)
+		term := strings.TrimSpace(strings.Trim(match, `"`))
+
+		exclude := false
+		if !quoted && strings.HasPrefix(term, "-") {
+			exclude = true
+			term = term[1:]
+		}
+
+		if len(term) == 0 {
+			continue
+		}
+
+		if !quoted && len(matches) > 1 && (len([]rune(term)) == 1 || Stopwords[strings.ToLower(term)]) {
+			continue
+		}
+
+		if exclude {
+			terms.Exclude = append(terms.Exclude, term)
+		} else {
+			terms.Include = append(terms.Include, term)
+		}
+	}
+
+	return terms
+}
+
+// Empty reports whether nothing is left to search for.
+func (t Terms) Empty() bool {
+	return len(t.Include) == 0
+}
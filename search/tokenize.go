@@ -0,0 +1,41 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// indexStopwords are common English words left out of the index. The list is
+// longer than the WordPress one since these words carry no weight in BM25.
+var indexStopwords = map[string]bool{
+	"a": true, "about": true, "an": true, "and": true, "are": true, "as": true,
+	"at": true, "be": true, "but": true, "by": true, "for": true, "from": true,
+	"has": true, "have": true, "he": true, "her": true, "his": true, "how": true,
+	"i": true, "if": true, "in": true, "into": true, "is": true, "it": true,
+	"its": true, "not": true, "of": true, "on": true, "or": true, "our": true,
+	"she": true, "so": true, "that": true, "the": true, "their": true, "them": true,
+	"then": true, "there": true, "these": true, "they": true, "this": true,
+	"to": true, "was": true, "we": true, "were": true, "what": true, "when": true,
+	"where": true, "which": true, "who": true, "will": true, "with": true,
+	"you": true, "your": true,
+}
+
+// Tokenize splits text into lower case, stemmed terms, dropping HTML markup,
+// punctuation and stopwords.
+func Tokenize(text string) []string {
+
+	var tokens []string
+
+	words := strings.FieldsFunc(strings.ToLower(PlainText(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		if indexStopwords[word] {
+			continue
+		}
+		tokens = append(tokens, Stem(word))
+	}
+
+	return tokens
+}
@@ -0,0 +1,127 @@
+package search
+
+// trie holds the terms of the index for prefix and fuzzy lookups. The index
+// keeps it up to date as terms come and go, so searches only read it.
+type trie struct {
+	children map[rune]*trie
+	term     bool
+}
+
+func newTrie() *trie {
+	return &trie{children: make(map[rune]*trie)}
+}
+
+func (t *trie) insert(term string) {
+
+	node := t
+	for _, r := range term {
+		child, ok := node.children[r]
+		if !ok {
+			child = newTrie()
+			node.children[r] = child
+		}
+		node = child
+	}
+
+	node.term = true
+}
+
+func (t *trie) remove(term string) {
+	t.removeRunes([]rune(term))
+}
+
+// removeRunes removes the term below the node, pruning the nodes left with
+// no term below them, and tells whether the node is one of them.
+func (t *trie) removeRunes(term []rune) bool {
+
+	if len(term) == 0 {
+		t.term = false
+	} else if child, ok := t.children[term[0]]; ok && child.removeRunes(term[1:]) {
+		delete(t.children, term[0])
+	}
+
+	return !t.term && len(t.children) == 0
+}
+
+// withPrefix calls fn with every term starting with the prefix.
+func (t *trie) withPrefix(prefix string, fn func(term string)) {
+
+	node := t
+	for _, r := range prefix {
+		if node = node.children[r]; node == nil {
+			return
+		}
+	}
+
+	node.walk([]rune(prefix), fn)
+}
+
+func (t *trie) walk(path []rune, fn func(term string)) {
+
+	if t.term {
+		fn(string(path))
+	}
+
+	for r, child := range t.children {
+		child.walk(append(path, r), fn)
+	}
+}
+
+// within calls fn with every term at most max edits away from word. It
+// computes the Levenshtein rows of word along the branches, leaving a
+// branch as soon as every cell of a row exceeds max, so only the terms
+// sharing a close enough beginning with word are looked at.
+func (t *trie) within(word string, max int, fn func(term string)) {
+
+	runes := []rune(word)
+
+	row := make([]int, len(runes)+1)
+	for j := range row {
+		row[j] = j
+	}
+
+	for r, child := range t.children {
+		child.withinRow(runes, []rune{r}, row, max, fn)
+	}
+}
+
+func (t *trie) withinRow(word []rune, path []rune, prev []int, max int, fn func(term string)) {
+
+	r := path[len(path)-1]
+
+	row := make([]int, len(word)+1)
+	row[0] = prev[0] + 1
+	rowMin := row[0]
+
+	for j := 1; j <= len(word); j++ {
+
+		cost := 1
+		if word[j-1] == r {
+			cost = 0
+		}
+
+		row[j] = prev[j-1] + cost
+		if prev[j]+1 < row[j] {
+			row[j] = prev[j] + 1
+		}
+		if row[j-1]+1 < row[j] {
+			row[j] = row[j-1] + 1
+		}
+
+		if row[j] < rowMin {
+			rowMin = row[j]
+		}
+	}
+
+	if t.term && row[len(word)] <= max {
+		fn(string(path))
+	}
+
+	if rowMin > max {
+		return
+	}
+
+	for r, child := range t.children {
+		child.withinRow(word, append(path, r), row, max, fn)
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"database/sql"
+
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/model"
+)
+
+func NewCommentService(db *sql.DB, prefix string) *Comment {
+
+	return &Comment{db: db, prefix: prefix}
+}
+
+type Comment struct {
+	db     *sql.DB
+	prefix string
+}
+
+type ArgsComment struct {
+	CommentID int64
+	PostID    int64
+	Approved  string
+}
+
+const commentColumns = `
+			comment_ID,
+			comment_post_ID,
+			comment_author,
+			comment_author_email,
+			comment_author_url,
+			comment_author_IP,
+			comment_date,
+			comment_date_gmt,
+			comment_content,
+			comment_karma,
+			comment_approved,
+			comment_agent,
+			comment_type,
+			comment_parent,
+			user_id
+`
+
+func (c *Comment) GetComments(args ArgsComment) ([]*model.Comments, error) {
+
+	var comments []*model.Comments
+
+	var queryMap []interface{}
+
+	query := `
+		SELECT
+	` + commentColumns + `
+		FROM
+	` + c.prefix + "comments" + `
+		WHERE
+			1 = 1
+	`
+
+	if args.CommentID > 0 {
+		query = query + " AND comment_ID = ? "
+		queryMap = append(queryMap, args.CommentID)
+	}
+
+	if args.PostID > 0 {
+		query = query + " AND comment_post_ID = ? "
+		queryMap = append(queryMap, args.PostID)
+	}
+
+	if len(args.Approved) > 0 {
+		query = query + " AND comment_approved = ? "
+		queryMap = append(queryMap, args.Approved)
+	}
+
+	query = query + " ORDER BY comment_date_gmt ASC;"
+
+	rows, err := c.db.Query(query, queryMap...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return comments, err
+}
+
+// FindByID returns a single comment, or sql.ErrNoRows when it does not exist.
+func (c *Comment) FindByID(commentID int64) (*model.Comments, error) {
+
+	comments, err := c.GetComments(ArgsComment{CommentID: commentID})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(comments) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return comments[0], nil
+}
+
+func scanComment(row rowScanner) (*model.Comments, error) {
+
+	var commentIDInt, postIDInt, parentIDInt, userIDInt int64
+	var commentDate, commentDateGMT string
+
+	comment := &model.Comments{}
+
+	err := row.Scan(
+		&commentIDInt,
+		&postIDInt,
+		&comment.CommentAuthor,
+		&comment.CommentAuthorEmail,
+		&comment.CommentAuthorURL,
+		&comment.CommentAuthorIP,
+		&commentDate,
+		&commentDateGMT,
+		&comment.CommentContent,
+		&comment.CommentKarma,
+		&comment.CommentApproved,
+		&comment.CommentAgent,
+		&comment.CommentType,
+		&parentIDInt,
+		&userIDInt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	comment.CommentID = helper.IntToGraphqlID(commentIDInt)
+	comment.CommentPostID = helper.IntToGraphqlID(postIDInt)
+	comment.CommentParent = helper.IntToGraphqlID(parentIDInt)
+	comment.UserID = helper.IntToGraphqlID(userIDInt)
+
+	if comment.CommentDate, err = helper.StringToTime(commentDate); err != nil {
+		return nil, err
+	}
+	if comment.CommentDateGMT, err = helper.StringToTime(commentDateGMT); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
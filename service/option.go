@@ -0,0 +1,39 @@
+package service
+
+import (
+	"database/sql"
+)
+
+func NewOptionService(db *sql.DB, prefix string) *Option {
+
+	return &Option{db: db, prefix: prefix}
+}
+
+type Option struct {
+	db     *sql.DB
+	prefix string
+}
+
+// Get returns the value of an option, or def when it is not set.
+func (o *Option) Get(name string, def string) (string, error) {
+
+	var value string
+
+	err := o.db.QueryRow(`
+		SELECT
+			option_value
+		FROM
+	`+o.prefix+"options"+`
+		WHERE
+			option_name = ?
+	`, name).Scan(&value)
+
+	if err == sql.ErrNoRows {
+		return def, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
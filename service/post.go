@@ -1,6 +1,15 @@
 package service
 
-import "database/sql"
+import (
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/model"
+)
 
 func NewPostService(db *sql.DB, prefix string) *Post {
 
@@ -11,3 +20,370 @@ type Post struct {
 	db     *sql.DB
 	prefix string
 }
+
+type ArgsPost struct {
+	PostID     int64
+	PostIDs    []int64
+	ParentID   int64
+	AuthorID   int64
+	PostType   string
+	PostStatus string
+	Slug       string
+	AfterID    int64
+	Limit      int
+	Visibility *Visibility
+}
+
+const postColumns = `
+			ID,
+			post_author,
+			post_date,
+			post_date_gmt,
+			post_content,
+			post_title,
+			post_excerpt,
+			post_status,
+			comment_status,
+			ping_status,
+			post_password,
+			post_name,
+			post_modified,
+			post_modified_gmt,
+			post_parent,
+			guid,
+			menu_order,
+			post_type,
+			post_mime_type,
+			comment_count
+`
+
+func (p *Post) GetPosts(args ArgsPost) ([]*model.Post, error) {
+
+	var posts []*model.Post
+
+	var queryMap []interface{}
+
+	query := `
+		SELECT
+	` + postColumns + `
+		FROM
+	` + p.prefix + "posts" + `
+		WHERE
+			1 = 1
+	`
+
+	visibilityWhere, visibilityMap := args.Visibility.where(p.prefix)
+	query = query + " AND " + visibilityWhere
+	queryMap = append(queryMap, visibilityMap...)
+
+	if args.PostID > 0 {
+		query = query + " AND ID = ? "
+		queryMap = append(queryMap, args.PostID)
+	}
+
+	if len(args.PostIDs) > 0 {
+		query = query + " AND ID IN (" + placeholders(len(args.PostIDs)) + ") "
+		for _, postID := range args.PostIDs {
+			queryMap = append(queryMap, postID)
+		}
+	}
+
+	if args.ParentID > 0 {
+		query = query + " AND post_parent = ? "
+		queryMap = append(queryMap, args.ParentID)
+	}
+
+	if args.AuthorID > 0 {
+		query = query + " AND post_author = ? "
+		queryMap = append(queryMap, args.AuthorID)
+	}
+
+	if len(args.PostType) > 0 {
+		query = query + " AND post_type = ? "
+		queryMap = append(queryMap, args.PostType)
+	}
+
+	if len(args.PostStatus) > 0 {
+		query = query + " AND post_status = ? "
+		queryMap = append(queryMap, args.PostStatus)
+	}
+
+	if len(args.Slug) > 0 {
+		query = query + " AND post_name = ? "
+		queryMap = append(queryMap, args.Slug)
+	}
+
+	if args.AfterID > 0 {
+		query = query + " AND ID > ? "
+		queryMap = append(queryMap, args.AfterID)
+	}
+
+	if args.Limit > 0 {
+		query = query + " ORDER BY ID ASC LIMIT ? "
+		queryMap = append(queryMap, args.Limit)
+	}
+
+	query = query + ";"
+
+	rows, err := p.db.Query(query, queryMap...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, err
+}
+
+// FindByID returns a single post, or sql.ErrNoRows when it does not exist or
+// is not visible.
+func (p *Post) FindByID(postID int64, visibility *Visibility) (*model.Post, error) {
+
+	posts, err := p.GetPosts(ArgsPost{PostID: postID, Visibility: visibility})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(posts) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return posts[0], nil
+}
+
+// FindAutosave returns the newest autosave of the post, or nil when there is
+// none.
+func (p *Post) FindAutosave(postID int64) (*model.Post, error) {
+
+	posts, err := p.GetPosts(ArgsPost{
+		ParentID:   postID,
+		PostType:   "revision",
+		Slug:       strconv.FormatInt(postID, 10) + "-autosave-v1",
+		Visibility: Unrestricted,
+	})
+
+	if err != nil || len(posts) == 0 {
+		return nil, err
+	}
+
+	return posts[len(posts)-1], nil
+}
+
+// GetAttachments returns the image attachments with the given IDs in that
+// order or, when ids is empty, the images attached to the parent post in
+// menu order.
+func (p *Post) GetAttachments(ids []int64, parentID int64) ([]*model.Post, error) {
+
+	args := ArgsPost{PostType: "attachment", PostIDs: ids}
+	if len(ids) == 0 {
+		args.ParentID = parentID
+	}
+
+	if len(args.PostIDs) == 0 && args.ParentID == 0 {
+		return nil, nil
+	}
+
+	posts, err := p.GetPosts(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []*model.Post
+	for _, post := range posts {
+		if strings.HasPrefix(post.PostMimeType, "image/") {
+			images = append(images, post)
+		}
+	}
+
+	if len(ids) > 0 {
+
+		position := make(map[graphql.ID]int)
+		for i, id := range ids {
+			position[helper.IntToGraphqlID(id)] = i
+		}
+
+		sort.SliceStable(images, func(i, j int) bool {
+			return position[images[i].PostID] < position[images[j].PostID]
+		})
+
+	} else {
+
+		sort.SliceStable(images, func(i, j int) bool {
+			if images[i].MenuOrder != images[j].MenuOrder {
+				return images[i].MenuOrder < images[j].MenuOrder
+			}
+			return helper.GraphqlIDToInt(images[i].PostID) < helper.GraphqlIDToInt(images[j].PostID)
+		})
+	}
+
+	return images, nil
+}
+
+func (p *Post) GetMeta(postID graphql.ID) ([]*model.PostMeta, error) {
+
+	var postMetas []*model.PostMeta
+	var metaIDInt int64
+	var postIDInt int64
+
+	rows, err := p.db.Query(`
+		SELECT
+			meta_id,
+			post_id,
+			meta_key,
+			meta_value
+		FROM
+	`+p.prefix+"postmeta"+`
+		WHERE
+			post_id = ?
+	`, postID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		postMeta := &model.PostMeta{}
+		err := rows.Scan(&metaIDInt, &postIDInt, &postMeta.MetaKey, &postMeta.MetaValue)
+
+		if err != nil {
+			return nil, err
+		}
+
+		postMeta.MetaID = helper.IntToGraphqlID(metaIDInt)
+		postMeta.PostID = helper.IntToGraphqlID(postIDInt)
+
+		postMetas = append(postMetas, postMeta)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return postMetas, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPost(row rowScanner) (*model.Post, error) {
+
+	var postIDInt int64
+	var authorIDInt int64
+	var parentIDInt int64
+	var postDate, postDateGMT, postModified, postModifiedGMT string
+
+	post := &model.Post{}
+
+	err := row.Scan(
+		&postIDInt,
+		&authorIDInt,
+		&postDate,
+		&postDateGMT,
+		&post.PostContent,
+		&post.PostTitle,
+		&post.PostExcerpt,
+		&post.PostStatus,
+		&post.CommentStatus,
+		&post.PingStatus,
+		&post.PostPassword,
+		&post.PostName,
+		&postModified,
+		&postModifiedGMT,
+		&parentIDInt,
+		&post.GUID,
+		&post.MenuOrder,
+		&post.PostType,
+		&post.PostMimeType,
+		&post.CommentCount)
+
+	if err != nil {
+		return nil, err
+	}
+
+	post.PostID = helper.IntToGraphqlID(postIDInt)
+	post.PostAuthor = helper.IntToGraphqlID(authorIDInt)
+	post.PostParent = helper.IntToGraphqlID(parentIDInt)
+
+	if post.PostDate, err = helper.StringToTime(postDate); err != nil {
+		return nil, err
+	}
+	if post.PostDateGMT, err = helper.StringToTime(postDateGMT); err != nil {
+		return nil, err
+	}
+	if post.PostModified, err = helper.StringToTime(postModified); err != nil {
+		return nil, err
+	}
+	if post.PostModifiedGMT, err = helper.StringToTime(postModifiedGMT); err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// GetTermNames returns the names of the terms attached to each of the posts,
+// keyed by post ID.
+func (p *Post) GetTermNames(postIDs []int64) (map[int64][]string, error) {
+
+	names := make(map[int64][]string)
+
+	if len(postIDs) == 0 {
+		return names, nil
+	}
+
+	var queryMap []interface{}
+	for _, postID := range postIDs {
+		queryMap = append(queryMap, postID)
+	}
+
+	rows, err := p.db.Query(`
+		SELECT
+			tr.object_id,
+			t.name
+		FROM
+	`+p.prefix+"term_relationships tr, "+p.prefix+"term_taxonomy tt, "+p.prefix+"terms t"+`
+		WHERE
+			tr.term_taxonomy_id = tt.term_taxonomy_id
+			AND tt.term_id = t.term_id
+			AND tr.object_id IN (`+placeholders(len(postIDs))+`)
+	`, queryMap...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var postID int64
+		var name string
+
+		if err := rows.Scan(&postID, &name); err != nil {
+			return nil, err
+		}
+
+		names[postID] = append(names[postID], name)
+	}
+
+	return names, rows.Err()
+}
@@ -0,0 +1,495 @@
+package service
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iyut/graphql-go/model"
+	"github.com/iyut/graphql-go/search"
+)
+
+func NewSearchService(db *sql.DB, prefix string) *Search {
+
+	return &Search{db: db, prefix: prefix}
+}
+
+// Search looks up the visible posts and pages, and approved comments on them.
+// Password protected posts, and their comments, are left out unless the
+// viewer can edit them. It uses MySQL FULLTEXT indexes when the tables have
+// them and falls back to the LIKE based search WordPress does for s=
+// otherwise.
+type Search struct {
+	db     *sql.DB
+	prefix string
+}
+
+type ArgsSearch struct {
+	Query      string
+	Types      []string
+	Offset     int
+	Limit      int
+	Visibility *Visibility
+}
+
+// MaxSearchWindow is how far into the hits Search pages. Every source ranks
+// the hits of the pages before the one asked for, so no hit past it is
+// returned.
+const MaxSearchWindow = 1000
+
+const (
+	SearchTypePost    = "post"
+	SearchTypePage    = "page"
+	SearchTypeComment = "comment"
+)
+
+// fulltextIndexes caches whether a table has a FULLTEXT index on given columns.
+var fulltextIndexes = struct {
+	sync.Mutex
+	m map[string]bool
+}{m: make(map[string]bool)}
+
+// Search returns the hits of the requested page ordered by relevance, and the
+// total number of hits, which a Limit of 0 only counts. Scores range from 0
+// to 1 whatever the source, see normalizeScores.
+func (s *Search) Search(args ArgsSearch) ([]*model.SearchHit, int, error) {
+
+	terms := search.ParseTerms(args.Query)
+	if terms.Empty() {
+		return nil, 0, nil
+	}
+
+	args.Offset, args.Limit = args.page()
+
+	var postTypes []string
+	withComments := false
+
+	for _, t := range args.Types {
+		switch t {
+		case SearchTypePost, SearchTypePage:
+			postTypes = append(postTypes, t)
+		case SearchTypeComment:
+			withComments = true
+		}
+	}
+
+	// Every source needs to return enough hits to fill the page after merging.
+	window := args.Offset + args.Limit
+
+	var hits []*model.SearchHit
+	total := 0
+
+	if len(postTypes) > 0 {
+
+		postHits, count, err := s.searchPosts(terms, postTypes, window, args.Visibility)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		hits = append(hits, postHits...)
+		total += count
+	}
+
+	if withComments {
+
+		commentHits, count, err := s.searchComments(terms, window, args.Visibility)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		hits = append(hits, commentHits...)
+		total += count
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hitDate(hits[i]).After(hitDate(hits[j]))
+	})
+
+	if args.Offset >= len(hits) {
+		return nil, total, nil
+	}
+
+	hits = hits[args.Offset:]
+	if len(hits) > args.Limit {
+		hits = hits[:args.Limit]
+	}
+
+	return hits, total, nil
+}
+
+// page returns the offset and the limit of the page, kept within
+// MaxSearchWindow.
+func (args ArgsSearch) page() (int, int) {
+
+	offset, limit := args.Offset, args.Limit
+
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > MaxSearchWindow {
+		offset = MaxSearchWindow
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	if limit > MaxSearchWindow-offset {
+		limit = MaxSearchWindow - offset
+	}
+
+	return offset, limit
+}
+
+func (s *Search) searchPosts(terms search.Terms, postTypes []string, limit int, visibility *Visibility) ([]*model.SearchHit, int, error) {
+
+	var score string
+	var scoreMap []interface{}
+	var maxScore float64
+
+	where, whereMap := visibility.where(s.prefix)
+
+	protected, protectedMap := visibility.protectedWhere()
+	where = where + " AND " + protected
+	whereMap = append(whereMap, protectedMap...)
+
+	where = where + " AND post_type IN (" + placeholders(len(postTypes)) + ") "
+	for _, postType := range postTypes {
+		whereMap = append(whereMap, postType)
+	}
+
+	if s.hasFulltext("posts", "post_title,post_content") {
+
+		where = where + " AND MATCH(post_title, post_content) AGAINST(? IN BOOLEAN MODE) "
+		whereMap = append(whereMap, booleanQuery(terms))
+
+		score = "MATCH(post_title, post_content) AGAINST(? IN BOOLEAN MODE) + CASE WHEN post_title LIKE ? THEN 1 ELSE 0 END"
+		scoreMap = append(scoreMap, booleanQuery(terms), likeTerm(terms.Full))
+
+	} else {
+
+		for _, term := range terms.Include {
+			where = where + " AND (post_title LIKE ? OR post_excerpt LIKE ? OR post_content LIKE ?) "
+			whereMap = append(whereMap, likeTerm(term), likeTerm(term), likeTerm(term))
+		}
+
+		for _, term := range terms.Exclude {
+			where = where + " AND post_title NOT LIKE ? AND post_excerpt NOT LIKE ? AND post_content NOT LIKE ? "
+			whereMap = append(whereMap, likeTerm(term), likeTerm(term), likeTerm(term))
+		}
+
+		score, scoreMap = likeOrder(terms)
+		maxScore = likeOrderMax
+	}
+
+	var total int
+	err := s.db.QueryRow(`
+		SELECT
+			COUNT(*)
+		FROM
+	`+s.prefix+"posts"+`
+		WHERE
+	`+where, whereMap...).Scan(&total)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT
+	`+postColumns+`,
+			`+score+` AS score
+		FROM
+	`+s.prefix+"posts"+`
+		WHERE
+	`+where+`
+		ORDER BY score DESC, post_date DESC
+		LIMIT ?
+	`, append(append(scoreMap, whereMap...), limit)...)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	var hits []*model.SearchHit
+
+	for rows.Next() {
+
+		hit := &model.SearchHit{ObjectType: SearchTypePost}
+
+		hit.Post, err = scanPost(&extraScanner{row: rows, extra: []interface{}{&hit.Score}})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		hit.ObjectType = hit.Post.PostType
+		hit.ObjectID = hit.Post.PostID
+
+		hits = append(hits, hit)
+	}
+
+	normalizeScores(hits, maxScore)
+
+	return hits, total, rows.Err()
+}
+
+func (s *Search) searchComments(terms search.Terms, limit int, visibility *Visibility) ([]*model.SearchHit, int, error) {
+
+	var score string
+	var scoreMap []interface{}
+	var maxScore float64
+
+	visibilityWhere, whereMap := visibility.where(s.prefix)
+
+	protected, protectedMap := visibility.protectedWhere()
+	whereMap = append(whereMap, protectedMap...)
+
+	where := ` comment_approved = '1' AND comment_post_ID IN (
+			SELECT ID FROM ` + s.prefix + `posts WHERE ` + visibilityWhere + ` AND ` + protected + `
+		) `
+
+	if s.hasFulltext("comments", "comment_content") {
+
+		where = where + " AND MATCH(comment_content) AGAINST(? IN BOOLEAN MODE) "
+		whereMap = append(whereMap, booleanQuery(terms))
+
+		score = "MATCH(comment_content) AGAINST(? IN BOOLEAN MODE)"
+		scoreMap = append(scoreMap, booleanQuery(terms))
+
+	} else {
+
+		for _, term := range terms.Include {
+			where = where + " AND comment_content LIKE ? "
+			whereMap = append(whereMap, likeTerm(term))
+		}
+
+		for _, term := range terms.Exclude {
+			where = where + " AND comment_content NOT LIKE ? "
+			whereMap = append(whereMap, likeTerm(term))
+		}
+
+		score = "CASE WHEN comment_content LIKE ? THEN 2 ELSE 1 END"
+		scoreMap = append(scoreMap, likeTerm(terms.Full))
+		maxScore = 2
+	}
+
+	var total int
+	err := s.db.QueryRow(`
+		SELECT
+			COUNT(*)
+		FROM
+	`+s.prefix+"comments"+`
+		WHERE
+	`+where, whereMap...).Scan(&total)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT
+	`+commentColumns+`,
+			`+score+` AS score
+		FROM
+	`+s.prefix+"comments"+`
+		WHERE
+	`+where+`
+		ORDER BY score DESC, comment_date DESC
+		LIMIT ?
+	`, append(append(scoreMap, whereMap...), limit)...)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	var hits []*model.SearchHit
+
+	for rows.Next() {
+
+		hit := &model.SearchHit{ObjectType: SearchTypeComment}
+
+		hit.Comment, err = scanComment(&extraScanner{row: rows, extra: []interface{}{&hit.Score}})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		hit.ObjectID = hit.Comment.CommentID
+
+		hits = append(hits, hit)
+	}
+
+	normalizeScores(hits, maxScore)
+
+	return hits, total, rows.Err()
+}
+
+func hitDate(hit *model.SearchHit) time.Time {
+
+	if hit.Post != nil {
+		return hit.Post.PostDateGMT
+	}
+
+	if hit.Comment != nil {
+		return hit.Comment.CommentDateGMT
+	}
+
+	return time.Time{}
+}
+
+// normalizeScores brings the scores of the hits of one source between 0 and
+// 1, so that sources merge on an equal footing: LIKE relevance ranks divide
+// by the best rank, unbounded FULLTEXT scores, for which maxScore is 0, by
+// the best score, that of the first hit on every page.
+func normalizeScores(hits []*model.SearchHit, maxScore float64) {
+
+	if maxScore <= 0 {
+		for _, hit := range hits {
+			if hit.Score > maxScore {
+				maxScore = hit.Score
+			}
+		}
+	}
+
+	if maxScore <= 0 {
+		return
+	}
+
+	for _, hit := range hits {
+		hit.Score = hit.Score / maxScore
+	}
+}
+
+// likeOrderMax is the best rank of likeOrder.
+const likeOrderMax = 6
+
+// likeOrder builds the relevance expression of WP_Query::parse_search_order():
+// the full sentence in the title first, then all terms in the title, any term
+// in the title, the sentence in the excerpt and finally in the content.
+func likeOrder(terms search.Terms) (string, []interface{}) {
+
+	var score []string
+	var scoreMap []interface{}
+
+	score = append(score, "WHEN post_title LIKE ? THEN 6")
+	scoreMap = append(scoreMap, likeTerm(terms.Full))
+
+	if len(terms.Include) > 1 {
+
+		var titleTerms []string
+		for _, term := range terms.Include {
+			titleTerms = append(titleTerms, "post_title LIKE ?")
+			scoreMap = append(scoreMap, likeTerm(term))
+		}
+		score = append(score, "WHEN "+strings.Join(titleTerms, " AND ")+" THEN 5")
+
+		for _, term := range terms.Include {
+			scoreMap = append(scoreMap, likeTerm(term))
+		}
+		score = append(score, "WHEN "+strings.Join(titleTerms, " OR ")+" THEN 4")
+	}
+
+	score = append(score, "WHEN post_excerpt LIKE ? THEN 3", "WHEN post_content LIKE ? THEN 2")
+	scoreMap = append(scoreMap, likeTerm(terms.Full), likeTerm(terms.Full))
+
+	return "CASE " + strings.Join(score, " ") + " ELSE 1 END", scoreMap
+}
+
+// hasFulltext reports whether the table has a FULLTEXT index on exactly the
+// given comma separated columns.
+func (s *Search) hasFulltext(table string, columns string) bool {
+
+	key := s.prefix + table + ":" + columns
+
+	fulltextIndexes.Lock()
+	defer fulltextIndexes.Unlock()
+
+	if found, ok := fulltextIndexes.m[key]; ok {
+		return found
+	}
+
+	var count int
+	err := s.db.QueryRow(`
+		SELECT
+			COUNT(*)
+		FROM (
+			SELECT
+				GROUP_CONCAT(COLUMN_NAME ORDER BY SEQ_IN_INDEX) AS index_columns
+			FROM
+				information_schema.STATISTICS
+			WHERE
+				TABLE_SCHEMA = DATABASE()
+				AND TABLE_NAME = ?
+				AND INDEX_TYPE = 'FULLTEXT'
+			GROUP BY
+				INDEX_NAME
+		) fulltext_indexes
+		WHERE
+			index_columns = ?
+	`, s.prefix+table, columns).Scan(&count)
+
+	// A failed lookup falls back to LIKE for now and is tried again.
+	if err != nil {
+		return false
+	}
+
+	found := count > 0
+	fulltextIndexes.m[key] = found
+
+	return found
+}
+
+// booleanQuery turns the terms into a MySQL boolean mode search string.
+func booleanQuery(terms search.Terms) string {
+
+	operators := strings.NewReplacer(`+`, " ", `-`, " ", `<`, " ", `>`, " ", `(`, " ", `)`, " ", `~`, " ", `*`, " ", `"`, " ", `@`, " ")
+
+	var parts []string
+
+	for _, term := range terms.Include {
+		term = strings.TrimSpace(operators.Replace(term))
+		if strings.Contains(term, " ") {
+			parts = append(parts, `+"`+term+`"`)
+		} else if len(term) > 0 {
+			parts = append(parts, "+"+term)
+		}
+	}
+
+	for _, term := range terms.Exclude {
+		term = strings.TrimSpace(operators.Replace(term))
+		if strings.Contains(term, " ") {
+			parts = append(parts, `-"`+term+`"`)
+		} else if len(term) > 0 {
+			parts = append(parts, "-"+term)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func likeTerm(term string) string {
+	return "%" + likeEscaper.Replace(term) + "%"
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// extraScanner scans additional trailing columns after the ones a scan
+// function knows about.
+type extraScanner struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+func (s *extraScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
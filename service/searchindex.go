@@ -0,0 +1,505 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iyut/graphql-go/events"
+	"github.com/iyut/graphql-go/helper"
+	"github.com/iyut/graphql-go/model"
+	"github.com/iyut/graphql-go/search"
+)
+
+// Searcher is implemented by the search backends.
+type Searcher interface {
+	Search(args ArgsSearch) ([]*model.SearchHit, int, error)
+}
+
+// rebuildBatchSize is the number of posts read per query while rebuilding,
+// and visibleBatchSize the number of hits checked per query while searching.
+const (
+	rebuildBatchSize = 500
+	visibleBatchSize = 1000
+)
+
+// NewIndexSearch opens the search index stored in dir. When the directory has
+// no index yet, it is built from the database in the background and searches
+// use MySQL until it is ready. A loaded index serves searches right away and
+// is checked against the database in the background, for the changes made
+// while the server was down.
+func NewIndexSearch(db *sql.DB, prefix string, dir string) (*IndexSearch, error) {
+
+	index, loaded, err := search.OpenIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &IndexSearch{
+		db:       db,
+		prefix:   prefix,
+		index:    index,
+		fallback: NewSearchService(db, prefix),
+		pending:  make(map[string]bool),
+		wake:     make(chan struct{}, 1),
+	}
+
+	if loaded {
+		s.ready = 1
+	}
+
+	go func() {
+		if err := s.Rebuild(); err != nil {
+			log.Printf("search index rebuild: %s", err)
+		}
+	}()
+
+	return s, nil
+}
+
+// IndexSearch searches the embedded inverted index instead of MySQL. Run
+// keeps the index up to date.
+type IndexSearch struct {
+	db       *sql.DB
+	prefix   string
+	index    *search.Index
+	fallback *Search
+	ready    int32
+
+	mu      sync.Mutex
+	pending map[string]bool
+	wake    chan struct{}
+}
+
+// Rebuild indexes every post and page, whatever its status, and every
+// approved comment, and removes the documents of the others. Search leaves
+// out what the viewer may not see.
+func (s *IndexSearch) Rebuild() error {
+
+	postService := NewPostService(s.db, s.prefix)
+	commentService := NewCommentService(s.db, s.prefix)
+
+	stale := make(map[string]bool)
+	for _, id := range s.index.IDs() {
+		stale[id] = true
+	}
+
+	var afterID int64
+	for {
+
+		posts, err := postService.GetPosts(ArgsPost{AfterID: afterID, Limit: rebuildBatchSize, Visibility: Unrestricted})
+		if err != nil {
+			return err
+		}
+
+		if len(posts) == 0 {
+			break
+		}
+
+		if err := s.putPosts(postService, posts); err != nil {
+			return err
+		}
+
+		for _, post := range posts {
+			if post.PostType == SearchTypePost || post.PostType == SearchTypePage {
+				delete(stale, postDocID(helper.GraphqlIDToInt(post.PostID)))
+			}
+		}
+
+		afterID = helper.GraphqlIDToInt(posts[len(posts)-1].PostID)
+	}
+
+	comments, err := commentService.GetComments(ArgsComment{Approved: "1"})
+	if err != nil {
+		return err
+	}
+
+	for _, comment := range comments {
+		s.putComment(comment)
+		delete(stale, commentDocID(helper.GraphqlIDToInt(comment.CommentID)))
+	}
+
+	for id := range stale {
+		s.index.Delete(id)
+	}
+
+	if err := s.index.Save(); err != nil {
+		return err
+	}
+
+	atomic.StoreInt32(&s.ready, 1)
+	log.Printf("search index rebuilt with %d documents, %d removed", s.index.Len(), len(stale))
+
+	return nil
+}
+
+func (s *IndexSearch) putPosts(postService *Post, posts []*model.Post) error {
+
+	var postIDs []int64
+	for _, post := range posts {
+		postIDs = append(postIDs, helper.GraphqlIDToInt(post.PostID))
+	}
+
+	termNames, err := postService.GetTermNames(postIDs)
+	if err != nil {
+		return err
+	}
+
+	for i, post := range posts {
+
+		if post.PostType != SearchTypePost && post.PostType != SearchTypePage {
+			continue
+		}
+
+		s.index.Put(search.Document{
+			ID:   postDocID(postIDs[i]),
+			Type: post.PostType,
+			Date: post.PostDateGMT,
+			Fields: map[string]string{
+				search.FieldTitle:   post.PostTitle,
+				search.FieldExcerpt: post.PostExcerpt,
+				search.FieldContent: post.PostContent,
+				search.FieldTerms:   strings.Join(termNames[postIDs[i]], " "),
+			},
+		})
+	}
+
+	return nil
+}
+
+func (s *IndexSearch) putComment(comment *model.Comments) {
+
+	s.index.Put(search.Document{
+		ID:   commentDocID(helper.GraphqlIDToInt(comment.CommentID)),
+		Type: SearchTypeComment,
+		Date: comment.CommentDateGMT,
+		Fields: map[string]string{
+			search.FieldContent: comment.CommentContent,
+		},
+	})
+}
+
+// IndexPost brings the post up to date in the index, removing it when it is
+// gone or no longer a post or page.
+func (s *IndexSearch) IndexPost(postID int64) error {
+
+	postService := NewPostService(s.db, s.prefix)
+
+	post, err := postService.FindByID(postID, Unrestricted)
+	if err == sql.ErrNoRows || (err == nil && post.PostType != SearchTypePost && post.PostType != SearchTypePage) {
+		s.index.Delete(postDocID(postID))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.putPosts(postService, []*model.Post{post})
+}
+
+// IndexComment brings the comment up to date in the index, removing it when
+// it is gone or no longer approved.
+func (s *IndexSearch) IndexComment(commentID int64) error {
+
+	commentService := NewCommentService(s.db, s.prefix)
+
+	comment, err := commentService.FindByID(commentID)
+	if err == sql.ErrNoRows || (err == nil && comment.CommentApproved != "1") {
+		s.index.Delete(commentDocID(commentID))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.putComment(comment)
+
+	return nil
+}
+
+// Run keeps the index up to date with the post and comment events of the
+// site, and rebuilds it every interval, if positive, for the changes no
+// event told about, until the context is done. It listens to the bus, which
+// drops no event for listeners, and indexes the objects of the events in
+// the background, each once however many events came about it meanwhile.
+func (s *IndexSearch) Run(ctx context.Context, bus *events.Bus, interval time.Duration) {
+
+	bus.Listen(s.Enqueue)
+
+	var rebuild <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		rebuild = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-s.wake:
+			for _, docID := range s.takePending() {
+				if err := s.indexDoc(docID); err != nil {
+					log.Printf("search index: %s: %s", docID, err)
+				}
+			}
+
+		case <-rebuild:
+			if err := s.Rebuild(); err != nil {
+				log.Printf("search index rebuild: %s", err)
+			}
+		}
+	}
+}
+
+// Enqueue marks the post or comment of the event for indexing. It is meant
+// to listen to the event bus, so it only takes note and wakes Run.
+func (s *IndexSearch) Enqueue(event events.Event) {
+
+	if len(event.Site) > 0 && event.Site != s.prefix {
+		return
+	}
+
+	var docID string
+	switch {
+	case strings.HasPrefix(event.Type, "post."):
+		docID = postDocID(event.ObjectID)
+	case strings.HasPrefix(event.Type, "comment."):
+		docID = commentDocID(event.ObjectID)
+	default:
+		return
+	}
+
+	s.mu.Lock()
+	s.pending[docID] = true
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// takePending returns the documents marked for indexing and clears them.
+func (s *IndexSearch) takePending() []string {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var docIDs []string
+	for docID := range s.pending {
+		docIDs = append(docIDs, docID)
+	}
+
+	s.pending = make(map[string]bool)
+
+	return docIDs
+}
+
+func (s *IndexSearch) indexDoc(docID string) error {
+
+	kind, id := splitDocID(docID)
+	if kind == "comment" {
+		return s.IndexComment(id)
+	}
+
+	return s.IndexPost(id)
+}
+
+// Search returns the hits the viewer can see. Hits are checked against the
+// database before counting, as the index may lag behind deletions and status
+// changes, and holds posts of every status.
+func (s *IndexSearch) Search(args ArgsSearch) ([]*model.SearchHit, int, error) {
+
+	if atomic.LoadInt32(&s.ready) == 0 {
+		return s.fallback.Search(args)
+	}
+
+	terms := search.ParseTerms(args.Query)
+	if terms.Empty() {
+		return nil, 0, nil
+	}
+
+	indexHits, err := s.visible(s.index.Search(terms, args.Types), args.Visibility)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := len(indexHits)
+
+	args.Offset, args.Limit = args.page()
+
+	if args.Offset >= len(indexHits) {
+		return nil, total, nil
+	}
+
+	indexHits = indexHits[args.Offset:]
+	if len(indexHits) > args.Limit {
+		indexHits = indexHits[:args.Limit]
+	}
+
+	postService := NewPostService(s.db, s.prefix)
+	commentService := NewCommentService(s.db, s.prefix)
+
+	var hits []*model.SearchHit
+
+	for _, indexHit := range indexHits {
+
+		kind, id := splitDocID(indexHit.ID)
+		hit := &model.SearchHit{ObjectType: indexHit.Type, ObjectID: helper.IntToGraphqlID(id), Score: indexHit.Score}
+
+		var err error
+		if kind == "comment" {
+			hit.Comment, err = commentService.FindByID(id)
+			if err == nil {
+				_, err = postService.FindByID(helper.GraphqlIDToInt(hit.Comment.CommentPostID), args.Visibility)
+			}
+		} else {
+			hit.Post, err = postService.FindByID(id, args.Visibility)
+		}
+
+		// Gone since the hits were checked.
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		hits = append(hits, hit)
+	}
+
+	return hits, total, nil
+}
+
+// visible returns the hits, in order, on posts the visibility allows and
+// approved comments on them, leaving out the password protected posts the
+// viewer cannot edit, as Search does.
+func (s *IndexSearch) visible(indexHits []*search.Hit, visibility *Visibility) ([]*search.Hit, error) {
+
+	var postIDs, commentIDs []int64
+	for _, indexHit := range indexHits {
+		kind, id := splitDocID(indexHit.ID)
+		if kind == "comment" {
+			commentIDs = append(commentIDs, id)
+		} else {
+			postIDs = append(postIDs, id)
+		}
+	}
+
+	where, whereMap := visibility.where(s.prefix)
+
+	protected, protectedMap := visibility.protectedWhere()
+	where = where + " AND " + protected
+	whereMap = append(whereMap, protectedMap...)
+
+	visiblePosts, err := s.visibleIDs(`
+		SELECT
+			ID
+		FROM
+	`+s.prefix+"posts"+`
+		WHERE
+			ID IN (%s) AND
+	`+where, postIDs, whereMap)
+
+	if err != nil {
+		return nil, err
+	}
+
+	visibleComments, err := s.visibleIDs(`
+		SELECT
+			comment_ID
+		FROM
+	`+s.prefix+"comments"+`
+		WHERE
+			comment_ID IN (%s)
+			AND comment_approved = '1'
+			AND comment_post_ID IN (
+				SELECT ID FROM `+s.prefix+`posts WHERE `+where+`
+			)
+	`, commentIDs, whereMap)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var visible []*search.Hit
+	for _, indexHit := range indexHits {
+		kind, id := splitDocID(indexHit.ID)
+		if (kind == "comment" && visibleComments[id]) || (kind != "comment" && visiblePosts[id]) {
+			visible = append(visible, indexHit)
+		}
+	}
+
+	return visible, nil
+}
+
+// visibleIDs runs the query, which takes the IDs in place of its %s and
+// then the arguments, in batches of IDs, and returns the IDs it selects.
+func (s *IndexSearch) visibleIDs(query string, ids []int64, args []interface{}) (map[int64]bool, error) {
+
+	visible := make(map[int64]bool)
+
+	for len(ids) > 0 {
+
+		batch := ids
+		if len(batch) > visibleBatchSize {
+			batch = batch[:visibleBatchSize]
+		}
+		ids = ids[len(batch):]
+
+		var queryMap []interface{}
+		for _, id := range batch {
+			queryMap = append(queryMap, id)
+		}
+
+		rows, err := s.db.Query(strings.Replace(query, "%s", placeholders(len(batch)), 1), append(queryMap, args...)...)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			visible[id] = true
+		}
+
+		err = rows.Err()
+		rows.Close()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return visible, nil
+}
+
+func postDocID(postID int64) string {
+	return "post:" + strconv.FormatInt(postID, 10)
+}
+
+func commentDocID(commentID int64) string {
+	return "comment:" + strconv.FormatInt(commentID, 10)
+}
+
+func splitDocID(docID string) (string, int64) {
+
+	parts := strings.SplitN(docID, ":", 2)
+	if len(parts) != 2 {
+		return "", 0
+	}
+
+	id, _ := strconv.ParseInt(parts[1], 10, 64)
+
+	return parts[0], id
+}
@@ -3,11 +3,16 @@ package service
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/graph-gophers/graphql-go"
 	"github.com/iyut/graphql-go/helper"
 	"github.com/iyut/graphql-go/model"
+	"github.com/iyut/graphql-go/phpass"
+	"github.com/iyut/graphql-go/phpserialize"
 )
 
 func NewUserService(db *sql.DB, prefix string) *User {
@@ -25,6 +30,138 @@ type ArgsUser struct {
 	Email    string
 	Slug     string
 	Username string
+
+	// Search matches part of SearchColumns, every column when empty.
+	Search          string
+	SearchColumns   []string
+	Roles           []string
+	PublishedPosts  bool
+	Include         []int64
+	Exclude         []int64
+	RegisteredAfter time.Time
+
+	// OrderBy is one of UserOrderColumns, ID when empty.
+	OrderBy string
+	Desc    bool
+	Offset  int
+
+	// Limit is capped at MaxUsers, which it also is when not above 0.
+	Limit int
+}
+
+// MaxUsers is the most users GetUsers returns at once.
+const MaxUsers = 100
+
+// UserOrderColumns maps the orders GetUsers accepts to their SQL.
+var UserOrderColumns = map[string]string{
+	"id":           "ID",
+	"login":        "user_login",
+	"nicename":     "user_nicename",
+	"email":        "user_email",
+	"display_name": "display_name",
+	"registered":   "user_registered",
+	"post_count":   "post_count",
+}
+
+// postCountColumn counts the published posts of each user, for ordering.
+const postCountColumn = `(
+			SELECT COUNT(*) FROM %sposts
+			WHERE post_author = %susers.ID AND post_status = 'publish' AND post_type = 'post'
+		)`
+
+// userSearchColumns are the columns a user search may look in.
+var userSearchColumns = []string{"user_login", "user_email", "user_url", "user_nicename", "display_name"}
+
+// where returns the conditions of the arguments, like WP_User_Query builds.
+func (args ArgsUser) where(prefix string) (string, []interface{}) {
+
+	var queryMap []interface{}
+
+	query := " 1 = 1 "
+
+	if args.UserID > 0 {
+		query = query + " AND ID = ? "
+		queryMap = append(queryMap, args.UserID)
+	}
+
+	if len(args.Email) > 0 {
+		query = query + " AND user_email = ? "
+		queryMap = append(queryMap, args.Email)
+	}
+
+	if len(args.Slug) > 0 {
+		query = query + " AND user_nicename = ? "
+		queryMap = append(queryMap, args.Slug)
+	}
+
+	if len(args.Username) > 0 {
+		query = query + " AND user_login = ? "
+		queryMap = append(queryMap, args.Username)
+	}
+
+	if len(args.Search) > 0 {
+
+		columns := args.SearchColumns
+		if len(columns) == 0 {
+			columns = userSearchColumns
+		}
+
+		var likes []string
+		for _, column := range columns {
+			for _, allowed := range userSearchColumns {
+				if column == allowed {
+					likes = append(likes, column+" LIKE ?")
+					queryMap = append(queryMap, likeTerm(args.Search))
+				}
+			}
+		}
+
+		if len(likes) > 0 {
+			query = query + " AND (" + strings.Join(likes, " OR ") + ") "
+		}
+	}
+
+	if len(args.Roles) > 0 {
+
+		var likes []string
+		for _, role := range args.Roles {
+			likes = append(likes, "meta_value LIKE ?")
+			queryMap = append(queryMap, likeTerm(`"`+role+`"`))
+		}
+
+		query = query + ` AND ID IN (
+			SELECT user_id FROM ` + prefix + `usermeta
+			WHERE meta_key = '` + prefix + `capabilities' AND (` + strings.Join(likes, " OR ") + `)
+		) `
+	}
+
+	if args.PublishedPosts {
+		query = query + ` AND ID IN (
+			SELECT post_author FROM ` + prefix + `posts
+			WHERE post_status = 'publish' AND post_type IN ('post', 'page')
+		) `
+	}
+
+	if len(args.Include) > 0 {
+		query = query + " AND ID IN (" + placeholders(len(args.Include)) + ") "
+		for _, userID := range args.Include {
+			queryMap = append(queryMap, userID)
+		}
+	}
+
+	if len(args.Exclude) > 0 {
+		query = query + " AND ID NOT IN (" + placeholders(len(args.Exclude)) + ") "
+		for _, userID := range args.Exclude {
+			queryMap = append(queryMap, userID)
+		}
+	}
+
+	if !args.RegisteredAfter.IsZero() {
+		query = query + " AND user_registered > ? "
+		queryMap = append(queryMap, args.RegisteredAfter.UTC().Format("2006-01-02 15:04:05"))
+	}
+
+	return query, queryMap
 }
 
 func (u *User) GetUsers(args ArgsUser) ([]*model.User, error) {
@@ -34,7 +171,7 @@ func (u *User) GetUsers(args ArgsUser) ([]*model.User, error) {
 	var useridInt int64
 	var userRegisteredString string
 
-	var queryMap []interface{}
+	where, queryMap := args.where(u.prefix)
 
 	query := `
 		SELECT
@@ -51,29 +188,31 @@ func (u *User) GetUsers(args ArgsUser) ([]*model.User, error) {
 		FROM	
 	` + u.prefix + "users" + `
 		WHERE
-			1 = 1
-	`
+	` + where
 
-	if args.UserID > 0 {
-		query = query + " AND ID = ? "
-		queryMap = append(queryMap, args.UserID)
+	orderBy, ok := UserOrderColumns[args.OrderBy]
+	if !ok {
+		orderBy = "ID"
 	}
-
-	if len(args.Email) > 0 {
-		query = query + " AND user_email = ? "
-		queryMap = append(queryMap, args.Email)
+	if orderBy == "post_count" {
+		orderBy = fmt.Sprintf(postCountColumn, u.prefix, u.prefix)
 	}
 
-	if len(args.Slug) > 0 {
-		query = query + " AND user_nicename = ? "
-		queryMap = append(queryMap, args.Slug)
+	direction := "ASC"
+	if args.Desc {
+		direction = "DESC"
 	}
 
-	if len(args.Username) > 0 {
-		query = query + " AND user_login = ? "
-		queryMap = append(queryMap, args.Username)
+	query = query + " ORDER BY " + orderBy + " " + direction + ", ID " + direction + " "
+
+	limit := args.Limit
+	if limit <= 0 || limit > MaxUsers {
+		limit = MaxUsers
 	}
 
+	query = query + " LIMIT ? OFFSET ? "
+	queryMap = append(queryMap, limit, args.Offset)
+
 	query = query + ";"
 
 	rows, err := u.db.Query(query, queryMap...)
@@ -117,6 +256,24 @@ func (u *User) GetUsers(args ArgsUser) ([]*model.User, error) {
 	return users, err
 }
 
+// CountUsers returns how many users match the filters of the arguments.
+func (u *User) CountUsers(args ArgsUser) (int, error) {
+
+	var total int
+
+	where, queryMap := args.where(u.prefix)
+
+	err := u.db.QueryRow(`
+		SELECT
+			COUNT(*)
+		FROM
+	`+u.prefix+"users"+`
+		WHERE
+	`+where, queryMap...).Scan(&total)
+
+	return total, err
+}
+
 func (u *User) FindBy(field string, value string) (*model.User, error) {
 
 	acceptedFields := [4]string{"id", "email", "slug", "username"}
@@ -189,7 +346,7 @@ func (u *User) GetMeta(userID graphql.ID) ([]*model.UserMeta, error) {
 			meta_key,
 			meta_value
 		FROM
-	`+u.prefix+"user_meta"+`
+	`+u.prefix+"usermeta"+`
 		WHERE
 			user_id = ?
 	`, userID)
@@ -223,9 +380,539 @@ func (u *User) GetMeta(userID graphql.ID) ([]*model.UserMeta, error) {
 	return userMetas, nil
 }
 
+// FindMetaByID returns a single user meta row, or sql.ErrNoRows when it does
+// not exist.
+func (u *User) FindMetaByID(uMetaID int64) (*model.UserMeta, error) {
+
+	var uMetaIDInt int64
+	var userIDInt int64
+
+	userMeta := &model.UserMeta{}
+
+	err := u.db.QueryRow(`
+		SELECT
+			umeta_id,
+			user_id,
+			meta_key,
+			meta_value
+		FROM
+	`+u.prefix+"usermeta"+`
+		WHERE
+			umeta_id = ?
+	`, uMetaID).Scan(&uMetaIDInt, &userIDInt, &userMeta.MetaKey, &userMeta.MetaValue)
+
+	if err != nil {
+		return nil, err
+	}
+
+	userMeta.UMetaID = helper.IntToGraphqlID(uMetaIDInt)
+	userMeta.UserID = helper.IntToGraphqlID(userIDInt)
+
+	return userMeta, nil
+}
+
 func (u *User) GetPosts(userID graphql.ID) ([]*model.Post, error) {
 
 	var posts []*model.Post
 
 	return posts, nil
 }
+
+var ErrInvalidResetKey = errors.New("invalid or expired password reset key")
+
+// ResetKeyLifetime is how long a password reset key stays valid, as the
+// password_reset_expiration filter defaults to.
+const ResetKeyLifetime = 24 * time.Hour
+
+// userColumns are the columns IsTaken may check.
+var userColumns = map[string]bool{"user_login": true, "user_email": true, "user_nicename": true}
+
+// IsTaken reports whether another user than exceptID already uses the value
+// for user_login, user_email or user_nicename.
+func (u *User) IsTaken(column string, value string, exceptID int64) (bool, error) {
+
+	if !userColumns[column] {
+		return false, errors.New("column is not accepted")
+	}
+
+	var count int
+
+	err := u.db.QueryRow(`
+		SELECT
+			COUNT(*)
+		FROM
+	`+u.prefix+"users"+`
+		WHERE
+			`+column+` = ?
+			AND ID <> ?
+	`, value, exceptID).Scan(&count)
+
+	return count > 0, err
+}
+
+// Exists reports whether a user has the ID, looked up by primary key. IDs
+// below 1 belong to nobody.
+func (u *User) Exists(userID int64) (bool, error) {
+
+	if userID <= 0 {
+		return false, nil
+	}
+
+	var count int
+
+	err := u.db.QueryRow(`
+		SELECT
+			COUNT(*)
+		FROM
+	`+u.prefix+"users"+`
+		WHERE
+			ID = ?
+	`, userID).Scan(&count)
+
+	return count > 0, err
+}
+
+// Create inserts the user row and their meta in one transaction and returns
+// the new user ID.
+func (u *User) Create(user *model.User, metas []*model.UserMeta) (int64, error) {
+
+	tx, err := u.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO `+u.prefix+"users"+` (
+			user_login,
+			user_pass,
+			user_nicename,
+			user_email,
+			user_url,
+			user_registered,
+			user_activation_key,
+			user_status,
+			display_name )
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+	`,
+		user.UserLogin,
+		user.UserPassword,
+		user.UserNicename,
+		user.UserEmail,
+		user.UserURL,
+		user.UserRegistered.UTC().Format("2006-01-02 15:04:05"),
+		user.UserActivationKey,
+		user.UserStatus,
+		user.DisplayName)
+
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	userID, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	for _, meta := range metas {
+
+		_, err := tx.Exec(`
+			INSERT INTO `+u.prefix+"usermeta"+` (
+				user_id,
+				meta_key,
+				meta_value )
+			VALUES (?, ?, ?);
+		`, userID, meta.MetaKey, meta.MetaValue)
+
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	return userID, tx.Commit()
+}
+
+type ArgsUserUpdate struct {
+	DisplayName *string
+	Nicename    *string
+	URL         *string
+	Email       *string
+}
+
+// Update writes the given profile columns of the user.
+func (u *User) Update(userID int64, args ArgsUserUpdate) error {
+
+	var sets []string
+	var queryMap []interface{}
+
+	if args.DisplayName != nil {
+		sets = append(sets, "display_name = ?")
+		queryMap = append(queryMap, *args.DisplayName)
+	}
+
+	if args.Nicename != nil {
+		sets = append(sets, "user_nicename = ?")
+		queryMap = append(queryMap, *args.Nicename)
+	}
+
+	if args.URL != nil {
+		sets = append(sets, "user_url = ?")
+		queryMap = append(queryMap, *args.URL)
+	}
+
+	if args.Email != nil {
+		sets = append(sets, "user_email = ?")
+		queryMap = append(queryMap, *args.Email)
+	}
+
+	if len(sets) == 0 {
+		return nil
+	}
+
+	queryMap = append(queryMap, userID)
+
+	_, err := u.db.Exec(`
+		UPDATE `+u.prefix+"users"+`
+		SET
+			`+strings.Join(sets, ", ")+`
+		WHERE
+			ID = ?
+	`, queryMap...)
+
+	return err
+}
+
+// Delete removes the user and their meta like wp_delete_user(). Their posts
+// and links are given to reassignTo, or deleted when it is 0, in which case
+// it returns the IDs of the deleted posts.
+func (u *User) Delete(userID int64, reassignTo int64) ([]int64, error) {
+
+	tx, err := u.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	var postIDs []int64
+
+	if reassignTo > 0 {
+		err = execAll(tx, []string{
+			"UPDATE " + u.prefix + "posts SET post_author = ? WHERE post_author = ?",
+			"UPDATE " + u.prefix + "links SET link_owner = ? WHERE link_owner = ?",
+		}, reassignTo, userID)
+	} else {
+		postIDs, err = u.deletePosts(tx, userID)
+		if err == nil {
+			err = u.deleteLinks(tx, userID)
+		}
+	}
+
+	if err == nil {
+		err = execAll(tx, []string{
+			"DELETE FROM " + u.prefix + "usermeta WHERE user_id = ?",
+			"DELETE FROM " + u.prefix + "users WHERE ID = ?",
+		}, userID)
+	}
+
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return postIDs, tx.Commit()
+}
+
+// deletePosts deletes the posts of the user the way wp_delete_post() does:
+// with their revisions, comments, meta and term relationships, moving their
+// children up to their own parent.
+func (u *User) deletePosts(tx *sql.Tx, userID int64) ([]int64, error) {
+
+	postIDs, err := queryIDs(tx, "SELECT ID FROM "+u.prefix+"posts WHERE post_author = ?", userID)
+	if err != nil || len(postIDs) == 0 {
+		return nil, err
+	}
+
+	revisionIDs, err := queryIDs(tx, `
+		SELECT ID FROM `+u.prefix+`posts
+		WHERE post_type = 'revision' AND post_author <> ? AND post_parent IN (`+placeholders(len(postIDs))+`)
+	`, append([]interface{}{userID}, idArgs(postIDs)...)...)
+	if err != nil {
+		return nil, err
+	}
+	postIDs = append(postIDs, revisionIDs...)
+
+	// The parent is read again for every post, since deleting an earlier one
+	// may have moved it.
+	for _, postID := range postIDs {
+
+		var parentID int64
+		if err := tx.QueryRow("SELECT post_parent FROM "+u.prefix+"posts WHERE ID = ?", postID).Scan(&parentID); err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.Exec("UPDATE "+u.prefix+"posts SET post_parent = ? WHERE post_parent = ?", parentID, postID); err != nil {
+			return nil, err
+		}
+	}
+
+	in := placeholders(len(postIDs))
+	args := idArgs(postIDs)
+
+	termTaxonomyIDs, err := queryIDs(tx, `
+		SELECT DISTINCT tr.term_taxonomy_id
+		FROM `+u.prefix+`term_relationships tr
+		JOIN `+u.prefix+`term_taxonomy tt ON tt.term_taxonomy_id = tr.term_taxonomy_id
+		WHERE tt.taxonomy <> 'link_category' AND tr.object_id IN (`+in+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = execAll(tx, []string{
+		"DELETE FROM " + u.prefix + "commentmeta WHERE comment_id IN (SELECT comment_ID FROM " + u.prefix + "comments WHERE comment_post_ID IN (" + in + "))",
+		"DELETE FROM " + u.prefix + "comments WHERE comment_post_ID IN (" + in + ")",
+		"DELETE FROM " + u.prefix + "postmeta WHERE post_id IN (" + in + ")",
+		"DELETE tr FROM " + u.prefix + "term_relationships tr JOIN " + u.prefix + "term_taxonomy tt ON tt.term_taxonomy_id = tr.term_taxonomy_id WHERE tt.taxonomy <> 'link_category' AND tr.object_id IN (" + in + ")",
+		"DELETE FROM " + u.prefix + "posts WHERE ID IN (" + in + ")",
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Like _update_post_term_count(), only published posts are counted.
+	if len(termTaxonomyIDs) > 0 {
+		_, err = tx.Exec(`
+			UPDATE `+u.prefix+`term_taxonomy tt
+			SET count = (
+				SELECT COUNT(*)
+				FROM `+u.prefix+`term_relationships tr
+				JOIN `+u.prefix+`posts p ON p.ID = tr.object_id
+				WHERE tr.term_taxonomy_id = tt.term_taxonomy_id AND p.post_status = 'publish'
+			)
+			WHERE tt.term_taxonomy_id IN (`+placeholders(len(termTaxonomyIDs))+`)
+		`, idArgs(termTaxonomyIDs)...)
+	}
+
+	return postIDs, err
+}
+
+// deleteLinks deletes the links of the user and their link categories, like
+// wp_delete_link().
+func (u *User) deleteLinks(tx *sql.Tx, userID int64) error {
+
+	linkIDs, err := queryIDs(tx, "SELECT link_id FROM "+u.prefix+"links WHERE link_owner = ?", userID)
+	if err != nil || len(linkIDs) == 0 {
+		return err
+	}
+
+	in := placeholders(len(linkIDs))
+
+	err = execAll(tx, []string{
+		"DELETE tr FROM " + u.prefix + "term_relationships tr JOIN " + u.prefix + "term_taxonomy tt ON tt.term_taxonomy_id = tr.term_taxonomy_id WHERE tt.taxonomy = 'link_category' AND tr.object_id IN (" + in + ")",
+		"DELETE FROM " + u.prefix + "links WHERE link_id IN (" + in + ")",
+	}, idArgs(linkIDs)...)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE ` + u.prefix + `term_taxonomy tt
+		SET count = (
+			SELECT COUNT(*)
+			FROM ` + u.prefix + `term_relationships tr
+			WHERE tr.term_taxonomy_id = tt.term_taxonomy_id
+		)
+		WHERE tt.taxonomy = 'link_category'
+	`)
+
+	return err
+}
+
+// execAll runs every query with the same arguments.
+func execAll(tx *sql.Tx, queries []string, args ...interface{}) error {
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queryIDs returns the first column of the rows as integers.
+func queryIDs(tx *sql.Tx, query string, args ...interface{}) ([]int64, error) {
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func idArgs(ids []int64) []interface{} {
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	return args
+}
+
+// SetMeta updates the user meta value, adding it when the key is not set.
+// Like update_user_meta(), it writes the value through maybe_serialize(),
+// so WordPress reads back the string rather than a value it spells.
+func (u *User) SetMeta(userID int64, key string, value string) error {
+
+	value = phpserialize.MaybeSerialize(value)
+
+	res, err := u.db.Exec(`
+		UPDATE `+u.prefix+"usermeta"+`
+		SET
+			meta_value = ?
+		WHERE
+			user_id = ?
+			AND meta_key = ?
+	`, value, userID, key)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, err := res.RowsAffected(); err != nil || rows > 0 {
+		return err
+	}
+
+	// MySQL reports 0 affected rows when the value did not change.
+	var count int
+	err = u.db.QueryRow(`
+		SELECT
+			COUNT(*)
+		FROM
+	`+u.prefix+"usermeta"+`
+		WHERE
+			user_id = ?
+			AND meta_key = ?
+	`, userID, key).Scan(&count)
+
+	if err != nil || count > 0 {
+		return err
+	}
+
+	_, err = u.db.Exec(`
+		INSERT INTO `+u.prefix+"usermeta"+` (
+			user_id,
+			meta_key,
+			meta_value )
+		VALUES (?, ?, ?);
+	`, userID, key, value)
+
+	return err
+}
+
+// CreateResetKey stores a new password reset key of the user hashed in
+// user_activation_key as get_password_reset_key() does, and returns it.
+func (u *User) CreateResetKey(userID int64) (string, error) {
+
+	key, err := helper.RandomString(20)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := phpass.Hash(key)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = u.db.Exec(`
+		UPDATE `+u.prefix+"users"+`
+		SET
+			user_activation_key = ?
+		WHERE
+			ID = ?
+	`, strconv.FormatInt(time.Now().Unix(), 10)+":"+hash, userID)
+
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// CheckResetKey returns the user the unexpired reset key was made for, like
+// check_password_reset_key().
+func (u *User) CheckResetKey(login string, key string) (*model.User, error) {
+
+	users, err := u.GetUsers(ArgsUser{Username: login})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(users) != 1 || len(key) == 0 {
+		return nil, ErrInvalidResetKey
+	}
+
+	parts := strings.SplitN(users[0].UserActivationKey, ":", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidResetKey
+	}
+
+	issued, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || time.Since(time.Unix(issued, 0)) > ResetKeyLifetime {
+		return nil, ErrInvalidResetKey
+	}
+
+	if !phpass.Check(key, parts[1]) {
+		return nil, ErrInvalidResetKey
+	}
+
+	return users[0], nil
+}
+
+// SetPassword stores the password hash and invalidates any reset key, like
+// wp_set_password().
+func (u *User) SetPassword(userID int64, password string) error {
+
+	hash, err := phpass.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = u.db.Exec(`
+		UPDATE `+u.prefix+"users"+`
+		SET
+			user_pass = ?,
+			user_activation_key = ''
+		WHERE
+			ID = ?
+	`, hash, userID)
+
+	return err
+}
+
+// DeleteMeta removes every value of the user meta key.
+func (u *User) DeleteMeta(userID int64, key string) error {
+
+	_, err := u.db.Exec(`
+		DELETE FROM `+u.prefix+"usermeta"+`
+		WHERE
+			user_id = ?
+			AND meta_key = ?
+	`, userID, key)
+
+	return err
+}
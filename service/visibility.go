@@ -0,0 +1,99 @@
+package service
+
+// Visibility decides which posts a viewer may read. A nil Visibility is an
+// anonymous visitor, who sees published posts, the attachments of the posts
+// they see and scheduled posts whose post_date_gmt has passed.
+type Visibility struct {
+	UserID int64
+
+	// ReadOwn lets the viewer read their own drafts, pending, scheduled and
+	// private posts (edit_posts).
+	ReadOwn bool
+
+	// ReadPrivate lets the viewer read everybody's private posts
+	// (read_private_posts).
+	ReadPrivate bool
+
+	// ReadAll lets the viewer read posts in any status (edit_others_posts).
+	ReadAll bool
+
+	// PreviewID is a post the viewer holds a preview token for.
+	PreviewID int64
+}
+
+// Unrestricted is the visibility of internal lookups that are not made on
+// behalf of a viewer.
+var Unrestricted = &Visibility{ReadAll: true}
+
+var ownStatuses = []string{"draft", "pending", "future", "private"}
+
+// where returns the SQL condition on the posts table of the policy. An
+// attachment inherits the status of the post it is attached to, so it is
+// visible when it is unattached or when its parent is.
+func (v *Visibility) where(prefix string) (string, []interface{}) {
+
+	if v != nil && v.ReadAll {
+		return " 1 = 1 ", nil
+	}
+
+	posts, queryMap := v.statusWhere("")
+	parents, parentMap := v.statusWhere("parent.")
+
+	where := ` (` + posts + `
+			OR (post_status = 'inherit' AND post_type = 'attachment' AND (post_parent = 0 OR post_parent IN (
+				SELECT parent.ID FROM ` + prefix + "posts" + ` parent WHERE ` + parents + `
+			)))) `
+
+	return where, append(queryMap, parentMap...)
+}
+
+// protectedWhere returns the SQL condition on the posts table leaving out
+// the password protected posts the viewer cannot edit, whose content and
+// comments must not be searched: that a search matches would tell what they
+// hold.
+func (v *Visibility) protectedWhere() (string, []interface{}) {
+
+	if v != nil && v.ReadAll {
+		return " 1 = 1 ", nil
+	}
+
+	if v != nil && v.ReadOwn && v.UserID > 0 {
+		return " (post_password = '' OR post_author = ?) ", []interface{}{v.UserID}
+	}
+
+	return " post_password = '' ", nil
+}
+
+// statusWhere returns the condition on the status of posts the policy
+// allows, with the columns qualified by alias.
+func (v *Visibility) statusWhere(alias string) (string, []interface{}) {
+
+	var queryMap []interface{}
+
+	where := ` (` + alias + `post_status = 'publish'
+			OR (` + alias + `post_status = 'future' AND ` + alias + `post_date_gmt <= UTC_TIMESTAMP()) `
+
+	if v != nil {
+
+		if v.ReadPrivate {
+			where = where + " OR " + alias + "post_status = 'private' "
+		}
+
+		if v.ReadOwn && v.UserID > 0 {
+			where = where + " OR (" + alias + "post_author = ? AND " + alias + "post_status IN (" + placeholders(len(ownStatuses)) + ")) "
+			queryMap = append(queryMap, v.UserID)
+			for _, status := range ownStatuses {
+				queryMap = append(queryMap, status)
+			}
+		}
+
+		if v.PreviewID > 0 {
+			where = where + " OR " + alias + "ID = ? "
+			queryMap = append(queryMap, v.PreviewID)
+		}
+	}
+
+	where = where + ") "
+
+	return where, queryMap
+}
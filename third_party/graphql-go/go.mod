@@ -0,0 +1,5 @@
+module github.com/graph-gophers/graphql-go
+
+require github.com/opentracing/opentracing-go v1.1.0
+
+go 1.13
@@ -0,0 +1,168 @@
+package graphql
+
+import (
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/query"
+)
+
+// Operation is an operation of a prepared query as it would be executed
+// with some variables: the values of the arguments are known, and the
+// selections left out by @skip or @include are gone. It lets the query be
+// analyzed, for instance to estimate its cost, before it runs.
+type Operation struct {
+	// Type is "query", "mutation" or "subscription".
+	Type       string
+	Name       string
+	Selections *SelectionSet
+}
+
+// SelectionSet is what is selected on an object. All the spreads of a named
+// fragment share its selection set, so the size of the operation is that of
+// the query, however often fragments are spread.
+type SelectionSet struct {
+	Fields    []*SelectedField
+	Fragments []*SelectedFragment
+}
+
+// SelectedField is a field and its arguments, with default values of the
+// schema left out. Selections is nil for fields of scalars and enums.
+type SelectedField struct {
+	Name       string
+	Alias      string
+	Arguments  map[string]interface{}
+	Selections *SelectionSet
+}
+
+// SelectedFragment is a named or inline fragment. TypeCondition is empty for
+// inline fragments without one.
+type SelectedFragment struct {
+	TypeCondition string
+	Selections    *SelectionSet
+}
+
+// Operation returns the operation to run with the variables, as Exec would
+// choose it. The variables are not validated here, but on execution.
+func (p *Prepared) Operation(operationName string, variables map[string]interface{}) (*Operation, error) {
+	op, err := getOperation(p.doc, operationName)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]interface{}, len(op.Vars)+len(variables))
+	for _, v := range op.Vars {
+		if v.Default != nil {
+			vars[v.Name.Name] = v.Default.Value(nil)
+		}
+	}
+	for name, value := range variables {
+		vars[name] = value
+	}
+
+	b := &operationBuilder{
+		doc:       p.doc,
+		vars:      vars,
+		fragments: make(map[string]*SelectionSet),
+		building:  make(map[string]bool),
+	}
+
+	return &Operation{
+		Type:       strings.ToLower(string(op.Type)),
+		Name:       op.Name.Name,
+		Selections: b.selectionSet(op.Selections),
+	}, nil
+}
+
+type operationBuilder struct {
+	doc       *query.Document
+	vars      map[string]interface{}
+	fragments map[string]*SelectionSet
+	building  map[string]bool
+}
+
+func (b *operationBuilder) selectionSet(selections []query.Selection) *SelectionSet {
+	set := &SelectionSet{}
+
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *query.Field:
+			if b.skip(sel.Directives) {
+				continue
+			}
+			field := &SelectedField{
+				Name:      sel.Name.Name,
+				Alias:     sel.Alias.Name,
+				Arguments: make(map[string]interface{}, len(sel.Arguments)),
+			}
+			for _, arg := range sel.Arguments {
+				field.Arguments[arg.Name.Name] = arg.Value.Value(b.vars)
+			}
+			if sel.Selections != nil {
+				field.Selections = b.selectionSet(sel.Selections)
+			}
+			set.Fields = append(set.Fields, field)
+
+		case *query.InlineFragment:
+			if b.skip(sel.Directives) {
+				continue
+			}
+			set.Fragments = append(set.Fragments, &SelectedFragment{
+				TypeCondition: sel.On.Name,
+				Selections:    b.selectionSet(sel.Selections),
+			})
+
+		case *query.FragmentSpread:
+			if b.skip(sel.Directives) {
+				continue
+			}
+			frag := b.doc.Fragments.Get(sel.Name.Name)
+			if frag == nil {
+				continue
+			}
+			set.Fragments = append(set.Fragments, &SelectedFragment{
+				TypeCondition: frag.On.Name,
+				Selections:    b.fragment(frag),
+			})
+		}
+	}
+
+	return set
+}
+
+// fragment builds the selection set of a named fragment once. Validation
+// rejects cycles of fragments; should one get here, it selects nothing.
+func (b *operationBuilder) fragment(frag *query.FragmentDecl) *SelectionSet {
+	name := frag.Name.Name
+	if set, ok := b.fragments[name]; ok {
+		return set
+	}
+	if b.building[name] {
+		return &SelectionSet{}
+	}
+
+	b.building[name] = true
+	set := b.selectionSet(frag.Selections)
+	delete(b.building, name)
+	b.fragments[name] = set
+
+	return set
+}
+
+// skip tells whether @skip or @include leave the selection out. An "if" that
+// is not a boolean leaves it in; execution reports it.
+func (b *operationBuilder) skip(directives common.DirectiveList) bool {
+	if d := directives.Get("skip"); d != nil {
+		if value, ok := d.Args.Get("if"); ok && value.Value(b.vars) == true {
+			return true
+		}
+	}
+
+	if d := directives.Get("include"); d != nil {
+		if value, ok := d.Args.Get("if"); ok && value.Value(b.vars) == false {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const deliveryExt = ".json"
+
+// queue keeps every delivery as a JSON file named by its ID.
+type queue struct {
+	dir string
+}
+
+func openQueue(dir string) (*queue, error) {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &queue{dir: dir}, nil
+}
+
+// load reads the deliveries of the directory, skipping unreadable files.
+func (q *queue) load() ([]*Delivery, error) {
+
+	files, err := ioutil.ReadDir(q.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []*Delivery
+
+	for _, file := range files {
+
+		if file.IsDir() || !strings.HasSuffix(file.Name(), deliveryExt) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(q.dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		delivery := &Delivery{}
+		if err := json.Unmarshal(data, delivery); err != nil || len(delivery.ID) == 0 {
+			log.Printf("webhook: skipping %s: %v", file.Name(), err)
+			continue
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// save writes the delivery, replacing its previous state atomically.
+func (q *queue) save(delivery *Delivery) error {
+
+	tmp, err := ioutil.TempFile(q.dir, delivery.ID+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(tmp).Encode(delivery); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(q.dir, delivery.ID+deliveryExt))
+}
+
+func (q *queue) remove(id string) error {
+
+	err := os.Remove(filepath.Join(q.dir, id+deliveryExt))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,495 @@
+// Package webhook notifies outside services, like static site builders and
+// CDNs, of content events with signed HTTP requests, retried until they
+// succeed or run out of attempts.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iyut/graphql-go/events"
+	"github.com/iyut/graphql-go/helper"
+)
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Request headers. The signature is "sha256=" and the hex HMAC-SHA256 of
+// the body, keyed with the endpoint's secret.
+const (
+	SignatureHeader = "X-Webhook-Signature-256"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Defaults of the Dispatcher settings.
+const (
+	DefaultMaxAttempts = 8
+	DefaultMinBackoff  = 10 * time.Second
+	DefaultMaxBackoff  = time.Hour
+	DefaultTimeout     = 10 * time.Second
+)
+
+// KeepFinished is how many delivered and failed deliveries are kept for
+// inspection.
+const KeepFinished = 500
+
+// workers is how many requests are sent at once.
+const workers = 4
+
+// Endpoint is a URL notified of the events it filters for, all of them when
+// Events is empty.
+type Endpoint struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+func (e *Endpoint) accepts(eventType string) bool {
+
+	if len(e.Events) == 0 {
+		return true
+	}
+
+	for _, t := range e.Events {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Payload is the JSON body of a request.
+type Payload struct {
+	Delivery string    `json:"delivery"`
+	Event    string    `json:"event"`
+	ObjectID int64     `json:"objectID"`
+	PostID   int64     `json:"postID,omitempty"`
+	Site     string    `json:"site,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Delivery is one event on its way to one endpoint.
+type Delivery struct {
+	ID             string       `json:"id"`
+	Endpoint       string       `json:"endpoint"`
+	URL            string       `json:"url"`
+	Event          events.Event `json:"event"`
+	Status         string       `json:"status"`
+	Attempts       int          `json:"attempts"`
+	LastStatusCode int          `json:"last_status_code,omitempty"`
+	LastError      string       `json:"last_error,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	NextAttemptAt  time.Time    `json:"next_attempt_at,omitempty"`
+	FinishedAt     time.Time    `json:"finished_at,omitempty"`
+}
+
+// Dispatcher turns events into deliveries and sends them. Every delivery is
+// a file of its queue directory, so pending ones survive restarts. Enqueue
+// is meant to listen to the event bus, which calls it for every event.
+type Dispatcher struct {
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	Timeout     time.Duration
+
+	endpoints map[string]*Endpoint
+	queue     *queue
+	client    *http.Client
+
+	mu         sync.Mutex
+	deliveries map[string]*Delivery
+	inFlight   map[string]bool
+	wake       chan struct{}
+}
+
+// NewDispatcher opens the queue in dir with the deliveries left from the
+// previous run.
+func NewDispatcher(dir string, endpoints []Endpoint) (*Dispatcher, error) {
+
+	q, err := openQueue(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := q.load()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dispatcher{
+		MaxAttempts: DefaultMaxAttempts,
+		MinBackoff:  DefaultMinBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+		Timeout:     DefaultTimeout,
+		endpoints:   make(map[string]*Endpoint),
+		queue:       q,
+		client:      &http.Client{},
+		deliveries:  make(map[string]*Delivery),
+		inFlight:    make(map[string]bool),
+		wake:        make(chan struct{}, 1),
+	}
+
+	for i := range endpoints {
+		d.endpoints[endpoints[i].Name] = &endpoints[i]
+	}
+
+	for _, delivery := range deliveries {
+		d.deliveries[delivery.ID] = delivery
+	}
+
+	return d, nil
+}
+
+// Run sends the pending deliveries until the context ends.
+func (d *Dispatcher) Run(ctx context.Context) {
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	sem := make(chan struct{}, workers)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-d.wake:
+
+		case <-timer.C:
+		}
+
+		for _, delivery := range d.due() {
+			go func(delivery Delivery) {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				d.attempt(ctx, delivery)
+			}(delivery)
+		}
+
+		timer.Reset(d.untilNext())
+	}
+}
+
+// Enqueue creates a delivery of the event for every endpoint wanting it and
+// saves it before returning.
+func (d *Dispatcher) Enqueue(event events.Event) {
+
+	now := time.Now().UTC()
+
+	for _, endpoint := range d.endpoints {
+
+		if !endpoint.accepts(event.Type) {
+			continue
+		}
+
+		id, err := helper.RandomString(20)
+		if err != nil {
+			log.Printf("webhook: %s", err)
+			return
+		}
+
+		delivery := &Delivery{
+			ID:            id,
+			Endpoint:      endpoint.Name,
+			URL:           endpoint.URL,
+			Event:         event,
+			Status:        StatusPending,
+			CreatedAt:     now,
+			NextAttemptAt: now,
+		}
+
+		if err := d.queue.save(delivery); err != nil {
+			log.Printf("webhook: %s", err)
+		}
+
+		d.mu.Lock()
+		d.deliveries[id] = delivery
+		d.mu.Unlock()
+	}
+
+	d.signal()
+}
+
+func (d *Dispatcher) signal() {
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// due returns copies of the pending deliveries whose time has come, marking
+// them in flight.
+func (d *Dispatcher) due() []Delivery {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+
+	var due []Delivery
+
+	for id, delivery := range d.deliveries {
+		if delivery.Status == StatusPending && !d.inFlight[id] && !delivery.NextAttemptAt.After(now) {
+			d.inFlight[id] = true
+			due = append(due, *delivery)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].CreatedAt.Before(due[j].CreatedAt)
+	})
+
+	return due
+}
+
+// untilNext is the wait for the next pending delivery, a minute at most.
+func (d *Dispatcher) untilNext() time.Duration {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	wait := time.Minute
+
+	for id, delivery := range d.deliveries {
+		if delivery.Status == StatusPending && !d.inFlight[id] {
+			if until := time.Until(delivery.NextAttemptAt); until < wait {
+				wait = until
+			}
+		}
+	}
+
+	if wait < 0 {
+		wait = 0
+	}
+
+	return wait
+}
+
+// attempt sends the delivery once and records the outcome.
+func (d *Dispatcher) attempt(ctx context.Context, delivery Delivery) {
+
+	statusCode, retryAfter, err := d.send(ctx, &delivery)
+
+	if ctx.Err() != nil {
+		d.mu.Lock()
+		delete(d.inFlight, delivery.ID)
+		d.mu.Unlock()
+		return
+	}
+
+	now := time.Now().UTC()
+
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.Status = StatusDelivered
+		delivery.FinishedAt = now
+		delivery.NextAttemptAt = time.Time{}
+
+	case delivery.Attempts >= d.MaxAttempts:
+		delivery.Status = StatusFailed
+		delivery.LastError = err.Error()
+		delivery.FinishedAt = now
+		delivery.NextAttemptAt = time.Time{}
+		log.Printf("webhook: giving up on %s to %s: %s", delivery.Event.Type, delivery.Endpoint, err)
+
+	default:
+		delivery.LastError = err.Error()
+		wait := d.backoff(delivery.Attempts)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		delivery.NextAttemptAt = now.Add(wait)
+	}
+
+	if err := d.queue.save(&delivery); err != nil {
+		log.Printf("webhook: %s", err)
+	}
+
+	d.mu.Lock()
+	d.deliveries[delivery.ID] = &delivery
+	delete(d.inFlight, delivery.ID)
+	d.mu.Unlock()
+
+	if delivery.Status != StatusPending {
+		d.prune()
+	}
+
+	d.signal()
+}
+
+// backoff is the wait after the given number of failed attempts, doubling
+// from MinBackoff up to MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+
+	wait := d.MinBackoff
+	for i := 1; i < attempts && wait < d.MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > d.MaxBackoff {
+		wait = d.MaxBackoff
+	}
+
+	return wait
+}
+
+// send posts the signed payload, returning the response status and the
+// Retry-After it asked for. Anything but a 2xx response is an error.
+func (d *Dispatcher) send(ctx context.Context, delivery *Delivery) (int, time.Duration, error) {
+
+	endpoint, ok := d.endpoints[delivery.Endpoint]
+	if !ok {
+		return 0, 0, fmt.Errorf("endpoint %s is no longer configured", delivery.Endpoint)
+	}
+
+	body, err := json.Marshal(Payload{
+		Delivery: delivery.ID,
+		Event:    delivery.Event.Type,
+		ObjectID: delivery.Event.ObjectID,
+		PostID:   delivery.Event.PostID,
+		Site:     delivery.Event.Site,
+		Time:     delivery.Event.Time,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "graphql-go-webhook")
+	req.Header.Set(EventHeader, delivery.Event.Type)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp.StatusCode, 0, nil
+	}
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return resp.StatusCode, retryAfter, fmt.Errorf("%s responded %s", endpoint.URL, resp.Status)
+}
+
+// Sign returns the signature header value of the body.
+func Sign(secret string, body []byte) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// prune forgets the oldest finished deliveries beyond KeepFinished.
+func (d *Dispatcher) prune() {
+
+	d.mu.Lock()
+
+	var finished []*Delivery
+	for _, delivery := range d.deliveries {
+		if delivery.Status != StatusPending {
+			finished = append(finished, delivery)
+		}
+	}
+
+	if len(finished) <= KeepFinished {
+		d.mu.Unlock()
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].FinishedAt.After(finished[j].FinishedAt)
+	})
+
+	stale := finished[KeepFinished:]
+	for _, delivery := range stale {
+		delete(d.deliveries, delivery.ID)
+	}
+
+	d.mu.Unlock()
+
+	for _, delivery := range stale {
+		if err := d.queue.remove(delivery.ID); err != nil {
+			log.Printf("webhook: %s", err)
+		}
+	}
+}
+
+// ArgsDeliveries filters Deliveries. Empty fields match everything.
+type ArgsDeliveries struct {
+	Status   string
+	Endpoint string
+	Limit    int
+}
+
+// Deliveries returns copies of the deliveries, newest first.
+func (d *Dispatcher) Deliveries(args ArgsDeliveries) []Delivery {
+
+	d.mu.Lock()
+
+	var list []Delivery
+	for _, delivery := range d.deliveries {
+		if len(args.Status) > 0 && delivery.Status != args.Status {
+			continue
+		}
+		if len(args.Endpoint) > 0 && delivery.Endpoint != args.Endpoint {
+			continue
+		}
+		list = append(list, *delivery)
+	}
+
+	d.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+
+	if args.Limit > 0 && len(list) > args.Limit {
+		list = list[:args.Limit]
+	}
+
+	return list
+}
@@ -0,0 +1,284 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iyut/graphql-go/events"
+)
+
+// recorder is an endpoint answering with the queued status codes, then 200.
+type recorder struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.requests = append(rec.requests, r)
+	rec.bodies = append(rec.bodies, body)
+
+	status := http.StatusOK
+	if len(rec.statuses) > 0 {
+		status = rec.statuses[0]
+		rec.statuses = rec.statuses[1:]
+	}
+
+	w.WriteHeader(status)
+}
+
+func (rec *recorder) count() int {
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	return len(rec.requests)
+}
+
+func newTestDispatcher(t *testing.T, dir, url string) *Dispatcher {
+
+	d, err := NewDispatcher(dir, []Endpoint{{Name: "build", URL: url, Secret: "s3cret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d.MinBackoff = 10 * time.Millisecond
+	d.MaxBackoff = 40 * time.Millisecond
+
+	return d
+}
+
+func tempDir(t *testing.T) string {
+
+	dir, err := ioutil.TempDir("", "webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+// waitFor polls the deliveries until ok accepts them or a few seconds pass.
+func waitFor(t *testing.T, d *Dispatcher, ok func([]Delivery) bool) []Delivery {
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		deliveries := d.Deliveries(ArgsDeliveries{})
+		if ok(deliveries) {
+			return deliveries
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out with deliveries %+v", deliveries)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func delivered(deliveries []Delivery) bool {
+
+	return len(deliveries) == 1 && deliveries[0].Status == StatusDelivered
+}
+
+func TestDispatcherSigns(t *testing.T) {
+
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	d := newTestDispatcher(t, dir, server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	d.Enqueue(events.Event{Type: events.PostPublished, ObjectID: 7, Site: "wp_"})
+
+	deliveries := waitFor(t, d, delivered)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	r, body := rec.requests[0], rec.bodies[0]
+
+	if got, want := r.Header.Get(SignatureHeader), Sign("s3cret", body); got != want {
+		t.Errorf("signature %q, want %q", got, want)
+	}
+	if got := r.Header.Get(EventHeader); got != events.PostPublished {
+		t.Errorf("event header %q, want %q", got, events.PostPublished)
+	}
+	if got := r.Header.Get(DeliveryHeader); got != deliveries[0].ID {
+		t.Errorf("delivery header %q, want %q", got, deliveries[0].ID)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Delivery != deliveries[0].ID || payload.Event != events.PostPublished || payload.ObjectID != 7 || payload.Site != "wp_" {
+		t.Errorf("payload %+v", payload)
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+
+	rec := &recorder{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	d := newTestDispatcher(t, dir, server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	d.Enqueue(events.Event{Type: events.PostUpdated, ObjectID: 1})
+
+	deliveries := waitFor(t, d, delivered)
+
+	if deliveries[0].Attempts != 3 || deliveries[0].LastStatusCode != http.StatusOK {
+		t.Errorf("delivered after %d attempts with %d, want 3 and 200", deliveries[0].Attempts, deliveries[0].LastStatusCode)
+	}
+	if n := rec.count(); n != 3 {
+		t.Errorf("endpoint got %d requests, want 3", n)
+	}
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+
+	rec := &recorder{statuses: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	d := newTestDispatcher(t, dir, server.URL)
+	d.MaxAttempts = 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	d.Enqueue(events.Event{Type: events.PostUpdated, ObjectID: 1})
+
+	deliveries := waitFor(t, d, func(deliveries []Delivery) bool {
+		return len(deliveries) == 1 && deliveries[0].Status == StatusFailed
+	})
+
+	if deliveries[0].Attempts != 2 || len(deliveries[0].LastError) == 0 {
+		t.Errorf("failed after %d attempts with %q", deliveries[0].Attempts, deliveries[0].LastError)
+	}
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+
+	d := &Dispatcher{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{10, 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := d.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDispatcherResumesAfterRestart(t *testing.T) {
+
+	rec := &recorder{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	d := newTestDispatcher(t, dir, server.URL)
+	d.MinBackoff = time.Hour
+	d.MaxBackoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+
+	d.Enqueue(events.Event{Type: events.CommentCreated, ObjectID: 3, PostID: 1})
+
+	pending := waitFor(t, d, func(deliveries []Delivery) bool {
+		return len(deliveries) == 1 && deliveries[0].Attempts == 1
+	})
+
+	cancel()
+
+	// The delivery waits an hour for its next attempt, so move it along
+	// the way an operator would: by editing the file.
+	pending[0].NextAttemptAt = time.Now()
+	if err := d.queue.save(&pending[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := newTestDispatcher(t, dir, server.URL)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go restarted.Run(ctx)
+
+	deliveries := waitFor(t, restarted, delivered)
+
+	if deliveries[0].ID != pending[0].ID || deliveries[0].Attempts != 2 {
+		t.Errorf("delivered %s after %d attempts, want %s after 2", deliveries[0].ID, deliveries[0].Attempts, pending[0].ID)
+	}
+}
+
+func TestDispatcherListensToBus(t *testing.T) {
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	d := newTestDispatcher(t, dir, "http://127.0.0.1:1")
+
+	bus := events.NewBus()
+	bus.Listen(d.Enqueue)
+
+	// More than a subscriber buffers, with nobody running the dispatcher.
+	n := 2 * events.SubscriberBuffer
+	for i := 0; i < n; i++ {
+		bus.Publish(events.Event{Type: events.PostUpdated, ObjectID: int64(i)})
+	}
+
+	if got := len(d.Deliveries(ArgsDeliveries{})); got != n {
+		t.Errorf("%d deliveries, want %d", got, n)
+	}
+
+	restarted := newTestDispatcher(t, dir, "http://127.0.0.1:1")
+	if got := len(restarted.Deliveries(ArgsDeliveries{Status: StatusPending})); got != n {
+		t.Errorf("%d pending deliveries after a restart, want %d", got, n)
+	}
+}
@@ -0,0 +1,405 @@
+// Package websocket is a minimal server side implementation of the WebSocket
+// protocol (RFC 6455), enough to carry GraphQL subscriptions.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Message and control frame opcodes.
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+// Close codes of RFC 6455 section 7.4.1.
+const (
+	CloseNormal         = 1000
+	CloseGoingAway      = 1001
+	CloseProtocolError  = 1002
+	CloseNoStatus       = 1005
+	CloseInvalidPayload = 1007
+	CloseMessageTooBig  = 1009
+	CloseInternalError  = 1011
+)
+
+const (
+	continuationFrame    = 0
+	maxControlPayload    = 125
+	defaultMaxMessageLen = 1 << 20
+)
+
+// DefaultWriteTimeout is how long a write may wait for a peer that does not
+// read.
+const DefaultWriteTimeout = 10 * time.Second
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	ErrNotWebSocket = errors.New("websocket: not a websocket handshake")
+	ErrClosed       = errors.New("websocket: connection closed")
+)
+
+// CloseError is returned by ReadMessage once the peer closed the connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed with %d %s", e.Code, e.Reason)
+}
+
+// Conn is an upgraded connection. Reads must come from one goroutine,
+// writes may come from several.
+type Conn struct {
+	// Subprotocol is the one agreed on during the handshake.
+	Subprotocol string
+
+	// MaxMessageLen limits the size of incoming messages.
+	MaxMessageLen int
+
+	// WriteTimeout limits each write, DefaultWriteTimeout when zero. A
+	// write that fails, for a peer that does not read in time or otherwise,
+	// closes the connection, as the frame may be cut.
+	WriteTimeout time.Duration
+
+	conn net.Conn
+	br   *bufio.Reader
+
+	wmu    sync.Mutex
+	closed bool
+}
+
+// IsUpgrade reports whether the request asks to switch to WebSocket.
+func IsUpgrade(r *http.Request) bool {
+	return headerHasToken(r.Header, "Connection", "upgrade") && headerHasToken(r.Header, "Upgrade", "websocket")
+}
+
+// Upgrade completes the opening handshake, picking the first of the client's
+// subprotocols the server supports. Clients asking only for unsupported
+// subprotocols are refused.
+func Upgrade(w http.ResponseWriter, r *http.Request, subprotocols []string) (*Conn, error) {
+
+	if r.Method != http.MethodGet || !IsUpgrade(r) {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return nil, ErrNotWebSocket
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Upgrade Required", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: unsupported version")
+	}
+
+	key := strings.TrimSpace(r.Header.Get("Sec-WebSocket-Key"))
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return nil, errors.New("websocket: invalid Sec-WebSocket-Key")
+	}
+
+	subprotocol := ""
+	requested := headerTokens(r.Header, "Sec-WebSocket-Protocol")
+
+	for _, p := range requested {
+		for _, supported := range subprotocols {
+			if p == supported && len(subprotocol) == 0 {
+				subprotocol = p
+			}
+		}
+	}
+
+	if len(requested) > 0 && len(subprotocol) == 0 {
+		http.Error(w, "Unsupported Subprotocol", http.StatusBadRequest)
+		return nil, errors.New("websocket: no supported subprotocol")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Server Error", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response does not support hijacking")
+	}
+
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + acceptGUID))
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n"
+	if len(subprotocol) > 0 {
+		response = response + "Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	}
+	response = response + "\r\n"
+
+	// Nothing may be written before the handshake completes.
+	netConn.SetDeadline(time.Time{})
+	if _, err := netConn.Write([]byte(response)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &Conn{
+		Subprotocol:   subprotocol,
+		MaxMessageLen: defaultMaxMessageLen,
+		conn:          netConn,
+		br:            rw.Reader,
+	}, nil
+}
+
+// ReadMessage returns the next text or binary message, answering pings and
+// close frames on the way.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+
+	var opcode int
+	var message []byte
+
+	for {
+
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case PingMessage:
+			if err := c.WriteMessage(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+
+		case PongMessage:
+			continue
+
+		case CloseMessage:
+			code, reason := CloseNoStatus, ""
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+				reason = string(payload[2:])
+			}
+			c.WriteClose(code, "")
+			return 0, nil, &CloseError{Code: code, Reason: reason}
+
+		case TextMessage, BinaryMessage:
+			if opcode != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "expected continuation frame")
+			}
+			opcode = op
+
+		case continuationFrame:
+			if opcode == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		if len(message)+len(payload) > c.MaxMessageLen {
+			return 0, nil, c.fail(CloseMessageTooBig, "message too big")
+		}
+
+		message = append(message, payload...)
+
+		if fin {
+			if opcode == TextMessage && !utf8.Valid(message) {
+				return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8")
+			}
+			return opcode, message, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (bool, int, []byte, error) {
+
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := int(header[0] & 0x0f)
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set")
+	}
+
+	if !masked {
+		return false, 0, nil, c.fail(CloseProtocolError, "client frames must be masked")
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if opcode >= CloseMessage && (length > maxControlPayload || !fin) {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+	}
+
+	if length > uint64(c.MaxMessageLen) {
+		return false, 0, nil, c.fail(CloseMessageTooBig, "message too big")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends a single unfragmented frame.
+func (c *Conn) WriteMessage(opcode int, data []byte) error {
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	return c.writeFrame(opcode, data)
+}
+
+func (c *Conn) writeFrame(opcode int, data []byte) error {
+
+	if c.closed {
+		return ErrClosed
+	}
+
+	header := []byte{0x80 | byte(opcode), 0}
+
+	switch {
+	case len(data) <= 125:
+		header[1] = byte(len(data))
+	case len(data) <= 0xffff:
+		header[1] = 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(len(data)))
+	default:
+		header[1] = 127
+		header = append(header, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(len(data)))
+	}
+
+	timeout := c.WriteTimeout
+	if timeout <= 0 {
+		timeout = DefaultWriteTimeout
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(timeout))
+
+	if _, err := c.conn.Write(append(header, data...)); err != nil {
+		c.closed = true
+		c.conn.Close()
+		return err
+	}
+
+	if opcode == CloseMessage {
+		c.closed = true
+	}
+
+	return nil
+}
+
+// WriteClose starts or answers the closing handshake. Further writes fail.
+func (c *Conn) WriteClose(code int, reason string) error {
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+
+	if len(payload) > maxControlPayload {
+		payload = payload[:maxControlPayload]
+	}
+
+	return c.writeFrame(CloseMessage, payload)
+}
+
+// fail closes the connection because of a protocol violation.
+func (c *Conn) fail(code int, reason string) error {
+
+	c.WriteClose(code, reason)
+
+	return &CloseError{Code: code, Reason: reason}
+}
+
+// SetReadDeadline limits how long the next ReadMessage may wait.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// Close closes the underlying connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+func headerTokens(header http.Header, name string) []string {
+
+	var tokens []string
+
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); len(token) > 0 {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+
+	return tokens
+}
+
+func headerHasToken(header http.Header, name string, token string) bool {
+
+	for _, t := range headerTokens(header, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+
+	return false
+}
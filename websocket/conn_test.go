@@ -0,0 +1,135 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+// frame returns a client frame, masked unless told otherwise.
+func frame(fin bool, opcode int, payload []byte, masked bool) []byte {
+
+	var b bytes.Buffer
+
+	first := byte(opcode)
+	if fin {
+		first |= 0x80
+	}
+	b.WriteByte(first)
+
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+
+	switch {
+	case len(payload) <= 125:
+		b.WriteByte(maskBit | byte(len(payload)))
+	case len(payload) <= 0xffff:
+		b.WriteByte(maskBit | 126)
+		binary.Write(&b, binary.BigEndian, uint16(len(payload)))
+	default:
+		b.WriteByte(maskBit | 127)
+		binary.Write(&b, binary.BigEndian, uint64(len(payload)))
+	}
+
+	if !masked {
+		b.Write(payload)
+		return b.Bytes()
+	}
+
+	mask := [4]byte{1, 2, 3, 4}
+	b.Write(mask[:])
+	for i, c := range payload {
+		b.WriteByte(c ^ mask[i%4])
+	}
+
+	return b.Bytes()
+}
+
+// pipe returns a connection reading what the client writes. What the
+// connection writes back is discarded.
+func pipe(client []byte) *Conn {
+
+	server, peer := net.Pipe()
+
+	go peer.Write(client)
+	go io.Copy(ioutil.Discard, peer)
+
+	c := &Conn{MaxMessageLen: 64, conn: server, br: bufio.NewReader(server)}
+	c.SetReadDeadline(time.Now().Add(time.Second))
+
+	return c
+}
+
+func TestReadMessage(t *testing.T) {
+
+	long := make([]byte, 65)
+	longHeader := []byte{0x81, 0x80 | 127, 0x80, 0, 0, 0, 0, 0, 0, 0}
+
+	tests := []struct {
+		name     string
+		client   []byte
+		wantCode int
+		wantData string
+	}{
+		{"text", frame(true, TextMessage, []byte("hello"), true), 0, "hello"},
+		{"fragments", append(frame(false, TextMessage, []byte("hel"), true), frame(true, continuationFrame, []byte("lo"), true)...), 0, "hello"},
+		{"ping between fragments", append(append(frame(false, TextMessage, []byte("hel"), true), frame(true, PingMessage, nil, true)...), frame(true, continuationFrame, []byte("lo"), true)...), 0, "hello"},
+		{"unmasked", frame(true, TextMessage, []byte("hello"), false), CloseProtocolError, ""},
+		{"reserved bits", append([]byte{0xc1}, frame(true, TextMessage, []byte("hello"), true)[1:]...), CloseProtocolError, ""},
+		{"unknown opcode", frame(true, 3, []byte("hello"), true), CloseProtocolError, ""},
+		{"long ping", frame(true, PingMessage, make([]byte, 126), true), CloseProtocolError, ""},
+		{"fragmented ping", frame(false, PingMessage, nil, true), CloseProtocolError, ""},
+		{"continuation first", frame(true, continuationFrame, []byte("hello"), true), CloseProtocolError, ""},
+		{"text within text", append(frame(false, TextMessage, []byte("hel"), true), frame(true, TextMessage, []byte("lo"), true)...), CloseProtocolError, ""},
+		{"too big", frame(true, TextMessage, long, true), CloseMessageTooBig, ""},
+		{"too big in fragments", append(frame(false, TextMessage, long[:40], true), frame(true, continuationFrame, long[40:], true)...), CloseMessageTooBig, ""},
+		{"huge length", longHeader, CloseMessageTooBig, ""},
+		{"invalid UTF-8", frame(true, TextMessage, []byte{0xff, 0xfe}, true), CloseInvalidPayload, ""},
+		{"close", frame(true, CloseMessage, []byte{0x03, 0xe8}, true), CloseNormal, ""},
+		{"close without status", frame(true, CloseMessage, nil, true), CloseNoStatus, ""},
+	}
+
+	for _, tt := range tests {
+
+		c := pipe(tt.client)
+
+		_, data, err := c.ReadMessage()
+
+		if tt.wantCode == 0 {
+			if err != nil || string(data) != tt.wantData {
+				t.Errorf("%s: got %q, %v, want %q", tt.name, data, err, tt.wantData)
+			}
+		} else {
+			closeErr, ok := err.(*CloseError)
+			if !ok || closeErr.Code != tt.wantCode {
+				t.Errorf("%s: got %v, want close %d", tt.name, err, tt.wantCode)
+			}
+		}
+
+		c.Close()
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+
+	server, peer := net.Pipe()
+	defer peer.Close()
+
+	// The peer reads nothing.
+	c := &Conn{WriteTimeout: 50 * time.Millisecond, conn: server, br: bufio.NewReader(server)}
+
+	if err := c.WriteMessage(TextMessage, []byte("hello")); err == nil {
+		t.Fatal("write to a peer that does not read succeeded")
+	}
+
+	if err := c.WriteMessage(TextMessage, []byte("hello")); err != ErrClosed {
+		t.Errorf("write after a failed one: %v, want %v", err, ErrClosed)
+	}
+}